@@ -1,27 +1,36 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"todo-api/internal/config"
 	"todo-api/internal/database"
 	"todo-api/internal/handlers"
+	"todo-api/internal/metrics"
+	"todo-api/internal/middleware"
 	"todo-api/internal/models"
 	"todo-api/internal/repository"
 	"todo-api/internal/routes"
 	"todo-api/internal/services"
+	"todo-api/pkg/logging"
 )
 
 func seedDefaultAdmin(userService *services.UserService, roleService *services.RoleService) {
 
-	superAdmin, err := roleService.GetRoleByName(models.RoleSuperAdmin)
+	superAdmin, err := roleService.GetRoleByName(context.Background(), models.RoleSuperAdmin)
 	if err != nil || superAdmin == nil {
-		log.Println("Warning: Could not find super admin role, skipping default admin creation...")
+		slog.Warn("Could not find super admin role, skipping default admin creation")
 		return
 	}
 
-	user, _ := userService.GetUserByRoleId(superAdmin.RoleId)
+	user, _ := userService.GetUserByRoleId(context.Background(), superAdmin.RoleId)
 	hasSuperAdmin := false
 
 	if user != nil {
@@ -31,9 +40,9 @@ func seedDefaultAdmin(userService *services.UserService, roleService *services.R
 	}
 
 	if !hasSuperAdmin {
-		log.Println("No super admin found, creating default super admin...")
+		slog.Info("No super admin found, creating default super admin")
 	} else {
-		log.Println("Super admin user already exists, skipping creation...")
+		slog.Info("Super admin user already exists, skipping creation")
 		return
 	}
 
@@ -47,14 +56,12 @@ func seedDefaultAdmin(userService *services.UserService, roleService *services.R
 		},
 	}
 
-	err = userService.Register(adminUser)
+	err = userService.Register(context.Background(), adminUser)
 
 	if err != nil {
-		log.Printf("Warning, could not create default admin user %v\n", err)
+		slog.Warn("Could not create default admin user", "error", err)
 	} else {
-		log.Println("Default admin user created successfully!")
-		log.Println("Email: admin@backend.com")
-		log.Println("Password: Admin@123")
+		slog.Info("Default admin user created", "email", "admin@backend.com", "password", "Admin@123")
 	}
 
 }
@@ -63,30 +70,69 @@ func main() {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("Failed to load configuration:", err)
+		log.Fatal("Failed to load configuration: ", err)
 	}
 
-	// Connect to database
-	err = database.Connect(cfg.GetConnectionString())
+	// Everything from here on logs through slog, including the standard log package
+	logger, err := logging.New(os.Stdout, cfg.LogLevel, cfg.LogFormat)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		log.Fatal("Failed to configure logging: ", err)
 	}
-	defer database.Close()
+	slog.SetDefault(logger)
 
-	// Initialize repositories
-	userRepo := repository.NewUserRepository()
-	todoRepo := repository.NewTodoRepository()
-	roleRepo := repository.NewRoleRepository()
+	// --demo keeps all data in memory, so the API runs without PostgreSQL
+	demo := flag.Bool("demo", false, "keep all data in memory instead of PostgreSQL; it is lost on exit")
+	flag.Parse()
+
+	// todo-api migrate ... manages the schema and exits; in demo mode there is none
+	args := flag.Args()
+	migrate := len(args) > 0 && args[0] == "migrate"
+	if *demo && migrate {
+		fatal("Cannot migrate in demo mode", errors.New("--demo keeps all data in memory and has no database schema"))
+	}
+
+	var repos *repository.Repositories
+	if *demo {
+		slog.Warn("Running in demo mode, all data is kept in memory and lost on exit")
+		repos = repository.NewMemoryRepositories()
+	} else {
+		// Connect to database
+		err = database.Connect(cfg.GetConnectionString())
+		if err != nil {
+			fatal("Failed to connect to database", err)
+		}
+		defer database.Close()
+		database.QueryTimeout = cfg.QueryTimeout
+
+		if migrate {
+			if err := runMigrate(args[1:]); err != nil {
+				fatal("Migration failed", err)
+			}
+			return
+		}
+
+		if cfg.AutoMigrate {
+			if err := autoMigrate(); err != nil {
+				fatal("Failed to migrate database", err)
+			}
+		}
+
+		repos = repository.NewRepositories()
+	}
 
 	// Initialize services with repository dependencies
-	userService := services.NewUserService(userRepo)
-	todoService := services.NewTodoService(todoRepo)
-	roleService := services.NewRoleService(roleRepo)
+	userService := services.NewUserService(repos.Users, repos.Roles, repos.Tx)
+	todoService := services.NewTodoService(repos.Todos, repos.Instances)
+	roleService := services.NewRoleService(repos.Roles)
+	workflowEngine := services.NewWorkflowEngine(repos)
+	todoWorkflowService := services.NewTodoWorkflowService(workflowEngine, repos.Todos, repos.Instances, repos.Users, repos.Tx)
+	workflowMetricsService := services.NewWorkflowMetricsService(repos.Metrics)
+	dataSourceService := services.NewDataSourceService(repos.DataSources, workflowMetricsService)
 
 	// Initialize predefined roles (run once at startup)
-	err = roleService.InitializePredefinedRoles()
+	err = roleService.InitializePredefinedRoles(context.Background())
 	if err != nil {
-		log.Println("Warning: Failed to initialize predefined roles:", err)
+		slog.Warn("Failed to initialize predefined roles", "error", err)
 	}
 
 	seedDefaultAdmin(userService, roleService)
@@ -95,28 +141,81 @@ func main() {
 	todoHandler := handlers.NewTodoHandler(todoService)
 	userHandler := handlers.NewUsersHandler(userService)
 	roleHandler := handlers.NewRoleHandler(roleService)
-	sharedTaskHandler := handlers.NewSharedTaskHandler()
-	todoWorkflowHandler := handlers.NewTodoWorkflowHandler()
-	workflowAdminHandler := handlers.NewWorkflowAdminHandler()
-	workflowInstanceHandler := handlers.NewWorkflowInstanceHandler()
-
-	// Register all routes using the routes package
-	routes.RegisterRoutes(
-		todoHandler,
-		userHandler,
-		sharedTaskHandler,
-		roleHandler,
-		todoWorkflowHandler,
-		workflowAdminHandler,
-		workflowInstanceHandler,
-	)
+	sharedTaskHandler := handlers.NewSharedTaskHandler(repos.SharedTasks)
+	todoWorkflowHandler := handlers.NewTodoWorkflowHandler(todoWorkflowService)
+	workflowAdminHandler := handlers.NewWorkflowAdminHandler(workflowEngine, repos.Workflows, repos.Roles, workflowMetricsService)
+	workflowInstanceHandler := handlers.NewWorkflowInstanceHandler(workflowEngine, repos.Instances)
+	dataSourceHandler := handlers.NewDataSourceHandler(dataSourceService)
+
+	// Escalate workflow instances that overstay their step SLA
+	slaScheduler := services.NewSLAScheduler(workflowEngine, cfg.SLACheckInterval)
+	slaScheduler.Start()
+	defer slaScheduler.Stop()
+
+	healthService := services.NewHealthService(database.DB, cfg.HealthCheckTimeout, slaScheduler)
+	if *demo {
+		healthService = services.NewMemoryHealthService(slaScheduler)
+	} else {
+		metrics.RegisterDBStats(metrics.Default, database.DB)
+	}
+	healthHandler := handlers.NewHealthHandler(healthService)
+
+	metricsHandler := handlers.NewMetricsHandler(metrics.Default, cfg.MetricsToken, cfg.MetricsAllowedNetworks)
+
+	// Build the router serving all routes
+	router := routes.NewRouter(routes.Handlers{
+		Health:           healthHandler,
+		Todo:             todoHandler,
+		User:             userHandler,
+		SharedTask:       sharedTaskHandler,
+		Role:             roleHandler,
+		TodoWorkflow:     todoWorkflowHandler,
+		WorkflowAdmin:    workflowAdminHandler,
+		WorkflowInstance: workflowInstanceHandler,
+		DataSource:       dataSourceHandler,
+		Metrics:          metricsHandler,
+
+		Users: repos.Users,
+	})
+
+	server := &http.Server{
+		Addr:              ":" + cfg.ServerPort,
+		Handler:           middleware.RequestLogger(logger)(middleware.Metrics(router)),
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
 
 	// Start server
-	port := ":" + cfg.ServerPort
-	fmt.Printf("🚀 Server listening on port %s\n", cfg.ServerPort)
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server listening", "port", cfg.ServerPort)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	// Drain in-flight requests on SIGTERM or Ctrl+C, then stop the workers and close the database
+	stop, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	select {
+	case err := <-serverErr:
+		fatal("Error starting server", err)
+	case <-stop.Done():
+	}
 
-	err = http.ListenAndServe(port, nil)
-	if err != nil {
-		log.Fatal("Error starting server:", err)
+	slog.Info("Shutting down, waiting for in-flight requests", "timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelShutdown()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Graceful shutdown did not finish", "error", err)
 	}
+	slog.Info("Server stopped")
+}
+
+// fatal logs err and exits; deferred cleanups do not run
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	os.Exit(1)
 }
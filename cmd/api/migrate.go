@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"todo-api/internal/database"
+	"todo-api/migrations"
+)
+
+const migrateUsage = `usage: todo-api migrate <command>
+
+commands:
+  up                apply all pending migrations
+  down [steps]      revert the last steps migrations (default 1)
+  status            show the applied version and pending migrations
+  force <version>   record version as clean without running migrations, after repairing a failed one`
+
+// runMigrate runs the migrate subcommand against the connected database
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := database.NewMigrator(database.DB, migrations.FS)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch command := args[0]; {
+	case command == "up" && len(args) == 1:
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s)\n", applied)
+
+	case command == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("invalid steps %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migration(s)\n", reverted)
+
+	case command == "status" && len(args) == 1:
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Version: %d (latest %d)\nDirty:   %t\nPending: %v\n", status.Version, status.Latest, status.Dirty, status.Pending)
+
+	case command == "force" && len(args) == 2:
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := migrator.Force(ctx, version); err != nil {
+			return err
+		}
+		fmt.Printf("Forced version %d\n", version)
+
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
+
+// autoMigrate applies pending migrations at startup. Replicas starting together
+// wait on the migration lock, so only the first one does any work.
+func autoMigrate() error {
+	migrator, err := database.NewMigrator(database.DB, migrations.FS)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		return err
+	}
+	if applied > 0 {
+		slog.Info("Applied migrations", "count", applied)
+	}
+	return nil
+}
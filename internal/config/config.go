@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,12 +18,61 @@ type Config struct {
 	DBName     string
 	DBSSLMode  string
 	ServerPort string
+
+	// How often the SLA scheduler looks for overdue workflow instances
+	SLACheckInterval time.Duration
+
+	// HTTP server timeouts; ShutdownTimeout bounds the drain of in-flight requests on SIGTERM
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+
+	// Deadline of the database ping done by the health probes
+	HealthCheckTimeout time.Duration
+
+	// Deadline of each repository call; requests that exceed it fail with 504
+	QueryTimeout time.Duration
+
+	// Apply pending migrations at startup
+	AutoMigrate bool
+
+	// Minimum level ("debug", "info", "warn" or "error") and format ("json" or "text") of the logs
+	LogLevel  string
+	LogFormat string
+
+	// /metrics answers scrapers presenting MetricsToken as a bearer token or
+	// connecting from one of MetricsAllowedNetworks
+	MetricsToken           string
+	MetricsAllowedNetworks []*net.IPNet
 }
 
 func Load() (*Config, error) {
 	// Load .env file (ignore error in production where env vars are set directly)
 	godotenv.Load()
 
+	durations := map[string]time.Duration{}
+	for key, defaultValue := range map[string]string{
+		"SLA_CHECK_INTERVAL":   "1m",
+		"HTTP_READ_TIMEOUT":    "15s",
+		"HTTP_WRITE_TIMEOUT":   "30s",
+		"HTTP_IDLE_TIMEOUT":    "60s",
+		"SHUTDOWN_TIMEOUT":     "20s",
+		"HEALTH_CHECK_TIMEOUT": "2s",
+		"QUERY_TIMEOUT":        "10s",
+	} {
+		d, err := time.ParseDuration(getEnv(key, defaultValue))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %s: must be a positive duration such as 30s or 5m", key)
+		}
+		durations[key] = d
+	}
+
+	metricsNetworks, err := parseNetworks(getEnv("METRICS_ALLOWED_IPS", "127.0.0.1,::1"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid METRICS_ALLOWED_IPS: %w", err)
+	}
+
 	return &Config{
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
@@ -29,6 +81,24 @@ func Load() (*Config, error) {
 		DBName:     getEnv("DB_NAME", "rbca_system"),
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
 		ServerPort: getEnv("SERVER_PORT", "8080"),
+
+		SLACheckInterval: durations["SLA_CHECK_INTERVAL"],
+
+		ReadTimeout:     durations["HTTP_READ_TIMEOUT"],
+		WriteTimeout:    durations["HTTP_WRITE_TIMEOUT"],
+		IdleTimeout:     durations["HTTP_IDLE_TIMEOUT"],
+		ShutdownTimeout: durations["SHUTDOWN_TIMEOUT"],
+
+		HealthCheckTimeout: durations["HEALTH_CHECK_TIMEOUT"],
+		QueryTimeout:       durations["QUERY_TIMEOUT"],
+
+		AutoMigrate: getEnv("AUTO_MIGRATE", "false") == "true",
+
+		LogLevel:  getEnv("LOG_LEVEL", "info"),
+		LogFormat: getEnv("LOG_FORMAT", "json"),
+
+		MetricsToken:           os.Getenv("METRICS_TOKEN"),
+		MetricsAllowedNetworks: metricsNetworks,
 	}, nil
 }
 
@@ -37,6 +107,39 @@ func (c *Config) GetConnectionString() string {
 		c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName, c.DBSSLMode)
 }
 
+// parseNetworks reads a comma separated list of IP addresses and CIDR ranges.
+// "none" allows no address.
+func parseNetworks(list string) ([]*net.IPNet, error) {
+	networks := []*net.IPNet{}
+	if list == "none" {
+		return networks, nil
+	}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
 func getEnv(key, defaultValue string) string {
 
 	value := os.Getenv(key)
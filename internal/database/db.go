@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"log/slog"
 
 	_ "github.com/lib/pq"
 )
@@ -21,7 +22,7 @@ func Connect(connStr string) error {
 		return fmt.Errorf("error pinging database: %w", err)
 	}
 
-	fmt.Println("Connected to PostgreSQL")
+	slog.Info("Connected to PostgreSQL")
 	return nil
 }
 
@@ -0,0 +1,288 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// migrationLockKey identifies the advisory lock held while migrating, so replicas
+// starting at the same time apply the migrations one after the other
+const migrationLockKey = 7_305_125_931
+
+// migrationFile matches names such as 000001_create_users_table.up.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus describes where a database stands relative to the known migrations
+type MigrationStatus struct {
+	Version int64   // Last applied migration, 0 when none is
+	Dirty   bool    // A migration failed half way and the schema needs manual repair
+	Latest  int64   // Newest known migration
+	Pending []int64 // Known migrations not applied yet
+}
+
+// Migrator applies migrations and records the current version in schema_migrations,
+// the same table the migrate CLI uses, so databases migrated with it carry on
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator loads the up and down migrations found in fsys
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// LoadMigrations reads and orders the migrations in fsys. Every version needs both an up and a down file.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %s: %w", entry.Name(), err)
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies all pending migrations and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		version, err := m.cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if err := apply(ctx, conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations and returns how many were reverted
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if steps <= 0 {
+		return 0, fmt.Errorf("steps must be positive")
+	}
+
+	reverted := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		version, err := m.cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version {
+				continue
+			}
+			if migration.Version != version {
+				return fmt.Errorf("database is at version %d, which has no migration file", version)
+			}
+			previous := int64(0)
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := apply(ctx, conn, migration.Down, previous); err != nil {
+				return fmt.Errorf("reverting migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			version = previous
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Force records version as the current, clean version without running any migration.
+// It repairs a dirty database once the failed migration has been fixed by hand.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version < 0 {
+		return fmt.Errorf("version must not be negative")
+	}
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback() // No-op once committed
+		if err := setVersion(ctx, tx, version); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// Status reports the applied version and the pending migrations
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	version, dirty, err := MigrationVersion(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{Version: version, Dirty: dirty, Pending: []int64{}}
+	for _, migration := range m.migrations {
+		status.Latest = migration.Version
+		if migration.Version > version {
+			status.Pending = append(status.Pending, migration.Version)
+		}
+	}
+	return status, nil
+}
+
+// MigrationVersion returns the version of the last applied migration, 0 when none is,
+// and whether it failed half way
+func MigrationVersion(ctx context.Context, db *sql.DB) (int64, bool, error) {
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return 0, false, fmt.Errorf("error reading migration version: %w", err)
+	}
+	if !exists {
+		return 0, false, nil
+	}
+	return readVersion(ctx, db)
+}
+
+// queryRower is implemented by *sql.DB, *sql.Conn and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func readVersion(ctx context.Context, db queryRower) (int64, bool, error) {
+	var version int64
+	var dirty bool
+	err := db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("error reading migration version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// locked runs fn on one connection holding the migration advisory lock.
+// The lock waits for other migrators instead of failing.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("error taking migration lock: %w", err)
+	}
+	// Unlock on a fresh context so a cancelled ctx still releases the lock
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`); err != nil {
+		return fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+// cleanVersion returns the current version, refusing to go on from a dirty one
+func (m *Migrator) cleanVersion(ctx context.Context, conn *sql.Conn) (int64, error) {
+	version, dirty, err := readVersion(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("database is dirty at version %d: repair the schema, then run migrate force", version)
+	}
+	return version, nil
+}
+
+// apply runs a migration script and records the resulting version in one transaction,
+// so a failing script leaves the database at the version it had before
+func apply(ctx context.Context, conn *sql.Conn, script string, version int64) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // No-op once committed
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if err := setVersion(ctx, tx, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setVersion replaces the recorded version; version 0 means no migration is applied
+func setVersion(ctx context.Context, tx *sql.Tx, version int64) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return fmt.Errorf("error recording migration version: %w", err)
+	}
+	if version == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, version); err != nil {
+		return fmt.Errorf("error recording migration version: %w", err)
+	}
+	return nil
+}
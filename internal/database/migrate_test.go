@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"todo-api/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	// Arrange
+	fsys := fstest.MapFS{
+		"000002_add_todos.up.sql":   {Data: []byte("CREATE TABLE todos (id TEXT);")},
+		"000002_add_todos.down.sql": {Data: []byte("DROP TABLE todos;")},
+		"000001_add_users.up.sql":   {Data: []byte("CREATE TABLE users (id TEXT);")},
+		"000001_add_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"embed.go":                  {Data: []byte("package migrations")},
+		"000010_add_roles.up.sql":   {Data: []byte("CREATE TABLE roles (id TEXT);")},
+		"000010_add_roles.down.sql": {Data: []byte("DROP TABLE roles;")},
+	}
+
+	// Act
+	loaded, err := LoadMigrations(fsys)
+
+	// Assert - ordered by version, other files ignored
+	if err != nil {
+		t.Fatalf("Expected migrations, got %v", err)
+	}
+	var versions []int64
+	for _, migration := range loaded {
+		versions = append(versions, migration.Version)
+	}
+	if len(versions) != 3 || versions[0] != 1 || versions[1] != 2 || versions[2] != 10 {
+		t.Errorf("Expected versions [1 2 10], got %v", versions)
+	}
+	if loaded[0].Name != "add_users" || loaded[0].Down != "DROP TABLE users;" {
+		t.Errorf("Expected the add_users migration with its down script, got %+v", loaded[0])
+	}
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{
+			name:    "missing down",
+			fsys:    fstest.MapFS{"000001_add_users.up.sql": {Data: []byte("SELECT 1;")}},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name: "conflicting names",
+			fsys: fstest.MapFS{
+				"000001_add_users.up.sql":    {Data: []byte("SELECT 1;")},
+				"000001_add_people.down.sql": {Data: []byte("SELECT 1;")},
+			},
+			wantErr: "has two names",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := LoadMigrations(tt.fsys)
+
+			// Assert
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	// Act
+	loaded, err := LoadMigrations(migrations.FS)
+
+	// Assert - every shipped migration has both directions and versions have no gaps
+	if err != nil {
+		t.Fatalf("Expected the embedded migrations to load, got %v", err)
+	}
+	for i, migration := range loaded {
+		if migration.Version != int64(i+1) {
+			t.Fatalf("Expected migration %d, got %d_%s", i+1, migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestMigrator_ConcurrentUp(t *testing.T) {
+	// Arrange - TEST_DATABASE_URL must point to a database that may be migrated
+	connStr := os.Getenv("TEST_DATABASE_URL")
+	if connStr == "" {
+		t.Skip("TEST_DATABASE_URL not set, skipping database test")
+	}
+	if err := Connect(connStr); err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { Close() })
+	migrator, err := NewMigrator(DB, migrations.FS)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	// Act - three replicas start at once
+	errs := make([]error, 3)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = migrator.Up(context.Background())
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert - the lock serializes them and the database ends up current
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("Expected every migrator to succeed, got %v", err)
+		}
+	}
+	status, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	if status.Version != status.Latest || status.Dirty || len(status.Pending) != 0 {
+		t.Errorf("Expected the database at version %d, got %+v", status.Latest, status)
+	}
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// QueryTimeout bounds every repository call, including reading its rows.
+// Zero or less disables the bound, leaving only the caller's deadline.
+var QueryTimeout = 10 * time.Second
+
+// queryCanceled is the PostgreSQL error code of a statement stopped by a
+// cancel request or by statement_timeout
+const queryCanceled = "57014"
+
+// WithQueryTimeout derives the context a repository call runs its queries with
+func WithQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, QueryTimeout)
+}
+
+// IsTimeout reports whether err comes from a query that ran out of time or was
+// cancelled by the server
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == queryCanceled
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestIsTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"deadline exceeded", fmt.Errorf("error getting instance: %w", context.DeadlineExceeded), true},
+		{"statement cancelled by the server", &pq.Error{Code: "57014"}, true},
+		{"other database error", &pq.Error{Code: "23505"}, false},
+		{"client cancellation", context.Canceled, false},
+		{"plain error", errors.New("instance not found"), false},
+		{"no error", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := IsTimeout(tt.err)
+
+			// Assert
+			if got != tt.want {
+				t.Errorf("Expected IsTimeout(%v) to be %t", tt.err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithQueryTimeout(t *testing.T) {
+	// Arrange
+	defer func(previous time.Duration) { QueryTimeout = previous }(QueryTimeout)
+	QueryTimeout = time.Millisecond
+
+	// Act
+	ctx, cancel := WithQueryTimeout(context.Background())
+	defer cancel()
+	<-ctx.Done()
+
+	// Assert
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("Expected the query context to time out, got %v", ctx.Err())
+	}
+}
+
+func TestWithQueryTimeout_Disabled(t *testing.T) {
+	// Arrange
+	defer func(previous time.Duration) { QueryTimeout = previous }(QueryTimeout)
+	QueryTimeout = 0
+
+	// Act
+	ctx, cancel := WithQueryTimeout(context.Background())
+	_, hasDeadline := ctx.Deadline()
+	cancel()
+
+	// Assert - still cancellable, but only the caller's deadline applies
+	if hasDeadline {
+		t.Errorf("Expected no deadline when the query timeout is disabled")
+	}
+	if ctx.Err() == nil {
+		t.Errorf("Expected cancel to stop the query context")
+	}
+}
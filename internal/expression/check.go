@@ -0,0 +1,267 @@
+package expression
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kind is the static type of a value in an expression
+type Kind int
+
+const (
+	KindAny Kind = iota // Not known until evaluation, e.g. payload data
+	KindBool
+	KindNumber
+	KindString
+	KindNull
+	KindList
+	KindObject
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindBool:
+		return "boolean"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindNull:
+		return "null"
+	case KindList:
+		return "list"
+	case KindObject:
+		return "object"
+	}
+	return "any"
+}
+
+// Type describes a value. Objects with nil Fields are open and accept any field name.
+type Type struct {
+	Kind   Kind
+	Fields map[string]*Type
+}
+
+// Predefined types
+var (
+	Any    = &Type{Kind: KindAny}
+	Bool   = &Type{Kind: KindBool}
+	Number = &Type{Kind: KindNumber}
+	String = &Type{Kind: KindString}
+	Null   = &Type{Kind: KindNull}
+	List   = &Type{Kind: KindList}
+)
+
+// Object returns an object type with the given fields, or an open object when fields is nil
+func Object(fields map[string]*Type) *Type {
+	return &Type{Kind: KindObject, Fields: fields}
+}
+
+// Env declares the variables an expression may reference
+type Env map[string]*Type
+
+// is reports whether t is one of the given kinds or not statically known
+func (t *Type) is(kinds ...Kind) bool {
+	if t.Kind == KindAny {
+		return true
+	}
+	for _, k := range kinds {
+		if t.Kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+// checker walks the syntax tree and infers the type of every node
+type checker struct {
+	env Env
+}
+
+func (c *checker) check(n node) (*Type, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		switch n.value.(type) {
+		case float64:
+			return Number, nil
+		case string:
+			return String, nil
+		case bool:
+			return Bool, nil
+		}
+		return Null, nil
+
+	case *identNode:
+		t, ok := c.env[n.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown variable %q at position %d (available: %s)", n.name, n.pos, names(c.env))
+		}
+		return t, nil
+
+	case *memberNode:
+		obj, err := c.check(n.object)
+		if err != nil {
+			return nil, err
+		}
+		if obj.Kind == KindAny || (obj.Kind == KindObject && obj.Fields == nil) {
+			return Any, nil
+		}
+		if obj.Kind != KindObject {
+			return nil, fmt.Errorf("cannot access field %q of %s at position %d", n.name, obj.Kind, n.pos)
+		}
+		field, ok := obj.Fields[n.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q at position %d (available: %s)", n.name, n.pos, names(obj.Fields))
+		}
+		return field, nil
+
+	case *indexNode:
+		obj, err := c.check(n.object)
+		if err != nil {
+			return nil, err
+		}
+		index, err := c.check(n.index)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case obj.Kind == KindList && index.is(KindNumber):
+			return Any, nil
+		case obj.Kind == KindObject && index.is(KindString):
+			if obj.Fields != nil {
+				return nil, fmt.Errorf("use '.' to access fields of a declared object at position %d", n.pos)
+			}
+			return Any, nil
+		case obj.Kind == KindAny && index.is(KindNumber, KindString):
+			return Any, nil
+		}
+		return nil, fmt.Errorf("cannot index %s with %s at position %d", obj.Kind, index.Kind, n.pos)
+
+	case *listNode:
+		for _, item := range n.items {
+			if _, err := c.check(item); err != nil {
+				return nil, err
+			}
+		}
+		return List, nil
+
+	case *unaryNode:
+		operand, err := c.check(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			if !operand.is(KindBool) {
+				return nil, fmt.Errorf("operator ! expects a boolean, got %s at position %d", operand.Kind, n.pos)
+			}
+			return Bool, nil
+		}
+		if !operand.is(KindNumber) {
+			return nil, fmt.Errorf("operator - expects a number, got %s at position %d", operand.Kind, n.pos)
+		}
+		return Number, nil
+
+	case *binaryNode:
+		return c.checkBinary(n)
+
+	case *callNode:
+		return c.checkCall(n)
+	}
+	return nil, fmt.Errorf("unsupported expression at position %d", n.position())
+}
+
+func (c *checker) checkBinary(n *binaryNode) (*Type, error) {
+	left, err := c.check(n.left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.check(n.right)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatch := func() error {
+		return fmt.Errorf("operator %s cannot be applied to %s and %s at position %d", n.op, left.Kind, right.Kind, n.pos)
+	}
+
+	switch n.op {
+	case "&&", "||":
+		if !left.is(KindBool) || !right.is(KindBool) {
+			return nil, mismatch()
+		}
+		return Bool, nil
+
+	case "==", "!=":
+		// null may be compared with anything to test for missing data
+		if left.Kind != KindAny && right.Kind != KindAny && left.Kind != KindNull && right.Kind != KindNull && left.Kind != right.Kind {
+			return nil, mismatch()
+		}
+		return Bool, nil
+
+	case "<", "<=", ">", ">=":
+		if (left.is(KindNumber) && right.is(KindNumber)) || (left.is(KindString) && right.is(KindString)) {
+			return Bool, nil
+		}
+		return nil, mismatch()
+
+	case "in":
+		if !right.is(KindList, KindString, KindObject) {
+			return nil, mismatch()
+		}
+		if right.Kind == KindString && !left.is(KindString) {
+			return nil, mismatch()
+		}
+		return Bool, nil
+
+	case "+":
+		switch {
+		case left.Kind == KindNumber && right.Kind == KindNumber:
+			return Number, nil
+		case left.Kind == KindString && right.Kind == KindString:
+			return String, nil
+		case left.is(KindNumber, KindString) && right.is(KindNumber, KindString) && (left.Kind == KindAny || right.Kind == KindAny):
+			if left.Kind == KindAny {
+				return right, nil
+			}
+			return left, nil
+		}
+		return nil, mismatch()
+
+	default: // - * / %
+		if !left.is(KindNumber) || !right.is(KindNumber) {
+			return nil, mismatch()
+		}
+		return Number, nil
+	}
+}
+
+func (c *checker) checkCall(n *callNode) (*Type, error) {
+	fn, ok := builtins[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at position %d", n.name, n.pos)
+	}
+	if len(n.args) != len(fn.params) {
+		return nil, fmt.Errorf("%s expects %d argument(s), got %d at position %d", n.name, len(fn.params), len(n.args), n.pos)
+	}
+	for i, arg := range n.args {
+		t, err := c.check(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !t.is(fn.params[i]...) {
+			return nil, fmt.Errorf("argument %d of %s cannot be %s at position %d", i+1, n.name, t.Kind, arg.position())
+		}
+	}
+	return fn.result, nil
+}
+
+// names lists the keys of a type map for error messages
+func names(m map[string]*Type) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,374 @@
+package expression
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Program is a parsed and type-checked expression, safe for concurrent use
+type Program struct {
+	source string
+	root   node
+}
+
+// Compile parses src and checks it against env. The expression must produce a boolean.
+func Compile(src string, env Env) (*Program, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, fmt.Errorf("expression is empty")
+	}
+
+	root, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checker{env: env}
+	t, err := c.check(root)
+	if err != nil {
+		return nil, err
+	}
+	if !t.is(KindBool) {
+		return nil, fmt.Errorf("expression must produce a boolean, got %s", t.Kind)
+	}
+	return &Program{source: src, root: root}, nil
+}
+
+// String returns the source of the expression
+func (p *Program) String() string {
+	return p.source
+}
+
+// Eval evaluates the expression against vars. Values are expected in their JSON
+// decoded form: float64, string, bool, nil, []interface{} and map[string]interface{}.
+func (p *Program) Eval(vars map[string]interface{}) (bool, error) {
+	value, err := eval(p.root, vars)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression produced %s, expected a boolean", kindOf(value))
+	}
+	return result, nil
+}
+
+func eval(n node, vars map[string]interface{}) (interface{}, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+
+	case *identNode:
+		return normalize(vars[n.name]), nil
+
+	case *memberNode:
+		obj, err := eval(n.object, vars)
+		if err != nil {
+			return nil, err
+		}
+		switch obj := obj.(type) {
+		case nil:
+			return nil, nil
+		case map[string]interface{}:
+			return normalize(obj[n.name]), nil
+		}
+		return nil, fmt.Errorf("cannot access field %q of %s", n.name, kindOf(obj))
+
+	case *indexNode:
+		obj, err := eval(n.object, vars)
+		if err != nil {
+			return nil, err
+		}
+		index, err := eval(n.index, vars)
+		if err != nil {
+			return nil, err
+		}
+		switch obj := obj.(type) {
+		case nil:
+			return nil, nil
+		case []interface{}:
+			i, ok := index.(float64)
+			if !ok || i != math.Trunc(i) {
+				return nil, fmt.Errorf("list index must be a whole number, got %v", index)
+			}
+			if i < 0 || int(i) >= len(obj) {
+				return nil, nil
+			}
+			return normalize(obj[int(i)]), nil
+		case map[string]interface{}:
+			key, ok := index.(string)
+			if !ok {
+				return nil, fmt.Errorf("object key must be a string, got %s", kindOf(index))
+			}
+			return normalize(obj[key]), nil
+		}
+		return nil, fmt.Errorf("cannot index %s", kindOf(obj))
+
+	case *listNode:
+		items := make([]interface{}, 0, len(n.items))
+		for _, item := range n.items {
+			value, err := eval(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+
+	case *unaryNode:
+		operand, err := eval(n.operand, vars)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			b, ok := operand.(bool)
+			if !ok {
+				return nil, fmt.Errorf("operator ! expects a boolean, got %s", kindOf(operand))
+			}
+			return !b, nil
+		}
+		num, ok := operand.(float64)
+		if !ok {
+			return nil, fmt.Errorf("operator - expects a number, got %s", kindOf(operand))
+		}
+		return -num, nil
+
+	case *binaryNode:
+		return evalBinary(n, vars)
+
+	case *callNode:
+		args := make([]interface{}, 0, len(n.args))
+		for _, arg := range n.args {
+			value, err := eval(arg, vars)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, value)
+		}
+		return builtins[n.name].call(args)
+	}
+	return nil, fmt.Errorf("unsupported expression at position %d", n.position())
+}
+
+func evalBinary(n *binaryNode, vars map[string]interface{}) (interface{}, error) {
+	left, err := eval(n.left, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// Boolean operators short-circuit so the right side may guard against missing data
+	if n.op == "&&" || n.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s expects booleans, got %s", n.op, kindOf(left))
+		}
+		if (n.op == "&&" && !l) || (n.op == "||" && l) {
+			return l, nil
+		}
+		right, err := eval(n.right, vars)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s expects booleans, got %s", n.op, kindOf(right))
+		}
+		return r, nil
+	}
+
+	right, err := eval(n.right, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		return contains(right, left)
+	}
+
+	if ls, ok := left.(string); ok {
+		rs, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("operator %s cannot be applied to string and %s", n.op, kindOf(right))
+		}
+		switch n.op {
+		case "<":
+			return ls < rs, nil
+		case "<=":
+			return ls <= rs, nil
+		case ">":
+			return ls > rs, nil
+		case ">=":
+			return ls >= rs, nil
+		case "+":
+			return ls + rs, nil
+		}
+		return nil, fmt.Errorf("operator %s cannot be applied to strings", n.op)
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator %s cannot be applied to %s and %s", n.op, kindOf(left), kindOf(right))
+	}
+	switch n.op {
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+	return nil, fmt.Errorf("unsupported operator %s", n.op)
+}
+
+// equal compares two values; numbers, strings, booleans and null compare by value
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// contains implements the in operator and the contains builtin
+func contains(container, item interface{}) (bool, error) {
+	switch c := container.(type) {
+	case nil:
+		return false, nil
+	case string:
+		s, ok := item.(string)
+		if !ok {
+			return false, fmt.Errorf("cannot search a string for %s", kindOf(item))
+		}
+		return strings.Contains(c, s), nil
+	case []interface{}:
+		for _, v := range c {
+			if equal(normalize(v), item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		key, ok := item.(string)
+		if !ok {
+			return false, fmt.Errorf("object key must be a string, got %s", kindOf(item))
+		}
+		_, found := c[key]
+		return found, nil
+	}
+	return false, fmt.Errorf("cannot search %s", kindOf(container))
+}
+
+// normalize converts Go values supplied by callers into their JSON decoded form
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return items
+	}
+	return v
+}
+
+// kindOf reports the expression kind of a runtime value, for error messages
+func kindOf(v interface{}) Kind {
+	switch v.(type) {
+	case nil:
+		return KindNull
+	case bool:
+		return KindBool
+	case float64:
+		return KindNumber
+	case string:
+		return KindString
+	case []interface{}:
+		return KindList
+	case map[string]interface{}:
+		return KindObject
+	}
+	return KindAny
+}
+
+// builtin is a function callable from expressions
+type builtin struct {
+	params [][]Kind
+	result *Type
+	call   func(args []interface{}) (interface{}, error)
+}
+
+var builtins = map[string]builtin{
+	"len": {
+		params: [][]Kind{{KindString, KindList, KindObject}},
+		result: Number,
+		call: func(args []interface{}) (interface{}, error) {
+			switch v := args[0].(type) {
+			case nil:
+				return float64(0), nil
+			case string:
+				return float64(len([]rune(v))), nil
+			case []interface{}:
+				return float64(len(v)), nil
+			case map[string]interface{}:
+				return float64(len(v)), nil
+			}
+			return nil, fmt.Errorf("len cannot be applied to %s", kindOf(args[0]))
+		},
+	},
+	"contains": {
+		params: [][]Kind{{KindString, KindList, KindObject}, {KindAny, KindBool, KindNumber, KindString, KindNull, KindList, KindObject}},
+		result: Bool,
+		call: func(args []interface{}) (interface{}, error) {
+			return contains(args[0], args[1])
+		},
+	},
+	"lower": {
+		params: [][]Kind{{KindString}},
+		result: String,
+		call: func(args []interface{}) (interface{}, error) {
+			s, ok := args[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("lower expects a string, got %s", kindOf(args[0]))
+			}
+			return strings.ToLower(s), nil
+		},
+	},
+	"upper": {
+		params: [][]Kind{{KindString}},
+		result: String,
+		call: func(args []interface{}) (interface{}, error) {
+			s, ok := args[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("upper expects a string, got %s", kindOf(args[0]))
+			}
+			return strings.ToUpper(s), nil
+		},
+	},
+}
@@ -0,0 +1,114 @@
+package expression
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// testEnv mirrors the shape of the workflow guard environment
+var testEnv = Env{
+	"payload": Object(nil),
+	"actor": Object(map[string]*Type{
+		"id":       String,
+		"username": String,
+		"role":     String,
+	}),
+	"todo": Object(map[string]*Type{
+		"completed": Bool,
+	}),
+}
+
+func TestCompile_RejectsInvalidExpressions(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"empty", "   ", "expression is empty"},
+		{"syntax error", "payload.amount <", "unexpected end of expression"},
+		{"unbalanced parens", "(actor.role == 'x'", `expected ")"`},
+		{"unknown variable", "user.role == 'x'", `unknown variable "user"`},
+		{"unknown field", "actor.name == 'x'", `unknown field "name"`},
+		{"type mismatch", "actor.role < 5", "cannot be applied to string and number"},
+		{"not boolean", "payload.amount + 1", "must produce a boolean"},
+		{"unknown function", "exec('rm')", `unknown function "exec"`},
+		{"wrong arity", "lower(actor.role, 1) == 'x'", "expects 1 argument(s)"},
+		{"unterminated string", "actor.role == 'x", "unterminated string"},
+		{"too long", strings.Repeat("true && ", 200) + "true", "longer than"},
+		{"too deep", strings.Repeat("(", 40) + "true" + strings.Repeat(")", 40), "nested too deeply"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := Compile(tt.src, testEnv)
+
+			// Assert
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestProgram_Eval(t *testing.T) {
+	// Arrange - vars in the form the engine builds them, with the payload decoded from JSON
+	var payload map[string]interface{}
+	err := json.Unmarshal([]byte(`{"amount": 250, "tags": ["urgent", "hw"], "vendor": {"name": "Acme"}}`), &payload)
+	if err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	vars := map[string]interface{}{
+		"payload": payload,
+		"actor":   map[string]interface{}{"id": "u1", "username": "alice", "role": "Moderator"},
+		"todo":    map[string]interface{}{"completed": false},
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    bool
+		wantErr string
+	}{
+		{"comparison and role", `payload.amount < 1000 && actor.role == "Moderator"`, true, ""},
+		{"arithmetic", "payload.amount * 2 + 10 == 510", true, ""},
+		{"in list literal", "actor.role in ['Admin', 'Moderator']", true, ""},
+		{"in payload list", "'urgent' in payload.tags", true, ""},
+		{"index", "payload.tags[1] == 'hw'", true, ""},
+		{"nested field", "lower(payload.vendor.name) == 'acme'", true, ""},
+		{"builtins", "len(payload.tags) == 2 && contains(upper(actor.username), 'ALI')", true, ""},
+		{"negation", "!todo.completed", true, ""},
+		{"missing key is null", "payload.missing == null", true, ""},
+		{"short circuit guards missing data", "payload.missing != null && payload.missing > 5", false, ""},
+		{"missing key in comparison", "payload.missing > 5", false, "cannot be applied to null and number"},
+		{"division by zero", "payload.amount / 0 > 1", false, "division by zero"},
+		{"runtime type error", "payload.vendor > 1", false, "cannot be applied to object and number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := Compile(tt.src, testEnv)
+			if err != nil {
+				t.Fatalf("Failed to compile %q: %v", tt.src, err)
+			}
+
+			// Act
+			got, err := program.Eval(vars)
+
+			// Assert
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package expression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string  // Operator or identifier text
+	num  float64 // Value of a number token
+	str  string  // Unquoted value of a string token
+	pos  int     // Byte offset in the source, for error messages
+}
+
+// operators lists the multi-character operators first so the longest match wins
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")", "[", "]", ".", ","}
+
+// lex splits an expression into tokens
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.') {
+				i++
+			}
+			num, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", src[start:i], start)
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], num: num, pos: start})
+
+		case c == '"' || c == '\'':
+			start := i
+			str, end, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			tokens = append(tokens, token{kind: tokString, text: src[start:i], str: str, pos: start})
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{kind: tokOperator, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// lexString reads a quoted string starting at src[start] and returns its value and the offset after the closing quote
+func lexString(src string, start int) (string, int, error) {
+	quote := src[start]
+	var sb strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case quote:
+			return sb.String(), i + 1, nil
+		case '\\':
+			if i+1 >= len(src) {
+				break
+			}
+			i++
+			switch src[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(src[i])
+			}
+		default:
+			sb.WriteByte(src[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string starting at position %d", start)
+}
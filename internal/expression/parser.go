@@ -0,0 +1,302 @@
+package expression
+
+import "fmt"
+
+// Limits that keep guards small and cheap to evaluate
+const (
+	MaxLength = 1000 // Maximum source length in bytes
+	maxDepth  = 32   // Maximum nesting depth of the syntax tree
+)
+
+// node is an element of the syntax tree
+type node interface {
+	position() int
+}
+
+type literalNode struct {
+	pos   int
+	value interface{} // float64, string, bool or nil
+}
+
+type identNode struct {
+	pos  int
+	name string
+}
+
+type memberNode struct {
+	pos    int
+	object node
+	name   string
+}
+
+type indexNode struct {
+	pos    int
+	object node
+	index  node
+}
+
+type listNode struct {
+	pos   int
+	items []node
+}
+
+type unaryNode struct {
+	pos     int
+	op      string
+	operand node
+}
+
+type binaryNode struct {
+	pos         int
+	op          string
+	left, right node
+}
+
+type callNode struct {
+	pos  int
+	name string
+	args []node
+}
+
+func (n *literalNode) position() int { return n.pos }
+func (n *identNode) position() int   { return n.pos }
+func (n *memberNode) position() int  { return n.pos }
+func (n *indexNode) position() int   { return n.pos }
+func (n *listNode) position() int    { return n.pos }
+func (n *unaryNode) position() int   { return n.pos }
+func (n *binaryNode) position() int  { return n.pos }
+func (n *callNode) position() int    { return n.pos }
+
+// parser is a recursive descent parser, one method per precedence level
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// parse builds the syntax tree of an expression
+func parse(src string) (node, error) {
+	if len(src) > MaxLength {
+		return nil, fmt.Errorf("expression is longer than %d characters", MaxLength)
+	}
+
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return root, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is one of the given operators or keywords
+func (p *parser) accept(ops ...string) (token, bool) {
+	tok := p.peek()
+	if tok.kind != tokOperator && tok.kind != tokIdent {
+		return tok, false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return p.next(), true
+		}
+	}
+	return tok, false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		tok := p.peek()
+		if tok.kind == tokEOF {
+			return fmt.Errorf("expected %q at end of expression", op)
+		}
+		return fmt.Errorf("expected %q at position %d, got %q", op, tok.pos, tok.text)
+	}
+	return nil
+}
+
+// binaryLevel parses a left-associative chain of operators of the same precedence
+func (p *parser) binaryLevel(operand func() (node, error), ops ...string) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{pos: tok.pos, op: tok.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseOr() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, fmt.Errorf("expression is nested too deeply")
+	}
+	return p.binaryLevel(p.parseAnd, "||")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.binaryLevel(p.parseEquality, "&&")
+}
+
+func (p *parser) parseEquality() (node, error) {
+	return p.binaryLevel(p.parseComparison, "==", "!=")
+}
+
+func (p *parser) parseComparison() (node, error) {
+	return p.binaryLevel(p.parseAdditive, "<", "<=", ">", ">=", "in")
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	return p.binaryLevel(p.parseMultiplicative, "+", "-")
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	return p.binaryLevel(p.parseUnary, "*", "/", "%")
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if tok, ok := p.accept("!", "-"); ok {
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxDepth {
+			return nil, fmt.Errorf("expression is nested too deeply")
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: tok.pos, op: tok.text, operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if tok, ok := p.accept("."); ok {
+			name := p.next()
+			if name.kind != tokIdent {
+				return nil, fmt.Errorf("expected field name after '.' at position %d", tok.pos)
+			}
+			n = &memberNode{pos: tok.pos, object: n, name: name.text}
+			continue
+		}
+		if tok, ok := p.accept("["); ok {
+			index, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = &indexNode{pos: tok.pos, object: n, index: index}
+			continue
+		}
+		return n, nil
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return &literalNode{pos: tok.pos, value: tok.num}, nil
+
+	case tokString:
+		return &literalNode{pos: tok.pos, value: tok.str}, nil
+
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{pos: tok.pos, value: true}, nil
+		case "false":
+			return &literalNode{pos: tok.pos, value: false}, nil
+		case "null":
+			return &literalNode{pos: tok.pos, value: nil}, nil
+		case "in":
+			return nil, fmt.Errorf("unexpected 'in' at position %d", tok.pos)
+		}
+		if _, ok := p.accept("("); ok {
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			return &callNode{pos: tok.pos, name: tok.text, args: args}, nil
+		}
+		return &identNode{pos: tok.pos, name: tok.text}, nil
+
+	case tokOperator:
+		switch tok.text {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{pos: tok.pos, items: items}, nil
+		}
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+
+	return nil, fmt.Errorf("unexpected end of expression")
+}
+
+// parseList parses comma separated expressions up to the closing token
+func (p *parser) parseList(closing string) ([]node, error) {
+	var items []node
+	if _, ok := p.accept(closing); ok {
+		return items, nil
+	}
+	for {
+		item, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if _, ok := p.accept(","); ok {
+			continue
+		}
+		if err := p.expect(closing); err != nil {
+			return nil, err
+		}
+		return items, nil
+	}
+}
@@ -3,10 +3,11 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
-	"strings"
+	"time"
 
 	"todo-api/internal/models"
 	"todo-api/internal/services"
+	"todo-api/pkg/apperrors"
 )
 
 type DataSourceHandler struct {
@@ -20,77 +21,62 @@ func NewDataSourceHandler(service *services.DataSourceService) *DataSourceHandle
 // GetAllDataSources handles GET /api/data-sources
 // Returns metadata about all available data sources
 func (h *DataSourceHandler) GetAllDataSources(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		h.sendError(w, "Method not allowed", "METHOD_NOT_ALLOWED", http.StatusMethodNotAllowed)
-		return
-	}
-
 	dataSources := h.service.GetAllDataSources()
 
 	h.sendSuccess(w, dataSources)
 }
 
-// GetDataSourceData handles GET /api/data-sources/:dataSourceId
-// Returns data for a specific data source based on widget_type query parameter
+// GetDataSourceData handles GET /api/data-sources/{id}
+// Returns data for a specific data source based on widget_type query parameter.
+// Data sources that require an entity also take entity_id and an optional from/to date range.
 func (h *DataSourceHandler) GetDataSourceData(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		h.sendError(w, "Method not allowed", "METHOD_NOT_ALLOWED", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Extract data source ID from path
-	// Path: /api/data-sources/{dataSourceId}
-	path := strings.TrimPrefix(r.URL.Path, "/api/data-sources/")
-	dataSourceID := strings.TrimSuffix(path, "/")
-
+	dataSourceID := r.PathValue("id")
 	if dataSourceID == "" {
-		h.sendError(w, "Data source ID is required", "MISSING_PARAMETER", http.StatusBadRequest)
+		h.sendError(w, r, apperrors.NewProblem(http.StatusBadRequest, "Data source ID is required"), "MISSING_PARAMETER")
 		return
 	}
 
 	// Get widget_type query parameter
 	widgetType := r.URL.Query().Get("widget_type")
 	if widgetType == "" {
-		h.sendError(w, "Missing required parameter: widget_type", "MISSING_PARAMETER", http.StatusBadRequest)
+		h.sendError(w, r, apperrors.NewProblem(http.StatusBadRequest, "Missing required parameter: widget_type"), "MISSING_PARAMETER")
 		return
 	}
 
 	// Validate widget type
-	if widgetType != "pie_chart" && widgetType != "table" {
-		h.sendError(w, "Invalid widget_type. Must be 'pie_chart' or 'table'", "INVALID_WIDGET_TYPE", http.StatusBadRequest)
+	if widgetType != "pie_chart" && widgetType != "table" && widgetType != "bar_chart" {
+		h.sendError(w, r, apperrors.NewProblem(http.StatusBadRequest, "Invalid widget_type. Must be 'pie_chart', 'bar_chart' or 'table'"), "INVALID_WIDGET_TYPE")
 		return
 	}
 
 	// Check if data source exists
 	ds := h.service.GetDataSourceByID(dataSourceID)
 	if ds == nil {
-		h.sendError(w, "Data source not found: "+dataSourceID, "DATA_SOURCE_NOT_FOUND", http.StatusNotFound)
+		h.sendError(w, r, apperrors.NewProblem(http.StatusNotFound, "Data source not found: "+dataSourceID), "DATA_SOURCE_NOT_FOUND")
 		return
 	}
 
-	// Fetch data
-	data, err := h.service.GetDataSourceData(dataSourceID, widgetType)
+	// Entity-specific data sources, e.g. workflow metrics, take the entity ID and a date range
+	entityID := r.URL.Query().Get("entity_id")
+	if ds.RequiresEntity && entityID == "" {
+		h.sendError(w, r, apperrors.NewProblem(http.StatusBadRequest, "Missing required parameter: entity_id"), "MISSING_PARAMETER")
+		return
+	}
+	from, to, err := services.ParseMetricsRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"), time.Now())
 	if err != nil {
-		h.sendError(w, err.Error(), "INTERNAL_ERROR", http.StatusInternalServerError)
+		h.sendError(w, r, apperrors.FromError(err), "INVALID_DATE_RANGE")
 		return
 	}
+	query := models.DataSourceQuery{EntityID: entityID, From: from, To: to}
 
-	h.sendSuccess(w, data)
-}
-
-// DataSourcesHandler is the main router for /api/data-sources endpoints
-func (h *DataSourceHandler) DataSourcesHandler(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/api/data-sources")
-	path = strings.TrimPrefix(path, "/")
-
-	// If path is empty, list all data sources
-	if path == "" {
-		h.GetAllDataSources(w, r)
+	// Fetch data
+	data, err := h.service.GetDataSourceData(r.Context(), dataSourceID, widgetType, query)
+	if err != nil {
+		respondError(w, r, err)
 		return
 	}
 
-	// Otherwise, get specific data source data
-	h.GetDataSourceData(w, r)
+	h.sendSuccess(w, data)
 }
 
 // Helper methods
@@ -106,14 +92,9 @@ func (h *DataSourceHandler) sendSuccess(w http.ResponseWriter, data interface{})
 	json.NewEncoder(w).Encode(response)
 }
 
-func (h *DataSourceHandler) sendError(w http.ResponseWriter, message string, code string, statusCode int) {
-	response := models.DataSourceResponse{
-		Success: false,
-		Error:   message,
-		Code:    code,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(response)
+// sendError writes problem with code, the machine readable reason data source clients switch on
+func (h *DataSourceHandler) sendError(w http.ResponseWriter, r *http.Request, problem *apperrors.Problem, code string) {
+	problem.Code = code
+	problem.Instance = r.URL.Path
+	apperrors.Write(w, problem)
 }
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"todo-api/internal/database"
+	"todo-api/pkg/apperrors"
+)
+
+// StatusClientClosedRequest is the non-standard status recorded when the client
+// disconnected before the response was written
+const StatusClientClosedRequest = 499
+
+// respondError reports a failed service call as a problem whose status follows the
+// error kind, unless the request was cancelled (499) or a query timed out (504)
+func respondError(w http.ResponseWriter, r *http.Request, err error) {
+	if problem := interruptedProblem(r, err); problem != nil {
+		problem.Instance = r.URL.Path
+		apperrors.Write(w, problem)
+		return
+	}
+	apperrors.Respond(w, r, err)
+}
+
+// interruptedProblem describes a request that failed because it was cancelled or
+// ran out of time rather than because of err's kind, or returns nil
+func interruptedProblem(r *http.Request, err error) *apperrors.Problem {
+	switch {
+	case errors.Is(r.Context().Err(), context.Canceled):
+		problem := apperrors.NewProblem(StatusClientClosedRequest, "The client closed the request")
+		problem.Title = "Client Closed Request"
+		return problem
+	case database.IsTimeout(err):
+		return apperrors.NewProblem(http.StatusGatewayTimeout, "The database did not answer in time")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-api/pkg/apperrors"
+)
+
+func TestRespondError(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		err        error
+		wantStatus int
+		wantDetail string
+	}{
+		{"client went away", cancelled, context.Canceled, StatusClientClosedRequest, "The client closed the request"},
+		{"query timed out", context.Background(), fmt.Errorf("error listing todos: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "The database did not answer in time"},
+		{"typed error", context.Background(), fmt.Errorf("error getting todo: %w", apperrors.NotFound("todo not found")), http.StatusNotFound, "error getting todo: todo not found"},
+		{"internal error", context.Background(), errors.New("pq: connection refused"), http.StatusInternalServerError, "The server failed to process the request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			r := httptest.NewRequest(http.MethodGet, "/todos", nil).WithContext(tt.ctx)
+			rec := httptest.NewRecorder()
+
+			// Act
+			respondError(rec, r, tt.err)
+
+			// Assert
+			var problem apperrors.Problem
+			if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+				t.Fatalf("Failed to decode problem: %v", err)
+			}
+			if rec.Code != tt.wantStatus || problem.Status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d with body status %d", tt.wantStatus, rec.Code, problem.Status)
+			}
+			if problem.Detail != tt.wantDetail || problem.Instance != "/todos" {
+				t.Errorf("Expected detail %q for /todos, got %q for %q", tt.wantDetail, problem.Detail, problem.Instance)
+			}
+			if got := rec.Header().Get("Content-Type"); got != apperrors.ContentType {
+				t.Errorf("Expected content type %s, got %s", apperrors.ContentType, got)
+			}
+		})
+	}
+}
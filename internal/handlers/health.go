@@ -3,14 +3,33 @@ package handlers
 import (
 	"net/http"
 	"todo-api/internal/models"
+	"todo-api/internal/services"
 	"todo-api/pkg/utils"
 )
 
-func HealthHandler(w http.ResponseWriter, r *http.Request) {
-	health := models.HealthResponse{
-		Status:  "OK",
-		Message: "API health is ok",
-	}
+type HealthHandler struct {
+	service *services.HealthService
+}
 
-	utils.RespondJSON(w, http.StatusOK, health)
+func NewHealthHandler(service *services.HealthService) *HealthHandler {
+	return &HealthHandler{service: service}
+}
+
+// Live handles GET /health/live, the liveness probe
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	respondHealth(w, h.service.Live(r.Context()))
+}
+
+// Ready handles GET /health/ready and GET /health, the readiness probe
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	respondHealth(w, h.service.Ready(r.Context()))
+}
+
+// respondHealth answers 503 when the probe failed so orchestrators act on the status code alone
+func respondHealth(w http.ResponseWriter, health *models.HealthResponse) {
+	status := http.StatusOK
+	if health.Status != models.HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+	utils.RespondJSON(w, status, health)
 }
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+	"todo-api/internal/metrics"
+	"todo-api/pkg/utils"
+)
+
+// MetricsHandler serves the Prometheus metrics to scrapers presenting the token
+// or connecting from an allowed network
+type MetricsHandler struct {
+	registry *metrics.Registry
+	token    string
+	allowed  []*net.IPNet
+}
+
+// NewMetricsHandler serves registry. An empty token disables token access.
+func NewMetricsHandler(registry *metrics.Registry, token string, allowed []*net.IPNet) *MetricsHandler {
+	return &MetricsHandler{registry: registry, token: token, allowed: allowed}
+}
+
+// Metrics handles GET /metrics
+func (h *MetricsHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	if !h.permitted(r) {
+		utils.RespondError(w, http.StatusForbidden, "Metrics are not available to this client")
+		return
+	}
+	h.registry.ServeHTTP(w, r)
+}
+
+// permitted checks the bearer token, then the address the connection comes from.
+// Forwarded headers are ignored since clients can set them.
+func (h *MetricsHandler) permitted(r *http.Request) bool {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && h.token != "" {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1 {
+			return true
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range h.allowed {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
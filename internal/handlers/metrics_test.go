@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-api/internal/metrics"
+)
+
+func TestMetricsHandler_Access(t *testing.T) {
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	handler := NewMetricsHandler(metrics.NewRegistry(), "scrape-token", []*net.IPNet{loopback})
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		authorization string
+		forwardedFor  string
+		wantStatus    int
+	}{
+		{"allowed network", "127.0.0.1:51234", "", "", http.StatusOK},
+		{"token", "203.0.113.7:51234", "Bearer scrape-token", "", http.StatusOK},
+		{"wrong token", "203.0.113.7:51234", "Bearer guessed", "", http.StatusForbidden},
+		{"forwarded header is ignored", "203.0.113.7:51234", "", "127.0.0.1", http.StatusForbidden},
+		{"neither", "203.0.113.7:51234", "", "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+
+			// Act
+			rec := httptest.NewRecorder()
+			handler.Metrics(rec, req)
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+		})
+	}
+}
+
+func TestMetricsHandler_EmptyTokenDisabled(t *testing.T) {
+	// Arrange - without a configured token, an empty bearer token must not match
+	handler := NewMetricsHandler(metrics.NewRegistry(), "", nil)
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer ")
+
+	// Act
+	rec := httptest.NewRecorder()
+	handler.Metrics(rec, req)
+
+	// Assert
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, rec.Code)
+	}
+}
@@ -3,10 +3,10 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
-	"strings"
 
 	"todo-api/internal/models"
 	"todo-api/internal/services"
+	"todo-api/pkg/utils"
 
 	"github.com/google/uuid"
 )
@@ -22,9 +22,9 @@ func NewRoleHandler(roleService *services.RoleService) *RoleHandler {
 }
 
 func (h *RoleHandler) GetAllRoles(w http.ResponseWriter, r *http.Request) {
-	roles, err := h.roleService.GetAllRoles()
+	roles, err := h.roleService.GetAllRoles(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondError(w, r, err)
 		return
 	}
 
@@ -32,44 +32,17 @@ func (h *RoleHandler) GetAllRoles(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(roles)
 }
 
-func (h *RoleHandler) RolesHandler(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/roles")
-	path = strings.TrimPrefix(path, "/")
-
-	switch r.Method {
-	case http.MethodGet:
-		if path == "" {
-			h.GetAllRoles(w, r)
-		} else {
-			h.GetRoleByID(w, r, path)
-		}
-	case http.MethodPost:
-		if path == "" {
-			h.CreateRole(w, r)
-		}
-	case http.MethodPut:
-		if path != "" {
-			h.UpdateRole(w, r, path)
-		}
-	case http.MethodDelete:
-		if path != "" {
-			h.DeleteRole(w, r, path)
-		}
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	}
-}
-
-func (h *RoleHandler) GetRoleByID(w http.ResponseWriter, r *http.Request, roleIDStr string) {
-	roleID, err := uuid.Parse(roleIDStr)
+// GetRoleByID handles GET /roles/{id}
+func (h *RoleHandler) GetRoleByID(w http.ResponseWriter, r *http.Request) {
+	roleID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, "Invalid role ID", http.StatusBadRequest)
+		utils.RespondError(w, http.StatusBadRequest, "Invalid role ID")
 		return
 	}
 
-	role, err := h.roleService.GetRoleByID(roleID)
+	role, err := h.roleService.GetRoleByID(r.Context(), roleID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		respondError(w, r, err)
 		return
 	}
 
@@ -80,23 +53,23 @@ func (h *RoleHandler) GetRoleByID(w http.ResponseWriter, r *http.Request, roleID
 func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
 	var role models.Role
 	if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	// Validate required fields
 	if role.Name == "" {
-		http.Error(w, "Role name is required", http.StatusBadRequest)
+		utils.RespondError(w, http.StatusBadRequest, "Role name is required")
 		return
 	}
 
 	if role.PermissionId == nil {
-		http.Error(w, "Permission ID is required", http.StatusBadRequest)
+		utils.RespondError(w, http.StatusBadRequest, "Permission ID is required")
 		return
 	}
 
-	if err := h.roleService.CreateRole(&role); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.roleService.CreateRole(r.Context(), &role); err != nil {
+		respondError(w, r, err)
 		return
 	}
 
@@ -105,23 +78,24 @@ func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(role)
 }
 
-func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request, roleIDStr string) {
-	roleID, err := uuid.Parse(roleIDStr)
+// UpdateRole handles PUT /roles/{id}
+func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	roleID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, "Invalid role ID", http.StatusBadRequest)
+		utils.RespondError(w, http.StatusBadRequest, "Invalid role ID")
 		return
 	}
 
 	var role models.Role
 	if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	role.RoleId = roleID
 
-	if err := h.roleService.UpdateRole(&role); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.roleService.UpdateRole(r.Context(), &role); err != nil {
+		respondError(w, r, err)
 		return
 	}
 
@@ -129,59 +103,44 @@ func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request, roleIDS
 	json.NewEncoder(w).Encode(role)
 }
 
-func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request, roleIDStr string) {
-	roleID, err := uuid.Parse(roleIDStr)
+// DeleteRole handles DELETE /roles/{id}
+func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	roleID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, "Invalid role ID", http.StatusBadRequest)
+		utils.RespondError(w, http.StatusBadRequest, "Invalid role ID")
 		return
 	}
 
-	if err := h.roleService.DeleteRole(roleID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.roleService.DeleteRole(r.Context(), roleID); err != nil {
+		respondError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// UserRoleHandler handles /users/{userId}/role
-func (h *RoleHandler) UserRoleHandler(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/users/")
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 {
-		http.Error(w, "Invalid URL", http.StatusBadRequest)
-		return
-	}
-
-	userIDStr := parts[0]
-	if r.Method == http.MethodPost && parts[1] == "role" {
-		h.AssignRoleToUser(w, r, userIDStr)
-	} else if r.Method == http.MethodGet && parts[1] == "permissions" {
-		h.GetUserPermissions(w, r, userIDStr)
-	} else {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	}
+// AssignRoleRequest is the body of POST /users/{id}/role
+type AssignRoleRequest struct {
+	RoleID uuid.UUID `json:"role_id"`
 }
 
-// AssignRoleToUser handles POST /users/{userId}/role
-func (h *RoleHandler) AssignRoleToUser(w http.ResponseWriter, r *http.Request, userIDStr string) {
-	userID, err := uuid.Parse(userIDStr)
+// AssignRoleToUser handles POST /users/{id}/role
+func (h *RoleHandler) AssignRoleToUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		utils.RespondError(w, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
-	var request struct {
-		RoleID uuid.UUID `json:"role_id"`
-	}
+	var request AssignRoleRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		utils.RespondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	if err := h.roleService.AssignRoleToUser(userID, request.RoleID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.roleService.AssignRoleToUser(r.Context(), userID, request.RoleID); err != nil {
+		respondError(w, r, err)
 		return
 	}
 
@@ -191,31 +150,31 @@ func (h *RoleHandler) AssignRoleToUser(w http.ResponseWriter, r *http.Request, u
 	})
 }
 
-// GetUserPermissions handles GET /api/users/{userId}/permissions
-func (h *RoleHandler) GetUserPermissions(w http.ResponseWriter, r *http.Request, userIDStr string) {
-	userID, err := uuid.Parse(userIDStr)
+// GetUserPermissions handles GET /users/{id}/permissions
+func (h *RoleHandler) GetUserPermissions(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		utils.RespondError(w, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
 	// Get user from context (set by auth middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok || user == nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Users can only view their own permissions unless they have view permission
 	if user.UserID != userID && !user.HasPermission(models.PermView) {
-		http.Error(w, "Forbidden", http.StatusForbidden)
+		utils.RespondError(w, http.StatusForbidden, "Forbidden")
 		return
 	}
 
 	// Get the target user's role
-	role, err := h.roleService.GetRoleByID(*user.RoleID)
+	role, err := h.roleService.GetRoleByID(r.Context(), *user.RoleID)
 	if err != nil {
-		http.Error(w, "Role not found", http.StatusNotFound)
+		utils.RespondError(w, http.StatusNotFound, "Role not found")
 		return
 	}
 
@@ -228,31 +187,3 @@ func (h *RoleHandler) GetUserPermissions(w http.ResponseWriter, r *http.Request,
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
-
-// AssignRoleHandler handles POST /users/{userId}/role with specific routing
-func (h *RoleHandler) AssignRoleHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract user ID from path like /users/{id}/role
-	path := strings.TrimPrefix(r.URL.Path, "/users/")
-	path = strings.TrimSuffix(path, "/role")
-
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	h.AssignRoleToUser(w, r, path)
-}
-
-// GetUserPermissionsHandler handles GET /users/{userId}/permissions with specific routing
-func (h *RoleHandler) GetUserPermissionsHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract user ID from path like /users/{id}/permissions
-	path := strings.TrimPrefix(r.URL.Path, "/users/")
-	path = strings.TrimSuffix(path, "/permissions")
-
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	h.GetUserPermissions(w, r, path)
-}
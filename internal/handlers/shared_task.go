@@ -5,27 +5,27 @@ import (
 	"fmt"
 	"net/http"
 
+	"todo-api/internal/interfaces"
 	"todo-api/internal/models"
-	"todo-api/internal/repository"
 	"todo-api/pkg/utils"
 
 	"github.com/google/uuid"
 )
 
 type SharedTaskHandler struct {
-	repo *repository.SharedTaskRepository
+	repo interfaces.SharedTaskRepositoryInterface
 }
 
-func NewSharedTaskHandler() *SharedTaskHandler {
+func NewSharedTaskHandler(repo interfaces.SharedTaskRepositoryInterface) *SharedTaskHandler {
 	return &SharedTaskHandler{
-		repo: repository.NewSharedTaskRepository(),
+		repo: repo,
 	}
 }
 
 func (h *SharedTaskHandler) GetAllSharedTasks(w http.ResponseWriter, r *http.Request) {
-	sharedTasks, err := h.repo.GetAll()
+	sharedTasks, err := h.repo.GetAll(r.Context())
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -33,15 +33,9 @@ func (h *SharedTaskHandler) GetAllSharedTasks(w http.ResponseWriter, r *http.Req
 }
 
 func (h *SharedTaskHandler) CreateSharedTask(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		utils.RespondError(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
-
 	var newSharedTask models.SharedTask
 
 	err := json.NewDecoder(r.Body).Decode(&newSharedTask)
-	fmt.Println("new shared task", newSharedTask)
 	if err != nil {
 		utils.RespondError(w, http.StatusBadRequest, "Error reading body")
 		return
@@ -54,32 +48,21 @@ func (h *SharedTaskHandler) CreateSharedTask(w http.ResponseWriter, r *http.Requ
 
 	newSharedTask.ID = uuid.New().String()
 
-	err = h.repo.Create(&newSharedTask)
+	err = h.repo.Create(r.Context(), &newSharedTask)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
 	utils.RespondJSON(w, http.StatusCreated, newSharedTask)
 }
 
-func (h *SharedTaskHandler) SharedTasksHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case "GET":
-		h.GetAllSharedTasks(w, r)
-	case "POST":
-		h.CreateSharedTask(w, r)
-	default:
-		utils.RespondError(w, http.StatusMethodNotAllowed, "Method not allowed")
-	}
-}
-
 func (h *SharedTaskHandler) GetSharedTaskById(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Path[len("/shared-tasks/"):]
+	id := r.PathValue("id")
 
-	sharedTask, err := h.repo.GetById(id)
+	sharedTask, err := h.repo.GetById(r.Context(), id)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 	if sharedTask == nil {
@@ -91,11 +74,11 @@ func (h *SharedTaskHandler) GetSharedTaskById(w http.ResponseWriter, r *http.Req
 }
 
 func (h *SharedTaskHandler) DeleteSharedTask(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Path[len("/shared-tasks/"):]
+	id := r.PathValue("id")
 
-	rowsAffected, err := h.repo.Delete(id)
+	rowsAffected, err := h.repo.Delete(r.Context(), id)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 	if rowsAffected == 0 {
@@ -108,17 +91,6 @@ func (h *SharedTaskHandler) DeleteSharedTask(w http.ResponseWriter, r *http.Requ
 	})
 }
 
-func (h *SharedTaskHandler) SharedTaskByIdHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case "GET":
-		h.GetSharedTaskById(w, r)
-	case "DELETE":
-		h.DeleteSharedTask(w, r)
-	default:
-		utils.RespondError(w, http.StatusMethodNotAllowed, "Method not allowed")
-	}
-}
-
 func (h *SharedTaskHandler) GetSharedTasksByOwnerId(w http.ResponseWriter, r *http.Request) {
 	// Extract owner_id from query parameter
 	ownerIDStr := r.URL.Query().Get("owner_id")
@@ -136,9 +108,9 @@ func (h *SharedTaskHandler) GetSharedTasksByOwnerId(w http.ResponseWriter, r *ht
 	}
 
 	// Get shared tasks from repository
-	sharedTasks, err := h.repo.GetByOwnerId(ownerID)
+	sharedTasks, err := h.repo.GetByOwnerId(r.Context(), ownerID)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -162,9 +134,9 @@ func (h *SharedTaskHandler) GetSharedTasksById(w http.ResponseWriter, r *http.Re
 	}
 
 	// Get todos shared with the user from repository
-	sharedTodos, err := h.repo.GetTodosBySharedId(sharedWithID)
+	sharedTodos, err := h.repo.GetTodosBySharedId(r.Context(), sharedWithID)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -180,9 +152,9 @@ func (h *SharedTaskHandler) GetSharedTasksByTodoId(w http.ResponseWriter, r *htt
 	}
 
 	// Get shared tasks from repository
-	sharedTasks, err := h.repo.GetByTodoId(todoID)
+	sharedTasks, err := h.repo.GetByTodoId(r.Context(), todoID)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 
@@ -24,9 +23,9 @@ func NewTodoHandler(service interfaces.TodoInterface) *TodoHandler {
 }
 
 func (h *TodoHandler) GetAllTodos(w http.ResponseWriter, r *http.Request) {
-	todos, err := h.service.GetAllTodos()
+	todos, err := h.service.GetAllTodos(r.Context())
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -36,15 +35,7 @@ func (h *TodoHandler) GetAllTodos(w http.ResponseWriter, r *http.Request) {
 func (h *TodoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 	var newTodo models.Todo
 
-	fmt.Println("body received", r.Body)
-
 	err := json.NewDecoder(r.Body).Decode(&newTodo)
-
-	fmt.Println("user id", newTodo.UserID)
-	fmt.Println("todo id", newTodo.Id)
-	fmt.Println("todo task", newTodo.TaskName)
-	fmt.Println("todo description", newTodo.TaskDescription)
-
 	if err != nil {
 		utils.RespondError(w, http.StatusBadRequest, "Error reading body")
 		return
@@ -57,32 +48,21 @@ func (h *TodoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 
 	newTodo.Id = uuid.New().String()
 
-	err = h.service.CreateTodo(&newTodo)
+	err = h.service.CreateTodo(r.Context(), &newTodo)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
 	utils.RespondJSON(w, http.StatusCreated, newTodo)
 }
 
-func (h *TodoHandler) TodosHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case "GET":
-		h.GetAllTodos(w, r)
-	case "POST":
-		h.CreateTodo(w, r)
-	default:
-		utils.RespondError(w, http.StatusMethodNotAllowed, "Method not allowed")
-	}
-}
-
 func (h *TodoHandler) GetTodoById(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Path[len("/todos/"):]
+	id := r.PathValue("id")
 
-	todo, err := h.service.GetTodoByID(id)
+	todo, err := h.service.GetTodoByID(r.Context(), id)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 	if todo == nil {
@@ -94,7 +74,7 @@ func (h *TodoHandler) GetTodoById(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *TodoHandler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Path[len("/todos/"):]
+	id := r.PathValue("id")
 
 	var updatedTodo models.Todo
 	body, err := ioutil.ReadAll(r.Body)
@@ -109,9 +89,9 @@ func (h *TodoHandler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rowsAffected, err := h.service.UpdateTodo(id, &updatedTodo)
+	rowsAffected, err := h.service.UpdateTodo(r.Context(), id, &updatedTodo)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 	if rowsAffected == 0 {
@@ -124,11 +104,11 @@ func (h *TodoHandler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *TodoHandler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Path[len("/todos/"):]
+	id := r.PathValue("id")
 
-	rowsAffected, err := h.service.DeleteTodo(id)
+	rowsAffected, err := h.service.DeleteTodo(r.Context(), id)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 	if rowsAffected == 0 {
@@ -141,19 +121,6 @@ func (h *TodoHandler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (h *TodoHandler) TodoByIdHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case "GET":
-		h.GetTodoById(w, r)
-	case "PUT":
-		h.UpdateTodo(w, r)
-	case "DELETE":
-		h.DeleteTodo(w, r)
-	default:
-		utils.RespondError(w, http.StatusMethodNotAllowed, "Method not allowed")
-	}
-}
-
 func (h *TodoHandler) GetTodosByUserId(w http.ResponseWriter, r *http.Request) {
 	// Extract user_id from query parameter
 	userIDStr := r.URL.Query().Get("user_id")
@@ -166,9 +133,9 @@ func (h *TodoHandler) GetTodosByUserId(w http.ResponseWriter, r *http.Request) {
 	userID := userIDStr
 
 	// Get todos from repository
-	todos, err := h.service.GetTodosByUserID(userID)
+	todos, err := h.service.GetTodosByUserID(r.Context(), userID)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -4,30 +4,32 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"todo-api/internal/interfaces"
 	"todo-api/internal/models"
-	"todo-api/internal/repository"
 	"todo-api/pkg/utils"
-
-	"github.com/google/uuid"
 )
 
+// TodoWorkflowHandler serves the legacy Draft/Review/Approved routes on top of the workflow engine
 type TodoWorkflowHandler struct {
-	repo *repository.TodoWorkflow
+	service interfaces.TodoWorkflowInterface
 }
 
-func NewTodoWorkflowHandler() *TodoWorkflowHandler {
+func NewTodoWorkflowHandler(service interfaces.TodoWorkflowInterface) *TodoWorkflowHandler {
 	return &TodoWorkflowHandler{
-		repo: repository.NewTodoWorkflow(),
+		service: service,
 	}
 }
 
+// CreateTodoTaskRequest is the body of POST /workflow/todos
+type CreateTodoTaskRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	AssignedTo  string `json:"assigned_to"`
+}
+
 // CreateTodoTask creates a new todo task in draft status
 func (h *TodoWorkflowHandler) CreateTodoTask(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		AssignedTo  string `json:"assigned_to"`
-	}
+	var req CreateTodoTaskRequest
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -40,11 +42,15 @@ func (h *TodoWorkflowHandler) CreateTodoTask(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	id := uuid.New().String()
+	todo := &models.TodoTask{
+		Title:       req.Title,
+		Description: req.Description,
+		AssignedTo:  req.AssignedTo,
+	}
 
-	todo, err := h.repo.CreateTodo(id, req.Title, req.Description, req.AssignedTo)
+	err = h.service.CreateTodoTask(r.Context(), todo)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -65,9 +71,9 @@ func (h *TodoWorkflowHandler) SubmitForReview(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	err := h.repo.SubmitForReview(id, submittedBy)
+	err := h.service.SubmitForReview(r.Context(), id, submittedBy)
 	if err != nil {
-		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -91,9 +97,9 @@ func (h *TodoWorkflowHandler) ApproveTodo(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err := h.repo.ApprovedTodo(id, approvedBy)
+	err := h.service.ApproveTodo(r.Context(), id, approvedBy)
 	if err != nil {
-		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -117,9 +123,9 @@ func (h *TodoWorkflowHandler) RejectTodo(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := h.repo.RejectTodo(id, rejectedBy)
+	err := h.service.RejectTodo(r.Context(), id, rejectedBy)
 	if err != nil {
-		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -136,9 +142,9 @@ func (h *TodoWorkflowHandler) GetTodosByUser(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	todos, err := h.repo.GetTodosByUser(userID)
+	todos, err := h.service.GetTodosByUser(r.Context(), userID)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -160,9 +166,9 @@ func (h *TodoWorkflowHandler) GetTodosByStatus(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	todos, err := h.repo.GetTodosByStatus(status)
+	todos, err := h.service.GetTodosByStatus(r.Context(), status)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
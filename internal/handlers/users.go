@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"todo-api/internal/interfaces"
 	"todo-api/internal/models"
@@ -34,18 +33,11 @@ func NewUsersHandler(service interfaces.UserInterface) *UsersHandler {
 }
 
 func (h *UsersHandler) Register(w http.ResponseWriter, r *http.Request) {
-
-	if r.Method != "POST" {
-		er := http.StatusMethodNotAllowed
-		http.Error(w, "Invalid method", er)
-		return
-	}
-
 	var req RegisterRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 
 	if err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		utils.RespondError(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
@@ -68,9 +60,9 @@ func (h *UsersHandler) Register(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	err = h.service.Register(newUser)
+	err = h.service.Register(r.Context(), newUser)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -78,26 +70,20 @@ func (h *UsersHandler) Register(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UsersHandler) Login(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		er := http.StatusMethodNotAllowed
-		http.Error(w, "Invalid method", er)
-		return
-	}
-
 	var req LoginRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 
 	if err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		utils.RespondError(w, http.StatusBadRequest, "Invalid JSON")
 		return
 	}
 
 	email := req.Email
 	password := req.Password
 
-	response, err := h.service.Login(email, password)
+	response, err := h.service.Login(r.Context(), email, password)
 	if err != nil {
-		utils.RespondError(w, http.StatusUnauthorized, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -105,25 +91,15 @@ func (h *UsersHandler) Login(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UsersHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "GET" {
-		utils.RespondError(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
-
-	users, err := h.service.GetAllUsers()
+	users, err := h.service.GetAllUsers(r.Context())
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 	utils.RespondJSON(w, http.StatusOK, users)
 }
 
 func (h *UsersHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		utils.RespondError(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
-
 	updates := &models.User{}
 
 	if err := utils.DecodeJson(r, updates); err != nil {
@@ -131,11 +107,9 @@ func (h *UsersHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Printf("the updates are %v", updates)
-
-	result, err := h.service.UpdateUser(updates)
+	result, err := h.service.UpdateUser(r.Context(), updates)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
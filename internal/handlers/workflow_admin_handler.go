@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
+	"todo-api/internal/interfaces"
+	"todo-api/internal/middleware"
 	"todo-api/internal/models"
-	"todo-api/internal/repository"
+	"todo-api/internal/services"
 	"todo-api/pkg/utils"
 
 	"github.com/google/uuid"
@@ -13,22 +17,32 @@ import (
 
 // WorkflowAdminHandler handles workflow administration (creating workflows, steps, transitions)
 type WorkflowAdminHandler struct {
-	repo *repository.WorkflowRepository
+	repo     interfaces.WorkflowRepositoryInterface
+	roleRepo interfaces.RoleRepositoryInterface
+	engine   *services.WorkflowEngine
+	metrics  *services.WorkflowMetricsService
 }
 
-func NewWorkflowAdminHandler() *WorkflowAdminHandler {
+func NewWorkflowAdminHandler(engine *services.WorkflowEngine, repo interfaces.WorkflowRepositoryInterface, roleRepo interfaces.RoleRepositoryInterface, metrics *services.WorkflowMetricsService) *WorkflowAdminHandler {
 	return &WorkflowAdminHandler{
-		repo: repository.NewWorkflowRepository(),
+		repo:     repo,
+		roleRepo: roleRepo,
+		engine:   engine,
+		metrics:  metrics,
 	}
 }
 
+// CreateWorkflowRequest is the body of POST /api/workflows
+type CreateWorkflowRequest struct {
+	Name                 string `json:"name"`
+	Description          string `json:"description"`
+	CreatedBy            string `json:"created_by"`
+	CompleteTodoOnFinish bool   `json:"complete_todo_on_finish"`
+}
+
 // CreateWorkflow creates a new workflow template
 func (h *WorkflowAdminHandler) CreateWorkflow(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
-		CreatedBy   string `json:"created_by"`
-	}
+	var req CreateWorkflowRequest
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -49,11 +63,13 @@ func (h *WorkflowAdminHandler) CreateWorkflow(w http.ResponseWriter, r *http.Req
 		CreatedBy:   req.CreatedBy,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
+
+		CompleteTodoOnFinish: req.CompleteTodoOnFinish,
 	}
 
-	err = h.repo.CreateWorkflow(workflow)
+	err = h.repo.CreateWorkflow(r.Context(), workflow)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -68,26 +84,106 @@ func (h *WorkflowAdminHandler) GetWorkflow(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	workflow, err := h.repo.GetWorkflow(id)
+	workflow, err := h.repo.GetWorkflow(r.Context(), id)
 	if err != nil {
-		utils.RespondError(w, http.StatusNotFound, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
 	utils.RespondJSON(w, http.StatusOK, workflow)
 }
 
+// SimulateWorkflow dry-runs a scripted sequence of actions through a workflow without creating a task
+func (h *WorkflowAdminHandler) SimulateWorkflow(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		utils.RespondError(w, http.StatusBadRequest, "Workflow ID is required")
+		return
+	}
+
+	var input models.SimulationInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Error reading body")
+		return
+	}
+
+	for i, step := range input.Steps {
+		if step.Actor == "" || step.Action == "" {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("steps[%d]: actor and action are required", i))
+			return
+		}
+	}
+
+	result, err := h.engine.SimulateWorkflow(r.Context(), id, &input)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
+// GetWorkflowMetrics reports cycle time, step dwell time, rework and user throughput of a workflow
+// for the date range given by the from and to query parameters
+func (h *WorkflowAdminHandler) GetWorkflowMetrics(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		utils.RespondError(w, http.StatusBadRequest, "Workflow ID is required")
+		return
+	}
+
+	from, to, err := services.ParseMetricsRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"), time.Now())
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	if _, err := h.repo.GetWorkflow(r.Context(), id); err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	metrics, err := h.metrics.GetWorkflowMetrics(r.Context(), id, from, to)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, metrics)
+}
+
 // GetAllWorkflows retrieves all active workflows
 func (h *WorkflowAdminHandler) GetAllWorkflows(w http.ResponseWriter, r *http.Request) {
-	workflows, err := h.repo.GetAllWorkflows()
+	workflows, err := h.repo.GetAllWorkflows(r.Context())
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
 	utils.RespondJSON(w, http.StatusOK, workflows)
 }
 
+// CreateStepRequest is the body of POST /api/workflows/{workflow_id}/steps
+type CreateStepRequest struct {
+	StepName         string   `json:"step_name"`
+	StepOrder        int      `json:"step_order"`
+	Initial          bool     `json:"initial"`
+	Final            bool     `json:"final"`
+	AllowedRoles     []string `json:"allowed_roles"`
+	SLAMinutes       int      `json:"sla_minutes"`
+	EscalationAction string   `json:"escalation_action"`
+	EscalationValue  string   `json:"escalation_value"`
+	ApprovalMode     string   `json:"approval_mode"`
+	Quorum           int      `json:"quorum"`
+	ReviewerUsers    []string `json:"reviewer_users"`
+	ReviewerRoles    []string `json:"reviewer_roles"`
+	VetoAction       string   `json:"veto_action"`
+	SubworkflowID    string   `json:"subworkflow_id"`
+	SubworkflowItems string   `json:"subworkflow_items"`
+	QueueRole        string   `json:"queue_role"`
+}
+
 // CreateStep creates a new workflow step
 func (h *WorkflowAdminHandler) CreateStep(w http.ResponseWriter, r *http.Request) {
 	workflowID := r.PathValue("workflow_id")
@@ -96,13 +192,7 @@ func (h *WorkflowAdminHandler) CreateStep(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var req struct {
-		StepName     string   `json:"step_name"`
-		StepOrder    int      `json:"step_order"`
-		Initial      bool     `json:"initial"`
-		Final        bool     `json:"final"`
-		AllowedRoles []string `json:"allowed_roles"`
-	}
+	var req CreateStepRequest
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -115,6 +205,72 @@ func (h *WorkflowAdminHandler) CreateStep(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if req.SLAMinutes < 0 {
+		utils.RespondError(w, http.StatusBadRequest, "sla_minutes cannot be negative")
+		return
+	}
+
+	switch req.EscalationAction {
+	case "", models.EscalationNotify:
+	case models.EscalationAutoTransition, models.EscalationReassignUser, models.EscalationReassignRole:
+		if req.EscalationValue == "" {
+			utils.RespondError(w, http.StatusBadRequest, "escalation_value is required for escalation_action "+req.EscalationAction)
+			return
+		}
+	default:
+		utils.RespondError(w, http.StatusBadRequest, "Invalid escalation_action. Must be auto_transition, reassign_user, reassign_role or notify")
+		return
+	}
+
+	switch req.ApprovalMode {
+	case "":
+		req.ApprovalMode = models.ApprovalSingle
+	case models.ApprovalSingle:
+	case models.ApprovalQuorum:
+		if req.Quorum < 1 {
+			utils.RespondError(w, http.StatusBadRequest, "quorum must be at least 1 for approval_mode quorum")
+			return
+		}
+	case models.ApprovalAllRoles:
+		if len(req.ReviewerRoles) == 0 {
+			utils.RespondError(w, http.StatusBadRequest, "reviewer_roles are required for approval_mode all_roles")
+			return
+		}
+	default:
+		utils.RespondError(w, http.StatusBadRequest, "Invalid approval_mode. Must be single, quorum or all_roles")
+		return
+	}
+
+	if req.SubworkflowItems != "" && req.SubworkflowID == "" {
+		utils.RespondError(w, http.StatusBadRequest, "subworkflow_items requires subworkflow_id")
+		return
+	}
+	if req.SubworkflowID != "" {
+		if req.Final || req.ApprovalMode != models.ApprovalSingle {
+			utils.RespondError(w, http.StatusBadRequest, "subworkflow steps cannot be final or collect votes")
+			return
+		}
+		if req.SubworkflowID == workflowID {
+			utils.RespondError(w, http.StatusBadRequest, "a workflow cannot start itself as a subworkflow")
+			return
+		}
+		if _, err := h.repo.GetWorkflow(r.Context(), req.SubworkflowID); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "subworkflow_id: "+err.Error())
+			return
+		}
+	}
+
+	if req.QueueRole != "" {
+		if req.Final || req.SubworkflowID != "" || req.ApprovalMode != models.ApprovalSingle {
+			utils.RespondError(w, http.StatusBadRequest, "queue steps cannot be final, start subworkflows or collect votes")
+			return
+		}
+		if role, err := h.roleRepo.GetRoleByName(r.Context(), req.QueueRole); err != nil || role == nil {
+			utils.RespondError(w, http.StatusBadRequest, "queue_role: role not found: "+req.QueueRole)
+			return
+		}
+	}
+
 	step := &models.WorkflowStep{
 		ID:           uuid.New().String(),
 		WorkflowID:   workflowID,
@@ -124,11 +280,35 @@ func (h *WorkflowAdminHandler) CreateStep(w http.ResponseWriter, r *http.Request
 		Final:        req.Final,
 		AllowedRoles: req.AllowedRoles,
 		CreatedAt:    time.Now(),
+
+		SLAMinutes:       req.SLAMinutes,
+		EscalationAction: req.EscalationAction,
+		EscalationValue:  req.EscalationValue,
+
+		ApprovalMode:  req.ApprovalMode,
+		Quorum:        req.Quorum,
+		ReviewerUsers: req.ReviewerUsers,
+		ReviewerRoles: req.ReviewerRoles,
+		VetoAction:    req.VetoAction,
+
+		SubworkflowID:    req.SubworkflowID,
+		SubworkflowItems: req.SubworkflowItems,
+
+		QueueRole: req.QueueRole,
+	}
+
+	if err := h.engine.ValidateEscalation(r.Context(), step); err != nil {
+		respondError(w, r, err)
+		return
+	}
+	if err := h.engine.ValidateApproval(r.Context(), step); err != nil {
+		respondError(w, r, err)
+		return
 	}
 
-	err = h.repo.CreateStep(step)
+	err = h.repo.CreateStep(r.Context(), step)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -143,15 +323,26 @@ func (h *WorkflowAdminHandler) GetWorkflowSteps(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	steps, err := h.repo.GetWorkflowSteps(workflowID)
+	steps, err := h.repo.GetWorkflowSteps(r.Context(), workflowID)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
 	utils.RespondJSON(w, http.StatusOK, steps)
 }
 
+// CreateTransitionRequest is the body of POST /api/workflows/{workflow_id}/transitions
+type CreateTransitionRequest struct {
+	FromStepID     string             `json:"from_step_id"`
+	ToStepID       string             `json:"to_step_id"`
+	ActionName     string             `json:"action_name"`
+	ConditionType  string             `json:"condition_type"`
+	ConditionValue string             `json:"condition_value"`
+	FormSchema     *models.FormSchema `json:"form_schema"`
+	Automatic      bool               `json:"automatic"`
+}
+
 // CreateTransition creates a new workflow transition
 func (h *WorkflowAdminHandler) CreateTransition(w http.ResponseWriter, r *http.Request) {
 	workflowID := r.PathValue("workflow_id")
@@ -160,13 +351,7 @@ func (h *WorkflowAdminHandler) CreateTransition(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	var req struct {
-		FromStepID     string `json:"from_step_id"`
-		ToStepID       string `json:"to_step_id"`
-		ActionName     string `json:"action_name"`
-		ConditionType  string `json:"condition_type"`
-		ConditionValue string `json:"condition_value"`
-	}
+	var req CreateTransitionRequest
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -179,6 +364,20 @@ func (h *WorkflowAdminHandler) CreateTransition(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if req.FormSchema != nil {
+		if err := req.FormSchema.Check(); err != nil {
+			respondError(w, r, err)
+			return
+		}
+	}
+
+	if req.ConditionType == services.ConditionExpression {
+		if err := services.ValidateGuardExpression(req.ConditionValue); err != nil {
+			respondError(w, r, err)
+			return
+		}
+	}
+
 	transition := &models.WorkflowTransition{
 		ID:             uuid.New().String(),
 		WorkflowID:     workflowID,
@@ -188,11 +387,18 @@ func (h *WorkflowAdminHandler) CreateTransition(w http.ResponseWriter, r *http.R
 		ConditionType:  req.ConditionType,
 		ConditionValue: req.ConditionValue,
 		CreatedAt:      time.Now(),
+		FormSchema:     req.FormSchema,
+		Automatic:      req.Automatic,
 	}
 
-	err = h.repo.CreateTransition(transition)
+	if err := services.ValidateAutomaticTransition(transition); err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	err = h.repo.CreateTransition(r.Context(), transition)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -207,11 +413,75 @@ func (h *WorkflowAdminHandler) GetWorkflowTransitions(w http.ResponseWriter, r *
 		return
 	}
 
-	transitions, err := h.repo.GetTransitions(workflowID)
+	transitions, err := h.repo.GetTransitions(r.Context(), workflowID)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
 	utils.RespondJSON(w, http.StatusOK, transitions)
 }
+
+// SuspendTask pauses a running task
+func (h *WorkflowAdminHandler) SuspendTask(w http.ResponseWriter, r *http.Request) {
+	h.changeTaskStatus(w, r, h.engine.SuspendInstance, "Task suspended successfully")
+}
+
+// ResumeTask reactivates a suspended task
+func (h *WorkflowAdminHandler) ResumeTask(w http.ResponseWriter, r *http.Request) {
+	h.changeTaskStatus(w, r, h.engine.ResumeInstance, "Task resumed successfully")
+}
+
+// CancelTask aborts a task
+func (h *WorkflowAdminHandler) CancelTask(w http.ResponseWriter, r *http.Request) {
+	h.changeTaskStatus(w, r, h.engine.CancelInstance, "Task cancelled successfully")
+}
+
+// TaskStatusRequest is the body of the suspend, resume and cancel task routes
+type TaskStatusRequest struct {
+	Reason string `json:"reason"`
+}
+
+// changeTaskStatus decodes the required reason of a lifecycle change and applies it as the current user
+func (h *WorkflowAdminHandler) changeTaskStatus(
+	w http.ResponseWriter,
+	r *http.Request,
+	change func(ctx context.Context, instanceID, performedBy, reason string) (*models.AssignedTodo, error),
+	message string,
+) {
+	instanceID := r.PathValue("instance_id")
+	if instanceID == "" {
+		utils.RespondError(w, http.StatusBadRequest, "Instance ID is required")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.UserKey).(*models.User)
+	if !ok {
+		utils.RespondError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req TaskStatusRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Error reading body")
+		return
+	}
+
+	if req.Reason == "" {
+		utils.RespondError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+
+	instance, err := change(r.Context(), instanceID, user.UserID.String(), req.Reason)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  message,
+		"instance": instance,
+	})
+}
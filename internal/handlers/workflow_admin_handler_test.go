@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"todo-api/internal/models"
+	"todo-api/internal/repository"
+	"todo-api/internal/services"
+
+	"github.com/google/uuid"
+)
+
+func TestChangeTaskStatus_RequiresReason(t *testing.T) {
+	// Arrange - the reason is checked before the task is looked up
+	repos := repository.NewMemoryRepositories()
+	alice := &models.User{UserID: uuid.New(), Username: "alice", Email: "alice@example.com", IsActive: true}
+	instanceID := uuid.NewString()
+	handler := NewWorkflowAdminHandler(services.NewWorkflowEngine(repos), repos.Workflows, repos.Roles, nil)
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		change http.HandlerFunc
+	}{
+		{"suspend without body", "/suspend", "", handler.SuspendTask},
+		{"resume without body", "/resume", "", handler.ResumeTask},
+		{"resume with empty reason", "/resume", `{"reason": ""}`, handler.ResumeTask},
+		{"cancel with empty reason", "/cancel", `{"reason": ""}`, handler.CancelTask},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asUser(http.MethodPost, "/api/tasks/"+instanceID+tt.target, tt.body, alice)
+			req.SetPathValue("instance_id", instanceID)
+
+			// Act
+			rec := httptest.NewRecorder()
+			tt.change(rec, req)
+
+			// Assert
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateStep_ValidatesApproval(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	handler := NewWorkflowAdminHandler(services.NewWorkflowEngine(repos), repos.Workflows, repos.Roles, nil)
+	alice := &models.User{UserID: uuid.New(), Username: "alice", Email: "alice@example.com", IsActive: true}
+	workflow := &models.Workflow{ID: uuid.NewString(), Name: "Contracts", IsActive: true, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := repos.Workflows.CreateWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("Failed to create workflow: %v", err)
+	}
+	if err := repos.Roles.CreateRole(ctx, &models.Role{Name: "Legal"}); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	bob, carol := uuid.NewString(), uuid.NewString()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"unknown reviewer role", `{"step_name": "Review", "approval_mode": "all_roles", "reviewer_roles": ["Legal", "Finance"]}`, http.StatusBadRequest},
+		{"quorum above the distinct reviewers", `{"step_name": "Review", "approval_mode": "quorum", "quorum": 2, "reviewer_users": ["` + bob + `", "` + bob + `"]}`, http.StatusBadRequest},
+		{"reachable quorum", `{"step_name": "Review", "approval_mode": "quorum", "quorum": 2, "reviewer_users": ["` + bob + `", "` + carol + `"]}`, http.StatusCreated},
+		{"existing reviewer role", `{"step_name": "Review", "approval_mode": "all_roles", "reviewer_roles": ["Legal"], "veto_action": "reject"}`, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asUser(http.MethodPost, "/api/workflows/"+workflow.ID+"/steps", tt.body, alice)
+			req.SetPathValue("workflow_id", workflow.ID)
+
+			// Act
+			rec := httptest.NewRecorder()
+			handler.CreateStep(rec, req)
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -2,36 +2,46 @@ package handlers
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
-	"todo-api/internal/repository"
+	"todo-api/internal/interfaces"
+	"todo-api/internal/middleware"
+	"todo-api/internal/models"
 	"todo-api/internal/services"
+	"todo-api/pkg/apperrors"
 	"todo-api/pkg/utils"
 )
 
 // WorkflowInstanceHandler handles workflow instance operations (starting tasks, executing actions)
 type WorkflowInstanceHandler struct {
 	engine       *services.WorkflowEngine
-	instanceRepo *repository.WorkflowInstanceRepository
+	instanceRepo interfaces.WorkflowInstanceRepositoryInterface
 }
 
-func NewWorkflowInstanceHandler() *WorkflowInstanceHandler {
+func NewWorkflowInstanceHandler(engine *services.WorkflowEngine, instanceRepo interfaces.WorkflowInstanceRepositoryInterface) *WorkflowInstanceHandler {
 	return &WorkflowInstanceHandler{
-		engine:       services.NewWorkflowEngine(),
-		instanceRepo: repository.NewWorkflowInstanceRepository(),
+		engine:       engine,
+		instanceRepo: instanceRepo,
 	}
 }
 
+// StartTaskRequest is the body of POST /api/tasks
+type StartTaskRequest struct {
+	WorkflowID    string                 `json:"workflow_id"`
+	AssignedTo    string                 `json:"assigned_to"`
+	CurrentStepId string                 `json:"current_step_id"`
+	TodoId        string                 `json:"todo_id"`
+	Payload       map[string]interface{} `json:"payload"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
 // StartTask creates a new workflow instance
 func (h *WorkflowInstanceHandler) StartTask(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		WorkflowID    string    `json:"workflow_id"`
-		AssignedTo    string    `json:"assigned_to"`
-		CurrentStepId string    `json:"current_step_id"`
-		TodoId        string    `json:"todo_id"`
-		UpdatedAt     time.Time `json:"updated_at"`
-		CreatedAt     time.Time `json:"created_at"`
-	}
+	var req StartTaskRequest
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -39,20 +49,30 @@ func (h *WorkflowInstanceHandler) StartTask(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if req.WorkflowID == "" || req.AssignedTo == "" || req.TodoId == "" {
-		utils.RespondError(w, http.StatusBadRequest, "workflow_id, assigned_to, and todo_id are required")
+	// assigned_to may be left out when the workflow starts on a work queue step
+	if req.WorkflowID == "" || req.TodoId == "" {
+		utils.RespondError(w, http.StatusBadRequest, "workflow_id and todo_id are required")
 		return
 	}
 
-	instance, err := h.engine.StartWorkflow(req.WorkflowID, req.AssignedTo)
+	instance, err := h.engine.StartWorkflow(r.Context(), req.WorkflowID, req.TodoId, req.AssignedTo, req.Payload)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
 	utils.RespondJSON(w, http.StatusCreated, instance)
 }
 
+// ExecuteActionRequest is the body of POST /api/tasks/{instance_id}/execute.
+// The action is performed by the authenticated user.
+type ExecuteActionRequest struct {
+	ActionName     string                 `json:"action_name"`
+	Comments       string                 `json:"comments"`
+	Data           map[string]interface{} `json:"data"`             // Values for the transition's form_schema
+	ExpectedStepID string                 `json:"expected_step_id"` // Step the caller saw the task in
+}
+
 // ExecuteAction executes a workflow action (transition)
 func (h *WorkflowInstanceHandler) ExecuteAction(w http.ResponseWriter, r *http.Request) {
 	instanceID := r.PathValue("instance_id")
@@ -61,34 +81,90 @@ func (h *WorkflowInstanceHandler) ExecuteAction(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	var req struct {
-		ActionName string `json:"action_name"`
-		UserID     string `json:"user_id"`
-		Comments   string `json:"comments"`
+	user, ok := r.Context().Value(middleware.UserKey).(*models.User)
+	if !ok {
+		utils.RespondError(w, http.StatusUnauthorized, "User not found in context")
+		return
 	}
 
+	var req ExecuteActionRequest
+
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		utils.RespondError(w, http.StatusBadRequest, "Error reading body")
 		return
 	}
 
-	if req.ActionName == "" || req.UserID == "" {
-		utils.RespondError(w, http.StatusBadRequest, "action_name and user_id are required")
+	if req.ActionName == "" {
+		utils.RespondError(w, http.StatusBadRequest, "action_name is required")
+		return
+	}
+
+	version, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		respondError(w, r, err)
 		return
 	}
+	expect := &models.InstancePrecondition{Version: version, StepID: req.ExpectedStepID}
 
-	err = h.engine.ExecuteTransition(instanceID, req.ActionName, req.UserID, req.Comments)
+	result, err := h.engine.ExecuteTransition(r.Context(), instanceID, req.ActionName, user.UserID.String(), req.Comments, req.Data, expect)
 	if err != nil {
-		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		respondError(w, r, err)
 		return
 	}
+	w.Header().Set("ETag", instanceETag(result.Version))
 
-	utils.RespondJSON(w, http.StatusOK, map[string]string{
-		"message": "Action executed successfully",
+	message := "Action executed successfully"
+	if !result.Transitioned {
+		message = "Vote recorded, waiting for more approvals"
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": message,
+		"result":  result,
 	})
 }
 
+// BulkExecuteRequest is the body of POST /api/tasks/bulk-execute.
+// The actions are performed by the authenticated user.
+type BulkExecuteRequest struct {
+	InstanceIDs []string               `json:"instance_ids"`
+	ActionName  string                 `json:"action_name"`
+	Comments    string                 `json:"comments"`
+	Data        map[string]interface{} `json:"data"` // Applied to every task
+	Mode        string                 `json:"mode"` // "best_effort" (default) or "all_or_nothing"
+}
+
+// BulkExecuteAction executes one workflow action on many tasks
+func (h *WorkflowInstanceHandler) BulkExecuteAction(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserKey).(*models.User)
+	if !ok {
+		utils.RespondError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req BulkExecuteRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Error reading body")
+		return
+	}
+
+	if req.ActionName == "" {
+		utils.RespondError(w, http.StatusBadRequest, "action_name is required")
+		return
+	}
+
+	result, err := h.engine.BulkExecuteTransition(r.Context(), req.InstanceIDs, req.ActionName, user.UserID.String(), req.Comments, req.Data, req.Mode)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
 // GetTask retrieves a task with details
 func (h *WorkflowInstanceHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 	instanceID := r.PathValue("instance_id")
@@ -104,15 +180,34 @@ func (h *WorkflowInstanceHandler) GetTask(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	instanceDetails, err := h.engine.GetInstanceWithDetails(instanceID, userID)
+	instanceDetails, err := h.engine.GetInstanceWithDetails(r.Context(), instanceID, userID)
 	if err != nil {
-		utils.RespondError(w, http.StatusNotFound, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", instanceETag(instanceDetails.Version))
 	utils.RespondJSON(w, http.StatusOK, instanceDetails)
 }
 
+// instanceETag formats an instance version as an entity tag for the If-Match header
+func instanceETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch reads the instance version from an If-Match header; an empty header means no check
+func parseIfMatch(header string) (int, error) {
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	tag := strings.Trim(strings.TrimPrefix(strings.TrimSpace(header), "W/"), `"`)
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, apperrors.Invalid("If-Match must be an instance version, e.g. \"3\"")
+	}
+	return version, nil
+}
+
 // GetAvailableActions retrieves available actions for a task
 func (h *WorkflowInstanceHandler) GetAvailableActions(w http.ResponseWriter, r *http.Request) {
 	instanceID := r.PathValue("instance_id")
@@ -128,9 +223,9 @@ func (h *WorkflowInstanceHandler) GetAvailableActions(w http.ResponseWriter, r *
 		return
 	}
 
-	actions, err := h.engine.GetAvailableActions(instanceID, userID)
+	actions, err := h.engine.GetAvailableActions(r.Context(), instanceID, userID)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -145,9 +240,10 @@ func (h *WorkflowInstanceHandler) GetTasksByUser(w http.ResponseWriter, r *http.
 		return
 	}
 
-	instances, err := h.instanceRepo.GetInstancesByUser(userID)
+	// Includes tasks of users currently delegating to this user
+	instances, err := h.engine.GetTasksForUser(r.Context(), userID)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
@@ -162,11 +258,249 @@ func (h *WorkflowInstanceHandler) GetTasksByWorkflow(w http.ResponseWriter, r *h
 		return
 	}
 
-	instances, err := h.instanceRepo.GetInstancesByWorkflow(workflowID)
+	instances, err := h.instanceRepo.GetInstancesByWorkflow(r.Context(), workflowID)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, err)
 		return
 	}
 
 	utils.RespondJSON(w, http.StatusOK, instances)
 }
+
+// GetTaskHistory retrieves the audit trail of a task
+func (h *WorkflowInstanceHandler) GetTaskHistory(w http.ResponseWriter, r *http.Request) {
+	instanceID := r.PathValue("instance_id")
+	if instanceID == "" {
+		utils.RespondError(w, http.StatusBadRequest, "Instance ID is required")
+		return
+	}
+
+	history, err := h.engine.GetInstanceHistory(r.Context(), instanceID)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, history)
+}
+
+// GetOverdueTasks retrieves all tasks that have passed the SLA of their current step
+func (h *WorkflowInstanceHandler) GetOverdueTasks(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.engine.GetOverdueTasks(r.Context())
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, tasks)
+}
+
+// GetTaskVotes retrieves the votes cast on the current step of a task
+func (h *WorkflowInstanceHandler) GetTaskVotes(w http.ResponseWriter, r *http.Request) {
+	instanceID := r.PathValue("instance_id")
+	if instanceID == "" {
+		utils.RespondError(w, http.StatusBadRequest, "Instance ID is required")
+		return
+	}
+
+	tally, err := h.engine.GetVoteTally(r.Context(), instanceID)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, tally)
+}
+
+// ReassignTaskRequest is the body of POST /api/tasks/{instance_id}/reassign
+type ReassignTaskRequest struct {
+	AssignedTo string `json:"assigned_to"`
+	Comments   string `json:"comments"`
+}
+
+// ReassignTask hands a task over to another user
+func (h *WorkflowInstanceHandler) ReassignTask(w http.ResponseWriter, r *http.Request) {
+	instanceID := r.PathValue("instance_id")
+	if instanceID == "" {
+		utils.RespondError(w, http.StatusBadRequest, "Instance ID is required")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.UserKey).(*models.User)
+	if !ok {
+		utils.RespondError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req ReassignTaskRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Error reading body")
+		return
+	}
+
+	if req.AssignedTo == "" {
+		utils.RespondError(w, http.StatusBadRequest, "assigned_to is required")
+		return
+	}
+
+	// Administrators may reassign any task, everyone else only their own
+	instance, err := h.engine.ReassignTask(r.Context(), instanceID, req.AssignedTo, user.UserID.String(), req.Comments, user.IsAdministrator())
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, instance)
+}
+
+// GetQueue retrieves the unclaimed tasks the current user can claim
+func (h *WorkflowInstanceHandler) GetQueue(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserKey).(*models.User)
+	if !ok {
+		utils.RespondError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	tasks, err := h.engine.GetQueue(r.Context(), user.UserID.String())
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, tasks)
+}
+
+// ClaimTask assigns a task waiting in a work queue to the current user
+func (h *WorkflowInstanceHandler) ClaimTask(w http.ResponseWriter, r *http.Request) {
+	h.changeClaim(w, r, func(instanceID string, user *models.User, comments string) (*models.AssignedTodo, error) {
+		return h.engine.ClaimTask(r.Context(), instanceID, user.UserID.String(), comments)
+	})
+}
+
+// ReleaseTask puts a claimed task back into its work queue
+func (h *WorkflowInstanceHandler) ReleaseTask(w http.ResponseWriter, r *http.Request) {
+	h.changeClaim(w, r, func(instanceID string, user *models.User, comments string) (*models.AssignedTodo, error) {
+		// Administrators may release any task, everyone else only their own
+		return h.engine.ReleaseTask(r.Context(), instanceID, user.UserID.String(), comments, user.IsAdministrator())
+	})
+}
+
+// ClaimRequest is the body of the claim and release task routes
+type ClaimRequest struct {
+	Comments string `json:"comments"`
+}
+
+// changeClaim reads the optional comments of a claim or release request and applies it
+func (h *WorkflowInstanceHandler) changeClaim(
+	w http.ResponseWriter,
+	r *http.Request,
+	change func(instanceID string, user *models.User, comments string) (*models.AssignedTodo, error),
+) {
+	instanceID := r.PathValue("instance_id")
+	if instanceID == "" {
+		utils.RespondError(w, http.StatusBadRequest, "Instance ID is required")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.UserKey).(*models.User)
+	if !ok {
+		utils.RespondError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req ClaimRequest
+	// The body is optional
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		utils.RespondError(w, http.StatusBadRequest, "Error reading body")
+		return
+	}
+
+	instance, err := change(instanceID, user, req.Comments)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, instance)
+}
+
+// CreateDelegationRequest is the body of POST /api/delegations
+type CreateDelegationRequest struct {
+	DelegateID string    `json:"delegate_id"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	Reason     string    `json:"reason"`
+}
+
+// CreateDelegation delegates the current user's tasks to another user for a period of time
+func (h *WorkflowInstanceHandler) CreateDelegation(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserKey).(*models.User)
+	if !ok {
+		utils.RespondError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req CreateDelegationRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Error reading body")
+		return
+	}
+
+	if req.DelegateID == "" || req.StartsAt.IsZero() || req.EndsAt.IsZero() {
+		utils.RespondError(w, http.StatusBadRequest, "delegate_id, starts_at, and ends_at are required")
+		return
+	}
+
+	delegation, err := h.engine.CreateDelegation(r.Context(), user.UserID.String(), req.DelegateID, req.StartsAt, req.EndsAt, req.Reason)
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, delegation)
+}
+
+// GetDelegations retrieves the delegations the current user gave or received
+func (h *WorkflowInstanceHandler) GetDelegations(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserKey).(*models.User)
+	if !ok {
+		utils.RespondError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	delegations, err := h.engine.GetDelegations(r.Context(), user.UserID.String())
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, delegations)
+}
+
+// DeleteDelegation removes a delegation of the current user
+func (h *WorkflowInstanceHandler) DeleteDelegation(w http.ResponseWriter, r *http.Request) {
+	delegationID := r.PathValue("id")
+	if delegationID == "" {
+		utils.RespondError(w, http.StatusBadRequest, "Delegation ID is required")
+		return
+	}
+
+	user, ok := r.Context().Value(middleware.UserKey).(*models.User)
+	if !ok {
+		utils.RespondError(w, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	// Administrators may delete any delegation, everyone else only their own
+	err := h.engine.DeleteDelegation(r.Context(), delegationID, user.UserID.String(), user.IsAdministrator())
+	if err != nil {
+		respondError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "Delegation deleted successfully"})
+}
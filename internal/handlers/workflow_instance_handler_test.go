@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todo-api/internal/middleware"
+	"todo-api/internal/models"
+	"todo-api/internal/repository"
+	"todo-api/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// newTaskFixture starts the built-in Draft/Review/Approved workflow on a todo of alice
+// in memory and returns the handler, the task and the users alice and mallory
+func newTaskFixture(t *testing.T) (*WorkflowInstanceHandler, *models.AssignedTodo, *models.User, *models.User) {
+	t.Helper()
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	engine := services.NewWorkflowEngine(repos)
+
+	alice := &models.User{UserID: uuid.New(), Username: "alice", Email: "alice@example.com", IsActive: true}
+	mallory := &models.User{UserID: uuid.New(), Username: "mallory", Email: "mallory@example.com", IsActive: true}
+	for _, user := range []*models.User{alice, mallory} {
+		if err := repos.Users.CreateUser(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	todo := &models.Todo{Id: uuid.NewString(), TaskName: "Report", TaskDescription: "Quarterly", UserID: alice.UserID.String()}
+	if err := repos.Todos.Create(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	task, err := engine.StartWorkflow(ctx, models.BuiltinApprovalWorkflowID, todo.Id, alice.UserID.String(), nil)
+	if err != nil {
+		t.Fatalf("Failed to start workflow: %v", err)
+	}
+	return NewWorkflowInstanceHandler(engine, repos.Instances), task, alice, mallory
+}
+
+// asUser returns a request authenticated as user
+func asUser(method, target, body string, user *models.User) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserKey, user))
+}
+
+func TestExecuteAction_ActsAsAuthenticatedUser(t *testing.T) {
+	// Arrange - only alice, the assignee, may submit; mallory names her in the body
+	handler, task, alice, mallory := newTaskFixture(t)
+	body := `{"action_name": "submit", "user_id": "` + alice.UserID.String() + `"}`
+
+	tests := []struct {
+		name       string
+		user       *models.User
+		wantStatus int
+	}{
+		{"someone else", mallory, http.StatusForbidden},
+		{"assignee", alice, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asUser(http.MethodPost, "/api/tasks/"+task.ID+"/execute", body, tt.user)
+			req.SetPathValue("instance_id", task.ID)
+
+			// Act
+			rec := httptest.NewRecorder()
+			handler.ExecuteAction(rec, req)
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestBulkExecuteAction_ActsAsAuthenticatedUser(t *testing.T) {
+	// Arrange - mallory names alice in the body to submit her task
+	handler, task, alice, mallory := newTaskFixture(t)
+	body := `{"instance_ids": ["` + task.ID + `"], "action_name": "submit", "user_id": "` + alice.UserID.String() + `"}`
+	req := asUser(http.MethodPost, "/api/tasks/bulk-execute", body, mallory)
+
+	// Act
+	rec := httptest.NewRecorder()
+	handler.BulkExecuteAction(rec, req)
+
+	// Assert - the action is checked against mallory and fails
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result models.BulkExecuteResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if result.Applied != 0 || result.Failed != 1 {
+		t.Errorf("Expected mallory's action to fail, got %+v", result)
+	}
+}
+
+func TestReassignTask_OverrideNeedsAdministratorRole(t *testing.T) {
+	// Arrange - mallory tries to take alice's task with ever more privileges
+	handler, task, _, mallory := newTaskFixture(t)
+	body := `{"assigned_to": "` + mallory.UserID.String() + `"}`
+	deleter := &models.Role{Name: "Janitor", Permission: &models.Permissions{View: true, Delete: true}}
+	withRole := func(role *models.Role, isAdmin bool) *models.User {
+		user := *mallory
+		user.Role = role
+		user.IsAdmin = isAdmin
+		return &user
+	}
+
+	tests := []struct {
+		name       string
+		user       *models.User
+		wantStatus int
+	}{
+		{"delete permission", withRole(deleter, false), http.StatusForbidden},
+		{"deprecated admin flag", withRole(nil, true), http.StatusForbidden},
+		{"admin role", withRole(&models.Role{Name: models.RoleAdmin}, false), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asUser(http.MethodPost, "/api/tasks/"+task.ID+"/reassign", body, tt.user)
+			req.SetPathValue("instance_id", task.ID)
+
+			// Act
+			rec := httptest.NewRecorder()
+			handler.ReassignTask(rec, req)
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+	"todo-api/internal/models"
+)
+
+// DataSourceRepositoryInterface defines the contract for the aggregates shown by dashboard widgets
+type DataSourceRepositoryInterface interface {
+	GetTodosByPriority(ctx context.Context) ([]models.PieChartSlice, error)
+	GetTodosByPriorityTable(ctx context.Context) (*models.TableData, error)
+	GetTodosByStatus(ctx context.Context) ([]models.PieChartSlice, error)
+	GetTodosByStatusTable(ctx context.Context) (*models.TableData, error)
+	GetTodosList(ctx context.Context) (*models.TableData, error)
+	GetUsersByRole(ctx context.Context) ([]models.PieChartSlice, error)
+	GetUsersByRoleTable(ctx context.Context) (*models.TableData, error)
+	GetUserActivity(ctx context.Context) (*models.TableData, error)
+}
@@ -1,6 +1,7 @@
 package interfaces
 
 import (
+	"context"
 	"todo-api/internal/models"
 
 	"github.com/google/uuid"
@@ -8,12 +9,12 @@ import (
 
 // RoleRepositoryInterface defines the contract for role data access
 type RoleRepositoryInterface interface {
-	CreateRole(role *models.Role) error
-	GetRoleByID(roleID uuid.UUID) (*models.Role, error)
-	GetRoleByName(name string) (*models.Role, error)
-	GetAllRoles() ([]models.Role, error)
-	UpdateRole(role *models.Role) error
-	DeleteRole(roleID uuid.UUID) error
-	AssignRoleToUser(userID, roleID uuid.UUID) error
-	GetUserPermissions(userID uuid.UUID) (*models.Permissions, error)
+	CreateRole(ctx context.Context, role *models.Role) error
+	GetRoleByID(ctx context.Context, roleID uuid.UUID) (*models.Role, error)
+	GetRoleByName(ctx context.Context, name string) (*models.Role, error)
+	GetAllRoles(ctx context.Context) ([]models.Role, error)
+	UpdateRole(ctx context.Context, role *models.Role) error
+	DeleteRole(ctx context.Context, roleID uuid.UUID) error
+	AssignRoleToUser(ctx context.Context, userID, roleID uuid.UUID) error
+	GetUserPermissions(ctx context.Context, userID uuid.UUID) (*models.Permissions, error)
 }
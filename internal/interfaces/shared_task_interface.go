@@ -1,14 +1,17 @@
 package interfaces
 
-import "todo-api/internal/models"
+import (
+	"context"
+	"todo-api/internal/models"
+)
 
 // SharedTaskInterface defines the business logic contract for shared task operations
 type SharedTaskInterface interface {
-	CreateSharedTask(sharedTask *models.SharedTask) error
-	GetSharedTaskByID(id string) (*models.SharedTask, error)
-	GetSharedTasksByOwnerID(ownerID int) ([]models.SharedTask, error)
-	GetSharedTasksByTodoID(todoID string) ([]models.SharedTask, error)
-	GetAllSharedTasks() ([]models.SharedTask, error)
-	UpdateSharedTask(id string, sharedTask *models.SharedTask) (int64, error)
-	DeleteSharedTask(id string) (int64, error)
+	CreateSharedTask(ctx context.Context, sharedTask *models.SharedTask) error
+	GetSharedTaskByID(ctx context.Context, id string) (*models.SharedTask, error)
+	GetSharedTasksByOwnerID(ctx context.Context, ownerID int) ([]models.SharedTask, error)
+	GetSharedTasksByTodoID(ctx context.Context, todoID string) ([]models.SharedTask, error)
+	GetAllSharedTasks(ctx context.Context) ([]models.SharedTask, error)
+	UpdateSharedTask(ctx context.Context, id string, sharedTask *models.SharedTask) (int64, error)
+	DeleteSharedTask(ctx context.Context, id string) (int64, error)
 }
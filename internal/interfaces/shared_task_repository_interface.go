@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"todo-api/internal/models"
+)
+
+// SharedTaskRepositoryInterface defines the contract for shared task data access
+type SharedTaskRepositoryInterface interface {
+	GetAll(ctx context.Context) ([]models.SharedTask, error)
+	GetById(ctx context.Context, id string) (*models.SharedTask, error)
+	GetByOwnerId(ctx context.Context, ownerID int) ([]models.SharedTask, error)
+	GetByTodoId(ctx context.Context, todoID string) ([]models.SharedTask, error)
+	GetTodosBySharedId(ctx context.Context, sharedWithID int) ([]models.SharedTodoWithOwner, error)
+	Create(ctx context.Context, sharedTask *models.SharedTask) error
+	Delete(ctx context.Context, id string) (int64, error)
+}
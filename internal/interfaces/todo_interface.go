@@ -1,13 +1,16 @@
 package interfaces
 
-import "todo-api/internal/models"
+import (
+	"context"
+	"todo-api/internal/models"
+)
 
 // TodoInterface defines the business logic contract for todo operations
 type TodoInterface interface {
-	CreateTodo(todo *models.Todo) error
-	GetTodoByID(id string) (*models.Todo, error)
-	GetTodosByUserID(userID string) ([]models.Todo, error)
-	GetAllTodos() ([]models.Todo, error)
-	UpdateTodo(id string, todo *models.Todo) (int64, error)
-	DeleteTodo(id string) (int64, error)
+	CreateTodo(ctx context.Context, todo *models.Todo) error
+	GetTodoByID(ctx context.Context, id string) (*models.Todo, error)
+	GetTodosByUserID(ctx context.Context, userID string) ([]models.Todo, error)
+	GetAllTodos(ctx context.Context) ([]models.Todo, error)
+	UpdateTodo(ctx context.Context, id string, todo *models.Todo) (int64, error)
+	DeleteTodo(ctx context.Context, id string) (int64, error)
 }
@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+	"todo-api/internal/models"
+)
+
+// TodoRepositoryInterface defines the contract for todo data access.
+// Lookups of a missing todo return nil without an error.
+type TodoRepositoryInterface interface {
+	GetAll(ctx context.Context) ([]models.Todo, error)
+	GetById(ctx context.Context, id string) (*models.Todo, error)
+	GetByUserId(ctx context.Context, userID string) ([]models.Todo, error)
+	Create(ctx context.Context, todo *models.Todo) error
+	Update(ctx context.Context, id string, todo *models.Todo) (int64, error)
+	SetCompleted(ctx context.Context, id string, completed bool) (int64, error)
+	Delete(ctx context.Context, id string) (int64, error)
+}
@@ -1,13 +1,16 @@
 package interfaces
 
-import "todo-api/internal/models"
+import (
+	"context"
+	"todo-api/internal/models"
+)
 
 // TodoWorkflowInterface defines the business logic contract for todo workflow operations
 type TodoWorkflowInterface interface {
-	CreateTodoTask(todo *models.TodoTask) error
-	GetTodosByUser(userID string) ([]models.TodoTask, error)
-	GetTodosByStatus(status models.TodoStatus) ([]models.TodoTask, error)
-	SubmitForReview(todoID string, submittedBy string) error
-	ApproveTodo(todoID string, approvedBy string) error
-	RejectTodo(todoID string, rejectedBy string) error
+	CreateTodoTask(ctx context.Context, todo *models.TodoTask) error
+	GetTodosByUser(ctx context.Context, userID string) ([]models.TodoTask, error)
+	GetTodosByStatus(ctx context.Context, status models.TodoStatus) ([]models.TodoTask, error)
+	SubmitForReview(ctx context.Context, todoID string, submittedBy string) error
+	ApproveTodo(ctx context.Context, todoID string, approvedBy string) error
+	RejectTodo(ctx context.Context, todoID string, rejectedBy string) error
 }
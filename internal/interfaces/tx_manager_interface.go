@@ -0,0 +1,12 @@
+package interfaces
+
+import "context"
+
+// TxManagerInterface runs a function in one transaction; the repositories called
+// with the context it receives take part in that transaction. A call nested in another
+// joins the outer transaction and cannot raise its isolation level, so InSerializableTx
+// fails when nested in InTx.
+type TxManagerInterface interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	InSerializableTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
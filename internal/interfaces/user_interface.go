@@ -1,15 +1,16 @@
 package interfaces
 
 import (
+	"context"
 	"todo-api/internal/models"
 )
 
 // UserInterface defines the business logic contract for user operations
 type UserInterface interface {
-	Register(user *models.User) error
-	Login(email, password string) (map[string]interface{}, error)
-	GetAllUsers() ([]models.User, error)
-	GetUserByID(id interface{}) (*models.User, error)
-	UpdateUser(updates *models.User) (*models.User, error)
-	DeleteUser(id int) error
+	Register(ctx context.Context, user *models.User) error
+	Login(ctx context.Context, email, password string) (map[string]interface{}, error)
+	GetAllUsers(ctx context.Context) ([]models.User, error)
+	GetUserByID(ctx context.Context, id interface{}) (*models.User, error)
+	UpdateUser(ctx context.Context, updates *models.User) (*models.User, error)
+	DeleteUser(ctx context.Context, id int) error
 }
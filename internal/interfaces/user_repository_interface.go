@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+	"todo-api/internal/models"
+)
+
+// UserRepositoryInterface defines the contract for user data access. Users are
+// returned with their role and its permission; lookups of a missing user fail with sql.ErrNoRows.
+type UserRepositoryInterface interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByID(ctx context.Context, id interface{}) (*models.User, error)
+	GetUsersByRoleId(ctx context.Context, id interface{}) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	GetAllUsers(ctx context.Context) ([]models.User, error)
+	UpdateUser(ctx context.Context, user *models.User) (*models.User, error)
+	DeleteUser(ctx context.Context, id int) error
+	UserExists(ctx context.Context, username string) (bool, error)
+	EmailExists(ctx context.Context, email string) (bool, error)
+}
@@ -1,14 +1,17 @@
 package interfaces
 
-import "todo-api/internal/models"
+import (
+	"context"
+	"todo-api/internal/models"
+)
 
 // WorkflowInstanceInterface defines the business logic contract for workflow instance operations
 type WorkflowInstanceInterface interface {
-	StartTask(workflowID string, todoID string, assignedTo string) (*models.AssignedTodo, error)
-	GetTask(instanceID string) (*models.WorkflowInstanceWithDetails, error)
-	GetTasksByUser(userID string) ([]models.AssignedTodo, error)
-	GetTasksByWorkflow(workflowID string) ([]models.AssignedTodo, error)
-	ExecuteAction(instanceID string, transitionID string, performedBy string, comments string) error
-	GetAvailableActions(instanceID string) ([]models.AvailableAction, error)
-	GetTaskHistory(instanceID string) ([]models.WorkflowHistory, error)
+	StartTask(ctx context.Context, workflowID string, todoID string, assignedTo string) (*models.AssignedTodo, error)
+	GetTask(ctx context.Context, instanceID string) (*models.WorkflowInstanceWithDetails, error)
+	GetTasksByUser(ctx context.Context, userID string) ([]models.AssignedTodo, error)
+	GetTasksByWorkflow(ctx context.Context, workflowID string) ([]models.AssignedTodo, error)
+	ExecuteAction(ctx context.Context, instanceID string, transitionID string, performedBy string, comments string) error
+	GetAvailableActions(ctx context.Context, instanceID string) ([]models.AvailableAction, error)
+	GetTaskHistory(ctx context.Context, instanceID string) ([]models.WorkflowHistory, error)
 }
@@ -1,19 +1,22 @@
 package interfaces
 
-import "todo-api/internal/models"
+import (
+	"context"
+	"todo-api/internal/models"
+)
 
 // WorkflowInterface defines the business logic contract for workflow operations
 type WorkflowInterface interface {
-	CreateWorkflow(workflow *models.Workflow) error
-	GetWorkflow(id string) (*models.Workflow, error)
-	GetAllWorkflows() ([]models.Workflow, error)
-	UpdateWorkflow(workflow *models.Workflow) error
-	DeleteWorkflow(id string) error
+	CreateWorkflow(ctx context.Context, workflow *models.Workflow) error
+	GetWorkflow(ctx context.Context, id string) (*models.Workflow, error)
+	GetAllWorkflows(ctx context.Context) ([]models.Workflow, error)
+	UpdateWorkflow(ctx context.Context, workflow *models.Workflow) error
+	DeleteWorkflow(ctx context.Context, id string) error
 
-	CreateStep(step *models.WorkflowStep) error
-	GetWorkflowSteps(workflowID string) ([]models.WorkflowStep, error)
+	CreateStep(ctx context.Context, step *models.WorkflowStep) error
+	GetWorkflowSteps(ctx context.Context, workflowID string) ([]models.WorkflowStep, error)
 
-	CreateTransition(transition *models.WorkflowTransition) error
-	GetWorkflowTransitions(workflowID string) ([]models.WorkflowTransition, error)
-	GetAvailableTransitions(stepID string) ([]models.WorkflowTransition, error)
+	CreateTransition(ctx context.Context, transition *models.WorkflowTransition) error
+	GetWorkflowTransitions(ctx context.Context, workflowID string) ([]models.WorkflowTransition, error)
+	GetAvailableTransitions(ctx context.Context, stepID string) ([]models.WorkflowTransition, error)
 }
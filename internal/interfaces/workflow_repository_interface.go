@@ -0,0 +1,77 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+	"todo-api/internal/models"
+)
+
+// WorkflowRepositoryInterface defines the contract for workflow templates, their steps and transitions
+type WorkflowRepositoryInterface interface {
+	CreateWorkflow(ctx context.Context, workflow *models.Workflow) error
+	GetWorkflow(ctx context.Context, id string) (*models.Workflow, error)
+	GetAllWorkflows(ctx context.Context) ([]*models.Workflow, error)
+
+	CreateStep(ctx context.Context, step *models.WorkflowStep) error
+	GetWorkflowSteps(ctx context.Context, workflowID string) ([]*models.WorkflowStep, error)
+	GetStep(ctx context.Context, stepID string) (*models.WorkflowStep, error)
+	GetStartStep(ctx context.Context, workflowID string) (*models.WorkflowStep, error)
+
+	CreateTransition(ctx context.Context, transition *models.WorkflowTransition) error
+	GetTransitions(ctx context.Context, workflowID string) ([]*models.WorkflowTransition, error)
+	GetAvailableTransitions(ctx context.Context, workflowID, fromStepID string) ([]*models.WorkflowTransition, error)
+	FindTransition(ctx context.Context, workflowID, fromStepID, actionName string) (*models.WorkflowTransition, error)
+}
+
+// WorkflowInstanceRepositoryInterface defines the contract for workflow instances and their history.
+// Versioned updates fail with repository.ErrVersionConflict when the instance changed since it was read.
+type WorkflowInstanceRepositoryInterface interface {
+	CreateInstance(ctx context.Context, instance *models.AssignedTodo) error
+	GetInstance(ctx context.Context, id string) (*models.AssignedTodo, error)
+	GetOpenInstanceByTodo(ctx context.Context, todoID string) (*models.AssignedTodo, error)
+	GetTodoWorkflowState(ctx context.Context, todoID string) (*models.TodoWorkflowState, error)
+	GetInstancesByWorkflow(ctx context.Context, workflowID string) ([]*models.AssignedTodo, error)
+	GetInstancesByUser(ctx context.Context, userID string) ([]*models.AssignedTodo, error)
+	GetInstancesByAssignees(ctx context.Context, userIDs []string) ([]*models.AssignedTodo, error)
+	GetInstancesByStep(ctx context.Context, stepID string) ([]*models.AssignedTodo, error)
+	GetQueuedInstances(ctx context.Context, queueRole string) ([]*models.AssignedTodo, error)
+	GetChildInstances(ctx context.Context, parentID string, since time.Time) ([]*models.AssignedTodo, error)
+	GetOverdueInstances(ctx context.Context, now time.Time) ([]*models.OverdueTask, error)
+	GetTodoTasksByAssignee(ctx context.Context, workflowID, userID string) ([]models.TodoTask, error)
+	GetTodoTasksByStep(ctx context.Context, workflowID, stepName string) ([]models.TodoTask, error)
+
+	UpdateInstanceStep(ctx context.Context, instanceID string, version int, newStepID, assignedTo string, enteredAt time.Time, dueAt *time.Time) error
+	ClaimInstance(ctx context.Context, instanceID, stepID, userID string, claimedAt time.Time) (bool, error)
+	ReleaseInstance(ctx context.Context, instanceID, stepID, assignee string, releasedAt time.Time) (bool, error)
+	UpdateInstance(ctx context.Context, instance *models.AssignedTodo) error
+	UpdateInstanceStatus(ctx context.Context, instance *models.AssignedTodo) error
+	UpdateInstancePayload(ctx context.Context, instanceID string, version int, payload map[string]interface{}, updatedAt time.Time) error
+	TouchInstance(ctx context.Context, instanceID string, version int, updatedAt time.Time) error
+	MarkEscalated(ctx context.Context, instanceID string, version int, escalatedAt time.Time) error
+
+	AddHistory(ctx context.Context, entry *models.WorkflowHistory) error
+	GetHistory(ctx context.Context, instanceID string) ([]*models.WorkflowHistory, error)
+}
+
+// WorkflowVoteRepositoryInterface defines the contract for votes on multi-approval steps
+type WorkflowVoteRepositoryInterface interface {
+	CreateVote(ctx context.Context, vote *models.WorkflowVote) error
+	GetVotes(ctx context.Context, instanceID, stepID string, since time.Time) ([]models.WorkflowVote, error)
+}
+
+// WorkflowDelegationRepositoryInterface defines the contract for delegation rules
+type WorkflowDelegationRepositoryInterface interface {
+	CreateDelegation(ctx context.Context, delegation *models.WorkflowDelegation) error
+	GetDelegation(ctx context.Context, id string) (*models.WorkflowDelegation, error)
+	GetDelegationsByUser(ctx context.Context, userID string) ([]*models.WorkflowDelegation, error)
+	GetActiveDelegatorIDs(ctx context.Context, delegateID string, at time.Time) ([]string, error)
+	IsActiveDelegate(ctx context.Context, delegatorID, delegateID string, at time.Time) (bool, error)
+	DeleteDelegation(ctx context.Context, id string) error
+}
+
+// WorkflowMetricsRepositoryInterface defines the contract for process metrics computed from the workflow history
+type WorkflowMetricsRepositoryInterface interface {
+	GetCycleTime(ctx context.Context, workflowID string, from, to time.Time) (*models.CycleTimeMetrics, error)
+	GetStepMetrics(ctx context.Context, workflowID string, from, to time.Time) ([]models.StepMetrics, error)
+	GetUserThroughput(ctx context.Context, workflowID string, from, to time.Time) ([]models.UserThroughput, error)
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import "database/sql"
+
+// Login results
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+// Default holds the metrics of the API, served on /metrics
+var Default = NewRegistry()
+
+var (
+	// HTTPRequests counts requests per route pattern, such as "GET /todos/{id}", and status
+	HTTPRequests = Default.NewCounter("http_requests_total",
+		"HTTP requests handled, by route pattern and status.", "route", "status")
+
+	// HTTPRequestDuration measures request latency per route pattern and status
+	HTTPRequestDuration = Default.NewHistogram("http_request_duration_seconds",
+		"Time taken to handle HTTP requests, by route pattern and status.", DefaultBuckets, "route", "status")
+
+	// Logins counts login attempts per result
+	Logins = Default.NewCounter("auth_logins_total",
+		"Login attempts, by result.", "result")
+
+	// WorkflowInstancesStarted counts committed workflow instances per workflow, sub-workflows included
+	WorkflowInstancesStarted = Default.NewCounter("workflow_instances_started_total",
+		"Workflow instances started, by workflow.", "workflow_id")
+
+	// WorkflowTransitions counts committed transitions per action, automatic ones included
+	WorkflowTransitions = Default.NewCounter("workflow_transitions_total",
+		"Workflow transitions executed, by action.", "action")
+
+	// WorkflowInstancesCompleted counts instances that reached a final step per workflow
+	WorkflowInstancesCompleted = Default.NewCounter("workflow_instances_completed_total",
+		"Workflow instances completed, by workflow.", "workflow_id")
+)
+
+// RegisterDBStats exposes the connection pool statistics of db, read at every scrape
+func RegisterDBStats(r *Registry, db *sql.DB) {
+	gauge := func(name, help string, value func(sql.DBStats) float64) {
+		r.NewGaugeFunc(name, help, func() float64 { return value(db.Stats()) })
+	}
+	counter := func(name, help string, value func(sql.DBStats) float64) {
+		r.NewCounterFunc(name, help, func() float64 { return value(db.Stats()) })
+	}
+
+	gauge("db_max_open_connections", "Maximum number of open connections to the database.",
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })
+	gauge("db_open_connections", "Established connections, in use or idle.",
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) })
+	gauge("db_in_use_connections", "Connections currently in use.",
+		func(s sql.DBStats) float64 { return float64(s.InUse) })
+	gauge("db_idle_connections", "Idle connections.",
+		func(s sql.DBStats) float64 { return float64(s.Idle) })
+	counter("db_wait_count_total", "Connections waited for because the pool was exhausted.",
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) })
+	counter("db_wait_duration_seconds_total", "Time spent waiting for a connection.",
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })
+	counter("db_max_idle_closed_total", "Connections closed because of the idle connection limit.",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) })
+	counter("db_max_idle_time_closed_total", "Connections closed because they were idle too long.",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) })
+	counter("db_max_lifetime_closed_total", "Connections closed because they reached their maximum lifetime.",
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) })
+}
@@ -0,0 +1,267 @@
+// Package metrics keeps counters, histograms and gauges and exposes them in the
+// Prometheus text format
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are latency buckets in seconds, from 5ms to 10s
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// family is a metric with all of its label combinations
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds metrics in the order they were created
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.families = append(r.families, f)
+}
+
+// WriteTo writes every metric in the Prometheus text format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := append([]family{}, r.families...)
+	r.mu.Unlock()
+
+	counter := &countingWriter{w: w}
+	buffered := bufio.NewWriter(counter)
+	for _, f := range families {
+		f.write(buffered)
+	}
+	err := buffered.Flush()
+	return counter.n, err
+}
+
+// ServeHTTP answers a scrape
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	r.WriteTo(w)
+}
+
+// Counter is a value that only goes up, partitioned by labels
+type Counter struct {
+	name, help string
+	labels     []string
+	mu         sync.Mutex
+	series     map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounter registers a counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{name: name, help: help, labels: labels, series: map[string]*counterSeries{}}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the series with the given label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the series with the given label values
+func (c *Counter) Add(v float64, labelValues ...string) {
+	key := seriesKey(c.name, c.labels, labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	series, ok := c.series[key]
+	if !ok {
+		series = &counterSeries{labelValues: labelValues}
+		c.series[key] = series
+	}
+	series.value += v
+}
+
+// Value returns the current value of the series with the given label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	key := seriesKey(c.name, c.labels, labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if series, ok := c.series[key]; ok {
+		return series.value
+	}
+	return 0
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writeHeader(w, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.series) {
+		series := c.series[key]
+		writeSample(w, c.name, c.labels, series.labelValues, "", "", series.value)
+	}
+}
+
+// Histogram counts observations, such as latencies, in cumulative buckets, partitioned by labels
+type Histogram struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+	mu         sync.Mutex
+	series     map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64 // Observations per bucket, not cumulative
+	count       uint64
+	sum         float64
+}
+
+// NewHistogram registers a histogram with the given upper bounds, in increasing order, and label names
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{name: name, help: help, labels: labels, buckets: buckets, series: map[string]*histogramSeries{}}
+	r.register(h)
+	return h
+}
+
+// Observe records v in the series with the given label values
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := seriesKey(h.name, h.labels, labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogramSeries{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		series.counts[i]++
+	}
+	series.count++
+	series.sum += v
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	writeHeader(w, h.name, h.help, "histogram")
+	for _, key := range sortedKeys(h.series) {
+		series := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += series.counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, series.labelValues, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, series.labelValues, "le", "+Inf", float64(series.count))
+		writeSample(w, h.name+"_sum", h.labels, series.labelValues, "", "", series.sum)
+		writeSample(w, h.name+"_count", h.labels, series.labelValues, "", "", float64(series.count))
+	}
+}
+
+// funcMetric is a single value read when scraped
+type funcMetric struct {
+	name, help, kind string
+	value            func() float64
+}
+
+// NewGaugeFunc registers a gauge whose value is read from value at every scrape
+func (r *Registry) NewGaugeFunc(name, help string, value func() float64) {
+	r.register(&funcMetric{name: name, help: help, kind: "gauge", value: value})
+}
+
+// NewCounterFunc registers a counter kept elsewhere, such as by database/sql, and read at every scrape
+func (r *Registry) NewCounterFunc(name, help string, value func() float64) {
+	r.register(&funcMetric{name: name, help: help, kind: "counter", value: value})
+}
+
+func (m *funcMetric) write(w *bufio.Writer) {
+	writeHeader(w, m.name, m.help, m.kind)
+	writeSample(w, m.name, nil, nil, "", "", m.value())
+}
+
+// seriesKey identifies a label combination. Passing the wrong number of label values
+// is a programming error.
+func seriesKey(name string, labels, labelValues []string) string {
+	if len(labels) != len(labelValues) {
+		panic("metrics: " + name + " takes " + strconv.Itoa(len(labels)) + " label values, got " + strconv.Itoa(len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+func sortedKeys[T any](series map[string]T) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	w.WriteString("# HELP " + name + " " + help + "\n")
+	w.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+// writeSample writes one line; extraLabel is the le label of histogram buckets
+func writeSample(w *bufio.Writer, name string, labels, labelValues []string, extraLabel, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		pairs := make([]string, 0, len(labels)+1)
+		for i, label := range labels {
+			pairs = append(pairs, label+`="`+escapeLabel(labelValues[i])+`"`)
+		}
+		if extraLabel != "" {
+			pairs = append(pairs, extraLabel+`="`+extraValue+`"`)
+		}
+		w.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	w.WriteString(" " + formatFloat(value) + "\n")
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// countingWriter counts the bytes written for WriteTo
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
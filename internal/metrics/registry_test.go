@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	// Arrange
+	registry := NewRegistry()
+	requests := registry.NewCounter("requests_total", "Requests handled.", "route", "status")
+	latency := registry.NewHistogram("latency_seconds", "Request latency.", []float64{0.1, 1}, "route")
+	registry.NewGaugeFunc("connections", "Open connections.", func() float64 { return 3 })
+
+	requests.Inc("GET /todos", "200")
+	requests.Add(2, "GET /todos", "200")
+	requests.Inc(`say "hi"`+"\n", "500")
+	latency.Observe(0.05, "GET /todos")
+	latency.Observe(0.5, "GET /todos")
+	latency.Observe(5, "GET /todos")
+
+	// Act
+	var out strings.Builder
+	_, err := registry.WriteTo(&out)
+
+	// Assert - series are sorted and histogram buckets are cumulative
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := `# HELP requests_total Requests handled.
+# TYPE requests_total counter
+requests_total{route="GET /todos",status="200"} 3
+requests_total{route="say \"hi\"\n",status="500"} 1
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="GET /todos",le="0.1"} 1
+latency_seconds_bucket{route="GET /todos",le="1"} 2
+latency_seconds_bucket{route="GET /todos",le="+Inf"} 3
+latency_seconds_sum{route="GET /todos"} 5.55
+latency_seconds_count{route="GET /todos"} 3
+# HELP connections Open connections.
+# TYPE connections gauge
+connections 3
+`
+	if out.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, out.String())
+	}
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	// Arrange
+	registry := NewRegistry()
+	registry.NewCounter("logins_total", "Login attempts.", "result").Inc(LoginSuccess)
+
+	// Act
+	rec := httptest.NewRecorder()
+	registry.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Assert
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("Expected content type %q, got %q", ContentType, got)
+	}
+	if !strings.Contains(rec.Body.String(), `logins_total{result="success"} 1`) {
+		t.Errorf("Expected the counter in the body, got:\n%s", rec.Body.String())
+	}
+}
+
+func TestCounter_WrongLabelCount(t *testing.T) {
+	// Arrange
+	counter := NewRegistry().NewCounter("requests_total", "Requests handled.", "route", "status")
+	defer func() {
+		// Assert
+		if recover() == nil {
+			t.Errorf("Expected a panic for a missing label value")
+		}
+	}()
+
+	// Act
+	counter.Inc("GET /todos")
+}
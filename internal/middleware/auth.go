@@ -4,7 +4,8 @@ import (
 	"context"
 	"net/http"
 	"strings"
-	"todo-api/internal/repository"
+	"todo-api/internal/database"
+	"todo-api/internal/interfaces"
 	"todo-api/pkg/utils"
 
 	"github.com/google/uuid"
@@ -19,48 +20,54 @@ const (
 	UserKey   ContextKey = "user"
 )
 
-// JWTAuth is a middleware that validates JWT tokens from the Authorization header
-func JWTAuth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get the Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			utils.RespondError(w, http.StatusUnauthorized, "Missing authorization header")
-			return
-		}
+// JWTAuth returns a middleware that validates JWT tokens from the Authorization header
+// and loads the user of the token from users
+func JWTAuth(users interfaces.UserRepositoryInterface) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get the Authorization header
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				utils.RespondError(w, http.StatusUnauthorized, "Missing authorization header")
+				return
+			}
 
-		// Check if it's a Bearer token
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			utils.RespondError(w, http.StatusUnauthorized, "Invalid authorization header format. Expected: Bearer <token>")
-			return
-		}
+			// Check if it's a Bearer token
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				utils.RespondError(w, http.StatusUnauthorized, "Invalid authorization header format. Expected: Bearer <token>")
+				return
+			}
 
-		tokenString := parts[1]
+			tokenString := parts[1]
 
-		// Validate the token
-		claims, err := utils.ValidateToken(tokenString)
-		if err != nil {
-			utils.RespondError(w, http.StatusUnauthorized, "Invalid or expired token: "+err.Error())
-			return
-		}
+			// Validate the token
+			claims, err := utils.ValidateToken(tokenString)
+			if err != nil {
+				utils.RespondError(w, http.StatusUnauthorized, "Invalid or expired token: "+err.Error())
+				return
+			}
 
-		userRepo := repository.NewUserRepository()
-		user, err := userRepo.GetUserByID(claims.UserID)
+			user, err := users.GetUserByID(r.Context(), claims.UserID)
+			if database.IsTimeout(err) {
+				utils.RespondError(w, http.StatusGatewayTimeout, err.Error())
+				return
+			}
+			if err != nil {
+				utils.RespondError(w, http.StatusUnauthorized, "user not found!")
+				return
+			}
 
-		if err != nil {
-			utils.RespondError(w, http.StatusUnauthorized, "user not found!")
-			return
-		}
+			// Add user information to the request context
+			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, UserEmail, claims.Email)
+			ctx = context.WithValue(ctx, UserKey, user)
+			ctx = setRequestUser(ctx, claims.UserID.String())
 
-		// Add user information to the request context
-		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
-		ctx = context.WithValue(ctx, UserEmail, claims.Email)
-		ctx = context.WithValue(ctx, UserKey, user)
-
-		// Call the next handler with the updated context
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+			// Call the next handler with the updated context
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
 // GetUserIDFromContext extracts the user ID from the request context
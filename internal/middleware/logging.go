@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+	"todo-api/pkg/logging"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID that ties a request to its log lines. An ID sent by
+// the client or a proxy is kept, otherwise one is generated.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client supplied IDs, so they cannot bloat the logs
+const maxRequestIDLength = 128
+
+const requestLogKey ContextKey = "requestLog"
+
+// requestLog collects what later middleware learns about a request, such as the
+// authenticated user, for the line logged when it completes
+type requestLog struct {
+	userID string
+}
+
+// RequestLogger assigns every request an ID, carries a logger annotated with it in the
+// request context and logs the method, route, status, latency and user once the request
+// completes. Wrap the whole router with it, so unmatched routes are logged too.
+func RequestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			id := requestID(r.Header.Get(RequestIDHeader))
+			w.Header().Set(RequestIDHeader, id)
+
+			requestLogger := logger.With("request_id", id)
+			entry := &requestLog{}
+			ctx := logging.WithLogger(r.Context(), requestLogger)
+			ctx = context.WithValue(ctx, requestLogKey, entry)
+			r = r.WithContext(ctx)
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			// The mux records the matched pattern on the request it was given
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("route", r.Pattern),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", recorder.status),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			}
+			if entry.userID != "" {
+				attrs = append(attrs, slog.String("user_id", entry.userID))
+			}
+			level := slog.LevelInfo
+			if recorder.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			requestLogger.LogAttrs(ctx, level, "request", attrs...)
+		})
+	}
+}
+
+// requestID returns the given ID when it is a usable one, or a new ID
+func requestID(given string) string {
+	if given == "" || len(given) > maxRequestIDLength {
+		return uuid.NewString()
+	}
+	for _, c := range given {
+		if c <= ' ' || c > '~' {
+			return uuid.NewString()
+		}
+	}
+	return given
+}
+
+// setRequestUser records the authenticated user for the request log line and
+// adds it to the logger the rest of the request uses
+func setRequestUser(ctx context.Context, userID string) context.Context {
+	if entry, ok := ctx.Value(requestLogKey).(*requestLog); ok {
+		entry.userID = userID
+	}
+	return logging.WithLogger(ctx, logging.FromContext(ctx).With("user_id", userID))
+}
+
+// statusRecorder remembers the status a handler responded with
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
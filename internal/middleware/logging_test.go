@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"todo-api/pkg/logging"
+)
+
+// newLoggedMux serves GET /todos/{id} behind RequestLogger, recording the user the
+// way JWTAuth does and logging from the handler through the request context
+func newLoggedMux(out *bytes.Buffer) http.Handler {
+	logger, _ := logging.New(out, "info", "json")
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /todos/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := setRequestUser(r.Context(), "user-1")
+		logging.FromContext(ctx).Info("loading todo")
+		w.WriteHeader(http.StatusTeapot)
+	})
+	return RequestLogger(logger)(mux)
+}
+
+// logLines decodes the JSON log lines written to out
+func logLines(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	decoder := json.NewDecoder(out)
+	for decoder.More() {
+		var line map[string]interface{}
+		if err := decoder.Decode(&line); err != nil {
+			t.Fatalf("Failed to decode log line: %v", err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestRequestLogger(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	handler := newLoggedMux(&out)
+	req := httptest.NewRequest(http.MethodGet, "/todos/todo-1", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+
+	// Act
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	// Assert - the client's ID is echoed and ties the handler's line to the request line
+	if got := rec.Header().Get(RequestIDHeader); got != "req-42" {
+		t.Errorf("Expected the request ID to be echoed, got %q", got)
+	}
+	lines := logLines(t, &out)
+	if len(lines) != 2 {
+		t.Fatalf("Expected a handler line and a request line, got %v", lines)
+	}
+	if lines[0]["request_id"] != "req-42" || lines[0]["user_id"] != "user-1" {
+		t.Errorf("Expected the handler to log with the request ID and user, got %v", lines[0])
+	}
+	request := lines[1]
+	want := map[string]interface{}{
+		"msg":        "request",
+		"request_id": "req-42",
+		"method":     "GET",
+		"route":      "GET /todos/{id}",
+		"status":     float64(http.StatusTeapot),
+		"user_id":    "user-1",
+	}
+	for key, value := range want {
+		if request[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, request[key])
+		}
+	}
+	if _, ok := request["latency_ms"]; !ok {
+		t.Errorf("Expected the latency to be logged")
+	}
+}
+
+func TestRequestLogger_GeneratesID(t *testing.T) {
+	tests := []struct {
+		name  string
+		given string
+	}{
+		{"missing", ""},
+		{"contains spaces", "not an id"},
+		{"too long", string(bytes.Repeat([]byte("a"), maxRequestIDLength+1))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var out bytes.Buffer
+			req := httptest.NewRequest(http.MethodGet, "/nothing-here", nil)
+			req.Header.Set(RequestIDHeader, tt.given)
+
+			// Act
+			rec := httptest.NewRecorder()
+			newLoggedMux(&out).ServeHTTP(rec, req)
+
+			// Assert - unmatched routes are logged too, under a fresh ID
+			id := rec.Header().Get(RequestIDHeader)
+			if id == "" || id == tt.given {
+				t.Errorf("Expected a generated request ID, got %q", id)
+			}
+			lines := logLines(t, &out)
+			if len(lines) != 1 || lines[0]["request_id"] != id || lines[0]["status"] != float64(http.StatusNotFound) {
+				t.Errorf("Expected one request line with the generated ID, got %v", lines)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"todo-api/internal/metrics"
+)
+
+// unmatchedRoute labels requests no route matched, so unknown paths cannot grow the number of series
+const unmatchedRoute = "unmatched"
+
+// Metrics counts requests and measures their latency per route pattern and status.
+// Wrap the whole router with it, so requests matching no route are counted too.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// The mux records the matched pattern on the request it was given
+		route := r.Pattern
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(recorder.status)
+		metrics.HTTPRequests.Inc(route, status)
+		metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), route, status)
+	})
+}
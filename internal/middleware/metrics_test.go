@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"todo-api/internal/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	// Arrange
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := Metrics(mux)
+	before := metrics.HTTPRequests.Value("GET /metrics-test/{id}", "418")
+	unmatchedBefore := metrics.HTTPRequests.Value(unmatchedRoute, "404")
+
+	// Act
+	for _, path := range []string{"/metrics-test/1", "/metrics-test/2", "/nothing-here"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Assert - requests are counted per pattern, not per path
+	if got := metrics.HTTPRequests.Value("GET /metrics-test/{id}", "418") - before; got != 2 {
+		t.Errorf("Expected 2 requests counted for the pattern, got %v", got)
+	}
+	if got := metrics.HTTPRequests.Value(unmatchedRoute, "404") - unmatchedBefore; got != 1 {
+		t.Errorf("Expected the unknown path counted as unmatched, got %v", got)
+	}
+}
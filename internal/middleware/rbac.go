@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"todo-api/internal/models"
+	"todo-api/pkg/utils"
 )
 
 // RequirePermission is a middleware that checks if the authenticated user has a specific permission
@@ -14,13 +15,13 @@ func RequirePermission(permission string) func(http.Handler) http.Handler {
 			// Get user from context (set by auth middleware)
 			user, ok := r.Context().Value(UserKey).(*models.User)
 			if !ok || user == nil {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
 				return
 			}
 
 			// Check if user has the required permission
 			if !user.HasPermission(permission) {
-				http.Error(w, "Forbidden: insufficient permissions", http.StatusForbidden)
+				utils.RespondError(w, http.StatusForbidden, "Forbidden: insufficient permissions")
 				return
 			}
 
@@ -35,7 +36,7 @@ func RequireAnyPermission(permissions ...string) func(http.Handler) http.Handler
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user, ok := r.Context().Value(UserKey).(*models.User)
 			if !ok || user == nil {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
 				return
 			}
 
@@ -49,7 +50,7 @@ func RequireAnyPermission(permissions ...string) func(http.Handler) http.Handler
 			}
 
 			if !hasPermission {
-				http.Error(w, "Forbidden: insufficient permissions", http.StatusForbidden)
+				utils.RespondError(w, http.StatusForbidden, "Forbidden: insufficient permissions")
 				return
 			}
 
@@ -64,14 +65,14 @@ func RequireAllPermissions(permissions ...string) func(http.Handler) http.Handle
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user, ok := r.Context().Value(UserKey).(*models.User)
 			if !ok || user == nil {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
 				return
 			}
 
 			// Check if user has all required permissions
 			for _, perm := range permissions {
 				if !user.HasPermission(perm) {
-					http.Error(w, "Forbidden: insufficient permissions", http.StatusForbidden)
+					utils.RespondError(w, http.StatusForbidden, "Forbidden: insufficient permissions")
 					return
 				}
 			}
@@ -87,12 +88,12 @@ func RequireRole(roleName string) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user, ok := r.Context().Value(UserKey).(*models.User)
 			if !ok || user == nil {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				utils.RespondError(w, http.StatusUnauthorized, "Unauthorized")
 				return
 			}
 
 			if user.Role == nil || user.Role.Name != roleName {
-				http.Error(w, "Forbidden: role required", http.StatusForbidden)
+				utils.RespondError(w, http.StatusForbidden, "Forbidden: role required")
 				return
 			}
 
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // DataSourceMetadata describes a data source and its capabilities
 type DataSourceMetadata struct {
 	ID                string   `json:"id"`
@@ -17,6 +19,21 @@ type PieChartSlice struct {
 	Color string  `json:"color"`
 }
 
+// BarChartBar represents a single bar in a bar chart
+type BarChartBar struct {
+	Label string  `json:"label"`
+	Value float64 `json:"value"`
+	Color string  `json:"color"`
+}
+
+// DataSourceQuery holds the parameters of a data source request.
+// EntityID is required by data sources with RequiresEntity, From and To bound time-based data sources.
+type DataSourceQuery struct {
+	EntityID string
+	From     time.Time
+	To       time.Time
+}
+
 // TableColumn defines a column in a table widget
 type TableColumn struct {
 	Key    string `json:"key"`
@@ -34,8 +51,6 @@ type TableData struct {
 type DataSourceResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data"`
-	Error   string      `json:"error,omitempty"`
-	Code    string      `json:"code,omitempty"`
 }
 
 // Standard color palettes
@@ -103,5 +118,37 @@ func GetAllDataSources() []DataSourceMetadata {
 			CompatibleWidgets: []string{"table"},
 			RequiresEntity:    false,
 		},
+		{
+			ID:                "workflow_cycle_time",
+			Name:              "Workflow Cycle Time",
+			Description:       "Median, p90 and average time to complete instances of a workflow",
+			Category:          "workflows",
+			CompatibleWidgets: []string{"table"},
+			RequiresEntity:    true,
+		},
+		{
+			ID:                "workflow_step_dwell",
+			Name:              "Workflow Step Dwell Time",
+			Description:       "Average time instances spend on each step of a workflow, highlighting the bottleneck",
+			Category:          "workflows",
+			CompatibleWidgets: []string{"bar_chart", "table"},
+			RequiresEntity:    true,
+		},
+		{
+			ID:                "workflow_rework",
+			Name:              "Workflow Rework",
+			Description:       "Steps of a workflow that instances re-enter or are sent back from",
+			Category:          "workflows",
+			CompatibleWidgets: []string{"bar_chart", "table"},
+			RequiresEntity:    true,
+		},
+		{
+			ID:                "workflow_user_throughput",
+			Name:              "Workflow User Throughput",
+			Description:       "Actions per user on a workflow and their average response time",
+			Category:          "workflows",
+			CompatibleWidgets: []string{"bar_chart", "table"},
+			RequiresEntity:    true,
+		},
 	}
 }
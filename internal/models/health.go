@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Health statuses
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
+)
+
+// HealthResponse is returned by the liveness and readiness probes
+type HealthResponse struct {
+	Status   string         `json:"status"` // "ok" or "unavailable"
+	Message  string         `json:"message"`
+	Database DatabaseHealth `json:"database"`
+	Workers  []WorkerStatus `json:"workers"`
+}
+
+// DatabaseHealth reports whether the database answered a ping and which migration it is on
+type DatabaseHealth struct {
+	Status           string `json:"status"`
+	Error            string `json:"error,omitempty"`
+	LatencyMs        int64  `json:"latency_ms"`
+	MigrationVersion int64  `json:"migration_version"`
+	MigrationDirty   bool   `json:"migration_dirty"`     // A migration failed half way and needs manual repair
+	InMemory         bool   `json:"in_memory,omitempty"` // Demo mode: the data lives in the process and is lost on exit
+}
+
+// WorkerStatus reports the state of a background worker such as the SLA scheduler
+type WorkerStatus struct {
+	Name      string     `json:"name"`
+	Running   bool       `json:"running"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
@@ -4,6 +4,12 @@ import (
 	"time"
 )
 
+// Longest name and description a todo may have, the sizes of todos.task_name and todos.task_description
+const (
+	MaxTodoNameLength        = 200
+	MaxTodoDescriptionLength = 500
+)
+
 type Todo struct {
 	Id              string    `json:"id"`
 	TaskName        string    `json:"task_name"`
@@ -12,9 +18,6 @@ type Todo struct {
 	UserID          string    `json:"user_id"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
-}
 
-type HealthResponse struct {
-	Status  string `json:"status"`
-	Message string `json:"message"`
+	Workflow *TodoWorkflowState `json:"workflow,omitempty"` // Latest workflow instance of the todo, if any
 }
@@ -13,6 +13,17 @@ const (
 	StatusApproved TodoStatus = "Approved"
 )
 
+// BuiltinApprovalWorkflowID is the seeded Draft/Review/Approved workflow behind the /workflow/todos routes.
+// Its step names match the TodoStatus values.
+const BuiltinApprovalWorkflowID = "a0000000-0000-4000-8000-000000000001"
+
+// Actions of the built-in approval workflow
+const (
+	ActionSubmit  = "submit"
+	ActionApprove = "approve"
+	ActionReject  = "reject"
+)
+
 // Todo represents a task in the system
 type TodoTask struct {
 	ID          string
@@ -34,3 +34,12 @@ func (u *User) HasPermission(permission string) bool {
 	// }
 	return false
 }
+
+// IsAdministrator reports whether the user holds the Admin or Super Admin role.
+// The deprecated IsAdmin flag does not count, as users can set it when registering.
+func (u *User) IsAdministrator() bool {
+	if u.Role == nil {
+		return false
+	}
+	return u.Role.Name == RoleAdmin || u.Role.Name == RoleSuperAdmin
+}
@@ -11,6 +11,8 @@ type Workflow struct {
 	CreatedBy   string    `json:"created_by"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	CompleteTodoOnFinish bool `json:"complete_todo_on_finish"` // Mark the linked todo completed when an instance reaches a final step
 }
 
 // WorkflowStep represents a step/stage in a workflow
@@ -23,8 +25,61 @@ type WorkflowStep struct {
 	Final        bool      `json:"final"`
 	AllowedRoles []string  `json:"allowed_roles"` // Will be stored as JSON in DB
 	CreatedAt    time.Time `json:"created_at"`
+
+	// SLA settings; a step with SLAMinutes = 0 never becomes overdue
+	SLAMinutes       int    `json:"sla_minutes"`
+	EscalationAction string `json:"escalation_action"` // e.g., "auto_transition", "reassign_user", "reassign_role", "notify"
+	EscalationValue  string `json:"escalation_value"`  // action name, user ID or role name depending on EscalationAction
+
+	// Approval settings; in "single" mode the first permitted action moves the instance on
+	ApprovalMode  string   `json:"approval_mode"`  // "single", "quorum" or "all_roles"
+	Quorum        int      `json:"quorum"`         // Number of matching votes needed in "quorum" mode
+	ReviewerUsers []string `json:"reviewer_users"` // User IDs allowed to vote, stored as JSON in DB
+	ReviewerRoles []string `json:"reviewer_roles"` // Role names allowed to vote, stored as JSON in DB
+	VetoAction    string   `json:"veto_action"`    // Action that fires on the first vote, e.g., "reject"
+
+	// Subworkflow settings; entering the step starts child instances of SubworkflowID
+	// and the step waits until all of them are completed or cancelled
+	SubworkflowID    string `json:"subworkflow_id,omitempty"`
+	SubworkflowItems string `json:"subworkflow_items,omitempty"` // Payload key of a list, one child is started per item
+
+	// Work queue; instances entering the step are unassigned until a member of QueueRole claims them
+	QueueRole string `json:"queue_role,omitempty"`
+}
+
+// Approval modes of a workflow step
+const (
+	ApprovalSingle   = "single"    // First permitted action fires immediately
+	ApprovalQuorum   = "quorum"    // Action fires once Quorum reviewers voted for it
+	ApprovalAllRoles = "all_roles" // Action fires once every reviewer role voted for it
+)
+
+// IsSubworkflow reports whether entering this step starts child instances
+func (s *WorkflowStep) IsSubworkflow() bool {
+	return s.SubworkflowID != ""
+}
+
+// IsQueue reports whether instances on this step are claimed from a role queue
+func (s *WorkflowStep) IsQueue() bool {
+	return s.QueueRole != ""
+}
+
+// RequiresVotes reports whether actions on this step are collected as votes
+func (s *WorkflowStep) RequiresVotes() bool {
+	return s.ApprovalMode == ApprovalQuorum || s.ApprovalMode == ApprovalAllRoles
 }
 
+// Escalation actions fired when an instance overstays a step's SLA
+const (
+	EscalationAutoTransition = "auto_transition"
+	EscalationReassignUser   = "reassign_user"
+	EscalationReassignRole   = "reassign_role"
+	EscalationNotify         = "notify"
+)
+
+// SystemActor is recorded as performed_by for actions taken by the engine itself
+const SystemActor = "system"
+
 // WorkflowTransition represents a transition between steps
 type WorkflowTransition struct {
 	ID             string    `json:"id"`
@@ -35,17 +90,64 @@ type WorkflowTransition struct {
 	ConditionType  string    `json:"condition_type"`  // e.g., "user_role", "assigned_user_only"
 	ConditionValue string    `json:"condition_value"` // JSON for complex conditions
 	CreatedAt      time.Time `json:"created_at"`
+
+	FormSchema *FormSchema `json:"form_schema,omitempty"` // Fields the caller must submit, nil when the action takes no data
+	Automatic  bool        `json:"automatic"`             // Fired by the engine on entering the from step instead of by a user
 }
 
-//this basically refers to an instance in the workflow
+// this basically refers to an instance in the workflow
 type AssignedTodo struct {
-	ID            string    `json:"id"`
-	WorkflowId    string    `json:"workflow_id"`
-	CurrentStepId string    `json:"current_step_id"`
-	TodoId        string    `json:"todo_id"`
-	AssignedTo    string    `json:"assigned_to"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID               string                 `json:"id"`
+	WorkflowId       string                 `json:"workflow_id"`
+	CurrentStepId    string                 `json:"current_step_id"`
+	TodoId           string                 `json:"todo_id"`
+	AssignedTo       string                 `json:"assigned_to"` // Empty while the instance waits in a work queue
+	StepEnteredAt    time.Time              `json:"step_entered_at"`
+	DueAt            *time.Time             `json:"due_at"`             // Nil when the current step has no SLA
+	EscalatedAt      *time.Time             `json:"escalated_at"`       // Set once the overdue step has been escalated
+	Status           string                 `json:"status"`             // "active", "suspended", "cancelled" or "completed"
+	SuspendedAt      *time.Time             `json:"suspended_at"`       // Set while the instance is suspended
+	Payload          map[string]interface{} `json:"payload"`            // Business data, merged from the forms submitted with actions
+	ParentInstanceID *string                `json:"parent_instance_id"` // Set on child instances started by a subworkflow step
+	Version          int                    `json:"version"`            // Incremented on every write, for optimistic concurrency
+	CreatedAt        time.Time              `json:"created_at"`
+	UpdatedAt        time.Time              `json:"updated_at"`
+}
+
+// Lifecycle states of a workflow instance
+const (
+	InstanceActive    = "active"
+	InstanceSuspended = "suspended"
+	InstanceCancelled = "cancelled"
+	InstanceCompleted = "completed"
+)
+
+// IsActive reports whether actions can be executed on the instance
+func (a *AssignedTodo) IsActive() bool {
+	return a.Status == InstanceActive
+}
+
+// IsClosed reports whether the instance has reached a terminal state
+func (a *AssignedTodo) IsClosed() bool {
+	return a.Status == InstanceCancelled || a.Status == InstanceCompleted
+}
+
+// IsUnclaimed reports whether the instance waits in a work queue without an assignee
+func (a *AssignedTodo) IsUnclaimed() bool {
+	return a.AssignedTo == ""
+}
+
+// TodoWorkflowState is the workflow progress shown on a todo
+type TodoWorkflowState struct {
+	InstanceID      string     `json:"instance_id"`
+	WorkflowID      string     `json:"workflow_id"`
+	WorkflowName    string     `json:"workflow_name"`
+	CurrentStepID   string     `json:"current_step_id"`
+	CurrentStepName string     `json:"current_step_name"`
+	Status          string     `json:"status"`
+	AssignedTo      string     `json:"assigned_to"`
+	DueAt           *time.Time `json:"due_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 // WorkflowHistory represents the audit trail of a workflow instance
@@ -58,13 +160,16 @@ type WorkflowHistory struct {
 	PerformedBy string    `json:"performed_by"`
 	Comments    string    `json:"comments"`
 	Timestamp   time.Time `json:"timestamp"`
+
+	Data map[string]interface{} `json:"data,omitempty"` // Form data submitted with the action
 }
 
 // AvailableAction represents an action a user can take on an instance
 type AvailableAction struct {
-	ActionName   string `json:"action_name"`
-	ToStepName   string `json:"to_step_name"`
-	TransitionID string `json:"transition_id"`
+	ActionName   string      `json:"action_name"`
+	ToStepName   string      `json:"to_step_name"`
+	TransitionID string      `json:"transition_id"`
+	FormSchema   *FormSchema `json:"form_schema,omitempty"`
 }
 
 // WorkflowInstanceWithDetails includes current step information
@@ -73,4 +178,98 @@ type WorkflowInstanceWithDetails struct {
 	CurrentStepName  string            `json:"current_step_name"`
 	WorkflowName     string            `json:"workflow_name"`
 	AvailableActions []AvailableAction `json:"available_actions"`
+	Children         []*AssignedTodo   `json:"children,omitempty"` // Instances started by subworkflow steps of this instance
+}
+
+// OverdueTask is an instance that has passed the SLA of its current step
+type OverdueTask struct {
+	AssignedTodo
+	CurrentStepName  string `json:"current_step_name"`
+	WorkflowName     string `json:"workflow_name"`
+	EscalationAction string `json:"escalation_action"`
+	OverdueMinutes   int    `json:"overdue_minutes"`
+}
+
+// WorkflowVote is a single reviewer's vote on a multi-approval step
+type WorkflowVote struct {
+	ID         string    `json:"id"`
+	InstanceID string    `json:"instance_id"`
+	StepID     string    `json:"step_id"`
+	VoterID    string    `json:"voter_id"`
+	VoterRole  string    `json:"voter_role"`
+	ActionName string    `json:"action_name"`
+	Comments   string    `json:"comments"`
+	Round      time.Time `json:"-"` // step_entered_at of the visit of the step the vote was cast in
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// VoteTally summarises the votes cast on an instance's current step
+type VoteTally struct {
+	StepID       string         `json:"step_id"`
+	ApprovalMode string         `json:"approval_mode"`
+	Quorum       int            `json:"quorum"`
+	VetoAction   string         `json:"veto_action"`
+	Counts       map[string]int `json:"counts"` // Votes per action name
+	Votes        []WorkflowVote `json:"votes"`
+}
+
+// TransitionResult describes the outcome of executing an action on an instance
+type TransitionResult struct {
+	InstanceID    string     `json:"instance_id"`
+	ActionName    string     `json:"action_name"`
+	FromStepID    string     `json:"from_step_id"`
+	ToStepID      string     `json:"to_step_id"`
+	CurrentStepID string     `json:"current_step_id"` // Step the instance is in now, after any automatic transitions
+	Version       int        `json:"version"`         // Version of the instance after the action
+	Transitioned  bool       `json:"transitioned"`    // False when a vote was recorded but the quorum is not met yet
+	Tally         *VoteTally `json:"tally,omitempty"`
+}
+
+// Bulk execution modes
+const (
+	BulkAllOrNothing = "all_or_nothing" // Apply the action to every instance or to none
+	BulkBestEffort   = "best_effort"    // Apply the action wherever it is allowed
+)
+
+// BulkExecuteResult reports the outcome of one action executed on many instances
+type BulkExecuteResult struct {
+	Mode       string               `json:"mode"`
+	Applied    int                  `json:"applied"`
+	Failed     int                  `json:"failed"`
+	RolledBack bool                 `json:"rolled_back"` // All-or-nothing runs with a failure apply nothing
+	Results    []BulkInstanceResult `json:"results"`     // In request order
+}
+
+// BulkInstanceResult is the outcome of a bulk action on one instance
+type BulkInstanceResult struct {
+	InstanceID string             `json:"instance_id"`
+	Applied    bool               `json:"applied"`
+	Error      string             `json:"error,omitempty"`
+	Conflict   bool               `json:"conflict,omitempty"` // The instance changed concurrently, retrying may succeed
+	Result     *TransitionResult  `json:"result,omitempty"`
+	History    []*WorkflowHistory `json:"history,omitempty"` // Entries written by the action, including automatic hops
+}
+
+// InstancePrecondition is the state a caller expects an instance to be in before
+// acting on it. Zero fields are not checked.
+type InstancePrecondition struct {
+	Version int    // From the If-Match header
+	StepID  string // From expected_step_id in the request body
+}
+
+// WorkflowDelegation hands a user's workflow tasks to another user for a period of time,
+// e.g. while the delegator is out of office
+type WorkflowDelegation struct {
+	ID          string    `json:"id"`
+	DelegatorID string    `json:"delegator_id"`
+	DelegateID  string    `json:"delegate_id"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// IsActive reports whether the delegation is in effect at the given time
+func (d *WorkflowDelegation) IsActive(at time.Time) bool {
+	return !at.Before(d.StartsAt) && at.Before(d.EndsAt)
 }
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"todo-api/pkg/apperrors"
+)
+
+// FormSchema declares the fields a caller must submit with a transition.
+// It is a small subset of JSON Schema: an object with typed properties,
+// required fields, enums and numeric/length bounds.
+type FormSchema struct {
+	Required   []string             `json:"required,omitempty"`
+	Properties map[string]FormField `json:"properties"`
+}
+
+// FormField describes a single submitted value
+type FormField struct {
+	Type        string        `json:"type"` // "string", "number", "integer", "boolean", "array" or "object"
+	Description string        `json:"description,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Minimum     *float64      `json:"minimum,omitempty"`
+	Maximum     *float64      `json:"maximum,omitempty"`
+	MinLength   *int          `json:"minLength,omitempty"`
+	MaxLength   *int          `json:"maxLength,omitempty"`
+}
+
+var formFieldTypes = map[string]bool{
+	"string": true, "number": true, "integer": true, "boolean": true, "array": true, "object": true,
+}
+
+// Check reports whether the schema itself is well formed
+func (s *FormSchema) Check() error {
+	if len(s.Properties) == 0 {
+		return apperrors.Invalid("form_schema must declare at least one property")
+	}
+	for name, field := range s.Properties {
+		if !formFieldTypes[field.Type] {
+			return apperrors.Invalid("form_schema property %q has unsupported type %q", name, field.Type)
+		}
+		for _, value := range field.Enum {
+			if err := field.checkType(value); err != nil {
+				return apperrors.Invalid("form_schema property %q has an invalid enum value: %w", name, err)
+			}
+		}
+	}
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; !ok {
+			return apperrors.Invalid("form_schema requires undeclared property %q", name)
+		}
+	}
+	return nil
+}
+
+// Validate checks submitted data against the schema. Undeclared fields are rejected
+// so that only known values end up in the instance payload.
+func (s *FormSchema) Validate(data map[string]interface{}) error {
+	var problems []apperrors.FieldError
+
+	for _, name := range s.Required {
+		if value, ok := data[name]; !ok || value == nil {
+			problems = append(problems, apperrors.FieldError{Field: name, Message: "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field, ok := s.Properties[name]
+		if !ok {
+			problems = append(problems, apperrors.FieldError{Field: name, Message: "is not part of the form"})
+			continue
+		}
+		if data[name] == nil {
+			continue
+		}
+		if err := field.validate(data[name]); err != nil {
+			problems = append(problems, apperrors.FieldError{Field: name, Message: err.Error()})
+		}
+	}
+
+	if len(problems) > 0 {
+		messages := make([]string, len(problems))
+		for i, problem := range problems {
+			messages[i] = problem.Field + " " + problem.Message
+		}
+		return apperrors.Validation("invalid form data: "+strings.Join(messages, "; "), problems...)
+	}
+	return nil
+}
+
+// validate checks a single non-nil value against the field
+func (f FormField) validate(value interface{}) error {
+	if err := f.checkType(value); err != nil {
+		return err
+	}
+
+	if len(f.Enum) > 0 && !enumContains(f.Enum, value) {
+		return fmt.Errorf("must be one of %v", f.Enum)
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if f.Minimum != nil && v < *f.Minimum {
+			return fmt.Errorf("must be at least %v", *f.Minimum)
+		}
+		if f.Maximum != nil && v > *f.Maximum {
+			return fmt.Errorf("must be at most %v", *f.Maximum)
+		}
+	case string:
+		length := len([]rune(v))
+		if f.MinLength != nil && length < *f.MinLength {
+			return fmt.Errorf("must be at least %d characters", *f.MinLength)
+		}
+		if f.MaxLength != nil && length > *f.MaxLength {
+			return fmt.Errorf("must be at most %d characters", *f.MaxLength)
+		}
+	}
+	return nil
+}
+
+// checkType checks a decoded JSON value against the field type
+func (f FormField) checkType(value interface{}) error {
+	ok := false
+	switch f.Type {
+	case "string":
+		_, ok = value.(string)
+	case "number":
+		_, ok = value.(float64)
+	case "integer":
+		n, isNumber := value.(float64)
+		ok = isNumber && n == math.Trunc(n)
+	case "boolean":
+		_, ok = value.(bool)
+	case "array":
+		_, ok = value.([]interface{})
+	case "object":
+		_, ok = value.(map[string]interface{})
+	}
+	if !ok {
+		return fmt.Errorf("must be of type %s", f.Type)
+	}
+	return nil
+}
+
+// enumContains compares decoded JSON scalars
+func enumContains(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
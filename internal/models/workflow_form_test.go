@@ -0,0 +1,81 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFormSchema_Validate(t *testing.T) {
+	// Arrange - a rejection form with a required reason and an optional bounded budget
+	var schema FormSchema
+	err := json.Unmarshal([]byte(`{
+		"required": ["reason", "severity"],
+		"properties": {
+			"reason":   {"type": "string", "minLength": 3},
+			"severity": {"type": "string", "enum": ["low", "high"]},
+			"budget":   {"type": "integer", "minimum": 0, "maximum": 1000}
+		}
+	}`), &schema)
+	if err != nil {
+		t.Fatalf("Failed to decode schema: %v", err)
+	}
+	if err := schema.Check(); err != nil {
+		t.Fatalf("Expected schema to be valid, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"valid", `{"reason": "too expensive", "severity": "high", "budget": 500}`, ""},
+		{"missing required", `{"severity": "low"}`, "reason is required"},
+		{"wrong type", `{"reason": 42, "severity": "low"}`, "reason must be of type string"},
+		{"not in enum", `{"reason": "nope", "severity": "medium"}`, "severity must be one of"},
+		{"not an integer", `{"reason": "nope", "severity": "low", "budget": 1.5}`, "budget must be of type integer"},
+		{"above maximum", `{"reason": "nope", "severity": "low", "budget": 5000}`, "budget must be at most 1000"},
+		{"too short", `{"reason": "no", "severity": "low"}`, "reason must be at least 3 characters"},
+		{"undeclared field", `{"reason": "nope", "severity": "low", "extra": true}`, "extra is not part of the form"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.data), &data); err != nil {
+				t.Fatalf("Failed to decode data: %v", err)
+			}
+
+			// Act
+			err := schema.Validate(data)
+
+			// Assert
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFormSchema_Check(t *testing.T) {
+	// Required fields must be declared
+	schema := FormSchema{
+		Required:   []string{"reason"},
+		Properties: map[string]FormField{"budget": {Type: "number"}},
+	}
+	if err := schema.Check(); err == nil {
+		t.Error("Expected an error for an undeclared required property")
+	}
+
+	// Unknown types are rejected
+	schema = FormSchema{Properties: map[string]FormField{"due": {Type: "date"}}}
+	if err := schema.Check(); err == nil {
+		t.Error("Expected an error for an unsupported type")
+	}
+}
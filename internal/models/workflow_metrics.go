@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// WorkflowMetrics summarises how instances of a workflow moved through it within a date range.
+// Durations are in hours.
+type WorkflowMetrics struct {
+	WorkflowID string           `json:"workflow_id"`
+	From       time.Time        `json:"from"`
+	To         time.Time        `json:"to"`
+	CycleTime  CycleTimeMetrics `json:"cycle_time"`
+	Steps      []StepMetrics    `json:"steps"`      // In step order
+	Users      []UserThroughput `json:"users"`      // Busiest first
+	Bottleneck *StepMetrics     `json:"bottleneck"` // Step with the longest average dwell time, nil without data
+	Rework     ReworkMetrics    `json:"rework"`
+}
+
+// CycleTimeMetrics describes the time from start to completion of instances completed in the range
+type CycleTimeMetrics struct {
+	Completed    int     `json:"completed"`
+	MedianHours  float64 `json:"median_hours"`
+	P90Hours     float64 `json:"p90_hours"`
+	AverageHours float64 `json:"average_hours"`
+}
+
+// StepMetrics describes how long instances stayed on a step entered in the range.
+// Visits that have not left the step yet count towards Visits and Waiting but not the dwell times.
+type StepMetrics struct {
+	StepID        string  `json:"step_id"`
+	StepName      string  `json:"step_name"`
+	StepOrder     int     `json:"step_order"`
+	Final         bool    `json:"final"`
+	Visits        int     `json:"visits"`
+	AvgDwellHours float64 `json:"avg_dwell_hours"`
+	MaxDwellHours float64 `json:"max_dwell_hours"`
+	Waiting       int     `json:"waiting"`   // Open instances currently on the step
+	Reentries     int     `json:"reentries"` // Visits by instances that had been on the step before
+	SentBack      int     `json:"sent_back"` // Moves from this step to an earlier one
+}
+
+// ReworkMetrics totals the loops over all steps
+type ReworkMetrics struct {
+	Reentries int `json:"reentries"`
+	SentBack  int `json:"sent_back"`
+}
+
+// UserThroughput counts the step-changing actions a user performed in the range.
+// ResponseHours is the average time between the task entering a step and the user's action.
+type UserThroughput struct {
+	UserID        string  `json:"user_id"`
+	Username      string  `json:"username,omitempty"`
+	Actions       int     `json:"actions"`
+	Instances     int     `json:"instances"`
+	ResponseHours float64 `json:"response_hours"`
+}
@@ -0,0 +1,53 @@
+package models
+
+// SimulationInput scripts a dry run of a workflow. Actors are plain user IDs;
+// nothing is looked up or written in the database for them.
+type SimulationInput struct {
+	AssignedTo string                 `json:"assigned_to"`
+	Payload    map[string]interface{} `json:"payload"`
+	Roles      map[string]string      `json:"roles"` // Role name of each actor, for reviewer lists and guard expressions
+	Steps      []SimulationAction     `json:"steps"`
+}
+
+// SimulationAction is one scripted action of a dry run
+type SimulationAction struct {
+	Actor  string                 `json:"actor"`
+	Action string                 `json:"action"`
+	Data   map[string]interface{} `json:"data"`
+}
+
+// SimulationResult reports what a dry run did
+type SimulationResult struct {
+	WorkflowID      string                 `json:"workflow_id"`
+	Status          string                 `json:"status"` // "active" or "completed" at the end of the run
+	CurrentStepID   string                 `json:"current_step_id"`
+	CurrentStepName string                 `json:"current_step_name"`
+	Payload         map[string]interface{} `json:"payload"`
+	Path            []SimulationHop        `json:"path"`                    // Steps entered, in order, starting with the start step
+	Steps           []SimulationStepResult `json:"steps"`                   // Outcome of every scripted action
+	Problems        []string               `json:"problems"`                // Misconfigurations found in the workflow definition
+	HaltedReason    string                 `json:"halted_reason,omitempty"` // Why the run stopped before the script ended
+}
+
+// SimulationHop is a step entered during a dry run
+type SimulationHop struct {
+	StepID    string `json:"step_id"`
+	StepName  string `json:"step_name"`
+	Action    string `json:"action,omitempty"` // Empty for the start step
+	Actor     string `json:"actor,omitempty"`
+	Automatic bool   `json:"automatic"`
+}
+
+// SimulationStepResult is the outcome of one scripted action
+type SimulationStepResult struct {
+	Actor            string     `json:"actor"`
+	Action           string     `json:"action"`
+	StepName         string     `json:"step_name"`         // Step the action was attempted in
+	AvailableActions []string   `json:"available_actions"` // Actions the actor could take in that step
+	Claimed          bool       `json:"claimed,omitempty"` // The actor claimed the task from the step's queue first
+	Executed         bool       `json:"executed"`
+	Transitioned     bool       `json:"transitioned"`          // False for votes that did not decide the step
+	RejectedBy       string     `json:"rejected_by,omitempty"` // The guard that refused the action
+	Error            string     `json:"error,omitempty"`
+	Tally            *VoteTally `json:"tally,omitempty"`
+}
@@ -0,0 +1,26 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+	"todo-api/pkg/utils"
+)
+
+// redocPage renders the document next to it with Redoc
+//
+//go:embed redoc.html
+var redocPage []byte
+
+// Handler serves doc as JSON
+func Handler(doc *Document) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		utils.RespondJSON(w, http.StatusOK, doc)
+	}
+}
+
+// Redoc serves a page that renders the document found at openapi.json, relative
+// to the page, so the pair keeps working when the API is mounted under a prefix
+func Redoc(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(redocPage)
+}
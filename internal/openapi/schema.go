@@ -0,0 +1,117 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// refPrefix is where component schemas are referenced from
+const refPrefix = "#/components/schemas/"
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	uuidType    = reflect.TypeOf(uuid.UUID{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Schemas derives schemas from Go types by their JSON encoding. Named structs
+// become components referenced by name, so each is described once.
+type Schemas map[string]*Schema
+
+// Fields describes an object that has no Go type, such as a map built by a handler,
+// by a value of each property's type
+type Fields map[string]interface{}
+
+// Of returns the schema of v's type. A *Schema is returned as is, and nil yields nil.
+func (s Schemas) Of(v interface{}) *Schema {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case *Schema:
+		return v
+	case Fields:
+		schema := Object(map[string]*Schema{})
+		for name, value := range v {
+			schema.Properties[name] = s.Of(value)
+		}
+		return schema
+	}
+	return s.ofType(reflect.TypeOf(v))
+}
+
+func (s Schemas) ofType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case rawJSONType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.ofType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.ofType(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		if _, ok := s[t.Name()]; !ok {
+			s[t.Name()] = nil // Reserve the name first, so recursive types terminate
+			s[t.Name()] = s.object(t)
+		}
+		return &Schema{Ref: refPrefix + t.Name()}
+	}
+	return &Schema{}
+}
+
+// object describes a struct by the fields encoding/json writes, including the
+// fields of embedded structs
+func (s Schemas) object(t reflect.Type) *Schema {
+	schema := Object(map[string]*Schema{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for property, embedded := range s.object(field.Type).Properties {
+				schema.Properties[property] = embedded
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property := s.ofType(field.Type)
+		if field.Type.Kind() == reflect.Ptr && property.Ref == "" {
+			property.Nullable = true
+		}
+		schema.Properties[name] = property
+	}
+	return schema
+}
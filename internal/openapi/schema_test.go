@@ -0,0 +1,77 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type testBase struct {
+	ID uuid.UUID `json:"id"`
+}
+
+type testNode struct {
+	testBase
+	Name     string                 `json:"name,omitempty"`
+	Parent   *testNode              `json:"parent"`
+	Due      *time.Time             `json:"due"`
+	Tags     []string               `json:"tags"`
+	Data     map[string]interface{} `json:"data"`
+	Secret   string                 `json:"-"`
+	Untagged int
+	internal bool
+}
+
+func TestSchemas_Of(t *testing.T) {
+	// Arrange
+	schemas := Schemas{}
+
+	// Act
+	ref := schemas.Of([]*testNode{})
+
+	// Assert
+	if ref.Type != "array" || ref.Items.Ref != "#/components/schemas/testNode" {
+		t.Fatalf("Expected an array of testNode references, got %+v", ref)
+	}
+	node := schemas["testNode"]
+	if node == nil {
+		t.Fatalf("Expected a testNode component, got %v", schemas)
+	}
+
+	tests := []struct {
+		property string
+		want     Schema
+	}{
+		{"id", Schema{Type: "string", Format: "uuid"}}, // Promoted from the embedded struct
+		{"name", Schema{Type: "string"}},
+		{"parent", Schema{Ref: "#/components/schemas/testNode"}},
+		{"due", Schema{Type: "string", Format: "date-time", Nullable: true}},
+		{"Untagged", Schema{Type: "integer"}},
+	}
+	for _, tt := range tests {
+		got := node.Properties[tt.property]
+		if got == nil || got.Ref != tt.want.Ref || got.Type != tt.want.Type || got.Format != tt.want.Format || got.Nullable != tt.want.Nullable {
+			t.Errorf("Expected %s to be %+v, got %+v", tt.property, tt.want, got)
+		}
+	}
+	if tags := node.Properties["tags"]; tags == nil || tags.Items == nil || tags.Items.Type != "string" {
+		t.Errorf("Expected tags to be an array of strings, got %+v", tags)
+	}
+	if data := node.Properties["data"]; data == nil || data.Type != "object" || data.AdditionalProperties == nil {
+		t.Errorf("Expected data to be an object of any values, got %+v", data)
+	}
+	if len(node.Properties) != 7 {
+		t.Errorf("Expected skipped and unexported fields to be left out, got %d properties", len(node.Properties))
+	}
+}
+
+func TestSchemas_OfFields(t *testing.T) {
+	// Act
+	schema := Schemas{}.Of(Fields{"message": "", "count": 0})
+
+	// Assert
+	if schema.Type != "object" || schema.Properties["message"].Type != "string" || schema.Properties["count"].Type != "integer" {
+		t.Errorf("Expected an object with a string and an integer, got %+v", schema)
+	}
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -20,7 +21,10 @@ func NewDataSourceRepository() *DataSourceRepository {
 }
 
 // GetTodosByPriority returns todos grouped by priority
-func (r *DataSourceRepository) GetTodosByPriority() ([]models.PieChartSlice, error) {
+func (r *DataSourceRepository) GetTodosByPriority(ctx context.Context) ([]models.PieChartSlice, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT
 			COALESCE(priority, 'medium') as priority,
@@ -37,7 +41,7 @@ func (r *DataSourceRepository) GetTodosByPriority() ([]models.PieChartSlice, err
 			END
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query todos by priority: %w", err)
 	}
@@ -66,7 +70,10 @@ func (r *DataSourceRepository) GetTodosByPriority() ([]models.PieChartSlice, err
 }
 
 // GetTodosByPriorityTable returns todos by priority in table format
-func (r *DataSourceRepository) GetTodosByPriorityTable() (*models.TableData, error) {
+func (r *DataSourceRepository) GetTodosByPriorityTable(ctx context.Context) (*models.TableData, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT
 			COALESCE(priority, 'medium') as priority,
@@ -84,7 +91,7 @@ func (r *DataSourceRepository) GetTodosByPriorityTable() (*models.TableData, err
 			END
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query todos by priority: %w", err)
 	}
@@ -121,7 +128,10 @@ func (r *DataSourceRepository) GetTodosByPriorityTable() (*models.TableData, err
 }
 
 // GetTodosByStatus returns todos grouped by completion status
-func (r *DataSourceRepository) GetTodosByStatus() ([]models.PieChartSlice, error) {
+func (r *DataSourceRepository) GetTodosByStatus(ctx context.Context) ([]models.PieChartSlice, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT
 			CASE WHEN completed THEN 'Completed' ELSE 'Pending' END as status,
@@ -130,7 +140,7 @@ func (r *DataSourceRepository) GetTodosByStatus() ([]models.PieChartSlice, error
 		GROUP BY completed
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query todos by status: %w", err)
 	}
@@ -161,7 +171,10 @@ func (r *DataSourceRepository) GetTodosByStatus() ([]models.PieChartSlice, error
 }
 
 // GetTodosByStatusTable returns todos by status in table format
-func (r *DataSourceRepository) GetTodosByStatusTable() (*models.TableData, error) {
+func (r *DataSourceRepository) GetTodosByStatusTable(ctx context.Context) (*models.TableData, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT
 			CASE WHEN completed THEN 'Completed' ELSE 'Pending' END as status,
@@ -171,7 +184,7 @@ func (r *DataSourceRepository) GetTodosByStatusTable() (*models.TableData, error
 		GROUP BY completed
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query todos by status: %w", err)
 	}
@@ -206,7 +219,10 @@ func (r *DataSourceRepository) GetTodosByStatusTable() (*models.TableData, error
 }
 
 // GetTodosList returns list of todos for table widget
-func (r *DataSourceRepository) GetTodosList() (*models.TableData, error) {
+func (r *DataSourceRepository) GetTodosList(ctx context.Context) (*models.TableData, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT
 			t.id,
@@ -221,7 +237,7 @@ func (r *DataSourceRepository) GetTodosList() (*models.TableData, error) {
 		LIMIT 100
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query todos list: %w", err)
 	}
@@ -273,7 +289,10 @@ func (r *DataSourceRepository) GetTodosList() (*models.TableData, error) {
 }
 
 // GetUsersByRole returns users grouped by role
-func (r *DataSourceRepository) GetUsersByRole() ([]models.PieChartSlice, error) {
+func (r *DataSourceRepository) GetUsersByRole(ctx context.Context) ([]models.PieChartSlice, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT
 			COALESCE(r.name, 'No Role') as role_name,
@@ -284,7 +303,7 @@ func (r *DataSourceRepository) GetUsersByRole() ([]models.PieChartSlice, error)
 		ORDER BY count DESC
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query users by role: %w", err)
 	}
@@ -315,7 +334,10 @@ func (r *DataSourceRepository) GetUsersByRole() ([]models.PieChartSlice, error)
 }
 
 // GetUsersByRoleTable returns users by role in table format
-func (r *DataSourceRepository) GetUsersByRoleTable() (*models.TableData, error) {
+func (r *DataSourceRepository) GetUsersByRoleTable(ctx context.Context) (*models.TableData, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT
 			COALESCE(r.name, 'No Role') as role_name,
@@ -327,7 +349,7 @@ func (r *DataSourceRepository) GetUsersByRoleTable() (*models.TableData, error)
 		ORDER BY count DESC
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query users by role: %w", err)
 	}
@@ -362,7 +384,10 @@ func (r *DataSourceRepository) GetUsersByRoleTable() (*models.TableData, error)
 }
 
 // GetUserActivity returns recent user activity
-func (r *DataSourceRepository) GetUserActivity() (*models.TableData, error) {
+func (r *DataSourceRepository) GetUserActivity(ctx context.Context) (*models.TableData, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT
 			u.id::TEXT as user_id,
@@ -377,7 +402,7 @@ func (r *DataSourceRepository) GetUserActivity() (*models.TableData, error) {
 		LIMIT 50
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query user activity: %w", err)
 	}
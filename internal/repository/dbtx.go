@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX is the subset of *sql.DB and *sql.Tx the repositories use, so a
+// repository can run its queries inside a transaction
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction a TxManager opened for ctx, or db outside of one
+func conn(ctx context.Context, db *sql.DB) DBTX {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return db
+}
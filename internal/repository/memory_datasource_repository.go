@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"todo-api/internal/models"
+)
+
+// MemoryDataSourceRepository is DataSourceRepository over a MemoryStore.
+// Todos have no priority, so every todo counts as "medium".
+type MemoryDataSourceRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryDataSourceRepository(store *MemoryStore) *MemoryDataSourceRepository {
+	return &MemoryDataSourceRepository{
+		store: store,
+	}
+}
+
+// defaultPriority is the priority of a todo without one
+const defaultPriority = "medium"
+
+// group is a label with the number of rows that have it
+type group struct {
+	label string
+	count int
+}
+
+// GetTodosByPriority returns todos grouped by priority
+func (r *MemoryDataSourceRepository) GetTodosByPriority(ctx context.Context) ([]models.PieChartSlice, error) {
+	groups, err := r.todosByPriority()
+	if err != nil {
+		return nil, err
+	}
+
+	var slices []models.PieChartSlice
+	for _, g := range groups {
+		slices = append(slices, models.PieChartSlice{
+			Label: capitalize(g.label),
+			Value: float64(g.count),
+			Color: models.PriorityColors[g.label],
+		})
+	}
+	return slices, nil
+}
+
+// GetTodosByPriorityTable returns todos by priority in table format
+func (r *MemoryDataSourceRepository) GetTodosByPriorityTable(ctx context.Context) (*models.TableData, error) {
+	groups, err := r.todosByPriority()
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		groups[i].label = capitalize(groups[i].label)
+	}
+	return groupTable("priority", "Priority", groups), nil
+}
+
+// GetTodosByStatus returns todos grouped by completion status
+func (r *MemoryDataSourceRepository) GetTodosByStatus(ctx context.Context) ([]models.PieChartSlice, error) {
+	groups, err := r.todosByStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	statusColors := map[string]string{
+		"Completed": "#22c55e",
+		"Pending":   "#f59e0b",
+	}
+
+	var slices []models.PieChartSlice
+	for _, g := range groups {
+		slices = append(slices, models.PieChartSlice{
+			Label: g.label,
+			Value: float64(g.count),
+			Color: statusColors[g.label],
+		})
+	}
+	return slices, nil
+}
+
+// GetTodosByStatusTable returns todos by status in table format
+func (r *MemoryDataSourceRepository) GetTodosByStatusTable(ctx context.Context) (*models.TableData, error) {
+	groups, err := r.todosByStatus()
+	if err != nil {
+		return nil, err
+	}
+	return groupTable("status", "Status", groups), nil
+}
+
+// GetTodosList returns the 100 newest todos for table widget
+func (r *MemoryDataSourceRepository) GetTodosList(ctx context.Context) (*models.TableData, error) {
+	tableData := &models.TableData{
+		Columns: []models.TableColumn{
+			{Key: "id", Header: "ID", Width: "10%"},
+			{Key: "title", Header: "Title", Width: "30%"},
+			{Key: "priority", Header: "Priority", Width: "15%"},
+			{Key: "status", Header: "Status", Width: "15%"},
+			{Key: "owner", Header: "Owner", Width: "15%"},
+			{Key: "created_at", Header: "Created", Width: "15%"},
+		},
+		Rows: []map[string]interface{}{},
+	}
+
+	err := r.store.read(func(t *memoryTables) error {
+		todos := t.todos.all()
+		sort.SliceStable(todos, func(i, j int) bool { return todos[i].CreatedAt.After(todos[j].CreatedAt) })
+		if len(todos) > 100 {
+			todos = todos[:100]
+		}
+
+		for _, todo := range todos {
+			owner := "Unassigned"
+			if user, ok := t.users.first(func(u models.User) bool { return u.UserID.String() == todo.UserID }); ok {
+				owner = user.Username
+			}
+			tableData.Rows = append(tableData.Rows, map[string]interface{}{
+				"id":         todo.Id,
+				"title":      todo.TaskName,
+				"priority":   capitalize(defaultPriority),
+				"status":     todoStatus(todo),
+				"owner":      owner,
+				"created_at": todo.CreatedAt.Format("2006-01-02"),
+			})
+		}
+		return nil
+	})
+	return tableData, err
+}
+
+// GetUsersByRole returns users grouped by role
+func (r *MemoryDataSourceRepository) GetUsersByRole(ctx context.Context) ([]models.PieChartSlice, error) {
+	groups, err := r.usersByRole()
+	if err != nil {
+		return nil, err
+	}
+
+	// Color palette for roles
+	colors := []string{"#3b82f6", "#22c55e", "#f59e0b", "#ef4444", "#8b5cf6", "#ec4899"}
+
+	var slices []models.PieChartSlice
+	for i, g := range groups {
+		slices = append(slices, models.PieChartSlice{
+			Label: g.label,
+			Value: float64(g.count),
+			Color: colors[i%len(colors)],
+		})
+	}
+	return slices, nil
+}
+
+// GetUsersByRoleTable returns users by role in table format
+func (r *MemoryDataSourceRepository) GetUsersByRoleTable(ctx context.Context) (*models.TableData, error) {
+	groups, err := r.usersByRole()
+	if err != nil {
+		return nil, err
+	}
+	return groupTable("role", "Role", groups), nil
+}
+
+// GetUserActivity returns the 50 newest users
+func (r *MemoryDataSourceRepository) GetUserActivity(ctx context.Context) (*models.TableData, error) {
+	tableData := &models.TableData{
+		Columns: []models.TableColumn{
+			{Key: "username", Header: "Username", Width: "20%"},
+			{Key: "email", Header: "Email", Width: "25%"},
+			{Key: "role", Header: "Role", Width: "20%"},
+			{Key: "status", Header: "Status", Width: "15%"},
+			{Key: "joined", Header: "Joined", Width: "20%"},
+		},
+		Rows: []map[string]interface{}{},
+	}
+
+	err := r.store.read(func(t *memoryTables) error {
+		users := t.users.all()
+		sort.SliceStable(users, func(i, j int) bool { return users[i].CreatedAt.After(users[j].CreatedAt) })
+		if len(users) > 50 {
+			users = users[:50]
+		}
+
+		for _, user := range users {
+			status := "Inactive"
+			if user.IsActive {
+				status = "Active"
+			}
+			tableData.Rows = append(tableData.Rows, map[string]interface{}{
+				"username": user.Username,
+				"email":    user.Email,
+				"role":     t.roleName(user),
+				"status":   status,
+				"joined":   user.CreatedAt.Format("2006-01-02"),
+			})
+		}
+		return nil
+	})
+	return tableData, err
+}
+
+// todosByPriority counts the todos per priority
+func (r *MemoryDataSourceRepository) todosByPriority() ([]group, error) {
+	var groups []group
+	err := r.store.read(func(t *memoryTables) error {
+		if count := len(t.todos.all()); count > 0 {
+			groups = append(groups, group{label: defaultPriority, count: count})
+		}
+		return nil
+	})
+	return groups, err
+}
+
+// todosByStatus counts the pending and the completed todos, leaving out a status no todo has
+func (r *MemoryDataSourceRepository) todosByStatus() ([]group, error) {
+	var groups []group
+	err := r.store.read(func(t *memoryTables) error {
+		groups = countGroups(t.todos.all(), todoStatus)
+		return nil
+	})
+	return groups, err
+}
+
+// usersByRole counts the users per role name, most common first
+func (r *MemoryDataSourceRepository) usersByRole() ([]group, error) {
+	var groups []group
+	err := r.store.read(func(t *memoryTables) error {
+		groups = countGroups(t.users.all(), t.roleName)
+		return nil
+	})
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].count > groups[j].count })
+	return groups, err
+}
+
+// roleName returns the name of the role of a user, or "No Role"
+func (t *memoryTables) roleName(user models.User) string {
+	if role := t.joinUser(user).Role; role != nil {
+		return role.Name
+	}
+	return "No Role"
+}
+
+// todoStatus returns the status shown for a todo
+func todoStatus(todo models.Todo) string {
+	if todo.Completed {
+		return "Completed"
+	}
+	return "Pending"
+}
+
+// countGroups counts rows per label in the order the labels first appear
+func countGroups[V any](rows []V, label func(row V) string) []group {
+	var groups []group
+	index := map[string]int{}
+	for _, row := range rows {
+		l := label(row)
+		i, ok := index[l]
+		if !ok {
+			i = len(groups)
+			index[l] = i
+			groups = append(groups, group{label: l})
+		}
+		groups[i].count++
+	}
+	return groups
+}
+
+// groupTable returns groups as a table of label, count and rounded share of the total
+func groupTable(key, header string, groups []group) *models.TableData {
+	tableData := &models.TableData{
+		Columns: []models.TableColumn{
+			{Key: key, Header: header, Width: "40%"},
+			{Key: "count", Header: "Count", Width: "30%"},
+			{Key: "percentage", Header: "Percentage", Width: "30%"},
+		},
+		Rows: []map[string]interface{}{},
+	}
+
+	total := 0
+	for _, g := range groups {
+		total += g.count
+	}
+	for _, g := range groups {
+		tableData.Rows = append(tableData.Rows, map[string]interface{}{
+			key:          g.label,
+			"count":      g.count,
+			"percentage": fmt.Sprintf("%.0f%%", math.Round(float64(g.count)*100/float64(total))),
+		})
+	}
+	return tableData
+}
+
+// capitalize upper-cases the first letter of a label
+func capitalize(label string) string {
+	if label == "" {
+		return label
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
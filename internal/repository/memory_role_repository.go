@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// MemoryRoleRepository is RoleRepository over a MemoryStore
+type MemoryRoleRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryRoleRepository(store *MemoryStore) *MemoryRoleRepository {
+	return &MemoryRoleRepository{
+		store: store,
+	}
+}
+
+// GetPermissionByName retrieves a permission by its name
+func (r *MemoryRoleRepository) GetPermissionByName(ctx context.Context, name string) (*models.Permissions, error) {
+	var permission *models.Permissions
+	err := r.store.read(func(t *memoryTables) error {
+		if found, ok := t.permissions.first(func(p models.Permissions) bool { return p.Name == name }); ok {
+			permission = &found
+		}
+		return nil
+	})
+	return permission, err
+}
+
+// CreatePermission creates a new permission, or reuses the ID of the one with the same name
+func (r *MemoryRoleRepository) CreatePermission(ctx context.Context, perm *models.Permissions) error {
+	return r.store.write(ctx, func(t *memoryTables) error {
+		return t.createPermission(perm)
+	})
+}
+
+// CreateRole creates a new role and its permission, or reuses the ID of the role with the same name
+func (r *MemoryRoleRepository) CreateRole(ctx context.Context, role *models.Role) error {
+	return r.store.write(ctx, func(t *memoryTables) error {
+		if existing, ok := t.roles.first(func(ro models.Role) bool { return ro.Name == role.Name }); ok {
+			// Role already exists, just return without error
+			role.RoleId = existing.RoleId
+			return nil
+		}
+		if role.RoleId == uuid.Nil {
+			role.RoleId = uuid.New()
+		}
+		if _, ok := t.roles.get(role.RoleId); ok {
+			return fmt.Errorf("role %s: %w", role.RoleId, errUniqueViolation)
+		}
+
+		// If role has a Permission object, create the permission first
+		if role.Permission != nil {
+			if err := t.createPermission(role.Permission); err != nil {
+				return fmt.Errorf("failed to create permission: %w", err)
+			}
+			role.PermissionId = &role.Permission.Id
+		}
+
+		t.roles.put(role.RoleId, copyRole(*role))
+		return nil
+	})
+}
+
+// GetRoleByID retrieves a role by its ID with permission details
+func (r *MemoryRoleRepository) GetRoleByID(ctx context.Context, roleID uuid.UUID) (*models.Role, error) {
+	var role *models.Role
+	err := r.store.read(func(t *memoryTables) error {
+		found, ok := t.roles.get(roleID)
+		if !ok {
+			return apperrors.NotFound("role not found")
+		}
+		role = t.joinRole(found)
+		return nil
+	})
+	return role, err
+}
+
+// GetRoleByName retrieves a role by its name with permission details, or nil if there is none
+func (r *MemoryRoleRepository) GetRoleByName(ctx context.Context, name string) (*models.Role, error) {
+	var role *models.Role
+	err := r.store.read(func(t *memoryTables) error {
+		if found, ok := t.roles.first(func(ro models.Role) bool { return ro.Name == name }); ok {
+			role = t.joinRole(found)
+		}
+		return nil
+	})
+	return role, err
+}
+
+// GetAllRoles retrieves all roles with their permissions, ordered by name
+func (r *MemoryRoleRepository) GetAllRoles(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.store.read(func(t *memoryTables) error {
+		for _, role := range t.roles.all() {
+			roles = append(roles, *t.joinRole(role))
+		}
+		return nil
+	})
+	sort.SliceStable(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, err
+}
+
+// UpdateRole updates an existing role
+func (r *MemoryRoleRepository) UpdateRole(ctx context.Context, role *models.Role) error {
+	return r.store.write(ctx, func(t *memoryTables) error {
+		if _, ok := t.roles.get(role.RoleId); !ok {
+			return apperrors.NotFound("role not found")
+		}
+		if _, taken := t.roles.first(func(ro models.Role) bool { return ro.RoleId != role.RoleId && ro.Name == role.Name }); taken {
+			return fmt.Errorf("failed to update role: %w", errUniqueViolation)
+		}
+		t.roles.put(role.RoleId, copyRole(*role))
+		return nil
+	})
+}
+
+// DeleteRole deletes a role by its ID; its users are left without a role
+func (r *MemoryRoleRepository) DeleteRole(ctx context.Context, roleID uuid.UUID) error {
+	return r.store.write(ctx, func(t *memoryTables) error {
+		if !t.roles.delete(roleID) {
+			return apperrors.NotFound("role not found")
+		}
+		for _, user := range t.users.filter(func(u models.User) bool { return u.RoleID != nil && *u.RoleID == roleID }) {
+			user.RoleID = nil
+			t.users.put(user.UserID, user)
+		}
+		return nil
+	})
+}
+
+// AssignRoleToUser assigns a role to a user
+func (r *MemoryRoleRepository) AssignRoleToUser(ctx context.Context, userID, roleID uuid.UUID) error {
+	return r.store.write(ctx, func(t *memoryTables) error {
+		user, ok := t.users.get(userID)
+		if !ok {
+			return apperrors.NotFound("user not found")
+		}
+		user.RoleID = &roleID
+		t.users.put(userID, user)
+		return nil
+	})
+}
+
+// GetUserPermissions retrieves the permissions of a user through their role
+func (r *MemoryRoleRepository) GetUserPermissions(ctx context.Context, userID uuid.UUID) (*models.Permissions, error) {
+	var permission *models.Permissions
+	err := r.store.read(func(t *memoryTables) error {
+		if user, ok := t.users.get(userID); ok {
+			if role := t.joinUser(user).Role; role != nil {
+				permission = role.Permission
+			}
+		}
+		if permission == nil {
+			return apperrors.NotFound("user or permissions not found")
+		}
+		return nil
+	})
+	return permission, err
+}
+
+// createPermission stores a permission unless one with its name exists, whose ID it takes then
+func (t *memoryTables) createPermission(perm *models.Permissions) error {
+	if existing, ok := t.permissions.first(func(p models.Permissions) bool { return p.Name == perm.Name }); ok {
+		perm.Id = existing.Id
+		return nil
+	}
+	if perm.Id == uuid.Nil {
+		perm.Id = uuid.New()
+	}
+	if _, ok := t.permissions.get(perm.Id); ok {
+		return fmt.Errorf("permission %s: %w", perm.Id, errUniqueViolation)
+	}
+	t.permissions.put(perm.Id, *perm)
+	return nil
+}
+
+// joinRole returns a copy of a stored role with its permission
+func (t *memoryTables) joinRole(role models.Role) *models.Role {
+	joined := copyRole(role)
+	if joined.PermissionId != nil {
+		if permission, ok := t.permissions.get(*joined.PermissionId); ok {
+			joined.Permission = &permission
+		}
+	}
+	return &joined
+}
+
+// copyRole returns a copy of role that shares no pointers with it, without its permission
+func copyRole(role models.Role) models.Role {
+	if role.PermissionId != nil {
+		permissionID := *role.PermissionId
+		role.PermissionId = &permissionID
+	}
+	role.Permission = nil
+	return role
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"todo-api/internal/models"
+)
+
+// MemorySharedTaskRepository is SharedTaskRepository over a MemoryStore
+type MemorySharedTaskRepository struct {
+	store *MemoryStore
+}
+
+func NewMemorySharedTaskRepository(store *MemoryStore) *MemorySharedTaskRepository {
+	return &MemorySharedTaskRepository{
+		store: store,
+	}
+}
+
+func (r *MemorySharedTaskRepository) GetAll(ctx context.Context) ([]models.SharedTask, error) {
+	return r.filter(func(models.SharedTask) bool { return true })
+}
+
+func (r *MemorySharedTaskRepository) GetById(ctx context.Context, id string) (*models.SharedTask, error) {
+	var sharedTask *models.SharedTask
+	err := r.store.read(func(t *memoryTables) error {
+		if found, ok := t.sharedTasks.get(id); ok {
+			sharedTask = &found
+		}
+		return nil
+	})
+	return sharedTask, err
+}
+
+func (r *MemorySharedTaskRepository) Create(ctx context.Context, sharedTask *models.SharedTask) error {
+	return r.store.write(ctx, func(t *memoryTables) error {
+		if _, ok := t.sharedTasks.get(sharedTask.ID); ok {
+			return fmt.Errorf("shared task %s: %w", sharedTask.ID, errUniqueViolation)
+		}
+		t.sharedTasks.put(sharedTask.ID, *sharedTask)
+		return nil
+	})
+}
+
+func (r *MemorySharedTaskRepository) Delete(ctx context.Context, id string) (int64, error) {
+	var deleted int64
+	err := r.store.write(ctx, func(t *memoryTables) error {
+		if t.sharedTasks.delete(id) {
+			deleted = 1
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+func (r *MemorySharedTaskRepository) GetByOwnerId(ctx context.Context, ownerID int) ([]models.SharedTask, error) {
+	return r.filter(func(s models.SharedTask) bool { return s.OwnerID == ownerID })
+}
+
+func (r *MemorySharedTaskRepository) GetByTodoId(ctx context.Context, todoID string) ([]models.SharedTask, error) {
+	return r.filter(func(s models.SharedTask) bool { return s.TodoID == todoID })
+}
+
+// GetTodosBySharedId returns the todos shared with a user together with the username of
+// their owner. Like the join in SharedTaskRepository, shares whose todo or owner is
+// missing are left out.
+func (r *MemorySharedTaskRepository) GetTodosBySharedId(ctx context.Context, sharedWithID int) ([]models.SharedTodoWithOwner, error) {
+	var sharedTodos []models.SharedTodoWithOwner
+	err := r.store.read(func(t *memoryTables) error {
+		for _, share := range t.sharedTasks.filter(func(s models.SharedTask) bool { return s.SharedWithID == sharedWithID }) {
+			todo, ok := t.todos.get(share.TodoID)
+			if !ok {
+				continue
+			}
+			owner, ok := t.users.first(func(u models.User) bool { return u.UserID.String() == strconv.Itoa(share.OwnerID) })
+			if !ok {
+				continue
+			}
+			sharedTodos = append(sharedTodos, models.SharedTodoWithOwner{
+				TodoID:          todo.Id,
+				TaskName:        todo.TaskName,
+				TaskDescription: todo.TaskDescription,
+				OwnerUsername:   owner.Username,
+				SharedWithID:    share.SharedWithID,
+				CreatedAt:       todo.CreatedAt,
+				UpdatedAt:       todo.UpdatedAt,
+			})
+		}
+		return nil
+	})
+	return sharedTodos, err
+}
+
+// filter returns the shared tasks match accepts
+func (r *MemorySharedTaskRepository) filter(match func(s models.SharedTask) bool) ([]models.SharedTask, error) {
+	var sharedTasks []models.SharedTask
+	err := r.store.read(func(t *memoryTables) error {
+		sharedTasks = t.sharedTasks.filter(match)
+		return nil
+	})
+	return sharedTasks, err
+}
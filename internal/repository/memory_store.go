@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+	"todo-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// errUniqueViolation is returned by the in-memory repositories where PostgreSQL would
+// reject a row with a unique violation
+var errUniqueViolation = errors.New("duplicate key value violates unique constraint")
+
+// errForeignKeyViolation is returned by the in-memory repositories where PostgreSQL would
+// refuse to delete a row other rows still reference
+var errForeignKeyViolation = errors.New("delete violates foreign key constraint")
+
+// MemoryStore holds the tables of the in-memory repositories. It serves tests and the
+// demo mode, which run the API without PostgreSQL.
+//
+// Transactions run one at a time: a MemoryTxManager holds the store for the whole
+// transaction and puts the tables back when it rolls back. Writes made outside a
+// transaction wait for the running one, reads do not and see its uncommitted writes.
+// Concurrent requests therefore never conflict here the way they can in PostgreSQL;
+// tests of such races need TEST_DATABASE_URL. Unique constraints are enforced,
+// foreign keys only where deleting a todo would break them.
+type MemoryStore struct {
+	txMu sync.Mutex   // Held by the running transaction and by writes outside one
+	mu   sync.RWMutex // Guards tables
+
+	tables memoryTables
+}
+
+// memoryTables are the rows of a MemoryStore. Rows are stored as copies and replaced
+// on update, never changed in place, so a shallow clone of the tables is a snapshot.
+type memoryTables struct {
+	todos       table[string, models.Todo]
+	users       table[uuid.UUID, models.User]
+	roles       table[uuid.UUID, models.Role]
+	permissions table[uuid.UUID, models.Permissions]
+	sharedTasks table[string, models.SharedTask]
+	workflows   table[string, models.Workflow]
+	steps       table[string, models.WorkflowStep]
+	transitions table[string, models.WorkflowTransition]
+	instances   table[string, models.AssignedTodo]
+	history     table[string, models.WorkflowHistory]
+	votes       table[string, models.WorkflowVote]
+	delegations table[string, models.WorkflowDelegation]
+}
+
+// NewMemoryStore returns a store holding the built-in approval workflow, like a freshly migrated database
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{
+		tables: memoryTables{
+			todos:       newTable[string, models.Todo](),
+			users:       newTable[uuid.UUID, models.User](),
+			roles:       newTable[uuid.UUID, models.Role](),
+			permissions: newTable[uuid.UUID, models.Permissions](),
+			sharedTasks: newTable[string, models.SharedTask](),
+			workflows:   newTable[string, models.Workflow](),
+			steps:       newTable[string, models.WorkflowStep](),
+			transitions: newTable[string, models.WorkflowTransition](),
+			instances:   newTable[string, models.AssignedTodo](),
+			history:     newTable[string, models.WorkflowHistory](),
+			votes:       newTable[string, models.WorkflowVote](),
+			delegations: newTable[string, models.WorkflowDelegation](),
+		},
+	}
+	s.seedBuiltinWorkflow()
+	return s
+}
+
+// seedBuiltinWorkflow adds the Draft/Review/Approved workflow the migrations seed
+func (s *MemoryStore) seedBuiltinWorkflow() {
+	const (
+		draft    = "a0000000-0000-4000-8000-000000000002"
+		review   = "a0000000-0000-4000-8000-000000000003"
+		approved = "a0000000-0000-4000-8000-000000000004"
+	)
+	now := time.Now()
+	t := &s.tables
+
+	t.workflows.put(models.BuiltinApprovalWorkflowID, models.Workflow{
+		ID:                   models.BuiltinApprovalWorkflowID,
+		Name:                 "Draft/Review/Approved",
+		Description:          "Built-in approval flow used by the /workflow/todos routes",
+		IsActive:             true,
+		CompleteTodoOnFinish: true,
+		CreatedBy:            models.SystemActor,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	})
+	for _, step := range []models.WorkflowStep{
+		{ID: draft, StepName: string(models.StatusDraft), StepOrder: 1, Initial: true},
+		{ID: review, StepName: string(models.StatusReview), StepOrder: 2},
+		{ID: approved, StepName: string(models.StatusApproved), StepOrder: 3, Final: true},
+	} {
+		step.WorkflowID = models.BuiltinApprovalWorkflowID
+		step.ApprovalMode = models.ApprovalSingle
+		step.CreatedAt = now
+		t.steps.put(step.ID, step)
+	}
+	for _, transition := range []models.WorkflowTransition{
+		{ID: "a0000000-0000-4000-8000-000000000005", FromStepID: draft, ToStepID: review, ActionName: models.ActionSubmit, ConditionType: "assigned_user_only"},
+		{ID: "a0000000-0000-4000-8000-000000000006", FromStepID: review, ToStepID: approved, ActionName: models.ActionApprove, ConditionType: "assigned_user_only"},
+		{ID: "a0000000-0000-4000-8000-000000000007", FromStepID: review, ToStepID: draft, ActionName: models.ActionReject, ConditionType: "any_user"},
+	} {
+		transition.WorkflowID = models.BuiltinApprovalWorkflowID
+		transition.CreatedAt = now
+		t.transitions.put(transition.ID, transition)
+	}
+}
+
+// read runs fn with the tables locked for reading
+func (s *MemoryStore) read(fn func(t *memoryTables) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(&s.tables)
+}
+
+// write runs fn with the tables locked for writing. Outside a transaction it first waits
+// for the running one, so a rollback cannot undo the write. fn must check everything
+// before changing a table, as nothing undoes a write that fails halfway.
+func (s *MemoryStore) write(ctx context.Context, fn func(t *memoryTables) error) error {
+	if _, ok := ctx.Value(txKey{}).(*txState); !ok {
+		s.txMu.Lock()
+		defer s.txMu.Unlock()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(&s.tables)
+}
+
+// run calls fn in the transaction described by state and puts the tables back
+// unless fn returns nil
+func (s *MemoryStore) run(ctx context.Context, state *txState, fn func(ctx context.Context) error) error {
+	s.mu.RLock()
+	snapshot := s.tables.clone()
+	s.mu.RUnlock()
+
+	committed := false
+	defer func() {
+		// Also undoes the writes of a panicking fn
+		if !committed {
+			s.mu.Lock()
+			s.tables = snapshot
+			s.mu.Unlock()
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// clone returns a snapshot of the tables
+func (t *memoryTables) clone() memoryTables {
+	return memoryTables{
+		todos:       t.todos.clone(),
+		users:       t.users.clone(),
+		roles:       t.roles.clone(),
+		permissions: t.permissions.clone(),
+		sharedTasks: t.sharedTasks.clone(),
+		workflows:   t.workflows.clone(),
+		steps:       t.steps.clone(),
+		transitions: t.transitions.clone(),
+		instances:   t.instances.clone(),
+		history:     t.history.clone(),
+		votes:       t.votes.clone(),
+		delegations: t.delegations.clone(),
+	}
+}
+
+// table is an in-memory table keyed by primary key that remembers insertion order,
+// the order PostgreSQL returns rows in when a query does not sort them
+type table[K comparable, V any] struct {
+	rows  map[K]V
+	order []K
+}
+
+func newTable[K comparable, V any]() table[K, V] {
+	return table[K, V]{rows: map[K]V{}}
+}
+
+func (t *table[K, V]) clone() table[K, V] {
+	rows := make(map[K]V, len(t.rows))
+	for k, v := range t.rows {
+		rows[k] = v
+	}
+	return table[K, V]{rows: rows, order: slices.Clone(t.order)}
+}
+
+func (t *table[K, V]) get(key K) (V, bool) {
+	v, ok := t.rows[key]
+	return v, ok
+}
+
+// put inserts a row or replaces the row with the same key
+func (t *table[K, V]) put(key K, v V) {
+	if _, ok := t.rows[key]; !ok {
+		t.order = append(t.order, key)
+	}
+	t.rows[key] = v
+}
+
+// delete removes a row and reports whether it existed
+func (t *table[K, V]) delete(key K) bool {
+	if _, ok := t.rows[key]; !ok {
+		return false
+	}
+	delete(t.rows, key)
+	t.order = slices.DeleteFunc(t.order, func(k K) bool { return k == key })
+	return true
+}
+
+// filter returns the rows match accepts in insertion order
+func (t *table[K, V]) filter(match func(v V) bool) []V {
+	var rows []V
+	for _, key := range t.order {
+		if v := t.rows[key]; match(v) {
+			rows = append(rows, v)
+		}
+	}
+	return rows
+}
+
+// first returns the first row match accepts in insertion order
+func (t *table[K, V]) first(match func(v V) bool) (V, bool) {
+	for _, key := range t.order {
+		if v := t.rows[key]; match(v) {
+			return v, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// all returns every row in insertion order
+func (t *table[K, V]) all() []V {
+	return t.filter(func(V) bool { return true })
+}
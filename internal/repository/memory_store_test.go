@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"todo-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// newMemoryInstance creates an active instance of the built-in workflow
+func newMemoryInstance(t *testing.T, instances *MemoryWorkflowInstanceRepository) *models.AssignedTodo {
+	t.Helper()
+	now := time.Now()
+	instance := &models.AssignedTodo{
+		ID:            uuid.NewString(),
+		WorkflowId:    models.BuiltinApprovalWorkflowID,
+		CurrentStepId: "a0000000-0000-4000-8000-000000000002",
+		TodoId:        uuid.NewString(),
+		AssignedTo:    "alice",
+		StepEnteredAt: now,
+		Status:        models.InstanceActive,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := instances.CreateInstance(context.Background(), instance); err != nil {
+		t.Fatalf("Failed to create instance: %v", err)
+	}
+	return instance
+}
+
+func TestMemoryInstance_VersionConflict(t *testing.T) {
+	// Arrange
+	instances := NewMemoryWorkflowInstanceRepository(NewMemoryStore())
+	instance := newMemoryInstance(t, instances)
+	review := "a0000000-0000-4000-8000-000000000003"
+
+	// Act - two requests move the instance they both read at version 0
+	first := instances.UpdateInstanceStep(context.Background(), instance.ID, instance.Version, review, "bob", time.Now(), nil)
+	second := instances.UpdateInstanceStep(context.Background(), instance.ID, instance.Version, review, "carol", time.Now(), nil)
+
+	// Assert - only the first write is applied
+	if first != nil {
+		t.Fatalf("Expected the first update to succeed, got %v", first)
+	}
+	if !errors.Is(second, ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict, got %v", second)
+	}
+	stored, err := instances.GetInstance(context.Background(), instance.ID)
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err)
+	}
+	if stored.AssignedTo != "bob" || stored.Version != 1 {
+		t.Errorf("Expected bob at version 1, got %q at version %d", stored.AssignedTo, stored.Version)
+	}
+}
+
+func TestMemoryInstance_OneOpenInstancePerTodo(t *testing.T) {
+	// Arrange
+	instances := NewMemoryWorkflowInstanceRepository(NewMemoryStore())
+	instance := newMemoryInstance(t, instances)
+	duplicate := *instance
+	duplicate.ID = uuid.NewString()
+
+	// Act
+	err := instances.CreateInstance(context.Background(), &duplicate)
+
+	// Assert
+	if !IsUniqueViolation(err) {
+		t.Errorf("Expected a unique violation, got %v", err)
+	}
+}
+
+func TestMemoryTxManager_Rollback(t *testing.T) {
+	// Arrange
+	store := NewMemoryStore()
+	users := NewMemoryUserRepository(store)
+	user := newTestUser()
+	errFailed := errors.New("failed after writing")
+
+	// Act
+	err := NewMemoryTxManager(store).InTx(context.Background(), func(ctx context.Context) error {
+		if err := users.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		return errFailed
+	})
+
+	// Assert
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Expected the function's error, got %v", err)
+	}
+	if exists, _ := users.UserExists(context.Background(), user.Username); exists {
+		t.Errorf("Expected the write to be rolled back")
+	}
+}
+
+func TestMemoryTxManager_NestedRollbackAndAfterCommit(t *testing.T) {
+	// Arrange
+	store := NewMemoryStore()
+	users := NewMemoryUserRepository(store)
+	txManager := NewMemoryTxManager(store)
+	kept, undone := newTestUser(), newTestUser()
+	var ran []string
+
+	// Act - the inner call fails, the outer one ignores that and commits
+	err := txManager.InTx(context.Background(), func(ctx context.Context) error {
+		AfterCommit(ctx, func() { ran = append(ran, "outer") })
+		if err := users.CreateUser(ctx, kept); err != nil {
+			return err
+		}
+		txManager.InTx(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() { ran = append(ran, "inner") })
+			if err := users.CreateUser(ctx, undone); err != nil {
+				return err
+			}
+			return errors.New("inner call failed")
+		})
+		if len(ran) != 0 {
+			return fmt.Errorf("expected nothing to run before the commit, got %v", ran)
+		}
+		return nil
+	})
+
+	// Assert - only the failed inner call is undone
+	if err != nil {
+		t.Fatalf("Expected the transaction to commit, got %v", err)
+	}
+	if exists, _ := users.UserExists(context.Background(), kept.Username); !exists {
+		t.Errorf("Expected the outer write to be committed")
+	}
+	if exists, _ := users.UserExists(context.Background(), undone.Username); exists {
+		t.Errorf("Expected the inner write to be rolled back")
+	}
+	if len(ran) != 1 || ran[0] != "outer" {
+		t.Errorf("Expected only the outer function to run, got %v", ran)
+	}
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todo-api/internal/models"
+)
+
+// MemoryTodoRepository is TodoRepository over a MemoryStore
+type MemoryTodoRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryTodoRepository(store *MemoryStore) *MemoryTodoRepository {
+	return &MemoryTodoRepository{
+		store: store,
+	}
+}
+
+func (r *MemoryTodoRepository) GetAll(ctx context.Context) ([]models.Todo, error) {
+	var todos []models.Todo
+	err := r.store.read(func(t *memoryTables) error {
+		todos = t.todos.all()
+		return nil
+	})
+	return todos, err
+}
+
+func (r *MemoryTodoRepository) GetById(ctx context.Context, id string) (*models.Todo, error) {
+	var todo *models.Todo
+	err := r.store.read(func(t *memoryTables) error {
+		if found, ok := t.todos.get(id); ok {
+			todo = &found
+		}
+		return nil
+	})
+	return todo, err
+}
+
+func (r *MemoryTodoRepository) GetByUserId(ctx context.Context, userID string) ([]models.Todo, error) {
+	var todos []models.Todo
+	err := r.store.read(func(t *memoryTables) error {
+		todos = t.todos.filter(func(todo models.Todo) bool { return todo.UserID == userID })
+		return nil
+	})
+	return todos, err
+}
+
+func (r *MemoryTodoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	return r.store.write(ctx, func(t *memoryTables) error {
+		if _, ok := t.todos.get(todo.Id); ok {
+			return fmt.Errorf("todo %s: %w", todo.Id, errUniqueViolation)
+		}
+		row := *todo
+		row.Workflow = nil
+		row.CreatedAt = time.Now()
+		row.UpdatedAt = row.CreatedAt
+		t.todos.put(row.Id, row)
+		return nil
+	})
+}
+
+func (r *MemoryTodoRepository) Update(ctx context.Context, id string, todo *models.Todo) (int64, error) {
+	return r.update(ctx, id, func(row *models.Todo) {
+		row.TaskName = todo.TaskName
+		row.TaskDescription = todo.TaskDescription
+		row.Completed = todo.Completed
+	})
+}
+
+// SetCompleted updates only the completed flag of a todo
+func (r *MemoryTodoRepository) SetCompleted(ctx context.Context, id string, completed bool) (int64, error) {
+	return r.update(ctx, id, func(row *models.Todo) {
+		row.Completed = completed
+	})
+}
+
+// Delete removes a todo unless a workflow instance or a shared task references it
+func (r *MemoryTodoRepository) Delete(ctx context.Context, id string) (int64, error) {
+	var deleted int64
+	err := r.store.write(ctx, func(t *memoryTables) error {
+		if _, ok := t.instances.first(func(a models.AssignedTodo) bool { return a.TodoId == id }); ok {
+			return fmt.Errorf("todo %s is referenced by a workflow instance: %w", id, errForeignKeyViolation)
+		}
+		if _, ok := t.sharedTasks.first(func(s models.SharedTask) bool { return s.TodoID == id }); ok {
+			return fmt.Errorf("todo %s is referenced by a shared task: %w", id, errForeignKeyViolation)
+		}
+		if t.todos.delete(id) {
+			deleted = 1
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// update applies change to a todo and reports how many todos it changed
+func (r *MemoryTodoRepository) update(ctx context.Context, id string, change func(row *models.Todo)) (int64, error) {
+	var updated int64
+	err := r.store.write(ctx, func(t *memoryTables) error {
+		row, ok := t.todos.get(id)
+		if !ok {
+			return nil
+		}
+		change(&row)
+		row.UpdatedAt = time.Now()
+		t.todos.put(id, row)
+		updated = 1
+		return nil
+	})
+	return updated, err
+}
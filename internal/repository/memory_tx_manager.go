@@ -0,0 +1,51 @@
+package repository
+
+import "context"
+
+// MemoryTxManager runs a function in one transaction of a MemoryStore. Transactions
+// run one at a time, so they are serializable and never have to be retried.
+type MemoryTxManager struct {
+	store *MemoryStore
+}
+
+func NewMemoryTxManager(store *MemoryStore) *MemoryTxManager {
+	return &MemoryTxManager{
+		store: store,
+	}
+}
+
+// InTx runs fn in a transaction, keeping its writes when fn returns nil and undoing them
+// otherwise. A call nested in another joins its transaction and only undoes its own writes
+// when it fails, like a savepoint; functions registered with AfterCommit run once the
+// outermost call returns nil.
+func (m *MemoryTxManager) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		inner := &txState{depth: state.depth + 1}
+		if err := m.store.run(ctx, inner, fn); err != nil {
+			return err
+		}
+		state.afterCommit = append(state.afterCommit, inner.afterCommit...)
+		return nil
+	}
+
+	state := &txState{}
+	if err := m.exclusive(ctx, state, fn); err != nil {
+		return err
+	}
+	for _, hook := range state.afterCommit {
+		hook()
+	}
+	return nil
+}
+
+// InSerializableTx is InTx; transactions of a MemoryStore are always serializable
+func (m *MemoryTxManager) InSerializableTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.InTx(ctx, fn)
+}
+
+// exclusive runs fn as the only transaction of the store
+func (m *MemoryTxManager) exclusive(ctx context.Context, state *txState, fn func(ctx context.Context) error) error {
+	m.store.txMu.Lock()
+	defer m.store.txMu.Unlock()
+	return m.store.run(ctx, state, fn)
+}
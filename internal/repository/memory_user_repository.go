@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+	"todo-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MemoryUserRepository is UserRepository over a MemoryStore
+type MemoryUserRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryUserRepository(store *MemoryStore) *MemoryUserRepository {
+	return &MemoryUserRepository{
+		store: store,
+	}
+}
+
+// CreateUser creates a new user
+func (r *MemoryUserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	return r.store.write(ctx, func(t *memoryTables) error {
+		if err := t.checkUniqueUser(*user); err != nil {
+			return err
+		}
+		row := copyUser(*user)
+		row.CreatedAt = time.Now()
+		row.UpdatedAt = row.CreatedAt
+		t.users.put(row.UserID, row)
+		return nil
+	})
+}
+
+// GetUserByID retrieves a user by their ID with role and permission information
+func (r *MemoryUserRepository) GetUserByID(ctx context.Context, id interface{}) (*models.User, error) {
+	userID, err := uuid.Parse(fmt.Sprint(id))
+	if err != nil {
+		return nil, fmt.Errorf("invalid user id %q: %w", fmt.Sprint(id), err)
+	}
+	return r.findUser(func(u models.User) bool { return u.UserID == userID })
+}
+
+// GetUsersByRoleId retrieves the active user with the given role that has the lowest ID,
+// with role and permission information
+func (r *MemoryUserRepository) GetUsersByRoleId(ctx context.Context, id interface{}) (*models.User, error) {
+	roleID := fmt.Sprint(id)
+	var user *models.User
+	err := r.store.read(func(t *memoryTables) error {
+		members := t.users.filter(func(u models.User) bool { return u.IsActive && u.RoleID != nil && u.RoleID.String() == roleID })
+		if len(members) == 0 {
+			return sql.ErrNoRows
+		}
+		sort.SliceStable(members, func(i, j int) bool { return members[i].UserID.String() < members[j].UserID.String() })
+		user = t.joinUser(members[0])
+		return nil
+	})
+	return user, err
+}
+
+// GetUserByEmail retrieves a user by their email with role and permission information
+func (r *MemoryUserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findUser(func(u models.User) bool { return u.Email == email })
+}
+
+// GetUserByUsername retrieves a user by their username with role and permission information
+func (r *MemoryUserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.findUser(func(u models.User) bool { return u.Username == username })
+}
+
+// GetAllUsers retrieves all users with role and permission information
+func (r *MemoryUserRepository) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	err := r.store.read(func(t *memoryTables) error {
+		for _, user := range t.users.all() {
+			users = append(users, *t.joinUser(user))
+		}
+		return nil
+	})
+	return users, err
+}
+
+// UpdateUser updates an existing user and returns the updated user
+func (r *MemoryUserRepository) UpdateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	err := r.store.write(ctx, func(t *memoryTables) error {
+		row, ok := t.users.get(user.UserID)
+		if !ok {
+			return nil
+		}
+		row.Username = user.Username
+		row.Email = user.Email
+		row.IsAdmin = user.IsAdmin
+		row.IsActive = user.IsActive
+		if err := t.checkUniqueUser(row); err != nil {
+			return err
+		}
+		row.UpdatedAt = time.Now()
+		t.users.put(row.UserID, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch and return the updated user
+	return r.GetUserByID(ctx, user.UserID)
+}
+
+// DeleteUser deletes a user. Like UserRepository.DeleteUser it compares the UUID of
+// each user with the number, so it matches no user.
+func (r *MemoryUserRepository) DeleteUser(ctx context.Context, id int) error {
+	return r.store.write(ctx, func(t *memoryTables) error {
+		if user, ok := t.users.first(func(u models.User) bool { return u.UserID.String() == strconv.Itoa(id) }); ok {
+			t.users.delete(user.UserID)
+		}
+		return nil
+	})
+}
+
+// UserExists checks if a user with the given username exists
+func (r *MemoryUserRepository) UserExists(ctx context.Context, username string) (bool, error) {
+	user, err := r.GetUserByUsername(ctx, username)
+	return user != nil, ignoreNoRows(err)
+}
+
+// EmailExists checks if a user with the given email exists
+func (r *MemoryUserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	user, err := r.GetUserByEmail(ctx, email)
+	return user != nil, ignoreNoRows(err)
+}
+
+// findUser returns the first user match accepts, or sql.ErrNoRows like a query scanning no row
+func (r *MemoryUserRepository) findUser(match func(u models.User) bool) (*models.User, error) {
+	var user *models.User
+	err := r.store.read(func(t *memoryTables) error {
+		found, ok := t.users.first(match)
+		if !ok {
+			return sql.ErrNoRows
+		}
+		user = t.joinUser(found)
+		return nil
+	})
+	return user, err
+}
+
+// checkUniqueUser rejects a user whose username or email belongs to another user
+func (t *memoryTables) checkUniqueUser(user models.User) error {
+	_, taken := t.users.first(func(u models.User) bool {
+		return u.UserID != user.UserID && (u.Username == user.Username || u.Email == user.Email)
+	})
+	if taken {
+		return fmt.Errorf("username or email of user %s: %w", user.Username, errUniqueViolation)
+	}
+	return nil
+}
+
+// joinUser returns a copy of a stored user with its role and permission
+func (t *memoryTables) joinUser(user models.User) *models.User {
+	joined := copyUser(user)
+	if joined.RoleID != nil {
+		if role, ok := t.roles.get(*joined.RoleID); ok {
+			joined.Role = t.joinRole(role)
+		}
+	}
+	return &joined
+}
+
+// copyUser returns a copy of user that shares no pointers with it, without its role
+func copyUser(user models.User) models.User {
+	if user.RoleID != nil {
+		roleID := *user.RoleID
+		user.RoleID = &roleID
+	}
+	user.Role = nil
+	return user
+}
+
+// ignoreNoRows drops sql.ErrNoRows, which only means nothing matched
+func ignoreNoRows(err error) error {
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
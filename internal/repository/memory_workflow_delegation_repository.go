@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+)
+
+// MemoryWorkflowDelegationRepository is WorkflowDelegationRepository over a MemoryStore
+type MemoryWorkflowDelegationRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryWorkflowDelegationRepository(store *MemoryStore) *MemoryWorkflowDelegationRepository {
+	return &MemoryWorkflowDelegationRepository{
+		store: store,
+	}
+}
+
+// CreateDelegation creates a new delegation rule
+func (r *MemoryWorkflowDelegationRepository) CreateDelegation(ctx context.Context, delegation *models.WorkflowDelegation) error {
+	return r.store.write(ctx, func(t *memoryTables) error {
+		if _, ok := t.delegations.get(delegation.ID); ok {
+			return fmt.Errorf("delegation %s: %w", delegation.ID, errUniqueViolation)
+		}
+		t.delegations.put(delegation.ID, *delegation)
+		return nil
+	})
+}
+
+// GetDelegation retrieves a delegation by ID
+func (r *MemoryWorkflowDelegationRepository) GetDelegation(ctx context.Context, id string) (*models.WorkflowDelegation, error) {
+	var delegation *models.WorkflowDelegation
+	err := r.store.read(func(t *memoryTables) error {
+		found, ok := t.delegations.get(id)
+		if !ok {
+			return apperrors.NotFound("delegation not found")
+		}
+		delegation = &found
+		return nil
+	})
+	return delegation, err
+}
+
+// GetDelegationsByUser retrieves all delegations a user gave or received, latest start first
+func (r *MemoryWorkflowDelegationRepository) GetDelegationsByUser(ctx context.Context, userID string) ([]*models.WorkflowDelegation, error) {
+	delegations := []*models.WorkflowDelegation{}
+	err := r.store.read(func(t *memoryTables) error {
+		for _, delegation := range t.delegations.filter(func(d models.WorkflowDelegation) bool {
+			return d.DelegatorID == userID || d.DelegateID == userID
+		}) {
+			delegations = append(delegations, &delegation)
+		}
+		return nil
+	})
+	sort.SliceStable(delegations, func(i, j int) bool { return delegations[i].StartsAt.After(delegations[j].StartsAt) })
+	return delegations, err
+}
+
+// GetActiveDelegatorIDs retrieves the users who currently delegate their tasks to the given user
+func (r *MemoryWorkflowDelegationRepository) GetActiveDelegatorIDs(ctx context.Context, delegateID string, at time.Time) ([]string, error) {
+	var delegatorIDs []string
+	err := r.store.read(func(t *memoryTables) error {
+		for _, delegation := range t.delegations.filter(func(d models.WorkflowDelegation) bool {
+			return d.DelegateID == delegateID && d.IsActive(at)
+		}) {
+			if !slices.Contains(delegatorIDs, delegation.DelegatorID) {
+				delegatorIDs = append(delegatorIDs, delegation.DelegatorID)
+			}
+		}
+		return nil
+	})
+	return delegatorIDs, err
+}
+
+// IsActiveDelegate reports whether delegateID may currently act for delegatorID
+func (r *MemoryWorkflowDelegationRepository) IsActiveDelegate(ctx context.Context, delegatorID, delegateID string, at time.Time) (bool, error) {
+	var exists bool
+	err := r.store.read(func(t *memoryTables) error {
+		_, exists = t.delegations.first(func(d models.WorkflowDelegation) bool {
+			return d.DelegatorID == delegatorID && d.DelegateID == delegateID && d.IsActive(at)
+		})
+		return nil
+	})
+	return exists, err
+}
+
+// DeleteDelegation removes a delegation rule
+func (r *MemoryWorkflowDelegationRepository) DeleteDelegation(ctx context.Context, id string) error {
+	return r.store.write(ctx, func(t *memoryTables) error {
+		t.delegations.delete(id)
+		return nil
+	})
+}
@@ -0,0 +1,518 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+)
+
+// MemoryWorkflowInstanceRepository is WorkflowInstanceRepository over a MemoryStore
+type MemoryWorkflowInstanceRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryWorkflowInstanceRepository(store *MemoryStore) *MemoryWorkflowInstanceRepository {
+	return &MemoryWorkflowInstanceRepository{
+		store: store,
+	}
+}
+
+// CreateInstance creates a new workflow instance at version 0. Like the partial unique
+// index on assigned_todos, a todo can only have one active or suspended instance.
+func (r *MemoryWorkflowInstanceRepository) CreateInstance(ctx context.Context, instance *models.AssignedTodo) error {
+	row, err := copyInstance(*instance)
+	if err != nil {
+		return err
+	}
+	row.EscalatedAt = nil
+	row.SuspendedAt = nil
+	row.Version = 0
+
+	return r.store.write(ctx, func(t *memoryTables) error {
+		if _, ok := t.instances.get(row.ID); ok {
+			return fmt.Errorf("instance %s: %w", row.ID, errUniqueViolation)
+		}
+		if isOpenInstance(row) && row.TodoId != "" {
+			if _, ok := t.instances.first(func(a models.AssignedTodo) bool { return a.TodoId == row.TodoId && isOpenInstance(a) }); ok {
+				return fmt.Errorf("open instance of todo %s: %w", row.TodoId, errUniqueViolation)
+			}
+		}
+		t.instances.put(row.ID, row)
+		return nil
+	})
+}
+
+// GetInstance retrieves a workflow instance by ID
+func (r *MemoryWorkflowInstanceRepository) GetInstance(ctx context.Context, id string) (*models.AssignedTodo, error) {
+	instances, err := r.filter(func(t *memoryTables, a models.AssignedTodo) bool { return a.ID == id })
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) == 0 {
+		return nil, apperrors.NotFound("instance not found")
+	}
+	return instances[0], nil
+}
+
+// GetOpenInstanceByTodo retrieves the active or suspended instance of a todo, or nil if there is none
+func (r *MemoryWorkflowInstanceRepository) GetOpenInstanceByTodo(ctx context.Context, todoID string) (*models.AssignedTodo, error) {
+	instances, err := r.filter(func(t *memoryTables, a models.AssignedTodo) bool { return a.TodoId == todoID && isOpenInstance(a) })
+	if err != nil || len(instances) == 0 {
+		return nil, err
+	}
+	return instances[0], nil
+}
+
+// GetTodoWorkflowState retrieves the progress of the latest instance of a todo, or nil if it never ran through a workflow.
+// Open instances take precedence over newer closed ones.
+func (r *MemoryWorkflowInstanceRepository) GetTodoWorkflowState(ctx context.Context, todoID string) (*models.TodoWorkflowState, error) {
+	var state *models.TodoWorkflowState
+	err := r.store.read(func(t *memoryTables) error {
+		var latest *models.AssignedTodo
+		for _, instance := range t.instances.filter(func(a models.AssignedTodo) bool { return a.TodoId == todoID }) {
+			workflow, ok := t.workflows.get(instance.WorkflowId)
+			if !ok {
+				continue
+			}
+			step, ok := t.steps.get(instance.CurrentStepId)
+			if !ok {
+				continue
+			}
+			if latest != nil && !isLaterState(instance, *latest) {
+				continue
+			}
+			latest = &instance
+			state = &models.TodoWorkflowState{
+				InstanceID:      instance.ID,
+				WorkflowID:      instance.WorkflowId,
+				WorkflowName:    workflow.Name,
+				CurrentStepID:   instance.CurrentStepId,
+				CurrentStepName: step.StepName,
+				Status:          instance.Status,
+				AssignedTo:      instance.AssignedTo,
+				DueAt:           copyTime(instance.DueAt),
+				UpdatedAt:       instance.UpdatedAt,
+			}
+		}
+		return nil
+	})
+	return state, err
+}
+
+// GetInstancesByWorkflow retrieves all instances for a workflow
+func (r *MemoryWorkflowInstanceRepository) GetInstancesByWorkflow(ctx context.Context, workflowID string) ([]*models.AssignedTodo, error) {
+	return r.newestFirst(r.filter(func(t *memoryTables, a models.AssignedTodo) bool { return a.WorkflowId == workflowID }))
+}
+
+// GetInstancesByUser retrieves all instances assigned to a user
+func (r *MemoryWorkflowInstanceRepository) GetInstancesByUser(ctx context.Context, userID string) ([]*models.AssignedTodo, error) {
+	return r.newestFirst(r.filter(func(t *memoryTables, a models.AssignedTodo) bool { return a.AssignedTo == userID }))
+}
+
+// GetInstancesByAssignees retrieves all instances assigned to any of the given users
+func (r *MemoryWorkflowInstanceRepository) GetInstancesByAssignees(ctx context.Context, userIDs []string) ([]*models.AssignedTodo, error) {
+	return r.newestFirst(r.filter(func(t *memoryTables, a models.AssignedTodo) bool { return slices.Contains(userIDs, a.AssignedTo) }))
+}
+
+// GetInstancesByStep retrieves all instances at a specific step
+func (r *MemoryWorkflowInstanceRepository) GetInstancesByStep(ctx context.Context, stepID string) ([]*models.AssignedTodo, error) {
+	return r.newestFirst(r.filter(func(t *memoryTables, a models.AssignedTodo) bool { return a.CurrentStepId == stepID }))
+}
+
+// GetQueuedInstances retrieves the unclaimed active instances on queue steps of a role, longest waiting first
+func (r *MemoryWorkflowInstanceRepository) GetQueuedInstances(ctx context.Context, queueRole string) ([]*models.AssignedTodo, error) {
+	instances, err := r.filter(func(t *memoryTables, a models.AssignedTodo) bool {
+		step, ok := t.steps.get(a.CurrentStepId)
+		return ok && step.QueueRole == queueRole && a.AssignedTo == "" && a.Status == models.InstanceActive
+	})
+	sort.SliceStable(instances, func(i, j int) bool {
+		if !instances[i].StepEnteredAt.Equal(instances[j].StepEnteredAt) {
+			return instances[i].StepEnteredAt.Before(instances[j].StepEnteredAt)
+		}
+		return instances[i].ID < instances[j].ID
+	})
+	return instances, err
+}
+
+// GetChildInstances retrieves the instances started by a parent instance since the given time, oldest first
+func (r *MemoryWorkflowInstanceRepository) GetChildInstances(ctx context.Context, parentID string, since time.Time) ([]*models.AssignedTodo, error) {
+	instances, err := r.filter(func(t *memoryTables, a models.AssignedTodo) bool {
+		return a.ParentInstanceID != nil && *a.ParentInstanceID == parentID && !a.CreatedAt.Before(since)
+	})
+	sort.SliceStable(instances, func(i, j int) bool {
+		if !instances[i].CreatedAt.Equal(instances[j].CreatedAt) {
+			return instances[i].CreatedAt.Before(instances[j].CreatedAt)
+		}
+		return instances[i].ID < instances[j].ID
+	})
+	return instances, err
+}
+
+// GetOverdueInstances retrieves all active instances whose current step SLA expired before the given time
+func (r *MemoryWorkflowInstanceRepository) GetOverdueInstances(ctx context.Context, now time.Time) ([]*models.OverdueTask, error) {
+	var tasks []*models.OverdueTask
+	err := r.store.read(func(t *memoryTables) error {
+		for _, instance := range t.instances.filter(func(a models.AssignedTodo) bool {
+			return a.Status == models.InstanceActive && a.DueAt != nil && a.DueAt.Before(now)
+		}) {
+			step, ok := t.steps.get(instance.CurrentStepId)
+			if !ok {
+				continue
+			}
+			workflow, ok := t.workflows.get(instance.WorkflowId)
+			if !ok {
+				continue
+			}
+			instance, err := copyInstance(instance)
+			if err != nil {
+				return err
+			}
+			tasks = append(tasks, &models.OverdueTask{
+				AssignedTodo:     instance,
+				CurrentStepName:  step.StepName,
+				WorkflowName:     workflow.Name,
+				EscalationAction: step.EscalationAction,
+				OverdueMinutes:   int(now.Sub(*instance.DueAt).Minutes()),
+			})
+		}
+		return nil
+	})
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].DueAt.Before(*tasks[j].DueAt) })
+	return tasks, err
+}
+
+// GetTodoTasksByAssignee retrieves the todos of a workflow assigned to a user in the legacy TodoTask shape
+func (r *MemoryWorkflowInstanceRepository) GetTodoTasksByAssignee(ctx context.Context, workflowID, userID string) ([]models.TodoTask, error) {
+	return r.todoTasks(workflowID, func(a models.AssignedTodo, step models.WorkflowStep) bool { return a.AssignedTo == userID })
+}
+
+// GetTodoTasksByStep retrieves the todos of a workflow sitting at the named step in the legacy TodoTask shape
+func (r *MemoryWorkflowInstanceRepository) GetTodoTasksByStep(ctx context.Context, workflowID, stepName string) ([]models.TodoTask, error) {
+	return r.todoTasks(workflowID, func(a models.AssignedTodo, step models.WorkflowStep) bool { return step.StepName == stepName })
+}
+
+// todoTasks maps the non-cancelled instances of a workflow that match onto TodoTasks, newest first.
+// The reviewer and approver are read back from the latest submit and approve history entries.
+func (r *MemoryWorkflowInstanceRepository) todoTasks(workflowID string, match func(a models.AssignedTodo, step models.WorkflowStep) bool) ([]models.TodoTask, error) {
+	type row struct {
+		task      models.TodoTask
+		createdAt time.Time
+	}
+	var rows []row
+	err := r.store.read(func(t *memoryTables) error {
+		for _, instance := range t.instances.filter(func(a models.AssignedTodo) bool {
+			return a.WorkflowId == workflowID && a.Status != models.InstanceCancelled
+		}) {
+			todo, ok := t.todos.get(instance.TodoId)
+			if !ok {
+				continue
+			}
+			step, ok := t.steps.get(instance.CurrentStepId)
+			if !ok || !match(instance, step) {
+				continue
+			}
+			task := models.TodoTask{
+				ID:          todo.Id,
+				Title:       todo.TaskName,
+				Description: todo.TaskDescription,
+				AssignedTo:  instance.AssignedTo,
+				Status:      models.TodoStatus(step.StepName),
+				CreatedAt:   todo.CreatedAt,
+				UpdatedAt:   instance.UpdatedAt,
+			}
+			// A rejected todo is back in Draft and, like before, has no reviewer
+			if task.Status != models.StatusDraft {
+				task.ReviewedBy = t.latestPerformer(instance.ID, models.ActionSubmit)
+			}
+			if task.Status == models.StatusApproved {
+				task.ApprovedBy = t.latestPerformer(instance.ID, models.ActionApprove)
+			}
+			rows = append(rows, row{task: task, createdAt: instance.CreatedAt})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].createdAt.After(rows[j].createdAt) })
+	todos := []models.TodoTask{}
+	for _, row := range rows {
+		todos = append(todos, row.task)
+	}
+	return todos, nil
+}
+
+// latestPerformer returns who took an action on an instance last, or "" if nobody did
+func (t *memoryTables) latestPerformer(instanceID, action string) string {
+	var latest *models.WorkflowHistory
+	for _, entry := range t.history.filter(func(h models.WorkflowHistory) bool {
+		return h.InstanceID == instanceID && h.ActionTaken == action
+	}) {
+		if latest == nil || !entry.Timestamp.Before(latest.Timestamp) {
+			latest = &entry
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.PerformedBy
+}
+
+// UpdateInstanceStep moves an instance to a new step, restarting its SLA clock, and sets
+// its assignee, which is empty when the step is a work queue.
+// It only applies while the instance is still at the given version and returns
+// ErrVersionConflict when another request changed it first.
+func (r *MemoryWorkflowInstanceRepository) UpdateInstanceStep(ctx context.Context, instanceID string, version int, newStepID, assignedTo string, enteredAt time.Time, dueAt *time.Time) error {
+	return r.updateVersioned(ctx, instanceID, version, func(a *models.AssignedTodo) {
+		a.CurrentStepId = newStepID
+		a.AssignedTo = assignedTo
+		a.StepEnteredAt = enteredAt
+		a.DueAt = copyTime(dueAt)
+		a.EscalatedAt = nil
+		a.UpdatedAt = enteredAt
+	})
+}
+
+// ClaimInstance assigns an unclaimed active instance on the given step to a user.
+// It reports false when the instance is no longer waiting in the queue.
+func (r *MemoryWorkflowInstanceRepository) ClaimInstance(ctx context.Context, instanceID, stepID, userID string, claimedAt time.Time) (bool, error) {
+	return r.updateIf(ctx, instanceID, func(a models.AssignedTodo) bool {
+		return a.CurrentStepId == stepID && a.AssignedTo == "" && a.Status == models.InstanceActive
+	}, func(a *models.AssignedTodo) {
+		a.AssignedTo = userID
+		a.UpdatedAt = claimedAt
+		a.Version++
+	})
+}
+
+// ReleaseInstance returns an instance claimed by assignee on the given step to the queue.
+// It reports false when the instance is no longer claimed by assignee on that step.
+func (r *MemoryWorkflowInstanceRepository) ReleaseInstance(ctx context.Context, instanceID, stepID, assignee string, releasedAt time.Time) (bool, error) {
+	return r.updateIf(ctx, instanceID, func(a models.AssignedTodo) bool {
+		return a.CurrentStepId == stepID && a.AssignedTo == assignee && a.Status == models.InstanceActive
+	}, func(a *models.AssignedTodo) {
+		a.AssignedTo = ""
+		a.UpdatedAt = releasedAt
+		a.Version++
+	})
+}
+
+// UpdateInstance saves the assignee of an instance at its current version
+func (r *MemoryWorkflowInstanceRepository) UpdateInstance(ctx context.Context, instance *models.AssignedTodo) error {
+	return r.updateVersioned(ctx, instance.ID, instance.Version, func(a *models.AssignedTodo) {
+		a.AssignedTo = instance.AssignedTo
+		a.UpdatedAt = instance.UpdatedAt
+	})
+}
+
+// UpdateInstanceStatus saves the lifecycle state of an instance together with its SLA fields.
+// Like UpdateInstanceStep it only applies at the instance's current version.
+func (r *MemoryWorkflowInstanceRepository) UpdateInstanceStatus(ctx context.Context, instance *models.AssignedTodo) error {
+	return r.updateVersioned(ctx, instance.ID, instance.Version, func(a *models.AssignedTodo) {
+		a.Status = instance.Status
+		a.SuspendedAt = copyTime(instance.SuspendedAt)
+		a.DueAt = copyTime(instance.DueAt)
+		a.UpdatedAt = instance.UpdatedAt
+	})
+}
+
+// UpdateInstancePayload replaces the business data of an instance at the given version
+func (r *MemoryWorkflowInstanceRepository) UpdateInstancePayload(ctx context.Context, instanceID string, version int, payload map[string]interface{}, updatedAt time.Time) error {
+	payload, err := copyPayload(payload)
+	if err != nil {
+		return err
+	}
+	return r.updateVersioned(ctx, instanceID, version, func(a *models.AssignedTodo) {
+		a.Payload = payload
+		a.UpdatedAt = updatedAt
+	})
+}
+
+// TouchInstance increments the version of an instance still at the given version without changing anything else
+func (r *MemoryWorkflowInstanceRepository) TouchInstance(ctx context.Context, instanceID string, version int, updatedAt time.Time) error {
+	return r.updateVersioned(ctx, instanceID, version, func(a *models.AssignedTodo) {
+		a.UpdatedAt = updatedAt
+	})
+}
+
+// MarkEscalated records that the overdue step of an instance has been escalated.
+// It only applies once per step visit and at the given version.
+func (r *MemoryWorkflowInstanceRepository) MarkEscalated(ctx context.Context, instanceID string, version int, escalatedAt time.Time) error {
+	updated, err := r.updateIf(ctx, instanceID, func(a models.AssignedTodo) bool {
+		return a.EscalatedAt == nil && a.Version == version
+	}, func(a *models.AssignedTodo) {
+		a.EscalatedAt = &escalatedAt
+		a.Version++
+	})
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// updateVersioned applies change to an instance still at the given version and increments it
+func (r *MemoryWorkflowInstanceRepository) updateVersioned(ctx context.Context, instanceID string, version int, change func(a *models.AssignedTodo)) error {
+	updated, err := r.updateIf(ctx, instanceID, func(a models.AssignedTodo) bool { return a.Version == version }, func(a *models.AssignedTodo) {
+		change(a)
+		a.Version++
+	})
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// updateIf applies change to an instance if it exists and matches, and reports whether it did
+func (r *MemoryWorkflowInstanceRepository) updateIf(ctx context.Context, instanceID string, match func(a models.AssignedTodo) bool, change func(a *models.AssignedTodo)) (bool, error) {
+	var updated bool
+	err := r.store.write(ctx, func(t *memoryTables) error {
+		row, ok := t.instances.get(instanceID)
+		if !ok || !match(row) {
+			return nil
+		}
+		change(&row)
+		t.instances.put(instanceID, row)
+		updated = true
+		return nil
+	})
+	return updated, err
+}
+
+// AddHistory records an entry in the audit trail of an instance
+func (r *MemoryWorkflowInstanceRepository) AddHistory(ctx context.Context, entry *models.WorkflowHistory) error {
+	row, err := copyHistory(*entry)
+	if err != nil {
+		return err
+	}
+	return r.store.write(ctx, func(t *memoryTables) error {
+		if _, ok := t.history.get(row.ID); ok {
+			return fmt.Errorf("history entry %s: %w", row.ID, errUniqueViolation)
+		}
+		t.history.put(row.ID, row)
+		return nil
+	})
+}
+
+// GetHistory retrieves the audit trail of an instance, newest first
+func (r *MemoryWorkflowInstanceRepository) GetHistory(ctx context.Context, instanceID string) ([]*models.WorkflowHistory, error) {
+	var history []*models.WorkflowHistory
+	err := r.store.read(func(t *memoryTables) error {
+		entries := t.history.filter(func(h models.WorkflowHistory) bool { return h.InstanceID == instanceID })
+		// Of entries written at the same time, the later written one counts as newer
+		for i := len(entries) - 1; i >= 0; i-- {
+			entry, err := copyHistory(entries[i])
+			if err != nil {
+				return err
+			}
+			history = append(history, &entry)
+		}
+		return nil
+	})
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Timestamp.After(history[j].Timestamp) })
+	return history, err
+}
+
+// filter returns copies of the instances match accepts
+func (r *MemoryWorkflowInstanceRepository) filter(match func(t *memoryTables, a models.AssignedTodo) bool) ([]*models.AssignedTodo, error) {
+	var instances []*models.AssignedTodo
+	err := r.store.read(func(t *memoryTables) error {
+		for _, instance := range t.instances.filter(func(a models.AssignedTodo) bool { return match(t, a) }) {
+			instance, err := copyInstance(instance)
+			if err != nil {
+				return err
+			}
+			instances = append(instances, &instance)
+		}
+		return nil
+	})
+	return instances, err
+}
+
+// newestFirst sorts the instances a filter returned by creation time, newest first
+func (r *MemoryWorkflowInstanceRepository) newestFirst(instances []*models.AssignedTodo, err error) ([]*models.AssignedTodo, error) {
+	sort.SliceStable(instances, func(i, j int) bool { return instances[i].CreatedAt.After(instances[j].CreatedAt) })
+	return instances, err
+}
+
+// isOpenInstance reports whether an instance is active or suspended
+func isOpenInstance(a models.AssignedTodo) bool {
+	return a.Status == models.InstanceActive || a.Status == models.InstanceSuspended
+}
+
+// isLaterState reports whether a is shown on its todo rather than b: open instances first, then the newest
+func isLaterState(a, b models.AssignedTodo) bool {
+	if isOpenInstance(a) != isOpenInstance(b) {
+		return isOpenInstance(a)
+	}
+	return a.CreatedAt.After(b.CreatedAt)
+}
+
+// copyInstance returns a copy of instance that shares no pointers with it. The payload
+// goes through JSON like the payload column, so it is never nil and numbers become float64.
+func copyInstance(instance models.AssignedTodo) (models.AssignedTodo, error) {
+	payload, err := copyPayload(instance.Payload)
+	if err != nil {
+		return instance, err
+	}
+	instance.Payload = payload
+	instance.DueAt = copyTime(instance.DueAt)
+	instance.EscalatedAt = copyTime(instance.EscalatedAt)
+	instance.SuspendedAt = copyTime(instance.SuspendedAt)
+	if instance.ParentInstanceID != nil {
+		parentID := *instance.ParentInstanceID
+		instance.ParentInstanceID = &parentID
+	}
+	return instance, nil
+}
+
+// copyPayload returns a copy of an instance payload as it reads back from the database
+func copyPayload(payload map[string]interface{}) (map[string]interface{}, error) {
+	payloadJSON, err := marshalPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalPayload([]byte(payloadJSON))
+}
+
+// copyHistory returns a copy of entry that shares no pointers with it. Its data goes
+// through JSON like the data column, which stores no empty data.
+func copyHistory(entry models.WorkflowHistory) (models.WorkflowHistory, error) {
+	if entry.FromStepID != nil {
+		fromStepID := *entry.FromStepID
+		entry.FromStepID = &fromStepID
+	}
+	if len(entry.Data) == 0 {
+		entry.Data = nil
+		return entry, nil
+	}
+	data, err := json.Marshal(entry.Data)
+	if err != nil {
+		return entry, fmt.Errorf("failed to marshal history data: %w", err)
+	}
+	entry.Data = nil
+	if err := json.Unmarshal(data, &entry.Data); err != nil {
+		return entry, fmt.Errorf("failed to parse history data: %w", err)
+	}
+	return entry, nil
+}
+
+// copyTime returns a copy of an optional time
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+	"todo-api/internal/models"
+)
+
+// MemoryWorkflowMetricsRepository is WorkflowMetricsRepository over a MemoryStore.
+// It computes the same metrics from the same moves in Go.
+type MemoryWorkflowMetricsRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryWorkflowMetricsRepository(store *MemoryStore) *MemoryWorkflowMetricsRepository {
+	return &MemoryWorkflowMetricsRepository{
+		store: store,
+	}
+}
+
+// move is a history entry that changed the step of an instance
+type move struct {
+	models.WorkflowHistory
+	backward bool // The instance went to a step with a lower step order
+}
+
+// GetCycleTime returns the cycle time of instances that reached a final step within [from, to)
+func (r *MemoryWorkflowMetricsRepository) GetCycleTime(ctx context.Context, workflowID string, from, to time.Time) (*models.CycleTimeMetrics, error) {
+	var hours []float64
+	err := r.store.read(func(t *memoryTables) error {
+		for _, instance := range t.instances.filter(func(a models.AssignedTodo) bool {
+			return a.WorkflowId == workflowID && a.Status == models.InstanceCompleted
+		}) {
+			var completedAt *time.Time
+			for _, entry := range t.history.filter(func(h models.WorkflowHistory) bool { return h.InstanceID == instance.ID }) {
+				step, ok := t.steps.get(entry.ToStepID)
+				if ok && step.Final && (completedAt == nil || entry.Timestamp.Before(*completedAt)) {
+					completedAt = &entry.Timestamp
+				}
+			}
+			if completedAt != nil && !completedAt.Before(from) && completedAt.Before(to) {
+				hours = append(hours, completedAt.Sub(instance.CreatedAt).Hours())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Float64s(hours)
+	return &models.CycleTimeMetrics{
+		Completed:    len(hours),
+		MedianHours:  percentile(hours, 0.5),
+		P90Hours:     percentile(hours, 0.9),
+		AverageHours: average(hours),
+	}, nil
+}
+
+// GetStepMetrics returns dwell time and rework counts of every step for moves within [from, to).
+// A visit lasts from the move onto the step until the next move of the same instance.
+func (r *MemoryWorkflowMetricsRepository) GetStepMetrics(ctx context.Context, workflowID string, from, to time.Time) ([]models.StepMetrics, error) {
+	steps := []models.StepMetrics{}
+	err := r.store.read(func(t *memoryTables) error {
+		workflowSteps := t.steps.filter(func(s models.WorkflowStep) bool { return s.WorkflowID == workflowID })
+		sort.SliceStable(workflowSteps, func(i, j int) bool {
+			if workflowSteps[i].StepOrder != workflowSteps[j].StepOrder {
+				return workflowSteps[i].StepOrder < workflowSteps[j].StepOrder
+			}
+			return workflowSteps[i].StepName < workflowSteps[j].StepName
+		})
+
+		byStep := map[string]*models.StepMetrics{}
+		dwell := map[string][]float64{}
+		for _, step := range workflowSteps {
+			metrics := models.StepMetrics{StepID: step.ID, StepName: step.StepName, StepOrder: step.StepOrder, Final: step.Final}
+			metrics.Waiting = len(t.instances.filter(func(a models.AssignedTodo) bool {
+				return a.CurrentStepId == step.ID && isOpenInstance(a)
+			}))
+			steps = append(steps, metrics)
+		}
+		for i := range steps {
+			byStep[steps[i].StepID] = &steps[i]
+		}
+
+		inRange := func(at time.Time) bool { return !at.Before(from) && at.Before(to) }
+		for _, moves := range t.workflowMoves(workflowID) {
+			visits := map[string]int{}
+			for i, m := range moves {
+				visits[m.ToStepID]++
+				if m.backward && m.FromStepID != nil && inRange(m.Timestamp) {
+					if metrics, ok := byStep[*m.FromStepID]; ok {
+						metrics.SentBack++
+					}
+				}
+				metrics, ok := byStep[m.ToStepID]
+				if !ok || !inRange(m.Timestamp) {
+					continue
+				}
+				metrics.Visits++
+				if visits[m.ToStepID] > 1 {
+					metrics.Reentries++
+				}
+				if i+1 < len(moves) {
+					dwell[m.ToStepID] = append(dwell[m.ToStepID], moves[i+1].Timestamp.Sub(m.Timestamp).Hours())
+				}
+			}
+		}
+		for id, hours := range dwell {
+			byStep[id].AvgDwellHours = average(hours)
+			byStep[id].MaxDwellHours = maximum(hours)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// GetUserThroughput returns the moves each user performed within [from, to), busiest first.
+// Moves made by the engine itself are left out.
+func (r *MemoryWorkflowMetricsRepository) GetUserThroughput(ctx context.Context, workflowID string, from, to time.Time) ([]models.UserThroughput, error) {
+	type tally struct {
+		throughput models.UserThroughput
+		instances  map[string]bool
+		response   []float64
+	}
+	tallies := map[string]*tally{}
+	err := r.store.read(func(t *memoryTables) error {
+		for _, moves := range t.workflowMoves(workflowID) {
+			for i := 1; i < len(moves); i++ {
+				m := moves[i]
+				if m.PerformedBy == models.SystemActor || m.Timestamp.Before(from) || !m.Timestamp.Before(to) {
+					continue
+				}
+				user, ok := tallies[m.PerformedBy]
+				if !ok {
+					user = &tally{throughput: models.UserThroughput{UserID: m.PerformedBy}, instances: map[string]bool{}}
+					if found, ok := t.users.first(func(u models.User) bool { return u.UserID.String() == m.PerformedBy }); ok {
+						user.throughput.Username = found.Username
+					}
+					tallies[m.PerformedBy] = user
+				}
+				user.throughput.Actions++
+				user.instances[m.InstanceID] = true
+				user.response = append(user.response, m.Timestamp.Sub(moves[i-1].Timestamp).Hours())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	users := []models.UserThroughput{}
+	for _, user := range tallies {
+		user.throughput.Instances = len(user.instances)
+		user.throughput.ResponseHours = average(user.response)
+		users = append(users, user.throughput)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Actions != users[j].Actions {
+			return users[i].Actions > users[j].Actions
+		}
+		return users[i].UserID < users[j].UserID
+	})
+	return users, nil
+}
+
+// workflowMoves returns the moves of every instance of a workflow, per instance in the
+// order they happened. Entries whose target step is missing are left out.
+func (t *memoryTables) workflowMoves(workflowID string) map[string][]move {
+	moves := map[string][]move{}
+	for _, entry := range t.history.all() {
+		instance, ok := t.instances.get(entry.InstanceID)
+		if !ok || instance.WorkflowId != workflowID {
+			continue
+		}
+		if entry.FromStepID != nil && *entry.FromStepID == entry.ToStepID {
+			continue
+		}
+		toStep, ok := t.steps.get(entry.ToStepID)
+		if !ok {
+			continue
+		}
+		m := move{WorkflowHistory: entry}
+		if entry.FromStepID != nil {
+			if fromStep, ok := t.steps.get(*entry.FromStepID); ok {
+				m.backward = toStep.StepOrder < fromStep.StepOrder
+			}
+		}
+		moves[entry.InstanceID] = append(moves[entry.InstanceID], m)
+	}
+	for _, instanceMoves := range moves {
+		sort.SliceStable(instanceMoves, func(i, j int) bool {
+			if !instanceMoves[i].Timestamp.Equal(instanceMoves[j].Timestamp) {
+				return instanceMoves[i].Timestamp.Before(instanceMoves[j].Timestamp)
+			}
+			return instanceMoves[i].ID < instanceMoves[j].ID
+		})
+	}
+	return moves
+}
+
+// percentile interpolates the p-th percentile of sorted values like percentile_cont, 0 without values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	position := p * float64(len(sorted)-1)
+	lower, upper := int(math.Floor(position)), int(math.Ceil(position))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
+
+// average returns the mean of values, 0 without values
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// maximum returns the largest of values, 0 without values
+func maximum(values []float64) float64 {
+	var largest float64
+	for i, v := range values {
+		if i == 0 || v > largest {
+			largest = v
+		}
+	}
+	return largest
+}
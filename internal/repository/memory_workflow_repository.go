@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+)
+
+// MemoryWorkflowRepository is WorkflowRepository over a MemoryStore
+type MemoryWorkflowRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryWorkflowRepository(store *MemoryStore) *MemoryWorkflowRepository {
+	return &MemoryWorkflowRepository{
+		store: store,
+	}
+}
+
+// CreateWorkflow creates a new workflow template
+func (r *MemoryWorkflowRepository) CreateWorkflow(ctx context.Context, workflow *models.Workflow) error {
+	return r.store.write(ctx, func(t *memoryTables) error {
+		if _, ok := t.workflows.get(workflow.ID); ok {
+			return fmt.Errorf("workflow %s: %w", workflow.ID, errUniqueViolation)
+		}
+		t.workflows.put(workflow.ID, *workflow)
+		return nil
+	})
+}
+
+// GetWorkflow retrieves a workflow by ID
+func (r *MemoryWorkflowRepository) GetWorkflow(ctx context.Context, id string) (*models.Workflow, error) {
+	var workflow *models.Workflow
+	err := r.store.read(func(t *memoryTables) error {
+		found, ok := t.workflows.get(id)
+		if !ok {
+			return apperrors.NotFound("workflow not found")
+		}
+		workflow = &found
+		return nil
+	})
+	return workflow, err
+}
+
+// GetAllWorkflows retrieves all active workflows, newest first
+func (r *MemoryWorkflowRepository) GetAllWorkflows(ctx context.Context) ([]*models.Workflow, error) {
+	var workflows []*models.Workflow
+	err := r.store.read(func(t *memoryTables) error {
+		for _, workflow := range t.workflows.filter(func(w models.Workflow) bool { return w.IsActive }) {
+			workflows = append(workflows, &workflow)
+		}
+		return nil
+	})
+	sort.SliceStable(workflows, func(i, j int) bool { return workflows[i].CreatedAt.After(workflows[j].CreatedAt) })
+	return workflows, err
+}
+
+// CreateStep creates a new workflow step
+func (r *MemoryWorkflowRepository) CreateStep(ctx context.Context, step *models.WorkflowStep) error {
+	return r.store.write(ctx, func(t *memoryTables) error {
+		if _, ok := t.steps.get(step.ID); ok {
+			return fmt.Errorf("step %s: %w", step.ID, errUniqueViolation)
+		}
+		row := copyStep(*step)
+		if row.ApprovalMode == "" {
+			row.ApprovalMode = models.ApprovalSingle
+		}
+		t.steps.put(row.ID, row)
+		return nil
+	})
+}
+
+// GetWorkflowSteps retrieves all steps for a workflow in step order
+func (r *MemoryWorkflowRepository) GetWorkflowSteps(ctx context.Context, workflowID string) ([]*models.WorkflowStep, error) {
+	var steps []*models.WorkflowStep
+	err := r.store.read(func(t *memoryTables) error {
+		for _, step := range t.steps.filter(func(s models.WorkflowStep) bool { return s.WorkflowID == workflowID }) {
+			step = copyStep(step)
+			steps = append(steps, &step)
+		}
+		return nil
+	})
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].StepOrder < steps[j].StepOrder })
+	return steps, err
+}
+
+// GetStep retrieves a single step by ID
+func (r *MemoryWorkflowRepository) GetStep(ctx context.Context, stepID string) (*models.WorkflowStep, error) {
+	return r.findStep(func(s models.WorkflowStep) bool { return s.ID == stepID }, "step not found")
+}
+
+// GetStartStep retrieves the start step for a workflow
+func (r *MemoryWorkflowRepository) GetStartStep(ctx context.Context, workflowID string) (*models.WorkflowStep, error) {
+	return r.findStep(func(s models.WorkflowStep) bool { return s.WorkflowID == workflowID && s.Initial }, "start step not found for workflow")
+}
+
+// findStep returns the first step match accepts, or a not found error with the given message
+func (r *MemoryWorkflowRepository) findStep(match func(s models.WorkflowStep) bool, notFound string) (*models.WorkflowStep, error) {
+	var step *models.WorkflowStep
+	err := r.store.read(func(t *memoryTables) error {
+		found, ok := t.steps.first(match)
+		if !ok {
+			return apperrors.NotFound(notFound)
+		}
+		found = copyStep(found)
+		step = &found
+		return nil
+	})
+	return step, err
+}
+
+// CreateTransition creates a new workflow transition
+func (r *MemoryWorkflowRepository) CreateTransition(ctx context.Context, transition *models.WorkflowTransition) error {
+	row, err := copyTransition(*transition)
+	if err != nil {
+		return err
+	}
+	return r.store.write(ctx, func(t *memoryTables) error {
+		if _, ok := t.transitions.get(row.ID); ok {
+			return fmt.Errorf("transition %s: %w", row.ID, errUniqueViolation)
+		}
+		t.transitions.put(row.ID, row)
+		return nil
+	})
+}
+
+// GetTransitions retrieves all transitions for a workflow
+func (r *MemoryWorkflowRepository) GetTransitions(ctx context.Context, workflowID string) ([]*models.WorkflowTransition, error) {
+	return r.filterTransitions(func(tr models.WorkflowTransition) bool { return tr.WorkflowID == workflowID })
+}
+
+// GetAvailableTransitions retrieves possible transitions from a specific step, oldest first
+func (r *MemoryWorkflowRepository) GetAvailableTransitions(ctx context.Context, workflowID, fromStepID string) ([]*models.WorkflowTransition, error) {
+	transitions, err := r.filterTransitions(func(tr models.WorkflowTransition) bool {
+		return tr.WorkflowID == workflowID && tr.FromStepID == fromStepID
+	})
+	sort.SliceStable(transitions, func(i, j int) bool {
+		if !transitions[i].CreatedAt.Equal(transitions[j].CreatedAt) {
+			return transitions[i].CreatedAt.Before(transitions[j].CreatedAt)
+		}
+		return transitions[i].ID < transitions[j].ID
+	})
+	return transitions, err
+}
+
+// FindTransition finds a specific transition by action name
+func (r *MemoryWorkflowRepository) FindTransition(ctx context.Context, workflowID, fromStepID, actionName string) (*models.WorkflowTransition, error) {
+	transitions, err := r.filterTransitions(func(tr models.WorkflowTransition) bool {
+		return tr.WorkflowID == workflowID && tr.FromStepID == fromStepID && tr.ActionName == actionName
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(transitions) == 0 {
+		return nil, apperrors.NotFound("transition not found")
+	}
+	return transitions[0], nil
+}
+
+// filterTransitions returns copies of the transitions match accepts
+func (r *MemoryWorkflowRepository) filterTransitions(match func(tr models.WorkflowTransition) bool) ([]*models.WorkflowTransition, error) {
+	var transitions []*models.WorkflowTransition
+	err := r.store.read(func(t *memoryTables) error {
+		for _, transition := range t.transitions.filter(match) {
+			transition, err := copyTransition(transition)
+			if err != nil {
+				return err
+			}
+			transitions = append(transitions, &transition)
+		}
+		return nil
+	})
+	return transitions, err
+}
+
+// copyStep returns a copy of step that shares no slices with it
+func copyStep(step models.WorkflowStep) models.WorkflowStep {
+	step.AllowedRoles = slices.Clone(step.AllowedRoles)
+	step.ReviewerUsers = slices.Clone(step.ReviewerUsers)
+	step.ReviewerRoles = slices.Clone(step.ReviewerRoles)
+	return step
+}
+
+// copyTransition returns a copy of transition whose form schema went through JSON,
+// like the form_schema column
+func copyTransition(transition models.WorkflowTransition) (models.WorkflowTransition, error) {
+	if transition.FormSchema == nil {
+		return transition, nil
+	}
+	formSchemaJSON, err := json.Marshal(transition.FormSchema)
+	if err != nil {
+		return transition, fmt.Errorf("failed to marshal form_schema: %w", err)
+	}
+	transition.FormSchema = &models.FormSchema{}
+	if err := json.Unmarshal(formSchemaJSON, transition.FormSchema); err != nil {
+		return transition, fmt.Errorf("failed to parse form_schema of transition %s: %w", transition.ID, err)
+	}
+	return transition, nil
+}
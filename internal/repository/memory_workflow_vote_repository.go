@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"todo-api/internal/models"
+)
+
+// MemoryWorkflowVoteRepository is WorkflowVoteRepository over a MemoryStore
+type MemoryWorkflowVoteRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryWorkflowVoteRepository(store *MemoryStore) *MemoryWorkflowVoteRepository {
+	return &MemoryWorkflowVoteRepository{
+		store: store,
+	}
+}
+
+// CreateVote records a reviewer's vote, at most one per voter and round
+func (r *MemoryWorkflowVoteRepository) CreateVote(ctx context.Context, vote *models.WorkflowVote) error {
+	return r.store.write(ctx, func(t *memoryTables) error {
+		if _, ok := t.votes.get(vote.ID); ok {
+			return fmt.Errorf("vote %s: %w", vote.ID, errUniqueViolation)
+		}
+		if _, ok := t.votes.first(func(v models.WorkflowVote) bool {
+			return v.InstanceID == vote.InstanceID && v.StepID == vote.StepID && v.VoterID == vote.VoterID && v.Round.Equal(vote.Round)
+		}); ok {
+			return fmt.Errorf("vote of %s: %w", vote.VoterID, errUniqueViolation)
+		}
+		t.votes.put(vote.ID, *vote)
+		return nil
+	})
+}
+
+// GetVotes retrieves the votes cast on a step of an instance since the given time, oldest first
+func (r *MemoryWorkflowVoteRepository) GetVotes(ctx context.Context, instanceID, stepID string, since time.Time) ([]models.WorkflowVote, error) {
+	votes := []models.WorkflowVote{}
+	err := r.store.read(func(t *memoryTables) error {
+		votes = append(votes, t.votes.filter(func(v models.WorkflowVote) bool {
+			return v.InstanceID == instanceID && v.StepID == stepID && !v.CreatedAt.Before(since)
+		})...)
+		return nil
+	})
+	sort.SliceStable(votes, func(i, j int) bool { return votes[i].CreatedAt.Before(votes[j].CreatedAt) })
+	return votes, err
+}
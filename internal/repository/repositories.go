@@ -0,0 +1,55 @@
+package repository
+
+import "todo-api/internal/interfaces"
+
+// Repositories bundles one implementation of every repository and the transaction
+// manager they take part in, so the API can be wired against PostgreSQL or memory
+type Repositories struct {
+	Todos       interfaces.TodoRepositoryInterface
+	Users       interfaces.UserRepositoryInterface
+	Roles       interfaces.RoleRepositoryInterface
+	SharedTasks interfaces.SharedTaskRepositoryInterface
+	Workflows   interfaces.WorkflowRepositoryInterface
+	Instances   interfaces.WorkflowInstanceRepositoryInterface
+	Votes       interfaces.WorkflowVoteRepositoryInterface
+	Delegations interfaces.WorkflowDelegationRepositoryInterface
+	Metrics     interfaces.WorkflowMetricsRepositoryInterface
+	DataSources interfaces.DataSourceRepositoryInterface
+	Tx          interfaces.TxManagerInterface
+}
+
+// NewRepositories returns the PostgreSQL repositories; database.Connect must have been called
+func NewRepositories() *Repositories {
+	return &Repositories{
+		Todos:       NewTodoRepository(),
+		Users:       NewUserRepository(),
+		Roles:       NewRoleRepository(),
+		SharedTasks: NewSharedTaskRepository(),
+		Workflows:   NewWorkflowRepository(),
+		Instances:   NewWorkflowInstanceRepository(),
+		Votes:       NewWorkflowVoteRepository(),
+		Delegations: NewWorkflowDelegationRepository(),
+		Metrics:     NewWorkflowMetricsRepository(),
+		DataSources: NewDataSourceRepository(),
+		Tx:          NewTxManager(),
+	}
+}
+
+// NewMemoryRepositories returns repositories over a new MemoryStore, which starts out
+// like a freshly migrated database
+func NewMemoryRepositories() *Repositories {
+	store := NewMemoryStore()
+	return &Repositories{
+		Todos:       NewMemoryTodoRepository(store),
+		Users:       NewMemoryUserRepository(store),
+		Roles:       NewMemoryRoleRepository(store),
+		SharedTasks: NewMemorySharedTaskRepository(store),
+		Workflows:   NewMemoryWorkflowRepository(store),
+		Instances:   NewMemoryWorkflowInstanceRepository(store),
+		Votes:       NewMemoryWorkflowVoteRepository(store),
+		Delegations: NewMemoryWorkflowDelegationRepository(store),
+		Metrics:     NewMemoryWorkflowMetricsRepository(store),
+		DataSources: NewMemoryDataSourceRepository(store),
+		Tx:          NewMemoryTxManager(store),
+	}
+}
@@ -1,9 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"fmt"
+	"todo-api/pkg/apperrors"
 
 	"todo-api/internal/database"
 	"todo-api/internal/models"
@@ -12,17 +13,22 @@ import (
 )
 
 type RoleRepository struct {
-	db *sql.DB
+	db        *sql.DB
+	txManager *TxManager
 }
 
 func NewRoleRepository() *RoleRepository {
 	return &RoleRepository{
-		db: database.DB,
+		db:        database.DB,
+		txManager: NewTxManager(),
 	}
 }
 
 // GetPermissionByName retrieves a permission by its name
-func (r *RoleRepository) GetPermissionByName(name string) (*models.Permissions, error) {
+func (r *RoleRepository) GetPermissionByName(ctx context.Context, name string) (*models.Permissions, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id::TEXT as id, name, description, view, create, update, delete
 		FROM permissions
@@ -32,7 +38,7 @@ func (r *RoleRepository) GetPermissionByName(name string) (*models.Permissions,
 	var permission models.Permissions
 	var idStr string
 
-	err := r.db.QueryRow(query, name).Scan(&idStr, &permission.Name, &permission.Description, &permission.View, &permission.Create, &permission.Update, &permission.Delete)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, name).Scan(&idStr, &permission.Name, &permission.Description, &permission.View, &permission.Create, &permission.Update, &permission.Delete)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -50,9 +56,12 @@ func (r *RoleRepository) GetPermissionByName(name string) (*models.Permissions,
 }
 
 // CreatePermission creates a new permission in the database
-func (r *RoleRepository) CreatePermission(perm *models.Permissions) error {
+func (r *RoleRepository) CreatePermission(ctx context.Context, perm *models.Permissions) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	// Check if permission already exists
-	existing, err := r.GetPermissionByName(perm.Name)
+	existing, err := r.GetPermissionByName(ctx, perm.Name)
 	if err != nil {
 		return err
 	}
@@ -71,7 +80,7 @@ func (r *RoleRepository) CreatePermission(perm *models.Permissions) error {
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
-	_, err = r.db.Exec(query, perm.Id, perm.Name, perm.Description, perm.View, perm.Create, perm.Update, perm.Delete)
+	_, err = conn(ctx, r.db).ExecContext(ctx, query, perm.Id, perm.Name, perm.Description, perm.View, perm.Create, perm.Update, perm.Delete)
 	if err != nil {
 		return fmt.Errorf("failed to create permission: %w", err)
 	}
@@ -80,47 +89,56 @@ func (r *RoleRepository) CreatePermission(perm *models.Permissions) error {
 }
 
 // CreateRole creates a new role in the database
-func (r *RoleRepository) CreateRole(role *models.Role) error {
-	// Check if role already exists by name
-	existing, err := r.GetRoleByName(role.Name)
-	if existing != nil {
-		// Role already exists, just return without error
-		role.RoleId = existing.RoleId
-		return nil
-	}
-	if err != nil {
-		return fmt.Errorf("error checking existing role: %w", err)
-	}
+func (r *RoleRepository) CreateRole(ctx context.Context, role *models.Role) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	// The permission and the role are created together or not at all
+	return r.txManager.InTx(ctx, func(ctx context.Context) error {
+		// Check if role already exists by name
+		existing, err := r.GetRoleByName(ctx, role.Name)
+		if existing != nil {
+			// Role already exists, just return without error
+			role.RoleId = existing.RoleId
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error checking existing role: %w", err)
+		}
 
-	if role.RoleId == uuid.Nil {
-		role.RoleId = uuid.New()
-	}
+		if role.RoleId == uuid.Nil {
+			role.RoleId = uuid.New()
+		}
 
-	// If role has a Permission object, create the permission first
-	if role.Permission != nil {
-		err := r.CreatePermission(role.Permission)
-		if err != nil {
-			return fmt.Errorf("failed to create permission: %w", err)
+		// If role has a Permission object, create the permission first
+		if role.Permission != nil {
+			err := r.CreatePermission(ctx, role.Permission)
+			if err != nil {
+				return fmt.Errorf("failed to create permission: %w", err)
+			}
+			// Ensure PermissionId is set to the Permission's ID
+			role.PermissionId = &role.Permission.Id
 		}
-		// Ensure PermissionId is set to the Permission's ID
-		role.PermissionId = &role.Permission.Id
-	}
 
-	query := `
-		INSERT INTO roles (role_id, name, description, permission_id)
-		VALUES ($1, $2, $3, $4)
-	`
+		query := `
+			INSERT INTO roles (role_id, name, description, permission_id)
+			VALUES ($1, $2, $3, $4)
+		`
 
-	_, err = r.db.Exec(query, role.RoleId, role.Name, role.Description, role.PermissionId)
-	if err != nil {
-		return fmt.Errorf("failed to create role: %w", err)
-	}
+		_, err = conn(ctx, r.db).ExecContext(ctx, query, role.RoleId, role.Name, role.Description, role.PermissionId)
+		if err != nil {
+			return fmt.Errorf("failed to create role: %w", err)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // GetRoleByID retrieves a role by its ID with permission details
-func (r *RoleRepository) GetRoleByID(roleID uuid.UUID) (*models.Role, error) {
+func (r *RoleRepository) GetRoleByID(ctx context.Context, roleID uuid.UUID) (*models.Role, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT
 			r.role_id::TEXT as role_id,
@@ -150,7 +168,7 @@ func (r *RoleRepository) GetRoleByID(roleID uuid.UUID) (*models.Role, error) {
 	var permUpdate sql.NullBool
 	var permDelete sql.NullBool
 
-	err := r.db.QueryRow(query, roleID).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, roleID).Scan(
 		&roleIDStr,
 		&role.Name,
 		&role.Description,
@@ -166,7 +184,7 @@ func (r *RoleRepository) GetRoleByID(roleID uuid.UUID) (*models.Role, error) {
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("role not found")
+			return nil, apperrors.NotFound("role not found")
 		}
 		return nil, fmt.Errorf("failed to get role: %w", err)
 	}
@@ -209,7 +227,10 @@ func (r *RoleRepository) GetRoleByID(roleID uuid.UUID) (*models.Role, error) {
 }
 
 // GetRoleByName retrieves a role by its name with permission details
-func (r *RoleRepository) GetRoleByName(name string) (*models.Role, error) {
+func (r *RoleRepository) GetRoleByName(ctx context.Context, name string) (*models.Role, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT
 			r.role_id::TEXT as role_id,
@@ -239,7 +260,7 @@ func (r *RoleRepository) GetRoleByName(name string) (*models.Role, error) {
 	var permUpdate sql.NullBool
 	var permDelete sql.NullBool
 
-	err := r.db.QueryRow(query, name).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, name).Scan(
 		&roleIDStr,
 		&role.Name,
 		&role.Description,
@@ -298,7 +319,10 @@ func (r *RoleRepository) GetRoleByName(name string) (*models.Role, error) {
 }
 
 // GetAllRoles retrieves all roles from the database with their permissions
-func (r *RoleRepository) GetAllRoles() ([]models.Role, error) {
+func (r *RoleRepository) GetAllRoles(ctx context.Context) ([]models.Role, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT
 			r.role_id::TEXT as role_id,
@@ -317,7 +341,7 @@ func (r *RoleRepository) GetAllRoles() ([]models.Role, error) {
 		ORDER BY r.name
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get roles: %w", err)
 	}
@@ -394,14 +418,17 @@ func (r *RoleRepository) GetAllRoles() ([]models.Role, error) {
 }
 
 // UpdateRole updates an existing role
-func (r *RoleRepository) UpdateRole(role *models.Role) error {
+func (r *RoleRepository) UpdateRole(ctx context.Context, role *models.Role) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		UPDATE roles
 		SET name = $2, description = $3, permission_id = $4
 		WHERE role_id = $1
 	`
 
-	result, err := r.db.Exec(query, role.RoleId, role.Name, role.Description, role.PermissionId)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, role.RoleId, role.Name, role.Description, role.PermissionId)
 	if err != nil {
 		return fmt.Errorf("failed to update role: %w", err)
 	}
@@ -412,17 +439,20 @@ func (r *RoleRepository) UpdateRole(role *models.Role) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("role not found")
+		return apperrors.NotFound("role not found")
 	}
 
 	return nil
 }
 
 // DeleteRole deletes a role by its ID
-func (r *RoleRepository) DeleteRole(roleID uuid.UUID) error {
+func (r *RoleRepository) DeleteRole(ctx context.Context, roleID uuid.UUID) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `DELETE FROM roles WHERE role_id = $1`
 
-	result, err := r.db.Exec(query, roleID)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, roleID)
 	if err != nil {
 		return fmt.Errorf("failed to delete role: %w", err)
 	}
@@ -433,21 +463,24 @@ func (r *RoleRepository) DeleteRole(roleID uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("role not found")
+		return apperrors.NotFound("role not found")
 	}
 
 	return nil
 }
 
 // AssignRoleToUser assigns a role to a user
-func (r *RoleRepository) AssignRoleToUser(userID, roleID uuid.UUID) error {
+func (r *RoleRepository) AssignRoleToUser(ctx context.Context, userID, roleID uuid.UUID) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		UPDATE users
 		SET role_id = $2
 		WHERE id = $1
 	`
 
-	result, err := r.db.Exec(query, userID, roleID)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, roleID)
 	if err != nil {
 		return fmt.Errorf("failed to assign role to user: %w", err)
 	}
@@ -458,14 +491,17 @@ func (r *RoleRepository) AssignRoleToUser(userID, roleID uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("user not found")
+		return apperrors.NotFound("user not found")
 	}
 
 	return nil
 }
 
 // GetUserPermissions retrieves permissions for a user by joining with roles and permissions
-func (r *RoleRepository) GetUserPermissions(userID uuid.UUID) (*models.Permissions, error) {
+func (r *RoleRepository) GetUserPermissions(ctx context.Context, userID uuid.UUID) (*models.Permissions, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT
 			p.id::TEXT as perm_id,
@@ -484,7 +520,7 @@ func (r *RoleRepository) GetUserPermissions(userID uuid.UUID) (*models.Permissio
 	var permIDStr string
 	var permission models.Permissions
 
-	err := r.db.QueryRow(query, userID).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(
 		&permIDStr,
 		&permission.Name,
 		&permission.Description,
@@ -496,7 +532,7 @@ func (r *RoleRepository) GetUserPermissions(userID uuid.UUID) (*models.Permissio
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user or permissions not found")
+			return nil, apperrors.NotFound("user or permissions not found")
 		}
 		return nil, fmt.Errorf("failed to get user permissions: %w", err)
 	}
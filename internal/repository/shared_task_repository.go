@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"todo-api/internal/database"
 	"todo-api/internal/models"
@@ -16,8 +17,11 @@ func NewSharedTaskRepository() *SharedTaskRepository {
 	}
 }
 
-func (r *SharedTaskRepository) GetAll() ([]models.SharedTask, error) {
-	rows, err := r.db.Query(`SELECT CAST(id AS VARCHAR(36)), owner_id, shared_with_id, CAST(todo_id AS VARCHAR(36)) FROM shared_tasks`)
+func (r *SharedTaskRepository) GetAll(ctx context.Context) ([]models.SharedTask, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT CAST(id AS VARCHAR(36)), owner_id, shared_with_id, CAST(todo_id AS VARCHAR(36)) FROM shared_tasks`)
 	if err != nil {
 		return nil, err
 	}
@@ -36,9 +40,12 @@ func (r *SharedTaskRepository) GetAll() ([]models.SharedTask, error) {
 	return sharedTasks, nil
 }
 
-func (r *SharedTaskRepository) GetById(id string) (*models.SharedTask, error) {
+func (r *SharedTaskRepository) GetById(ctx context.Context, id string) (*models.SharedTask, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	var sharedTask models.SharedTask
-	err := r.db.QueryRow(`SELECT CAST(id AS VARCHAR(36)), owner_id, shared_with_id, CAST(todo_id AS VARCHAR(36)) FROM shared_tasks WHERE id = $1`, id).
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT CAST(id AS VARCHAR(36)), owner_id, shared_with_id, CAST(todo_id AS VARCHAR(36)) FROM shared_tasks WHERE id = $1`, id).
 		Scan(&sharedTask.ID, &sharedTask.OwnerID, &sharedTask.SharedWithID, &sharedTask.TodoID)
 
 	if err == sql.ErrNoRows {
@@ -51,14 +58,20 @@ func (r *SharedTaskRepository) GetById(id string) (*models.SharedTask, error) {
 	return &sharedTask, nil
 }
 
-func (r *SharedTaskRepository) Create(sharedTask *models.SharedTask) error {
-	_, err := r.db.Exec(`INSERT INTO shared_tasks (id, owner_id, shared_with_id, todo_id) VALUES ($1, $2, $3, $4)`,
+func (r *SharedTaskRepository) Create(ctx context.Context, sharedTask *models.SharedTask) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, `INSERT INTO shared_tasks (id, owner_id, shared_with_id, todo_id) VALUES ($1, $2, $3, $4)`,
 		sharedTask.ID, sharedTask.OwnerID, sharedTask.SharedWithID, sharedTask.TodoID)
 	return err
 }
 
-func (r *SharedTaskRepository) Delete(id string) (int64, error) {
-	result, err := r.db.Exec("DELETE FROM shared_tasks WHERE id = $1", id)
+func (r *SharedTaskRepository) Delete(ctx context.Context, id string) (int64, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM shared_tasks WHERE id = $1", id)
 	if err != nil {
 		return 0, err
 	}
@@ -67,8 +80,11 @@ func (r *SharedTaskRepository) Delete(id string) (int64, error) {
 	return rowsAffected, err
 }
 
-func (r *SharedTaskRepository) GetByOwnerId(ownerID int) ([]models.SharedTask, error) {
-	rows, err := r.db.Query(`SELECT CAST(id AS VARCHAR(36)), owner_id, shared_with_id, CAST(todo_id AS VARCHAR(36)) FROM shared_tasks WHERE owner_id = $1`, ownerID)
+func (r *SharedTaskRepository) GetByOwnerId(ctx context.Context, ownerID int) ([]models.SharedTask, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT CAST(id AS VARCHAR(36)), owner_id, shared_with_id, CAST(todo_id AS VARCHAR(36)) FROM shared_tasks WHERE owner_id = $1`, ownerID)
 	if err != nil {
 		return nil, err
 	}
@@ -87,8 +103,11 @@ func (r *SharedTaskRepository) GetByOwnerId(ownerID int) ([]models.SharedTask, e
 	return sharedTasks, nil
 }
 
-func (r *SharedTaskRepository) GetTodosBySharedId(sharedWithID int) ([]models.SharedTodoWithOwner, error) {
-	rows, err := r.db.Query(`
+func (r *SharedTaskRepository) GetTodosBySharedId(ctx context.Context, sharedWithID int) ([]models.SharedTodoWithOwner, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
 		SELECT 
 			CAST(t.id AS VARCHAR(36)) AS todo_id,
 			t.task_name,
@@ -120,8 +139,11 @@ func (r *SharedTaskRepository) GetTodosBySharedId(sharedWithID int) ([]models.Sh
 	return sharedTodos, nil
 }
 
-func (r *SharedTaskRepository) GetByTodoId(todoID string) ([]models.SharedTask, error) {
-	rows, err := r.db.Query(`SELECT CAST(id AS VARCHAR(36)), owner_id, shared_with_id, CAST(todo_id AS VARCHAR(36)) FROM shared_tasks WHERE todo_id = $1`, todoID)
+func (r *SharedTaskRepository) GetByTodoId(ctx context.Context, todoID string) ([]models.SharedTask, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT CAST(id AS VARCHAR(36)), owner_id, shared_with_id, CAST(todo_id AS VARCHAR(36)) FROM shared_tasks WHERE todo_id = $1`, todoID)
 	if err != nil {
 		return nil, err
 	}
@@ -1,8 +1,8 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
 	"todo-api/internal/database"
 	"todo-api/internal/models"
 )
@@ -17,8 +17,11 @@ func NewTodoRepository() *TodoRepository {
 	}
 }
 
-func (r *TodoRepository) GetAll() ([]models.Todo, error) {
-	rows, err := r.db.Query(`SELECT CAST(id AS VARCHAR(36)), task_name, task_description, completed, user_id, created_at, updated_at FROM todos`)
+func (r *TodoRepository) GetAll(ctx context.Context) ([]models.Todo, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT CAST(id AS VARCHAR(36)), task_name, task_description, completed, user_id, created_at, updated_at FROM todos`)
 	if err != nil {
 		return nil, err
 	}
@@ -37,9 +40,12 @@ func (r *TodoRepository) GetAll() ([]models.Todo, error) {
 	return todos, nil
 }
 
-func (r *TodoRepository) GetById(userID string) (*models.Todo, error) {
+func (r *TodoRepository) GetById(ctx context.Context, userID string) (*models.Todo, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	var todo models.Todo
-	err := r.db.QueryRow(`SELECT CAST(id AS VARCHAR(36)), task_name, task_description, completed, user_id, created_at, updated_at FROM todos WHERE id = $1`, userID).
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT CAST(id AS VARCHAR(36)), task_name, task_description, completed, user_id, created_at, updated_at FROM todos WHERE id = $1`, userID).
 		Scan(&todo.Id, &todo.TaskName, &todo.TaskDescription, &todo.Completed, &todo.UserID, &todo.CreatedAt, &todo.UpdatedAt)
 
 	if err == sql.ErrNoRows {
@@ -52,15 +58,20 @@ func (r *TodoRepository) GetById(userID string) (*models.Todo, error) {
 	return &todo, nil
 }
 
-func (r *TodoRepository) Create(todo *models.Todo) error {
-	fmt.Println("the todo", todo)
-	_, err := r.db.Exec(`INSERT INTO todos (id, task_name, task_description, completed, user_id) VALUES ($1, $2, $3, $4, $5)`,
+func (r *TodoRepository) Create(ctx context.Context, todo *models.Todo) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, `INSERT INTO todos (id, task_name, task_description, completed, user_id) VALUES ($1, $2, $3, $4, $5)`,
 		todo.Id, todo.TaskName, todo.TaskDescription, todo.Completed, todo.UserID)
 	return err
 }
 
-func (r *TodoRepository) Update(id string, todo *models.Todo) (int64, error) {
-	result, err := r.db.Exec(`UPDATE todos SET task_name = $1, task_description = $2, completed = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4`,
+func (r *TodoRepository) Update(ctx context.Context, id string, todo *models.Todo) (int64, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE todos SET task_name = $1, task_description = $2, completed = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4`,
 		todo.TaskName, todo.TaskDescription, todo.Completed, id)
 	if err != nil {
 		return 0, err
@@ -70,8 +81,12 @@ func (r *TodoRepository) Update(id string, todo *models.Todo) (int64, error) {
 	return rowsAffected, err
 }
 
-func (r *TodoRepository) Delete(id string) (int64, error) {
-	result, err := r.db.Exec("DELETE FROM todos WHERE id = $1", id)
+// SetCompleted updates only the completed flag of a todo
+func (r *TodoRepository) SetCompleted(ctx context.Context, id string, completed bool) (int64, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE todos SET completed = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, completed, id)
 	if err != nil {
 		return 0, err
 	}
@@ -80,8 +95,24 @@ func (r *TodoRepository) Delete(id string) (int64, error) {
 	return rowsAffected, err
 }
 
-func (r *TodoRepository) GetByUserId(userID string) ([]models.Todo, error) {
-	rows, err := r.db.Query(`SELECT CAST(id AS VARCHAR(36)), task_name, task_description, completed, user_id, created_at, updated_at FROM todos WHERE user_id = $1`, userID)
+func (r *TodoRepository) Delete(ctx context.Context, id string) (int64, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM todos WHERE id = $1", id)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected, err
+}
+
+func (r *TodoRepository) GetByUserId(ctx context.Context, userID string) ([]models.Todo, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT CAST(id AS VARCHAR(36)), task_name, task_description, completed, user_id, created_at, updated_at FROM todos WHERE user_id = $1`, userID)
 	if err != nil {
 		return nil, err
 	}
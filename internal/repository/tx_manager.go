@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"todo-api/internal/database"
+	"todo-api/pkg/logging"
+
+	"github.com/lib/pq"
+)
+
+// Maximum number of times a transaction is retried after a serialization failure or deadlock
+const maxTxRetries = 3
+
+// PostgreSQL error codes of transactions that failed only because of concurrent ones
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// PostgreSQL error codes of a row rejected by a unique constraint or index, and of a
+// change that breaks a foreign key
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// ErrWeakerIsolation is returned when a call asks for a stronger isolation level than the
+// transaction it is nested in runs with, which a savepoint cannot raise
+var ErrWeakerIsolation = errors.New("cannot run a serializable call in a transaction with weaker isolation")
+
+// txKey is the context key of the transaction a TxManager runs a function in
+type txKey struct{}
+
+// txState is the transaction bound to a context, its isolation level, how deeply InTx calls
+// are nested in it and the functions to run once it commits
+type txState struct {
+	tx          *sql.Tx
+	isolation   sql.IsolationLevel
+	depth       int
+	afterCommit []func()
+}
+
+// TxManager runs a function in one transaction. Every repository called with the
+// context the function receives runs its queries in that transaction.
+type TxManager struct {
+	db *sql.DB
+}
+
+func NewTxManager() *TxManager {
+	return &TxManager{
+		db: database.DB,
+	}
+}
+
+// InTx runs fn in a read committed transaction, committing when fn returns nil and rolling
+// back otherwise. A call nested in another joins its transaction under a savepoint, so a
+// failing inner call only undoes its own writes and the outermost call commits. The outermost
+// call retries fn from the start after a serialization failure or deadlock, so fn must not
+// have effects outside the database that cannot be repeated.
+func (m *TxManager) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.run(ctx, nil, fn)
+}
+
+// InSerializableTx is InTx with serializable isolation, for checks whose outcome must still
+// hold when the transaction commits. Conflicting transactions fail and are retried. Nested in
+// a transaction that is not serializable it fails with ErrWeakerIsolation.
+func (m *TxManager) InSerializableTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.run(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}, fn)
+}
+
+func (m *TxManager) run(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		if opts != nil && opts.Isolation > state.isolation {
+			return ErrWeakerIsolation
+		}
+		return state.nested(ctx, fn)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := m.attempt(ctx, opts, fn)
+		if !IsRetryable(err) || attempt == maxTxRetries {
+			return err
+		}
+		logging.FromContext(ctx).Debug("retrying transaction", "attempt", attempt+1, "error", err)
+		// Back off a little longer each time so the conflicting transactions can finish
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt+1) * 10 * time.Millisecond):
+		}
+	}
+}
+
+// attempt runs fn once in a new transaction
+func (m *TxManager) attempt(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	tx, err := m.db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	state := &txState{tx: tx}
+	if opts != nil {
+		state.isolation = opts.Isolation
+	}
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	for _, hook := range state.afterCommit {
+		hook()
+	}
+	return nil
+}
+
+// nested runs fn under a savepoint of the transaction already bound to ctx
+func (s *txState) nested(ctx context.Context, fn func(ctx context.Context) error) error {
+	inner := &txState{tx: s.tx, isolation: s.isolation, depth: s.depth + 1}
+	savepoint := fmt.Sprintf("tx_savepoint_%d", inner.depth)
+
+	if _, err := s.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+	if err := fn(context.WithValue(ctx, txKey{}, inner)); err != nil {
+		// The error still reaches the outermost call, which retries when it is retryable
+		if _, rollbackErr := s.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); rollbackErr != nil {
+			return fmt.Errorf("%w (rolling back savepoint failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+	if _, err := s.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	s.afterCommit = append(s.afterCommit, inner.afterCommit...)
+	return nil
+}
+
+// AfterCommit runs fn once the transaction bound to ctx commits, or right away when ctx
+// has none. fn is dropped when the transaction, or the savepoint it was registered under,
+// rolls back, and a retried transaction only runs the functions of its last attempt.
+func AfterCommit(ctx context.Context, fn func()) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+// IsRetryable reports whether err failed a transaction only because of a concurrent one,
+// so running it again may succeed
+func IsRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == serializationFailure || pqErr.Code == deadlockDetected
+}
+
+// IsUniqueViolation reports whether err rejected a row because another one already has its key
+func IsUniqueViolation(err error) bool {
+	if errors.Is(err, errUniqueViolation) {
+		return true
+	}
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
+// IsForeignKeyViolation reports whether err rejected a change because other rows still reference the row
+func IsForeignKeyViolation(err error) bool {
+	if errors.Is(err, errForeignKeyViolation) {
+		return true
+	}
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation
+}
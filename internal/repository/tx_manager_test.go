@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", &pq.Error{Code: serializationFailure}, true},
+		{"wrapped deadlock", fmt.Errorf("failed to update instance step: %w", &pq.Error{Code: deadlockDetected}), true},
+		{"unique violation", &pq.Error{Code: "23505"}, false},
+		{"plain error", errors.New("instance not found"), false},
+		{"no error", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := IsRetryable(tt.err)
+
+			// Assert
+			if got != tt.want {
+				t.Errorf("Expected IsRetryable(%v) to be %t", tt.err, tt.want)
+			}
+		})
+	}
+}
+
+// connectTestDB connects to TEST_DATABASE_URL, a migrated database, or skips the test
+func connectTestDB(t *testing.T) {
+	t.Helper()
+	connStr := os.Getenv("TEST_DATABASE_URL")
+	if connStr == "" {
+		t.Skip("TEST_DATABASE_URL not set, skipping database test")
+	}
+	if err := database.Connect(connStr); err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+}
+
+// newTestUser returns a user with a unique username and email
+func newTestUser() *models.User {
+	user := &models.User{UserID: uuid.New(), Username: "tx-" + uuid.NewString()[:8], IsActive: true}
+	user.Email = user.Username + "@example.com"
+	user.Password = "not-used"
+	return user
+}
+
+func TestTxManager_NestedRollback(t *testing.T) {
+	// Arrange
+	connectTestDB(t)
+	ctx := context.Background()
+	users := NewUserRepository()
+	txManager := NewTxManager()
+	kept, undone := newTestUser(), newTestUser()
+	errInner := errors.New("inner call failed")
+
+	// Act - the inner call fails, the outer one ignores that and commits
+	err := txManager.InTx(ctx, func(ctx context.Context) error {
+		if err := users.CreateUser(ctx, kept); err != nil {
+			return err
+		}
+		innerErr := txManager.InTx(ctx, func(ctx context.Context) error {
+			if err := users.CreateUser(ctx, undone); err != nil {
+				return err
+			}
+			return errInner
+		})
+		if !errors.Is(innerErr, errInner) {
+			return fmt.Errorf("expected the inner error, got %v", innerErr)
+		}
+		return nil
+	})
+
+	// Assert - only the writes of the failed inner call are undone
+	if err != nil {
+		t.Fatalf("Expected the transaction to commit, got %v", err)
+	}
+	if exists, _ := users.UserExists(ctx, kept.Username); !exists {
+		t.Errorf("Expected the outer write to be committed")
+	}
+	if exists, _ := users.UserExists(ctx, undone.Username); exists {
+		t.Errorf("Expected the inner write to be rolled back")
+	}
+}
+
+func TestTxManager_Rollback(t *testing.T) {
+	// Arrange
+	connectTestDB(t)
+	ctx := context.Background()
+	users := NewUserRepository()
+	user := newTestUser()
+	errFailed := errors.New("failed after writing")
+
+	// Act
+	err := NewTxManager().InTx(ctx, func(ctx context.Context) error {
+		if err := users.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		return errFailed
+	})
+
+	// Assert
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Expected the function's error, got %v", err)
+	}
+	if exists, _ := users.UserExists(ctx, user.Username); exists {
+		t.Errorf("Expected the write to be rolled back")
+	}
+}
+
+func TestTxManager_RetriesSerializationFailures(t *testing.T) {
+	// Arrange
+	connectTestDB(t)
+	ctx := context.Background()
+	users := NewUserRepository()
+	user := newTestUser()
+	attempts := 0
+
+	// Act - the first attempt loses against a concurrent transaction
+	err := NewTxManager().InSerializableTx(ctx, func(ctx context.Context) error {
+		attempts++
+		if err := users.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		if attempts == 1 {
+			return &pq.Error{Code: serializationFailure}
+		}
+		return nil
+	})
+
+	// Assert - the retry starts over, so the user is created once
+	if err != nil {
+		t.Fatalf("Expected the retry to succeed, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+	if exists, _ := users.UserExists(ctx, user.Username); !exists {
+		t.Errorf("Expected the user to be created")
+	}
+}
+
+func TestAfterCommit_WithoutTransaction(t *testing.T) {
+	// Arrange
+	ran := false
+
+	// Act
+	AfterCommit(context.Background(), func() { ran = true })
+
+	// Assert
+	if !ran {
+		t.Errorf("Expected the function to run right away outside of a transaction")
+	}
+}
+
+func TestTxManager_AfterCommit(t *testing.T) {
+	// Arrange
+	connectTestDB(t)
+	ctx := context.Background()
+	txManager := NewTxManager()
+	var ran []string
+
+	// Act - the inner call fails, so only the outer function is kept
+	err := txManager.InTx(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, func() { ran = append(ran, "outer") })
+		txManager.InTx(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() { ran = append(ran, "inner") })
+			return errors.New("inner call failed")
+		})
+		if len(ran) != 0 {
+			return fmt.Errorf("expected nothing to run before the commit, got %v", ran)
+		}
+		return nil
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the transaction to commit, got %v", err)
+	}
+	if len(ran) != 1 || ran[0] != "outer" {
+		t.Errorf("Expected only the outer function to run, got %v", ran)
+	}
+}
+
+func TestTxManager_NestedIsolation(t *testing.T) {
+	// Arrange
+	connectTestDB(t)
+	ctx := context.Background()
+	txManager := NewTxManager()
+	noop := func(ctx context.Context) error { return nil }
+	var inReadCommitted, inSerializable error
+
+	// Act - a serializable call nested in a read committed and in a serializable transaction
+	txManager.InTx(ctx, func(ctx context.Context) error {
+		inReadCommitted = txManager.InSerializableTx(ctx, noop)
+		return nil
+	})
+	txManager.InSerializableTx(ctx, func(ctx context.Context) error {
+		inSerializable = txManager.InSerializableTx(ctx, noop)
+		return nil
+	})
+
+	// Assert - only the read committed transaction is too weak
+	if !errors.Is(inReadCommitted, ErrWeakerIsolation) {
+		t.Errorf("Expected ErrWeakerIsolation, got %v", inReadCommitted)
+	}
+	if inSerializable != nil {
+		t.Errorf("Expected the nested call to join the serializable transaction, got %v", inSerializable)
+	}
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"todo-api/internal/database"
 	"todo-api/internal/models"
@@ -19,14 +20,20 @@ func NewUserRepository() *UserRepository {
 }
 
 // CreateUser creates a new user in the database
-func (r *UserRepository) CreateUser(user *models.User) error {
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := "INSERT INTO users (id, username, email, password, is_admin, is_active, role_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)"
-	_, err := r.db.Exec(query, user.UserID, user.Username, user.Email, user.Password, user.IsAdmin, user.IsActive, user.RoleID)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, user.UserID, user.Username, user.Email, user.Password, user.IsAdmin, user.IsActive, user.RoleID)
 	return err
 }
 
 // GetUserByID retrieves a user by their ID with role and permission information
-func (r *UserRepository) GetUserByID(id interface{}) (*models.User, error) {
+func (r *UserRepository) GetUserByID(ctx context.Context, id interface{}) (*models.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	user := &models.User{}
 	query := `
 		SELECT
@@ -53,7 +60,7 @@ func (r *UserRepository) GetUserByID(id interface{}) (*models.User, error) {
 	var permUpdate sql.NullBool
 	var permDelete sql.NullBool
 
-	err := r.db.QueryRow(query, id).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&user.UserID, &user.Username, &user.Email, &user.Password, &user.IsAdmin, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &roleID,
 		&roleIDStr, &roleName, &roleDescription, &permissionIDStr,
 		&permIDStr, &permName, &permDescription, &permView, &permCreate, &permUpdate, &permDelete,
@@ -110,8 +117,12 @@ func (r *UserRepository) GetUserByID(id interface{}) (*models.User, error) {
 	return user, nil
 }
 
-// GetUsersByRoleId retrieves a user by their role ID with role and permission information
-func (r *UserRepository) GetUsersByRoleId(id interface{}) (*models.User, error) {
+// GetUsersByRoleId retrieves the active user with the given role ID that has the lowest ID,
+// so repeated calls pick the same member, with role and permission information
+func (r *UserRepository) GetUsersByRoleId(ctx context.Context, id interface{}) (*models.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	user := &models.User{}
 	query := `
 		SELECT
@@ -122,7 +133,9 @@ func (r *UserRepository) GetUsersByRoleId(id interface{}) (*models.User, error)
 		FROM users u
 		LEFT JOIN roles r ON u.role_id = r.role_id
 		LEFT JOIN permissions p ON r.permission_id = p.id
-		WHERE u.role_id = $1
+		WHERE u.role_id = $1 AND u.is_active
+		ORDER BY u.id
+		LIMIT 1
 	`
 
 	var roleID sql.NullString
@@ -138,7 +151,7 @@ func (r *UserRepository) GetUsersByRoleId(id interface{}) (*models.User, error)
 	var permUpdate sql.NullBool
 	var permDelete sql.NullBool
 
-	err := r.db.QueryRow(query, id).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&user.UserID, &user.Username, &user.Email, &user.Password, &user.IsAdmin, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &roleID,
 		&roleIDStr, &roleName, &roleDescription, &permissionIDStr,
 		&permIDStr, &permName, &permDescription, &permView, &permCreate, &permUpdate, &permDelete,
@@ -197,7 +210,10 @@ func (r *UserRepository) GetUsersByRoleId(id interface{}) (*models.User, error)
 }
 
 // GetUserByEmail retrieves a user by their email with role and permission information
-func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	user := &models.User{}
 	query := `
 		SELECT
@@ -224,7 +240,7 @@ func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
 	var permUpdate sql.NullBool
 	var permDelete sql.NullBool
 
-	err := r.db.QueryRow(query, email).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, email).Scan(
 		&user.UserID, &user.Username, &user.Email, &user.Password, &user.IsAdmin, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &roleID,
 		&roleIDStr, &roleName, &roleDescription, &permissionIDStr,
 		&permIDStr, &permName, &permDescription, &permView, &permCreate, &permUpdate, &permDelete,
@@ -283,7 +299,10 @@ func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
 }
 
 // GetUserByUsername retrieves a user by their username with role and permission information
-func (r *UserRepository) GetUserByUsername(username string) (*models.User, error) {
+func (r *UserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	user := &models.User{}
 	query := `
 		SELECT
@@ -310,7 +329,7 @@ func (r *UserRepository) GetUserByUsername(username string) (*models.User, error
 	var permUpdate sql.NullBool
 	var permDelete sql.NullBool
 
-	err := r.db.QueryRow(query, username).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, username).Scan(
 		&user.UserID, &user.Username, &user.Email, &user.Password, &user.IsAdmin, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &roleID,
 		&roleIDStr, &roleName, &roleDescription, &permissionIDStr,
 		&permIDStr, &permName, &permDescription, &permView, &permCreate, &permUpdate, &permDelete,
@@ -369,7 +388,10 @@ func (r *UserRepository) GetUserByUsername(username string) (*models.User, error
 }
 
 // GetAllUsers retrieves all users from the database with role and permission information
-func (r *UserRepository) GetAllUsers() ([]models.User, error) {
+func (r *UserRepository) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT
 			u.id, u.username, u.email, u.password, u.is_admin, u.is_active, u.created_at, u.updated_at, u.role_id,
@@ -380,7 +402,7 @@ func (r *UserRepository) GetAllUsers() ([]models.User, error) {
 		LEFT JOIN roles r ON u.role_id = r.role_id
 		LEFT JOIN permissions p ON r.permission_id = p.id
 	`
-	rows, err := r.db.Query(query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -463,50 +485,68 @@ func (r *UserRepository) GetAllUsers() ([]models.User, error) {
 }
 
 // UpdateUser updates an existing user in the database and returns the updated user
-func (r *UserRepository) UpdateUser(user *models.User) (*models.User, error) {
+func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `UPDATE users SET username = $1, email = $2, is_admin = $3, is_active = $4, updated_at = CURRENT_TIMESTAMP WHERE id = $5`
-	_, err := r.db.Exec(query, user.Username, user.Email, user.IsAdmin, user.IsActive, user.UserID)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, user.Username, user.Email, user.IsAdmin, user.IsActive, user.UserID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Fetch and return the updated user
-	return r.GetUserByID(user.UserID)
+	return r.GetUserByID(ctx, user.UserID)
 }
 
 // UpdateUserTokens is deprecated - JWT tokens are stateless and don't need database storage
 // Kept for backward compatibility but does nothing
-func (r *UserRepository) UpdateUserTokens(username, sessionToken, csrfToken string) error {
+func (r *UserRepository) UpdateUserTokens(ctx context.Context, username, sessionToken, csrfToken string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	// No-op: JWT tokens are not stored in the database
 	return nil
 }
 
 // ClearUserTokens is deprecated - JWT tokens are stateless
 // Kept for backward compatibility but does nothing
-func (r *UserRepository) ClearUserTokens(username string) error {
+func (r *UserRepository) ClearUserTokens(ctx context.Context, username string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	// No-op: JWT tokens are not stored in the database
 	return nil
 }
 
 // DeleteUser deletes a user from the database
-func (r *UserRepository) DeleteUser(id int) error {
+func (r *UserRepository) DeleteUser(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `DELETE FROM users WHERE id = $1`
-	_, err := r.db.Exec(query, id)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
 	return err
 }
 
 // UserExists checks if a user with the given username exists
-func (r *UserRepository) UserExists(username string) (bool, error) {
+func (r *UserRepository) UserExists(ctx context.Context, username string) (bool, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	var count int
 	query := `SELECT COUNT(*) FROM users WHERE username = $1`
-	err := r.db.QueryRow(query, username).Scan(&count)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, username).Scan(&count)
 	return count > 0, err
 }
 
 // EmailExists checks if a user with the given email exists
-func (r *UserRepository) EmailExists(email string) (bool, error) {
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	var count int
 	query := `SELECT COUNT(*) FROM users WHERE email = $1`
-	err := r.db.QueryRow(query, email).Scan(&count)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, email).Scan(&count)
 	return count > 0, err
 }
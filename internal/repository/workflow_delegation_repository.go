@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+)
+
+type WorkflowDelegationRepository struct {
+	db *sql.DB
+}
+
+func NewWorkflowDelegationRepository() *WorkflowDelegationRepository {
+	return &WorkflowDelegationRepository{
+		db: database.DB,
+	}
+}
+
+const delegationColumns = `id, delegator_id, delegate_id, starts_at, ends_at, reason, created_at`
+
+// CreateDelegation creates a new delegation rule
+func (r *WorkflowDelegationRepository) CreateDelegation(ctx context.Context, delegation *models.WorkflowDelegation) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, `INSERT INTO workflow_delegations (id, delegator_id, delegate_id, starts_at, ends_at, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		delegation.ID, delegation.DelegatorID, delegation.DelegateID, delegation.StartsAt, delegation.EndsAt,
+		delegation.Reason, delegation.CreatedAt)
+	return err
+}
+
+// GetDelegation retrieves a delegation by ID
+func (r *WorkflowDelegationRepository) GetDelegation(ctx context.Context, id string) (*models.WorkflowDelegation, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	delegation, err := scanDelegation(conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+delegationColumns+`
+		FROM workflow_delegations WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("delegation not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return delegation, nil
+}
+
+// GetDelegationsByUser retrieves all delegations a user gave or received
+func (r *WorkflowDelegationRepository) GetDelegationsByUser(ctx context.Context, userID string) ([]*models.WorkflowDelegation, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.queryDelegations(ctx, `SELECT `+delegationColumns+`
+		FROM workflow_delegations WHERE delegator_id = $1 OR delegate_id = $1
+		ORDER BY starts_at DESC`, userID)
+}
+
+// GetActiveDelegatorIDs retrieves the users who currently delegate their tasks to the given user
+func (r *WorkflowDelegationRepository) GetActiveDelegatorIDs(ctx context.Context, delegateID string, at time.Time) ([]string, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT DISTINCT delegator_id FROM workflow_delegations
+		WHERE delegate_id = $1 AND starts_at <= $2 AND ends_at > $2`, delegateID, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var delegatorIDs []string
+	for rows.Next() {
+		var delegatorID string
+		if err := rows.Scan(&delegatorID); err != nil {
+			return nil, err
+		}
+		delegatorIDs = append(delegatorIDs, delegatorID)
+	}
+	return delegatorIDs, nil
+}
+
+// IsActiveDelegate reports whether delegateID may currently act for delegatorID
+func (r *WorkflowDelegationRepository) IsActiveDelegate(ctx context.Context, delegatorID, delegateID string, at time.Time) (bool, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var exists bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM workflow_delegations
+		WHERE delegator_id = $1 AND delegate_id = $2 AND starts_at <= $3 AND ends_at > $3)`,
+		delegatorID, delegateID, at).Scan(&exists)
+	return exists, err
+}
+
+// DeleteDelegation removes a delegation rule
+func (r *WorkflowDelegationRepository) DeleteDelegation(ctx context.Context, id string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM workflow_delegations WHERE id = $1`, id)
+	return err
+}
+
+// queryDelegations runs a query selecting delegationColumns and scans every row
+func (r *WorkflowDelegationRepository) queryDelegations(ctx context.Context, query string, args ...interface{}) ([]*models.WorkflowDelegation, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	delegations := []*models.WorkflowDelegation{}
+	for rows.Next() {
+		delegation, err := scanDelegation(rows)
+		if err != nil {
+			return nil, err
+		}
+		delegations = append(delegations, delegation)
+	}
+	return delegations, nil
+}
+
+// scanDelegation scans a row selected with delegationColumns into a WorkflowDelegation
+func scanDelegation(row rowScanner) (*models.WorkflowDelegation, error) {
+	delegation := &models.WorkflowDelegation{}
+	var reason sql.NullString
+	err := row.Scan(&delegation.ID, &delegation.DelegatorID, &delegation.DelegateID,
+		&delegation.StartsAt, &delegation.EndsAt, &reason, &delegation.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	delegation.Reason = reason.String
+	return delegation, nil
+}
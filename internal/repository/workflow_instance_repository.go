@@ -1,12 +1,22 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"time"
 	"todo-api/internal/database"
 	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+
+	"github.com/lib/pq"
 )
 
+// ErrVersionConflict is returned by instance updates when the instance was changed since it was read.
+// Every update checks and increments assigned_todos.version.
+var ErrVersionConflict = apperrors.Conflict("instance was modified by another request")
+
 type WorkflowInstanceRepository struct {
 	db *sql.DB
 }
@@ -17,25 +27,55 @@ func NewWorkflowInstanceRepository() *WorkflowInstanceRepository {
 	}
 }
 
+// instanceColumns is the column list shared by all assigned_todos queries
+const instanceColumns = `a.id, a.workflow_id, a.current_step_id, a.todo_id, a.assigned_to,
+	a.step_entered_at, a.due_at, a.escalated_at, a.status, a.suspended_at, a.payload, a.created_at, a.updated_at, a.parent_instance_id, a.version`
+
 // CreateInstance creates a new workflow instance
-func (r *WorkflowInstanceRepository) CreateInstance(instance *models.AssignedTodo) error {
-	_, err := r.db.Exec(`INSERT INTO workflow_instances (id, workflow_id, current_step_id, assigned_to, created_at, updated_at) 
-		VALUES ($1, $2, $3, $4, $5, $6 )`,
-		instance.ID, instance.WorkflowId, instance.CurrentStepId, instance.AssignedTo, instance.CreatedAt, instance.UpdatedAt)
+func (r *WorkflowInstanceRepository) CreateInstance(ctx context.Context, instance *models.AssignedTodo) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	payloadJSON, err := marshalPayload(instance.Payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, `INSERT INTO assigned_todos (id, workflow_id, current_step_id, todo_id, assigned_to, step_entered_at, due_at, status, payload, created_at, updated_at, parent_instance_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		instance.ID, instance.WorkflowId, instance.CurrentStepId, instance.TodoId, instance.AssignedTo,
+		instance.StepEnteredAt, instance.DueAt, instance.Status, payloadJSON, instance.CreatedAt, instance.UpdatedAt, instance.ParentInstanceID)
 	return err
 }
 
 // GetInstance retrieves a workflow instance by ID
-func (r *WorkflowInstanceRepository) GetInstance(id string) (*models.AssignedTodo, error) {
-	instance := &models.AssignedTodo{}
-	var todoData sql.NullString
-	err := r.db.QueryRow(`SELECT id, workflow_id, current_step_id, title, description, task_data, assigned_to, created_by, created_at, updated_at 
-		FROM workflow_instances WHERE id = $1`, id).Scan(
-		&instance.ID, &instance.WorkflowId, &instance.CurrentStepId,
-		&todoData, &instance.AssignedTo, &instance.CreatedAt, &instance.UpdatedAt)
+func (r *WorkflowInstanceRepository) GetInstance(ctx context.Context, id string) (*models.AssignedTodo, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	instance, err := scanInstance(conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+instanceColumns+`
+		FROM assigned_todos a WHERE a.id = $1`, id))
+
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("instance not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return instance, nil
+}
+
+// GetOpenInstanceByTodo retrieves the active or suspended instance of a todo, or nil if there is none
+func (r *WorkflowInstanceRepository) GetOpenInstanceByTodo(ctx context.Context, todoID string) (*models.AssignedTodo, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	instance, err := scanInstance(conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+instanceColumns+`
+		FROM assigned_todos a WHERE a.todo_id = $1 AND a.status IN ('active', 'suspended')`, todoID))
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("instance not found")
+		return nil, nil
 	}
 	if err != nil {
 		return nil, err
@@ -44,56 +84,375 @@ func (r *WorkflowInstanceRepository) GetInstance(id string) (*models.AssignedTod
 	return instance, nil
 }
 
+// GetTodoWorkflowState retrieves the progress of the latest instance of a todo, or nil if it never ran through a workflow.
+// Open instances take precedence over newer closed ones.
+func (r *WorkflowInstanceRepository) GetTodoWorkflowState(ctx context.Context, todoID string) (*models.TodoWorkflowState, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	state := &models.TodoWorkflowState{}
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT a.id, a.workflow_id, w.name, a.current_step_id, s.step_name, a.status, a.assigned_to, a.due_at, a.updated_at
+		FROM assigned_todos a
+		JOIN workflows w ON a.workflow_id = w.id
+		JOIN workflow_steps s ON a.current_step_id = s.id
+		WHERE a.todo_id = $1
+		ORDER BY a.status IN ('active', 'suspended') DESC, a.created_at DESC
+		LIMIT 1`, todoID).Scan(
+		&state.InstanceID, &state.WorkflowID, &state.WorkflowName, &state.CurrentStepID, &state.CurrentStepName,
+		&state.Status, &state.AssignedTo, &state.DueAt, &state.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
 // GetInstancesByWorkflow retrieves all instances for a workflow
-func (r *WorkflowInstanceRepository) GetInstancesByWorkflow(workflowID string) ([]*models.AssignedTodo, error) {
-	rows, err := r.db.Query(`SELECT id, workflow_id, current_step_id, title, description, task_data, assigned_to, created_by, created_at, updated_at 
-		FROM workflow_instances WHERE workflow_id = $1 ORDER BY created_at DESC`, workflowID)
+func (r *WorkflowInstanceRepository) GetInstancesByWorkflow(ctx context.Context, workflowID string) ([]*models.AssignedTodo, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.queryInstances(ctx, `SELECT `+instanceColumns+`
+		FROM assigned_todos a WHERE a.workflow_id = $1 ORDER BY a.created_at DESC`, workflowID)
+}
+
+// GetInstancesByUser retrieves all instances assigned to a user
+func (r *WorkflowInstanceRepository) GetInstancesByUser(ctx context.Context, userID string) ([]*models.AssignedTodo, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.queryInstances(ctx, `SELECT `+instanceColumns+`
+		FROM assigned_todos a WHERE a.assigned_to = $1 ORDER BY a.created_at DESC`, userID)
+}
+
+// GetInstancesByAssignees retrieves all instances assigned to any of the given users
+func (r *WorkflowInstanceRepository) GetInstancesByAssignees(ctx context.Context, userIDs []string) ([]*models.AssignedTodo, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.queryInstances(ctx, `SELECT `+instanceColumns+`
+		FROM assigned_todos a WHERE a.assigned_to = ANY($1) ORDER BY a.created_at DESC`, pq.Array(userIDs))
+}
+
+// GetTodoTasksByAssignee retrieves the todos of a workflow assigned to a user in the legacy TodoTask shape
+func (r *WorkflowInstanceRepository) GetTodoTasksByAssignee(ctx context.Context, workflowID, userID string) ([]models.TodoTask, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.queryTodoTasks(ctx, `a.assigned_to = $2`, workflowID, userID)
+}
+
+// GetTodoTasksByStep retrieves the todos of a workflow sitting at the named step in the legacy TodoTask shape
+func (r *WorkflowInstanceRepository) GetTodoTasksByStep(ctx context.Context, workflowID, stepName string) ([]models.TodoTask, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.queryTodoTasks(ctx, `s.step_name = $2`, workflowID, stepName)
+}
+
+// queryTodoTasks maps the non-cancelled instances of a workflow onto TodoTasks.
+// The reviewer and approver are read back from the latest submit and approve history entries.
+func (r *WorkflowInstanceRepository) queryTodoTasks(ctx context.Context, condition string, workflowID, value string) ([]models.TodoTask, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT CAST(t.id AS VARCHAR(36)), t.task_name, t.task_description, a.assigned_to, s.step_name,
+			(SELECT h.performed_by FROM workflow_history h
+				WHERE h.instance_id = a.id AND h.action_taken = 'submit' ORDER BY h.timestamp DESC LIMIT 1),
+			(SELECT h.performed_by FROM workflow_history h
+				WHERE h.instance_id = a.id AND h.action_taken = 'approve' ORDER BY h.timestamp DESC LIMIT 1),
+			t.created_at, a.updated_at
+		FROM assigned_todos a
+		JOIN todos t ON a.todo_id = t.id
+		JOIN workflow_steps s ON a.current_step_id = s.id
+		WHERE a.workflow_id = $1 AND a.status <> 'cancelled' AND `+condition+`
+		ORDER BY a.created_at DESC`, workflowID, value)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var instances []*models.AssignedTodo
+	todos := []models.TodoTask{}
 	for rows.Next() {
-		instance := &models.AssignedTodo{}
+		var todo models.TodoTask
+		var reviewedBy, approvedBy sql.NullString
+		err := rows.Scan(&todo.ID, &todo.Title, &todo.Description, &todo.AssignedTo, &todo.Status,
+			&reviewedBy, &approvedBy, &todo.CreatedAt, &todo.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		// A rejected todo is back in Draft and, like before, has no reviewer
+		if todo.Status != models.StatusDraft {
+			todo.ReviewedBy = reviewedBy.String
+		}
+		if todo.Status == models.StatusApproved {
+			todo.ApprovedBy = approvedBy.String
+		}
+		todos = append(todos, todo)
+	}
+	return todos, nil
+}
+
+// GetInstancesByStep retrieves all instances at a specific step
+func (r *WorkflowInstanceRepository) GetInstancesByStep(ctx context.Context, stepID string) ([]*models.AssignedTodo, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
 
-		err := rows.Scan(&instance.ID, &instance.WorkflowId, &instance.CurrentStepId, &instance.AssignedTo, &instance.CreatedAt, &instance.UpdatedAt)
+	return r.queryInstances(ctx, `SELECT `+instanceColumns+`
+		FROM assigned_todos a WHERE a.current_step_id = $1 ORDER BY a.created_at DESC`, stepID)
+}
+
+// GetQueuedInstances retrieves the unclaimed active instances on queue steps of a role, longest waiting first
+func (r *WorkflowInstanceRepository) GetQueuedInstances(ctx context.Context, queueRole string) ([]*models.AssignedTodo, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.queryInstances(ctx, `SELECT `+instanceColumns+`
+		FROM assigned_todos a JOIN workflow_steps s ON s.id = a.current_step_id
+		WHERE s.queue_role = $1 AND a.assigned_to = '' AND a.status = 'active'
+		ORDER BY a.step_entered_at, a.id`, queueRole)
+}
+
+// GetChildInstances retrieves the instances started by a parent instance since the given time, oldest first
+func (r *WorkflowInstanceRepository) GetChildInstances(ctx context.Context, parentID string, since time.Time) ([]*models.AssignedTodo, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.queryInstances(ctx, `SELECT `+instanceColumns+`
+		FROM assigned_todos a WHERE a.parent_instance_id = $1 AND a.created_at >= $2 ORDER BY a.created_at, a.id`, parentID, since)
+}
+
+// GetOverdueInstances retrieves all active instances whose current step SLA expired before the given time
+func (r *WorkflowInstanceRepository) GetOverdueInstances(ctx context.Context, now time.Time) ([]*models.OverdueTask, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT `+instanceColumns+`, s.step_name, w.name, COALESCE(s.escalation_action, '')
+		FROM assigned_todos a
+		JOIN workflow_steps s ON a.current_step_id = s.id
+		JOIN workflows w ON a.workflow_id = w.id
+		WHERE a.status = 'active' AND a.due_at IS NOT NULL AND a.due_at < $1
+		ORDER BY a.due_at`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []*models.OverdueTask
+	for rows.Next() {
+		task := &models.OverdueTask{}
+		var todoID sql.NullString
+		var payloadJSON []byte
+		err := rows.Scan(&task.ID, &task.WorkflowId, &task.CurrentStepId, &todoID, &task.AssignedTo,
+			&task.StepEnteredAt, &task.DueAt, &task.EscalatedAt, &task.Status, &task.SuspendedAt, &payloadJSON,
+			&task.CreatedAt, &task.UpdatedAt, &task.ParentInstanceID, &task.Version,
+			&task.CurrentStepName, &task.WorkflowName, &task.EscalationAction)
 		if err != nil {
 			return nil, err
 		}
+		task.TodoId = todoID.String
+		if task.Payload, err = unmarshalPayload(payloadJSON); err != nil {
+			return nil, err
+		}
+		task.OverdueMinutes = int(now.Sub(*task.DueAt).Minutes())
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
 
-		instances = append(instances, instance)
+// UpdateInstanceStep moves an instance to a new step, restarting its SLA clock, and sets
+// its assignee, which is empty when the step is a work queue.
+// It only applies while the instance is still at the given version and returns
+// ErrVersionConflict when another request changed it first.
+func (r *WorkflowInstanceRepository) UpdateInstanceStep(ctx context.Context, instanceID string, version int, newStepID, assignedTo string, enteredAt time.Time, dueAt *time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE assigned_todos
+		SET current_step_id = $1, assigned_to = $2, step_entered_at = $3, due_at = $4, escalated_at = NULL, updated_at = $3, version = version + 1
+		WHERE id = $5 AND version = $6`,
+		newStepID, assignedTo, enteredAt, dueAt, instanceID, version)
+	return checkVersioned(result, err)
+}
+
+// ClaimInstance assigns an unclaimed active instance on the given step to a user.
+// The conditions are checked by the update itself, so of two concurrent claims only
+// one matches; it reports false when the instance is no longer waiting in the queue.
+func (r *WorkflowInstanceRepository) ClaimInstance(ctx context.Context, instanceID, stepID, userID string, claimedAt time.Time) (bool, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE assigned_todos
+		SET assigned_to = $1, updated_at = $2, version = version + 1
+		WHERE id = $3 AND current_step_id = $4 AND assigned_to = '' AND status = 'active'`,
+		userID, claimedAt, instanceID, stepID)
+	return updatedOne(result, err)
+}
+
+// ReleaseInstance returns an instance claimed by assignee on the given step to the queue.
+// It reports false when the instance is no longer claimed by assignee on that step.
+func (r *WorkflowInstanceRepository) ReleaseInstance(ctx context.Context, instanceID, stepID, assignee string, releasedAt time.Time) (bool, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE assigned_todos
+		SET assigned_to = '', updated_at = $1, version = version + 1
+		WHERE id = $2 AND current_step_id = $3 AND assigned_to = $4 AND status = 'active'`,
+		releasedAt, instanceID, stepID, assignee)
+	return updatedOne(result, err)
+}
+
+// UpdateInstance saves the assignee of an instance at its current version
+func (r *WorkflowInstanceRepository) UpdateInstance(ctx context.Context, instance *models.AssignedTodo) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE assigned_todos
+		SET assigned_to = $1, updated_at = $2, version = version + 1
+		WHERE id = $3 AND version = $4`,
+		instance.AssignedTo, instance.UpdatedAt, instance.ID, instance.Version)
+	return checkVersioned(result, err)
+}
+
+// UpdateInstanceStatus saves the lifecycle state of an instance together with its
+// SLA fields, which are shifted when a suspended instance is resumed.
+// Like UpdateInstanceStep it only applies at the instance's current version.
+func (r *WorkflowInstanceRepository) UpdateInstanceStatus(ctx context.Context, instance *models.AssignedTodo) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE assigned_todos
+		SET status = $1, suspended_at = $2, due_at = $3, updated_at = $4, version = version + 1
+		WHERE id = $5 AND version = $6`,
+		instance.Status, instance.SuspendedAt, instance.DueAt, instance.UpdatedAt, instance.ID, instance.Version)
+	return checkVersioned(result, err)
+}
+
+// UpdateInstancePayload replaces the business data of an instance at the given version
+func (r *WorkflowInstanceRepository) UpdateInstancePayload(ctx context.Context, instanceID string, version int, payload map[string]interface{}, updatedAt time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	payloadJSON, err := marshalPayload(payload)
+	if err != nil {
+		return err
 	}
-	return instances, nil
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE assigned_todos SET payload = $1, updated_at = $2, version = version + 1 WHERE id = $3 AND version = $4`,
+		payloadJSON, updatedAt, instanceID, version)
+	return checkVersioned(result, err)
 }
 
-// GetInstancesByUser retrieves all instances assigned to a user
-func (r *WorkflowInstanceRepository) GetInstancesByUser(userID string) ([]*models.AssignedTodo, error) {
-	rows, err := r.db.Query(`SELECT id, workflow_id, current_step_id, title, description, task_data, assigned_to, created_by, created_at, updated_at 
-		FROM workflow_instances WHERE assigned_to = $1 ORDER BY created_at DESC`, userID)
+// TouchInstance increments the version of an instance still at the given version without
+// changing anything else. Writers that only add rows for an instance, like votes, call it
+// first so that concurrent writers wait for each other and all but one get ErrVersionConflict.
+func (r *WorkflowInstanceRepository) TouchInstance(ctx context.Context, instanceID string, version int, updatedAt time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE assigned_todos SET updated_at = $1, version = version + 1 WHERE id = $2 AND version = $3`,
+		updatedAt, instanceID, version)
+	return checkVersioned(result, err)
+}
+
+// checkVersioned turns a versioned update that matched no row into ErrVersionConflict
+func checkVersioned(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// updatedOne reports whether a conditional update matched the row
+func updatedOne(result sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// MarkEscalated records that the overdue step of an instance has been escalated.
+// It only applies once per step visit and at the given version.
+func (r *WorkflowInstanceRepository) MarkEscalated(ctx context.Context, instanceID string, version int, escalatedAt time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE assigned_todos SET escalated_at = $1, version = version + 1
+		WHERE id = $2 AND escalated_at IS NULL AND version = $3`, escalatedAt, instanceID, version)
+	return checkVersioned(result, err)
+}
+
+// AddHistory records an entry in the audit trail of an instance
+func (r *WorkflowInstanceRepository) AddHistory(ctx context.Context, entry *models.WorkflowHistory) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var dataJSON sql.NullString
+	if len(entry.Data) > 0 {
+		data, err := json.Marshal(entry.Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal history data: %w", err)
+		}
+		dataJSON = sql.NullString{String: string(data), Valid: true}
+	}
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, `INSERT INTO workflow_history (id, instance_id, from_step_id, to_step_id, action_taken, performed_by, comments, timestamp, data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		entry.ID, entry.InstanceID, entry.FromStepID, entry.ToStepID, entry.ActionTaken, entry.PerformedBy, entry.Comments, entry.Timestamp, dataJSON)
+	return err
+}
+
+// GetHistory retrieves the audit trail of an instance, newest first
+func (r *WorkflowInstanceRepository) GetHistory(ctx context.Context, instanceID string) ([]*models.WorkflowHistory, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT id, instance_id, from_step_id, to_step_id, action_taken, performed_by, comments, timestamp, data
+		FROM workflow_history WHERE instance_id = $1 ORDER BY timestamp DESC`, instanceID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var instances []*models.AssignedTodo
+	var history []*models.WorkflowHistory
 	for rows.Next() {
-		instance := &models.AssignedTodo{}
-
-		err := rows.Scan(&instance.ID, &instance.WorkflowId, &instance.CurrentStepId, &instance.AssignedTo, &instance.CreatedAt, &instance.UpdatedAt)
+		entry := &models.WorkflowHistory{}
+		var fromStepID, comments sql.NullString
+		var dataJSON []byte
+		err := rows.Scan(&entry.ID, &entry.InstanceID, &fromStepID, &entry.ToStepID,
+			&entry.ActionTaken, &entry.PerformedBy, &comments, &entry.Timestamp, &dataJSON)
 		if err != nil {
 			return nil, err
 		}
-
-		instances = append(instances, instance)
+		if len(dataJSON) > 0 {
+			if err := json.Unmarshal(dataJSON, &entry.Data); err != nil {
+				return nil, fmt.Errorf("failed to parse history data: %w", err)
+			}
+		}
+		if fromStepID.Valid {
+			entry.FromStepID = &fromStepID.String
+		}
+		entry.Comments = comments.String
+		history = append(history, entry)
 	}
-	return instances, nil
+	return history, nil
 }
 
-// GetInstancesByStep retrieves all instances at a specific step
-func (r *WorkflowInstanceRepository) GetInstancesByStep(stepID string) ([]*models.AssignedTodo, error) {
-	rows, err := r.db.Query(`SELECT id, workflow_id, current_step_id, assigned_to, created_at, updated_at 
-		FROM workflow_instances WHERE current_step_id = $1 ORDER BY created_at DESC`, stepID)
+// queryInstances runs a query selecting instanceColumns and scans every row
+func (r *WorkflowInstanceRepository) queryInstances(ctx context.Context, query string, args ...interface{}) ([]*models.AssignedTodo, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -101,30 +460,54 @@ func (r *WorkflowInstanceRepository) GetInstancesByStep(stepID string) ([]*model
 
 	var instances []*models.AssignedTodo
 	for rows.Next() {
-		instance := &models.AssignedTodo{}
-
-		err := rows.Scan(&instance.ID, &instance.WorkflowId, &instance.CurrentStepId, &instance.AssignedTo, &instance.CreatedAt, &instance.UpdatedAt)
+		instance, err := scanInstance(rows)
 		if err != nil {
 			return nil, err
 		}
-
 		instances = append(instances, instance)
 	}
 	return instances, nil
 }
 
-// UpdateInstanceStep updates the current step of an instance
-func (r *WorkflowInstanceRepository) UpdateInstanceStep(instanceID, newStepID string) error {
-	_, err := r.db.Exec(`UPDATE workflow_instances SET current_step_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
-		newStepID, instanceID)
-	return err
+// scanInstance scans a row selected with instanceColumns into an AssignedTodo
+func scanInstance(row rowScanner) (*models.AssignedTodo, error) {
+	instance := &models.AssignedTodo{}
+	var todoID sql.NullString
+	var payloadJSON []byte
+	err := row.Scan(&instance.ID, &instance.WorkflowId, &instance.CurrentStepId, &todoID, &instance.AssignedTo,
+		&instance.StepEnteredAt, &instance.DueAt, &instance.EscalatedAt, &instance.Status, &instance.SuspendedAt,
+		&payloadJSON, &instance.CreatedAt, &instance.UpdatedAt, &instance.ParentInstanceID, &instance.Version)
+	if err != nil {
+		return nil, err
+	}
+	instance.TodoId = todoID.String
+	if instance.Payload, err = unmarshalPayload(payloadJSON); err != nil {
+		return nil, err
+	}
+	return instance, nil
 }
 
-// UpdateInstance updates an instance
-func (r *WorkflowInstanceRepository) UpdateInstance(instance *models.AssignedTodo) error {
-	_, err := r.db.Exec(`UPDATE workflow_instances 
-		SET assigned_to = $1, updated_at = $2 
-		WHERE id = $3`,
-		instance.AssignedTo, instance.UpdatedAt, instance.ID)
-	return err
+// marshalPayload encodes an instance payload, storing an empty object for nil.
+// JSON is passed as a string: lib/pq would send []byte as bytea.
+func marshalPayload(payload map[string]interface{}) (string, error) {
+	if payload == nil {
+		return "{}", nil
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return string(payloadJSON), nil
+}
+
+// unmarshalPayload decodes an instance payload, never returning nil
+func unmarshalPayload(payloadJSON []byte) (map[string]interface{}, error) {
+	payload := map[string]interface{}{}
+	if len(payloadJSON) == 0 {
+		return payload, nil
+	}
+	if err := json.Unmarshal(payloadJSON, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse payload: %w", err)
+	}
+	return payload, nil
 }
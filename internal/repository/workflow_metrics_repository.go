@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+// WorkflowMetricsRepository computes process metrics from workflow_history.
+// A "move" is a history entry that changed the step (including the initial "created" entry);
+// votes, reassignments and escalations keep the step and are ignored.
+type WorkflowMetricsRepository struct {
+	db *sql.DB
+}
+
+func NewWorkflowMetricsRepository() *WorkflowMetricsRepository {
+	return &WorkflowMetricsRepository{
+		db: database.DB,
+	}
+}
+
+// workflowMoves selects the moves of all instances of workflow $1
+const workflowMoves = `
+	SELECT h.id, h.instance_id, h.from_step_id, h.to_step_id, h.performed_by, h.timestamp,
+		COALESCE(ts.step_order < fs.step_order, FALSE) AS backward
+	FROM workflow_history h
+	JOIN assigned_todos a ON a.id = h.instance_id
+	JOIN workflow_steps ts ON ts.id = h.to_step_id
+	LEFT JOIN workflow_steps fs ON fs.id = h.from_step_id
+	WHERE a.workflow_id = $1 AND h.from_step_id IS DISTINCT FROM h.to_step_id`
+
+// GetCycleTime returns the cycle time of instances that reached a final step within [from, to)
+func (r *WorkflowMetricsRepository) GetCycleTime(ctx context.Context, workflowID string, from, to time.Time) (*models.CycleTimeMetrics, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		WITH completions AS (
+			SELECT a.id,
+				MIN(h.timestamp) AS completed_at,
+				EXTRACT(EPOCH FROM (MIN(h.timestamp) - a.created_at)) / 3600 AS hours
+			FROM assigned_todos a
+			JOIN workflow_history h ON h.instance_id = a.id
+			JOIN workflow_steps s ON s.id = h.to_step_id AND s.final
+			WHERE a.workflow_id = $1 AND a.status = 'completed'
+			GROUP BY a.id, a.created_at
+		)
+		SELECT
+			COUNT(*),
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY hours), 0),
+			COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY hours), 0),
+			COALESCE(AVG(hours), 0)
+		FROM completions
+		WHERE completed_at >= $2 AND completed_at < $3
+	`
+
+	var metrics models.CycleTimeMetrics
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, workflowID, from, to).Scan(
+		&metrics.Completed, &metrics.MedianHours, &metrics.P90Hours, &metrics.AverageHours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cycle time: %w", err)
+	}
+
+	return &metrics, nil
+}
+
+// GetStepMetrics returns dwell time and rework counts of every step for moves within [from, to).
+// A visit lasts from the move onto the step until the next move of the same instance.
+func (r *WorkflowMetricsRepository) GetStepMetrics(ctx context.Context, workflowID string, from, to time.Time) ([]models.StepMetrics, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		WITH moves AS (` + workflowMoves + `
+		), visits AS (
+			SELECT m.*,
+				LEAD(m.timestamp) OVER (PARTITION BY m.instance_id ORDER BY m.timestamp, m.id) AS left_at,
+				ROW_NUMBER() OVER (PARTITION BY m.instance_id, m.to_step_id ORDER BY m.timestamp, m.id) AS visit
+			FROM moves m
+		)
+		SELECT s.id, s.step_name, s.step_order, COALESCE(s.final, FALSE),
+			COUNT(v.id),
+			COALESCE(AVG(EXTRACT(EPOCH FROM (v.left_at - v.timestamp))) / 3600, 0),
+			COALESCE(MAX(EXTRACT(EPOCH FROM (v.left_at - v.timestamp))) / 3600, 0),
+			(SELECT COUNT(*) FROM assigned_todos o
+				WHERE o.current_step_id = s.id AND o.status IN ('active', 'suspended')),
+			COUNT(v.id) FILTER (WHERE v.visit > 1),
+			(SELECT COUNT(*) FROM visits b
+				WHERE b.from_step_id = s.id AND b.backward AND b.timestamp >= $2 AND b.timestamp < $3)
+		FROM workflow_steps s
+		LEFT JOIN visits v ON v.to_step_id = s.id AND v.timestamp >= $2 AND v.timestamp < $3
+		WHERE s.workflow_id = $1
+		GROUP BY s.id, s.step_name, s.step_order, s.final
+		ORDER BY s.step_order, s.step_name
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, workflowID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query step metrics: %w", err)
+	}
+	defer rows.Close()
+
+	steps := []models.StepMetrics{}
+	for rows.Next() {
+		var step models.StepMetrics
+		if err := rows.Scan(&step.StepID, &step.StepName, &step.StepOrder, &step.Final, &step.Visits,
+			&step.AvgDwellHours, &step.MaxDwellHours, &step.Waiting, &step.Reentries, &step.SentBack); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, rows.Err()
+}
+
+// GetUserThroughput returns the moves each user performed within [from, to), busiest first.
+// Moves made by the engine itself are left out.
+func (r *WorkflowMetricsRepository) GetUserThroughput(ctx context.Context, workflowID string, from, to time.Time) ([]models.UserThroughput, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		WITH moves AS (` + workflowMoves + `
+		), actions AS (
+			SELECT m.performed_by, m.instance_id, m.timestamp,
+				m.timestamp - LAG(m.timestamp) OVER (PARTITION BY m.instance_id ORDER BY m.timestamp, m.id) AS response
+			FROM moves m
+		)
+		SELECT ac.performed_by, COALESCE(u.username, ''),
+			COUNT(*),
+			COUNT(DISTINCT ac.instance_id),
+			COALESCE(AVG(EXTRACT(EPOCH FROM ac.response)) / 3600, 0)
+		FROM actions ac
+		LEFT JOIN users u ON u.id::TEXT = ac.performed_by
+		WHERE ac.response IS NOT NULL AND ac.performed_by <> $4
+			AND ac.timestamp >= $2 AND ac.timestamp < $3
+		GROUP BY ac.performed_by, u.username
+		ORDER BY COUNT(*) DESC, ac.performed_by
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, workflowID, from, to, models.SystemActor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user throughput: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.UserThroughput{}
+	for rows.Next() {
+		var user models.UserThroughput
+		if err := rows.Scan(&user.UserID, &user.Username, &user.Actions, &user.Instances, &user.ResponseHours); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
@@ -1,11 +1,13 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"todo-api/internal/database"
 	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
 )
 
 type WorkflowRepository struct {
@@ -19,31 +21,41 @@ func NewWorkflowRepository() *WorkflowRepository {
 }
 
 // CreateWorkflow creates a new workflow template
-func (r *WorkflowRepository) CreateWorkflow(workflow *models.Workflow) error {
-	_, err := r.db.Exec(`INSERT INTO workflows (id, name, description, is_active, created_by, created_at, updated_at) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		workflow.ID, workflow.Name, workflow.Description, workflow.IsActive, workflow.CreatedBy, workflow.CreatedAt, workflow.UpdatedAt)
+func (r *WorkflowRepository) CreateWorkflow(ctx context.Context, workflow *models.Workflow) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, `INSERT INTO workflows (id, name, description, is_active, complete_todo_on_finish, created_by, created_at, updated_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		workflow.ID, workflow.Name, workflow.Description, workflow.IsActive, workflow.CompleteTodoOnFinish,
+		workflow.CreatedBy, workflow.CreatedAt, workflow.UpdatedAt)
 	return err
 }
 
 // GetWorkflow retrieves a workflow by ID
-func (r *WorkflowRepository) GetWorkflow(id string) (*models.Workflow, error) {
+func (r *WorkflowRepository) GetWorkflow(ctx context.Context, id string) (*models.Workflow, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	workflow := &models.Workflow{}
-	err := r.db.QueryRow(`SELECT id, name, description, is_active, created_by, created_at, updated_at 
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT id, name, description, is_active, complete_todo_on_finish, created_by, created_at, updated_at 
 		FROM workflows WHERE id = $1`, id).Scan(
-		&workflow.ID, &workflow.Name, &workflow.Description, &workflow.IsActive,
+		&workflow.ID, &workflow.Name, &workflow.Description, &workflow.IsActive, &workflow.CompleteTodoOnFinish,
 		&workflow.CreatedBy, &workflow.CreatedAt, &workflow.UpdatedAt)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("workflow not found")
+		return nil, apperrors.NotFound("workflow not found")
 	}
 	return workflow, err
 }
 
 // GetAllWorkflows retrieves all active workflows
-func (r *WorkflowRepository) GetAllWorkflows() ([]*models.Workflow, error) {
-	rows, err := r.db.Query(`SELECT id, name, description, is_active, created_by, created_at, updated_at 
-		FROM workflows WHERE is_active = 1 ORDER BY created_at DESC`)
+func (r *WorkflowRepository) GetAllWorkflows(ctx context.Context) ([]*models.Workflow, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT id, name, description, is_active, complete_todo_on_finish, created_by, created_at, updated_at 
+		FROM workflows WHERE is_active = TRUE ORDER BY created_at DESC`)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +64,7 @@ func (r *WorkflowRepository) GetAllWorkflows() ([]*models.Workflow, error) {
 	var workflows []*models.Workflow
 	for rows.Next() {
 		workflow := &models.Workflow{}
-		err := rows.Scan(&workflow.ID, &workflow.Name, &workflow.Description, &workflow.IsActive,
+		err := rows.Scan(&workflow.ID, &workflow.Name, &workflow.Description, &workflow.IsActive, &workflow.CompleteTodoOnFinish,
 			&workflow.CreatedBy, &workflow.CreatedAt, &workflow.UpdatedAt)
 		if err != nil {
 			return nil, err
@@ -62,22 +74,51 @@ func (r *WorkflowRepository) GetAllWorkflows() ([]*models.Workflow, error) {
 	return workflows, nil
 }
 
+// stepColumns is the column list shared by all workflow step queries
+const stepColumns = `id, workflow_id, step_name, step_order, initial, final, allowed_roles, created_at,
+	sla_minutes, escalation_action, escalation_value,
+	approval_mode, quorum, reviewer_users, reviewer_roles, veto_action,
+	subworkflow_id, subworkflow_items, queue_role`
+
 // CreateStep creates a new workflow step
-func (r *WorkflowRepository) CreateStep(step *models.WorkflowStep) error {
+func (r *WorkflowRepository) CreateStep(ctx context.Context, step *models.WorkflowStep) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	allowedRolesJSON, err := json.Marshal(step.AllowedRoles)
 	if err != nil {
 		return fmt.Errorf("failed to marshal allowed_roles: %w", err)
 	}
+	reviewerUsersJSON, err := json.Marshal(step.ReviewerUsers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reviewer_users: %w", err)
+	}
+	reviewerRolesJSON, err := json.Marshal(step.ReviewerRoles)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reviewer_roles: %w", err)
+	}
 
-	_, err = r.db.Exec(`INSERT INTO workflow_steps (id, workflow_id, step_name, step_order, initial, final, allowed_roles, created_at) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, @p8)`,
-		step.ID, step.WorkflowID, step.StepName, step.StepOrder, step.Initial, step.Final, string(allowedRolesJSON), step.CreatedAt)
+	approvalMode := step.ApprovalMode
+	if approvalMode == "" {
+		approvalMode = models.ApprovalSingle
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, `INSERT INTO workflow_steps (`+stepColumns+`) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)`,
+		step.ID, step.WorkflowID, step.StepName, step.StepOrder, step.Initial, step.Final, string(allowedRolesJSON), step.CreatedAt,
+		step.SLAMinutes, step.EscalationAction, step.EscalationValue,
+		approvalMode, step.Quorum, string(reviewerUsersJSON), string(reviewerRolesJSON), step.VetoAction,
+		sql.NullString{String: step.SubworkflowID, Valid: step.SubworkflowID != ""}, step.SubworkflowItems,
+		sql.NullString{String: step.QueueRole, Valid: step.QueueRole != ""})
 	return err
 }
 
 // GetWorkflowSteps retrieves all steps for a workflow
-func (r *WorkflowRepository) GetWorkflowSteps(workflowID string) ([]*models.WorkflowStep, error) {
-	rows, err := r.db.Query(`SELECT id, workflow_id, step_name, step_order, initial, final, allowed_roles, created_at 
+func (r *WorkflowRepository) GetWorkflowSteps(ctx context.Context, workflowID string) ([]*models.WorkflowStep, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT `+stepColumns+` 
 		FROM workflow_steps WHERE workflow_id = $1 ORDER BY step_order`, workflowID)
 	if err != nil {
 		return nil, err
@@ -86,111 +127,151 @@ func (r *WorkflowRepository) GetWorkflowSteps(workflowID string) ([]*models.Work
 
 	var steps []*models.WorkflowStep
 	for rows.Next() {
-		step := &models.WorkflowStep{}
-		var allowedRolesJSON string
-		err := rows.Scan(&step.ID, &step.WorkflowID, &step.StepName, &step.StepOrder,
-			&step.Initial, &step.Final, &allowedRolesJSON, &step.CreatedAt)
+		step, err := scanStep(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		// Parse allowed_roles JSON
-		if allowedRolesJSON != "" {
-			json.Unmarshal([]byte(allowedRolesJSON), &step.AllowedRoles)
-		}
 		steps = append(steps, step)
 	}
 	return steps, nil
 }
 
 // GetStep retrieves a single step by ID
-func (r *WorkflowRepository) GetStep(stepID string) (*models.WorkflowStep, error) {
-	step := &models.WorkflowStep{}
-	var allowedRolesJSON string
-	err := r.db.QueryRow(`SELECT id, workflow_id, step_name, step_order, initial, final, allowed_roles, created_at 
-		FROM workflow_steps WHERE id = $1`, stepID).Scan(
-		&step.ID, &step.WorkflowID, &step.StepName, &step.StepOrder,
-		&step.Initial, &step.Final, &allowedRolesJSON, &step.CreatedAt)
+func (r *WorkflowRepository) GetStep(ctx context.Context, stepID string) (*models.WorkflowStep, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	step, err := scanStep(conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+stepColumns+` 
+		FROM workflow_steps WHERE id = $1`, stepID))
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("step not found")
+		return nil, apperrors.NotFound("step not found")
 	}
 	if err != nil {
 		return nil, err
 	}
-
-	// Parse allowed_roles JSON
-	if allowedRolesJSON != "" {
-		json.Unmarshal([]byte(allowedRolesJSON), &step.AllowedRoles)
-	}
 	return step, nil
 }
 
 // GetStartStep retrieves the start step for a workflow
-func (r *WorkflowRepository) GetStartStep(workflowID string) (*models.WorkflowStep, error) {
-	step := &models.WorkflowStep{}
-	var allowedRolesJSON string
-	err := r.db.QueryRow(`SELECT id, workflow_id, step_name, step_order, initial, final, allowed_roles, created_at 
-		FROM workflow_steps WHERE workflow_id = $1 AND initial = 1`, workflowID).Scan(
-		&step.ID, &step.WorkflowID, &step.StepName, &step.StepOrder,
-		&step.Initial, &step.Final, &allowedRolesJSON, &step.CreatedAt)
+func (r *WorkflowRepository) GetStartStep(ctx context.Context, workflowID string) (*models.WorkflowStep, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	step, err := scanStep(conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+stepColumns+` 
+		FROM workflow_steps WHERE workflow_id = $1 AND initial = TRUE`, workflowID))
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("start step not found for workflow")
+		return nil, apperrors.NotFound("start step not found for workflow")
+	}
+	if err != nil {
+		return nil, err
 	}
+	return step, nil
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanStep scans a row selected with stepColumns into a WorkflowStep
+func scanStep(row rowScanner) (*models.WorkflowStep, error) {
+	step := &models.WorkflowStep{}
+	var allowedRolesJSON, escalationAction, escalationValue sql.NullString
+	var reviewerUsersJSON, reviewerRolesJSON, vetoAction sql.NullString
+	var subworkflowID, subworkflowItems, queueRole sql.NullString
+	err := row.Scan(&step.ID, &step.WorkflowID, &step.StepName, &step.StepOrder,
+		&step.Initial, &step.Final, &allowedRolesJSON, &step.CreatedAt,
+		&step.SLAMinutes, &escalationAction, &escalationValue,
+		&step.ApprovalMode, &step.Quorum, &reviewerUsersJSON, &reviewerRolesJSON, &vetoAction,
+		&subworkflowID, &subworkflowItems, &queueRole)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse allowed_roles JSON
-	if allowedRolesJSON != "" {
-		json.Unmarshal([]byte(allowedRolesJSON), &step.AllowedRoles)
+	// Parse JSON arrays
+	if allowedRolesJSON.Valid && allowedRolesJSON.String != "" {
+		json.Unmarshal([]byte(allowedRolesJSON.String), &step.AllowedRoles)
+	}
+	if reviewerUsersJSON.Valid && reviewerUsersJSON.String != "" {
+		json.Unmarshal([]byte(reviewerUsersJSON.String), &step.ReviewerUsers)
 	}
+	if reviewerRolesJSON.Valid && reviewerRolesJSON.String != "" {
+		json.Unmarshal([]byte(reviewerRolesJSON.String), &step.ReviewerRoles)
+	}
+	step.EscalationAction = escalationAction.String
+	step.EscalationValue = escalationValue.String
+	step.VetoAction = vetoAction.String
+	step.SubworkflowID = subworkflowID.String
+	step.SubworkflowItems = subworkflowItems.String
+	step.QueueRole = queueRole.String
 	return step, nil
 }
 
+// transitionColumns is the column list shared by all workflow transition queries
+const transitionColumns = `id, workflow_id, from_step_id, to_step_id, action_name, condition_type, condition_value, created_at, form_schema, automatic`
+
 // CreateTransition creates a new workflow transition
-func (r *WorkflowRepository) CreateTransition(transition *models.WorkflowTransition) error {
-	_, err := r.db.Exec(`INSERT INTO workflow_transitions (id, workflow_id, from_step_id, to_step_id, action_name, condition_type, condition_value, created_at) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, @p8)`,
+func (r *WorkflowRepository) CreateTransition(ctx context.Context, transition *models.WorkflowTransition) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var formSchemaJSON sql.NullString
+	if transition.FormSchema != nil {
+		formSchema, err := json.Marshal(transition.FormSchema)
+		if err != nil {
+			return fmt.Errorf("failed to marshal form_schema: %w", err)
+		}
+		formSchemaJSON = sql.NullString{String: string(formSchema), Valid: true}
+	}
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, `INSERT INTO workflow_transitions (`+transitionColumns+`) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
 		transition.ID, transition.WorkflowID, transition.FromStepID, transition.ToStepID,
-		transition.ActionName, transition.ConditionType, transition.ConditionValue, transition.CreatedAt)
+		transition.ActionName, transition.ConditionType, transition.ConditionValue, transition.CreatedAt, formSchemaJSON, transition.Automatic)
 	return err
 }
 
 // GetTransitions retrieves all transitions for a workflow
-func (r *WorkflowRepository) GetTransitions(workflowID string) ([]*models.WorkflowTransition, error) {
-	rows, err := r.db.Query(`SELECT id, workflow_id, from_step_id, to_step_id, action_name, condition_type, condition_value, created_at 
+func (r *WorkflowRepository) GetTransitions(ctx context.Context, workflowID string) ([]*models.WorkflowTransition, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.queryTransitions(ctx, `SELECT `+transitionColumns+` 
 		FROM workflow_transitions WHERE workflow_id = $1`, workflowID)
+}
+
+// GetAvailableTransitions retrieves possible transitions from a specific step, oldest first
+func (r *WorkflowRepository) GetAvailableTransitions(ctx context.Context, workflowID, fromStepID string) ([]*models.WorkflowTransition, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.queryTransitions(ctx, `SELECT `+transitionColumns+` 
+		FROM workflow_transitions WHERE workflow_id = $1 AND from_step_id = $2 ORDER BY created_at, id`, workflowID, fromStepID)
+}
+
+// FindTransition finds a specific transition by action name
+func (r *WorkflowRepository) FindTransition(ctx context.Context, workflowID, fromStepID, actionName string) (*models.WorkflowTransition, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	transition, err := scanTransition(conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+transitionColumns+` 
+		FROM workflow_transitions WHERE workflow_id = $1 AND from_step_id = $2 AND action_name = $3`,
+		workflowID, fromStepID, actionName))
+
+	if err == sql.ErrNoRows {
+		return nil, apperrors.NotFound("transition not found")
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var transitions []*models.WorkflowTransition
-	for rows.Next() {
-		transition := &models.WorkflowTransition{}
-		var conditionType, conditionValue sql.NullString
-		err := rows.Scan(&transition.ID, &transition.WorkflowID, &transition.FromStepID, &transition.ToStepID,
-			&transition.ActionName, &conditionType, &conditionValue, &transition.CreatedAt)
-		if err != nil {
-			return nil, err
-		}
-		if conditionType.Valid {
-			transition.ConditionType = conditionType.String
-		}
-		if conditionValue.Valid {
-			transition.ConditionValue = conditionValue.String
-		}
-		transitions = append(transitions, transition)
-	}
-	return transitions, nil
+	return transition, nil
 }
 
-// GetAvailableTransitions retrieves possible transitions from a specific step
-func (r *WorkflowRepository) GetAvailableTransitions(workflowID, fromStepID string) ([]*models.WorkflowTransition, error) {
-	rows, err := r.db.Query(`SELECT id, workflow_id, from_step_id, to_step_id, action_name, condition_type, condition_value, created_at 
-		FROM workflow_transitions WHERE workflow_id = $1 AND from_step_id = $2`, workflowID, fromStepID)
+// queryTransitions runs a query selecting transitionColumns and scans every row
+func (r *WorkflowRepository) queryTransitions(ctx context.Context, query string, args ...interface{}) ([]*models.WorkflowTransition, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -198,46 +279,33 @@ func (r *WorkflowRepository) GetAvailableTransitions(workflowID, fromStepID stri
 
 	var transitions []*models.WorkflowTransition
 	for rows.Next() {
-		transition := &models.WorkflowTransition{}
-		var conditionType, conditionValue sql.NullString
-		err := rows.Scan(&transition.ID, &transition.WorkflowID, &transition.FromStepID, &transition.ToStepID,
-			&transition.ActionName, &conditionType, &conditionValue, &transition.CreatedAt)
+		transition, err := scanTransition(rows)
 		if err != nil {
 			return nil, err
 		}
-		if conditionType.Valid {
-			transition.ConditionType = conditionType.String
-		}
-		if conditionValue.Valid {
-			transition.ConditionValue = conditionValue.String
-		}
 		transitions = append(transitions, transition)
 	}
 	return transitions, nil
 }
 
-// FindTransition finds a specific transition by action name
-func (r *WorkflowRepository) FindTransition(workflowID, fromStepID, actionName string) (*models.WorkflowTransition, error) {
+// scanTransition scans a row selected with transitionColumns into a WorkflowTransition
+func scanTransition(row rowScanner) (*models.WorkflowTransition, error) {
 	transition := &models.WorkflowTransition{}
 	var conditionType, conditionValue sql.NullString
-	err := r.db.QueryRow(`SELECT id, workflow_id, from_step_id, to_step_id, action_name, condition_type, condition_value, created_at 
-		FROM workflow_transitions WHERE workflow_id = $1 AND from_step_id = $2 AND action_name = $3`,
-		workflowID, fromStepID, actionName).Scan(
-		&transition.ID, &transition.WorkflowID, &transition.FromStepID, &transition.ToStepID,
-		&transition.ActionName, &conditionType, &conditionValue, &transition.CreatedAt)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("transition not found")
-	}
+	var formSchemaJSON []byte
+	err := row.Scan(&transition.ID, &transition.WorkflowID, &transition.FromStepID, &transition.ToStepID,
+		&transition.ActionName, &conditionType, &conditionValue, &transition.CreatedAt, &formSchemaJSON, &transition.Automatic)
 	if err != nil {
 		return nil, err
 	}
 
-	if conditionType.Valid {
-		transition.ConditionType = conditionType.String
-	}
-	if conditionValue.Valid {
-		transition.ConditionValue = conditionValue.String
+	transition.ConditionType = conditionType.String
+	transition.ConditionValue = conditionValue.String
+	if len(formSchemaJSON) > 0 {
+		transition.FormSchema = &models.FormSchema{}
+		if err := json.Unmarshal(formSchemaJSON, transition.FormSchema); err != nil {
+			return nil, fmt.Errorf("failed to parse form_schema of transition %s: %w", transition.ID, err)
+		}
 	}
 	return transition, nil
 }
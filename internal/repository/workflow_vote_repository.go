@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+type WorkflowVoteRepository struct {
+	db *sql.DB
+}
+
+func NewWorkflowVoteRepository() *WorkflowVoteRepository {
+	return &WorkflowVoteRepository{
+		db: database.DB,
+	}
+}
+
+// CreateVote records a reviewer's vote. A second vote of the same voter in the same
+// round fails the unique index uq_workflow_votes_voter_round.
+func (r *WorkflowVoteRepository) CreateVote(ctx context.Context, vote *models.WorkflowVote) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, `INSERT INTO workflow_votes (id, instance_id, step_id, voter_id, voter_role, action_name, comments, round_started_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		vote.ID, vote.InstanceID, vote.StepID, vote.VoterID, vote.VoterRole, vote.ActionName, vote.Comments, vote.Round, vote.CreatedAt)
+	return err
+}
+
+// GetVotes retrieves the votes cast on a step of an instance since the given time.
+// Passing the instance's step_entered_at limits the result to the current visit of the step.
+func (r *WorkflowVoteRepository) GetVotes(ctx context.Context, instanceID, stepID string, since time.Time) ([]models.WorkflowVote, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT id, instance_id, step_id, voter_id, voter_role, action_name, comments, round_started_at, created_at
+		FROM workflow_votes WHERE instance_id = $1 AND step_id = $2 AND created_at >= $3
+		ORDER BY created_at`, instanceID, stepID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	votes := []models.WorkflowVote{}
+	for rows.Next() {
+		var vote models.WorkflowVote
+		var voterRole, comments sql.NullString
+		err := rows.Scan(&vote.ID, &vote.InstanceID, &vote.StepID, &vote.VoterID, &voterRole,
+			&vote.ActionName, &comments, &vote.Round, &vote.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		vote.VoterRole = voterRole.String
+		vote.Comments = comments.String
+		votes = append(votes, vote)
+	}
+	return votes, nil
+}
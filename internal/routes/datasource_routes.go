@@ -7,9 +7,10 @@ import (
 	"todo-api/internal/models"
 )
 
-func RegisterDataSourceRoutes(dataSourceHandler *handlers.DataSourceHandler) {
+func RegisterDataSourceRoutes(authenticated *Group, dataSourceHandler *handlers.DataSourceHandler) {
 	// GET /api/data-sources - List all available data sources
-	// GET /api/data-sources/:id?widget_type=X - Get data for specific data source
-	http.HandleFunc("/api/data-sources", withAuthAndPermission(dataSourceHandler.DataSourcesHandler, models.PermView))
-	http.HandleFunc("/api/data-sources/", withAuthAndPermission(dataSourceHandler.DataSourcesHandler, models.PermView))
+	// GET /api/data-sources/{id}?widget_type=X - Get data for specific data source
+	view := authenticated.requiring(models.PermView)
+	view.handle(http.MethodGet, "/api/data-sources", dataSourceHandler.GetAllDataSources)
+	view.handle(http.MethodGet, "/api/data-sources/{id}", dataSourceHandler.GetDataSourceData)
 }
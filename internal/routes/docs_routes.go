@@ -0,0 +1,16 @@
+// internal/routes/docs_routes.go
+package routes
+
+import (
+	"net/http"
+	"todo-api/internal/openapi"
+)
+
+// RegisterDocsRoutes serves the OpenAPI document of every route registered on the
+// mux so far, and a page rendering it. Register it after all other routes.
+func RegisterDocsRoutes(public *Group) {
+	doc := &openapi.Document{}
+	public.handle(http.MethodGet, "/openapi.json", openapi.Handler(doc))
+	public.handle(http.MethodGet, "/docs", openapi.Redoc)
+	*doc = *newDocument(public.Routes())
+}
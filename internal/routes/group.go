@@ -0,0 +1,80 @@
+// internal/routes/group.go
+package routes
+
+import (
+	"net/http"
+	"todo-api/internal/interfaces"
+	"todo-api/internal/middleware"
+)
+
+// Middleware wraps a handler, e.g. to authenticate the request first
+type Middleware func(http.Handler) http.Handler
+
+// Route is a registered method and path with the access it requires
+type Route struct {
+	Method        string
+	Path          string
+	Authenticated bool
+	Permission    string
+}
+
+// Group registers routes on a mux behind a shared middleware chain
+type Group struct {
+	mux           *http.ServeMux
+	middleware    []Middleware
+	preflight     map[string]bool // Paths with an OPTIONS route, shared by all groups of the mux
+	routes        *[]Route        // Routes registered on the mux, shared by all groups of the mux
+	authenticated bool
+	permission    string
+}
+
+func newGroup(mux *http.ServeMux, middleware ...Middleware) *Group {
+	return &Group{mux: mux, middleware: middleware, preflight: map[string]bool{}, routes: &[]Route{}}
+}
+
+// with returns a group whose routes run behind this group's middleware followed by the given one
+func (g *Group) with(middleware ...Middleware) *Group {
+	group := *g
+	group.middleware = append(append([]Middleware{}, g.middleware...), middleware...)
+	return &group
+}
+
+// authenticating returns a group whose routes need a valid token of a user in users
+func (g *Group) authenticating(users interfaces.UserRepositoryInterface) *Group {
+	group := g.with(middleware.JWTAuth(users))
+	group.authenticated = true
+	return group
+}
+
+// requiring returns a group whose routes need the given permission
+func (g *Group) requiring(permission string) *Group {
+	group := g.with(middleware.RequirePermission(permission))
+	group.permission = permission
+	return group
+}
+
+// Routes returns the routes registered on the group's mux so far, without the preflight routes
+func (g *Group) Routes() []Route {
+	return append([]Route{}, *g.routes...)
+}
+
+// handle registers a handler for a method and path pattern such as "/api/tasks/{instance_id}".
+// The first route on a path also answers CORS preflight requests for it.
+func (g *Group) handle(method, path string, handler http.HandlerFunc) {
+	var h http.Handler = handler
+	for i := len(g.middleware) - 1; i >= 0; i-- {
+		h = g.middleware[i](h)
+	}
+	g.mux.Handle(method+" "+path, h)
+	*g.routes = append(*g.routes, Route{Method: method, Path: path, Authenticated: g.authenticated, Permission: g.permission})
+
+	if !g.preflight[path] {
+		g.preflight[path] = true
+		g.mux.HandleFunc(http.MethodOptions+" "+path, middleware.CORS(func(w http.ResponseWriter, r *http.Request) {}))
+	}
+}
+
+// cors adapts the CORS middleware to a Middleware
+func cors(next http.Handler) http.Handler {
+	return middleware.CORS(next.ServeHTTP)
+}
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"net/http"
+	"todo-api/internal/handlers"
+)
+
+// RegisterMetricsRoutes serves the Prometheus metrics; the handler checks the scraper's token or address
+func RegisterMetricsRoutes(public *Group, metricsHandler *handlers.MetricsHandler) {
+	public.handle(http.MethodGet, "/metrics", metricsHandler.Metrics)
+}
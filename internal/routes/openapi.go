@@ -0,0 +1,219 @@
+// internal/routes/openapi.go
+package routes
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"todo-api/internal/handlers"
+	"todo-api/internal/models"
+	"todo-api/internal/openapi"
+	"todo-api/pkg/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// endpoint documents one route. Path parameters are taken from the route itself.
+type endpoint struct {
+	tag         string
+	summary     string
+	description string
+	params      []openapi.Parameter
+	request     interface{} // Value of the body type, nil when there is no body
+	status      int         // Success status, 200 when zero
+	response    interface{} // Value of the response type, openapi.Fields or a *openapi.Schema
+}
+
+func query(name, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: openapi.String()}
+}
+
+func header(name, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "header", Description: description, Schema: openapi.String()}
+}
+
+// message is the response of routes that only confirm what they did
+var message = openapi.Fields{"message": ""}
+
+// metricsRange are the parameters of the date range of workflow metrics
+var metricsRange = []openapi.Parameter{
+	query("from", "Start of the range, YYYY-MM-DD or RFC 3339; defaults to 30 days before to"),
+	query("to", "End of the range, YYYY-MM-DD or RFC 3339; defaults to now"),
+}
+
+// endpoints documents every route by "METHOD path". A route registered without
+// an entry here is missing from the OpenAPI document, which the tests catch.
+var endpoints = map[string]endpoint{
+	"GET /health":       {tag: "health", summary: "Readiness probe", response: models.HealthResponse{}},
+	"GET /health/live":  {tag: "health", summary: "Liveness probe", response: models.HealthResponse{}},
+	"GET /health/ready": {tag: "health", summary: "Readiness probe", response: models.HealthResponse{}},
+	"GET /openapi.json": {tag: "docs", summary: "This OpenAPI document", response: &openapi.Schema{Type: "object"}},
+	"GET /docs":         {tag: "docs", summary: "Page rendering this document"},
+	"GET /metrics": {tag: "operations", summary: "Prometheus metrics in the text format, for scrapers holding METRICS_TOKEN or connecting from METRICS_ALLOWED_IPS",
+		params: []openapi.Parameter{header("Authorization", "Bearer METRICS_TOKEN, unless connecting from an allowed network")}},
+
+	"POST /login": {tag: "users", summary: "Log in and get a token", request: handlers.LoginRequest{}, response: openapi.Fields{
+		"token":    "",
+		"user_id":  uuid.UUID{},
+		"username": "",
+		"email":    "",
+		"is_admin": false,
+	}},
+	"POST /register":              {tag: "users", summary: "Register a user", request: handlers.RegisterRequest{}, status: http.StatusCreated, response: models.User{}},
+	"GET /users":                  {tag: "users", summary: "List users", response: []models.User{}},
+	"POST /users/update":          {tag: "users", summary: "Update a user", request: models.User{}, response: models.User{}},
+	"POST /logout":                {tag: "users", summary: "Log out; clients discard the token", response: message},
+	"GET /protected":              {tag: "users", summary: "Show the authenticated user", response: &openapi.Schema{Type: "object"}},
+	"GET /users/{id}/permissions": {tag: "roles", summary: "Get the role and permissions of a user", response: &openapi.Schema{Type: "object"}},
+	"POST /users/{id}/role":       {tag: "roles", summary: "Assign a role to a user", request: handlers.AssignRoleRequest{}, response: message},
+
+	"GET /roles":         {tag: "roles", summary: "List roles", response: []models.Role{}},
+	"POST /roles":        {tag: "roles", summary: "Create a role", request: models.Role{}, status: http.StatusCreated, response: models.Role{}},
+	"GET /roles/{id}":    {tag: "roles", summary: "Get a role", response: models.Role{}},
+	"PUT /roles/{id}":    {tag: "roles", summary: "Update a role", request: models.Role{}, response: models.Role{}},
+	"DELETE /roles/{id}": {tag: "roles", summary: "Delete a role", status: http.StatusNoContent},
+
+	"GET /todos":         {tag: "todos", summary: "List todos", response: []models.Todo{}},
+	"POST /todos":        {tag: "todos", summary: "Create a todo", request: models.Todo{}, status: http.StatusCreated, response: models.Todo{}},
+	"GET /todos/user":    {tag: "todos", summary: "List the todos of a user", params: []openapi.Parameter{query("user_id", "User ID")}, response: []models.Todo{}},
+	"GET /todos/{id}":    {tag: "todos", summary: "Get a todo", response: models.Todo{}},
+	"PUT /todos/{id}":    {tag: "todos", summary: "Update a todo", request: models.Todo{}, response: models.Todo{}},
+	"DELETE /todos/{id}": {tag: "todos", summary: "Delete a todo", response: message},
+
+	"GET /shared-tasks":         {tag: "shared tasks", summary: "List shared tasks", response: []models.SharedTask{}},
+	"POST /shared-tasks":        {tag: "shared tasks", summary: "Share a todo", request: models.SharedTask{}, status: http.StatusCreated, response: models.SharedTask{}},
+	"GET /shared-tasks/owner":   {tag: "shared tasks", summary: "List the tasks a user shared", params: []openapi.Parameter{query("owner_id", "Owner user ID")}, response: []models.SharedTask{}},
+	"GET /shared-tasks/id":      {tag: "shared tasks", summary: "List the todos shared with a user", params: []openapi.Parameter{query("id", "User ID the todos are shared with")}, response: []models.SharedTodoWithOwner{}},
+	"GET /shared-tasks/todo":    {tag: "shared tasks", summary: "List the shares of a todo", params: []openapi.Parameter{query("todo_id", "Todo ID")}, response: []models.SharedTask{}},
+	"GET /shared-tasks/{id}":    {tag: "shared tasks", summary: "Get a shared task", response: models.SharedTask{}},
+	"DELETE /shared-tasks/{id}": {tag: "shared tasks", summary: "Stop sharing a todo", response: message},
+
+	"POST /workflow/todos":                            {tag: "todo workflow", summary: "Create a todo task", request: handlers.CreateTodoTaskRequest{}, status: http.StatusCreated, response: models.TodoTask{}},
+	"GET /workflow/todos/user":                        {tag: "todo workflow", summary: "List the todo tasks of a user", params: []openapi.Parameter{query("user_id", "User ID")}, response: []models.TodoTask{}},
+	"GET /workflow/todos/status":                      {tag: "todo workflow", summary: "List todo tasks by status", params: []openapi.Parameter{query("status", "Todo status")}, response: []models.TodoTask{}},
+	"POST /workflow/todos/{id}/submit/{submitted_by}": {tag: "todo workflow", summary: "Submit a todo task for review", response: message},
+	"POST /workflow/todos/{id}/approve/{approved_by}": {tag: "todo workflow", summary: "Approve a todo task", response: message},
+	"POST /workflow/todos/{id}/reject/{rejected_by}":  {tag: "todo workflow", summary: "Reject a todo task", response: message},
+	"POST /api/workflows":                             {tag: "workflows", summary: "Create a workflow", request: handlers.CreateWorkflowRequest{}, status: http.StatusCreated, response: models.Workflow{}},
+	"GET /api/workflows":                              {tag: "workflows", summary: "List workflows", response: []models.Workflow{}},
+	"GET /api/workflows/{id}":                         {tag: "workflows", summary: "Get a workflow", response: models.Workflow{}},
+	"POST /api/workflows/{id}/simulate":               {tag: "workflows", summary: "Dry-run actions through a workflow", request: models.SimulationInput{}, response: models.SimulationResult{}},
+	"GET /api/workflows/{id}/metrics":                 {tag: "workflows", summary: "Get cycle time, dwell time, rework and throughput", params: metricsRange, response: models.WorkflowMetrics{}},
+	"POST /api/workflows/{workflow_id}/steps":         {tag: "workflows", summary: "Create a step", request: handlers.CreateStepRequest{}, status: http.StatusCreated, response: models.WorkflowStep{}},
+	"GET /api/workflows/{workflow_id}/steps":          {tag: "workflows", summary: "List the steps of a workflow", response: []models.WorkflowStep{}},
+	"POST /api/workflows/{workflow_id}/transitions":   {tag: "workflows", summary: "Create a transition", request: handlers.CreateTransitionRequest{}, status: http.StatusCreated, response: models.WorkflowTransition{}},
+	"GET /api/workflows/{workflow_id}/transitions":    {tag: "workflows", summary: "List the transitions of a workflow", response: []models.WorkflowTransition{}},
+	"GET /api/workflows/{workflow_id}/tasks":          {tag: "tasks", summary: "List the tasks of a workflow", response: []models.AssignedTodo{}},
+	"POST /api/tasks":                                 {tag: "tasks", summary: "Start a task", request: handlers.StartTaskRequest{}, status: http.StatusCreated, response: models.AssignedTodo{}},
+	"POST /api/tasks/bulk-execute":                    {tag: "tasks", summary: "Execute one action on many tasks", request: handlers.BulkExecuteRequest{}, response: models.BulkExecuteResult{}},
+	"POST /api/tasks/{instance_id}/execute": {tag: "tasks", summary: "Execute an action on a task", request: handlers.ExecuteActionRequest{},
+		params:   []openapi.Parameter{header("If-Match", "ETag of the task version the caller saw; a changed task answers 409")},
+		response: openapi.Fields{"message": "", "result": models.TransitionResult{}}},
+	"GET /api/tasks/{instance_id}":           {tag: "tasks", summary: "Get a task with its workflow and step", params: []openapi.Parameter{query("user_id", "User whose available actions are included")}, response: models.WorkflowInstanceWithDetails{}},
+	"GET /api/tasks/{instance_id}/actions":   {tag: "tasks", summary: "List the actions a user can take", params: []openapi.Parameter{query("user_id", "User ID")}, response: []models.AvailableAction{}},
+	"GET /api/tasks/{instance_id}/history":   {tag: "tasks", summary: "Get the history of a task", response: []models.WorkflowHistory{}},
+	"GET /api/tasks/{instance_id}/votes":     {tag: "tasks", summary: "Get the vote tally of the current step", response: models.VoteTally{}},
+	"POST /api/tasks/{instance_id}/reassign": {tag: "tasks", summary: "Reassign a task", description: "The assignee and their active delegates may reassign a task. " + adminOverride, request: handlers.ReassignTaskRequest{}, response: models.AssignedTodo{}},
+	"POST /api/tasks/{instance_id}/claim":    {tag: "queues", summary: "Claim a task from a work queue", request: handlers.ClaimRequest{}, response: models.AssignedTodo{}},
+	"POST /api/tasks/{instance_id}/release":  {tag: "queues", summary: "Release a claimed task back to its queue", description: "The user who claimed a task may release it. " + adminOverride, request: handlers.ClaimRequest{}, response: models.AssignedTodo{}},
+	"POST /api/tasks/{instance_id}/suspend":  {tag: "tasks", summary: "Suspend a task", request: handlers.TaskStatusRequest{}, response: taskStatusResponse},
+	"POST /api/tasks/{instance_id}/resume":   {tag: "tasks", summary: "Resume a suspended task", request: handlers.TaskStatusRequest{}, response: taskStatusResponse},
+	"POST /api/tasks/{instance_id}/cancel":   {tag: "tasks", summary: "Cancel a task", request: handlers.TaskStatusRequest{}, response: taskStatusResponse},
+	"GET /api/tasks/overdue":                 {tag: "tasks", summary: "List tasks past their step SLA", response: []models.OverdueTask{}},
+	"GET /api/tasks/queue":                   {tag: "queues", summary: "List the unclaimed tasks in the caller's queues", response: []models.AssignedTodo{}},
+	"GET /api/tasks/user":                    {tag: "tasks", summary: "List the tasks assigned to a user", params: []openapi.Parameter{query("user_id", "User ID")}, response: []models.AssignedTodo{}},
+	"POST /api/delegations":                  {tag: "delegations", summary: "Delegate the caller's tasks for a period", request: handlers.CreateDelegationRequest{}, status: http.StatusCreated, response: models.WorkflowDelegation{}},
+	"GET /api/delegations":                   {tag: "delegations", summary: "List the caller's delegations", response: []models.WorkflowDelegation{}},
+	"DELETE /api/delegations/{id}":           {tag: "delegations", summary: "Delete a delegation", description: "The user who delegated may delete a delegation. " + adminOverride, response: message},
+	"GET /api/data-sources":                  {tag: "data sources", summary: "List the data sources dashboards can use", response: models.DataSourceResponse{}},
+	"GET /api/data-sources/{id}":             {tag: "data sources", summary: "Get the data of a data source for a widget type", params: dataSourceParams, response: models.DataSourceResponse{}},
+}
+
+// adminOverride documents the routes where administrators may act on anyone's behalf
+const adminOverride = "Users with the Admin or Super Admin role may do so for anyone."
+
+// taskStatusResponse is the response of the suspend, resume and cancel routes
+var taskStatusResponse = openapi.Fields{"message": "", "instance": models.AssignedTodo{}}
+
+var dataSourceParams = append([]openapi.Parameter{
+	query("widget_type", "pie_chart, bar_chart or table"),
+	query("entity_id", "Entity the data source reports on, for data sources that require one"),
+}, metricsRange...)
+
+// pathParam matches the wildcards of a route pattern, which OpenAPI writes the same way
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// newDocument describes the given routes with their endpoints
+func newDocument(routes []Route) *openapi.Document {
+	schemas := openapi.Schemas{}
+	problem := map[string]openapi.MediaType{apperrors.ContentType: {Schema: schemas.Of(apperrors.Problem{})}}
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "Todo API",
+			Version:     "1.0.0",
+			Description: "Todos, users and roles, and the dynamic workflow engine. Errors are RFC 7807 problem details.",
+		},
+		Paths: map[string]openapi.PathItem{},
+		Components: openapi.Components{
+			Schemas:         schemas,
+			SecuritySchemes: map[string]openapi.SecurityScheme{"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"}},
+		},
+	}
+
+	for _, route := range routes {
+		e, ok := endpoints[route.Method+" "+route.Path]
+		if !ok {
+			continue
+		}
+		operation := &openapi.Operation{
+			OperationID: operationID(route),
+			Summary:     e.summary,
+			Description: e.description,
+			Tags:        []string{e.tag},
+			Parameters:  append(pathParams(route.Path), e.params...),
+			Responses:   map[string]openapi.Response{"default": {Description: "Problem details", Content: problem}},
+			Permission:  route.Permission,
+		}
+		if e.request != nil {
+			operation.RequestBody = &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{"application/json": {Schema: schemas.Of(e.request)}}}
+		}
+		status := e.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := openapi.Response{Description: http.StatusText(status)}
+		if e.response != nil {
+			success.Content = map[string]openapi.MediaType{"application/json": {Schema: schemas.Of(e.response)}}
+		}
+		operation.Responses[strconv.Itoa(status)] = success
+		if route.Authenticated {
+			operation.Security = []map[string][]string{{"bearerAuth": {}}}
+			operation.Responses["401"] = openapi.Response{Description: "Missing or invalid token", Content: problem}
+		}
+		if route.Permission != "" {
+			operation.Responses["403"] = openapi.Response{Description: "The caller's role lacks the " + route.Permission + " permission", Content: problem}
+		}
+
+		if doc.Paths[route.Path] == nil {
+			doc.Paths[route.Path] = openapi.PathItem{}
+		}
+		doc.Paths[route.Path][strings.ToLower(route.Method)] = operation
+	}
+	return doc
+}
+
+func pathParams(path string) []openapi.Parameter {
+	var params []openapi.Parameter
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		params = append(params, openapi.Parameter{Name: match[1], In: "path", Required: true, Schema: openapi.String()})
+	}
+	return params
+}
+
+// operationID derives a stable ID such as post_api_tasks_instance_id_claim
+func operationID(route Route) string {
+	id := strings.ToLower(route.Method) + strings.NewReplacer("/", "_", "-", "_", "{", "", "}", "", ".", "_").Replace(route.Path)
+	return strings.TrimSuffix(id, "_")
+}
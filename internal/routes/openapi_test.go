@@ -0,0 +1,117 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPI_CoversRoutes(t *testing.T) {
+	// Arrange
+	_, routes := newRouter(testHandlers())
+	registered := map[string]bool{}
+	for _, route := range routes {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	// Assert - every route is documented, and every documented route exists
+	for key := range registered {
+		if _, ok := endpoints[key]; !ok {
+			t.Errorf("Route %s is missing from the OpenAPI document", key)
+		}
+	}
+	for key := range endpoints {
+		if !registered[key] {
+			t.Errorf("The OpenAPI document describes %s, which is not registered", key)
+		}
+	}
+}
+
+func TestOpenAPI_Served(t *testing.T) {
+	// Arrange
+	router, routes := newRouter(testHandlers())
+
+	// Act
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	var doc struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	body := rec.Body.Bytes()
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("Failed to decode the document: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("Expected OpenAPI 3.0.3, got %q", doc.OpenAPI)
+	}
+	for _, route := range routes {
+		if _, ok := doc.Paths[route.Path][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("Expected %s %s in the served document", route.Method, route.Path)
+		}
+	}
+	for _, ref := range schemaRefs(t, body) {
+		if _, ok := doc.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]; !ok {
+			t.Errorf("Reference %s has no schema", ref)
+		}
+	}
+}
+
+func TestOpenAPI_Docs(t *testing.T) {
+	// Arrange - the page loads the document relative to itself, so it works when mounted
+	server := http.NewServeMux()
+	server.Handle("/v1/", http.StripPrefix("/v1", newTestRouter()))
+
+	// Act
+	page := httptest.NewRecorder()
+	server.ServeHTTP(page, httptest.NewRequest(http.MethodGet, "/v1/docs", nil))
+	spec := httptest.NewRecorder()
+	server.ServeHTTP(spec, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+
+	// Assert
+	if page.Code != http.StatusOK || !strings.Contains(page.Body.String(), `spec-url="openapi.json"`) {
+		t.Errorf("Expected the docs page to load openapi.json, got %d", page.Code)
+	}
+	if spec.Code != http.StatusOK {
+		t.Errorf("Expected the mounted document to be served, got %d", spec.Code)
+	}
+}
+
+// schemaRefs returns every $ref in a JSON document
+func schemaRefs(t *testing.T, body []byte) []string {
+	t.Helper()
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		t.Fatalf("Failed to decode the document: %v", err)
+	}
+
+	var refs []string
+	var walk func(interface{})
+	walk = func(value interface{}) {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			for key, child := range value {
+				if ref, ok := child.(string); ok && key == "$ref" {
+					refs = append(refs, ref)
+				}
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range value {
+				walk(child)
+			}
+		}
+	}
+	walk(value)
+	return refs
+}
@@ -3,10 +3,11 @@ package routes
 import (
 	"net/http"
 	"todo-api/internal/handlers"
-	"todo-api/internal/middleware"
 )
 
-func RegisterPublicRoutes(userHandler *handlers.UsersHandler) {
-	http.HandleFunc("/health", middleware.CORS(handlers.HealthHandler))
-	http.HandleFunc("/login", middleware.CORS(userHandler.Login))
+func RegisterPublicRoutes(public *Group, healthHandler *handlers.HealthHandler, userHandler *handlers.UsersHandler) {
+	public.handle(http.MethodGet, "/health", healthHandler.Ready)
+	public.handle(http.MethodGet, "/health/live", healthHandler.Live)
+	public.handle(http.MethodGet, "/health/ready", healthHandler.Ready)
+	public.handle(http.MethodPost, "/login", userHandler.Login)
 }
@@ -0,0 +1,50 @@
+// internal/routes/router.go
+package routes
+
+import (
+	"net/http"
+	"todo-api/internal/handlers"
+	"todo-api/internal/interfaces"
+)
+
+// Handlers are the handlers the API is served by
+type Handlers struct {
+	Health           *handlers.HealthHandler
+	Todo             *handlers.TodoHandler
+	User             *handlers.UsersHandler
+	SharedTask       *handlers.SharedTaskHandler
+	Role             *handlers.RoleHandler
+	TodoWorkflow     *handlers.TodoWorkflowHandler
+	WorkflowAdmin    *handlers.WorkflowAdminHandler
+	WorkflowInstance *handlers.WorkflowInstanceHandler
+	DataSource       *handlers.DataSourceHandler
+	Metrics          *handlers.MetricsHandler
+
+	Users interfaces.UserRepositoryInterface // Loads the user of the token on authenticated routes
+}
+
+// NewRouter builds a mux serving the whole API. Nothing is registered on
+// http.DefaultServeMux, so the API can be mounted in tests or in a larger server.
+func NewRouter(h Handlers) *http.ServeMux {
+	mux, _ := newRouter(h)
+	return mux
+}
+
+// newRouter builds the API mux and returns the routes registered on it
+func newRouter(h Handlers) (*http.ServeMux, []Route) {
+	mux := http.NewServeMux()
+	public := newGroup(mux, cors)
+	authenticated := public.authenticating(h.Users)
+
+	RegisterPublicRoutes(public, h.Health, h.User)
+	RegisterTodoRoutes(authenticated, h.Todo)
+	RegisterUserRoutes(authenticated, h.User)
+	RegisterSharedTaskRoutes(authenticated, h.SharedTask)
+	RegisterRoleRoutes(authenticated, h.Role)
+	RegisterWorkflowRoutes(authenticated, h.TodoWorkflow, h.WorkflowAdmin, h.WorkflowInstance)
+	RegisterDataSourceRoutes(authenticated, h.DataSource)
+	RegisterMetricsRoutes(public, h.Metrics)
+	RegisterDocsRoutes(public) // Last, so the document covers every route
+
+	return mux, public.Routes()
+}
@@ -0,0 +1,106 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"todo-api/internal/handlers"
+	"todo-api/internal/metrics"
+	"todo-api/internal/repository"
+	"todo-api/internal/services"
+)
+
+// newTestRouter builds the router without services; the tests only reach
+// handlers that need none, everything else stops at authentication
+func newTestRouter() *http.ServeMux {
+	return NewRouter(testHandlers())
+}
+
+func testHandlers() Handlers {
+	return Handlers{
+		Health:           handlers.NewHealthHandler(services.NewHealthService(nil, time.Second)),
+		Todo:             handlers.NewTodoHandler(nil),
+		User:             handlers.NewUsersHandler(nil),
+		SharedTask:       handlers.NewSharedTaskHandler(nil),
+		Role:             handlers.NewRoleHandler(nil),
+		TodoWorkflow:     handlers.NewTodoWorkflowHandler(nil),
+		WorkflowAdmin:    handlers.NewWorkflowAdminHandler(nil, nil, nil, nil),
+		WorkflowInstance: handlers.NewWorkflowInstanceHandler(nil, nil),
+		DataSource:       handlers.NewDataSourceHandler(nil),
+		Metrics:          handlers.NewMetricsHandler(metrics.NewRegistry(), "", nil),
+
+		Users: repository.NewMemoryUserRepository(repository.NewMemoryStore()),
+	}
+}
+
+func TestNewRouter(t *testing.T) {
+	// Arrange
+	router := newTestRouter()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"public route", http.MethodGet, "/health/live", http.StatusOK},
+		{"readiness without database", http.MethodGet, "/health/ready", http.StatusServiceUnavailable},
+		{"authenticated route", http.MethodGet, "/todos", http.StatusUnauthorized},
+		{"path parameter", http.MethodDelete, "/todos/todo-1", http.StatusUnauthorized},
+		{"user sub-resource", http.MethodPost, "/users/user-1/role", http.StatusUnauthorized},
+		{"workflow route", http.MethodPost, "/api/tasks/instance-1/claim", http.StatusUnauthorized},
+		{"preflight", http.MethodOptions, "/api/tasks/instance-1/execute", http.StatusOK},
+		{"wrong method", http.MethodDelete, "/health/live", http.StatusMethodNotAllowed},
+		{"wrong method on sub-resource", http.MethodGet, "/users/user-1/role", http.StatusMethodNotAllowed},
+		{"unknown route", http.MethodGet, "/nothing-here", http.StatusNotFound},
+		{"metrics from a network not allowed", http.MethodGet, "/metrics", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d for %s %s, got %d", tt.wantStatus, tt.method, tt.path, rec.Code)
+			}
+		})
+	}
+}
+
+func TestNewRouter_GroupMiddleware(t *testing.T) {
+	// Arrange
+	router := newTestRouter()
+
+	// Act
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/workflows", nil))
+
+	// Assert - CORS runs before authentication, so even rejected requests carry the headers
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+	if rec.Header().Get("Access-Control-Allow-Origin") == "" {
+		t.Errorf("Expected CORS headers on an authenticated route")
+	}
+}
+
+func TestNewRouter_Mountable(t *testing.T) {
+	// Arrange - two independent routers, one mounted under a prefix of a larger server
+	newTestRouter()
+	server := http.NewServeMux()
+	server.Handle("/v1/", http.StripPrefix("/v1", newTestRouter()))
+
+	// Act
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/health/live", nil))
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+}
@@ -7,10 +7,13 @@ import (
 	"todo-api/internal/models"
 )
 
-func RegisterSharedTaskRoutes(sharedTaskHandler *handlers.SharedTaskHandler) {
-	http.HandleFunc("/shared-tasks", withAuthAndPermission(sharedTaskHandler.SharedTasksHandler, models.PermView))
-	http.HandleFunc("/shared-tasks/", withAuthAndPermission(sharedTaskHandler.SharedTaskByIdHandler, models.PermView))
-	http.HandleFunc("/shared-tasks/owner", withAuthAndPermission(sharedTaskHandler.GetSharedTasksByOwnerId, models.PermView))
-	http.HandleFunc("/shared-tasks/id", withAuthAndPermission(sharedTaskHandler.GetSharedTasksById, models.PermView))
-	http.HandleFunc("/shared-tasks/todo", withAuthAndPermission(sharedTaskHandler.GetSharedTasksByTodoId, models.PermView))
+func RegisterSharedTaskRoutes(authenticated *Group, sharedTaskHandler *handlers.SharedTaskHandler) {
+	view := authenticated.requiring(models.PermView)
+	view.handle(http.MethodGet, "/shared-tasks", sharedTaskHandler.GetAllSharedTasks)
+	view.handle(http.MethodPost, "/shared-tasks", sharedTaskHandler.CreateSharedTask)
+	view.handle(http.MethodGet, "/shared-tasks/owner", sharedTaskHandler.GetSharedTasksByOwnerId)
+	view.handle(http.MethodGet, "/shared-tasks/id", sharedTaskHandler.GetSharedTasksById)
+	view.handle(http.MethodGet, "/shared-tasks/todo", sharedTaskHandler.GetSharedTasksByTodoId)
+	view.handle(http.MethodGet, "/shared-tasks/{id}", sharedTaskHandler.GetSharedTaskById)
+	view.handle(http.MethodDelete, "/shared-tasks/{id}", sharedTaskHandler.DeleteSharedTask)
 }
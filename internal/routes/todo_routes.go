@@ -7,8 +7,12 @@ import (
 	"todo-api/internal/models"
 )
 
-func RegisterTodoRoutes(todoHandler *handlers.TodoHandler) {
-	http.HandleFunc("/todos", withAuthAndPermission(todoHandler.TodosHandler, models.PermView))
-	http.HandleFunc("/todos/", withAuthAndPermission(todoHandler.TodoByIdHandler, models.PermView))
-	http.HandleFunc("/todos/user", withAuthAndPermission(todoHandler.GetTodosByUserId, models.PermView))
+func RegisterTodoRoutes(authenticated *Group, todoHandler *handlers.TodoHandler) {
+	view := authenticated.requiring(models.PermView)
+	view.handle(http.MethodGet, "/todos", todoHandler.GetAllTodos)
+	view.handle(http.MethodPost, "/todos", todoHandler.CreateTodo)
+	view.handle(http.MethodGet, "/todos/user", todoHandler.GetTodosByUserId)
+	view.handle(http.MethodGet, "/todos/{id}", todoHandler.GetTodoById)
+	view.handle(http.MethodPut, "/todos/{id}", todoHandler.UpdateTodo)
+	view.handle(http.MethodDelete, "/todos/{id}", todoHandler.DeleteTodo)
 }
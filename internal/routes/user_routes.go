@@ -3,31 +3,29 @@ package routes
 
 import (
 	"net/http"
-	"strings"
 	"todo-api/internal/handlers"
 	"todo-api/internal/models"
 )
 
-func RegisterUserRoutes(userHandler *handlers.UsersHandler) {
-	http.HandleFunc("/register", withAuthAndPermission(userHandler.Register, models.PermCreate))
-	http.HandleFunc("/users", withAuthAndPermission(userHandler.GetUsers, models.PermView))
-	http.HandleFunc("/users/update", withAuthAndPermission(userHandler.UpdateUser, models.PermUpdate))
-	http.HandleFunc("/logout", withAuth(handlers.Logout))
-	http.HandleFunc("/protected", withAuth(handlers.Protected))
+func RegisterUserRoutes(authenticated *Group, userHandler *handlers.UsersHandler) {
+	authenticated.requiring(models.PermCreate).handle(http.MethodPost, "/register", userHandler.Register)
+	authenticated.requiring(models.PermView).handle(http.MethodGet, "/users", userHandler.GetUsers)
+	authenticated.requiring(models.PermUpdate).handle(http.MethodPost, "/users/update", userHandler.UpdateUser)
+	authenticated.handle(http.MethodPost, "/logout", handlers.Logout)
+	authenticated.handle(http.MethodGet, "/protected", handlers.Protected)
 }
 
-func RegisterRoleRoutes(roleHandler *handlers.RoleHandler, userHandler *handlers.UsersHandler) {
-	http.HandleFunc("/roles", withAuthAndPermission(roleHandler.RolesHandler, models.PermView))
-	http.HandleFunc("/roles/", withAuthAndPermission(roleHandler.RolesHandler, models.PermCreate))
+func RegisterRoleRoutes(authenticated *Group, roleHandler *handlers.RoleHandler) {
+	view := authenticated.requiring(models.PermView)
+	view.handle(http.MethodGet, "/roles", roleHandler.GetAllRoles)
+	view.handle(http.MethodPost, "/roles", roleHandler.CreateRole)
+	view.handle(http.MethodGet, "/users/{id}/permissions", roleHandler.GetUserPermissions)
 
-	// User role assignment routes
-	http.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasSuffix(r.URL.Path, "/role") {
-			withAuthAndPermission(roleHandler.AssignRoleHandler, models.PermUpdate)(w, r)
-		} else if strings.HasSuffix(r.URL.Path, "/permissions") {
-			withAuthAndPermission(roleHandler.GetUserPermissionsHandler, models.PermView)(w, r)
-		} else {
-			withAuthAndPermission(userHandler.GetUsers, models.PermView)(w, r)
-		}
-	})
+	create := authenticated.requiring(models.PermCreate)
+	create.handle(http.MethodGet, "/roles/{id}", roleHandler.GetRoleByID)
+	create.handle(http.MethodPut, "/roles/{id}", roleHandler.UpdateRole)
+	create.handle(http.MethodDelete, "/roles/{id}", roleHandler.DeleteRole)
+
+	// User role assignment
+	authenticated.requiring(models.PermUpdate).handle(http.MethodPost, "/users/{id}/role", roleHandler.AssignRoleToUser)
 }
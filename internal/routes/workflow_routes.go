@@ -4,47 +4,57 @@ package routes
 import (
 	"net/http"
 	"todo-api/internal/handlers"
-	"todo-api/internal/middleware"
 	"todo-api/internal/models"
 )
 
 func RegisterWorkflowRoutes(
+	authenticated *Group,
 	todoWorkflowHandler *handlers.TodoWorkflowHandler,
 	workflowAdminHandler *handlers.WorkflowAdminHandler,
 	workflowInstanceHandler *handlers.WorkflowInstanceHandler,
 ) {
+	view := authenticated.requiring(models.PermView)
+	create := authenticated.requiring(models.PermCreate)
+	update := authenticated.requiring(models.PermUpdate)
+	del := authenticated.requiring(models.PermDelete)
+
 	// Old hardcoded workflow routes
-	http.HandleFunc("POST /workflow/todos", withAuthAndPermission(todoWorkflowHandler.CreateTodoTask, models.PermCreate))
-	http.HandleFunc("GET /workflow/todos/user", withAuthAndPermission(todoWorkflowHandler.GetTodosByUser, models.PermView))
-	http.HandleFunc("GET /workflow/todos/status", withAuthAndPermission(todoWorkflowHandler.GetTodosByStatus, models.PermView))
-	http.HandleFunc("POST /workflow/todos/{id}/submit/{submitted_by}", withAuthAndPermission(todoWorkflowHandler.SubmitForReview, models.PermUpdate))
-	http.HandleFunc("POST /workflow/todos/{id}/approve/{approved_by}", withAuthAndPermission(todoWorkflowHandler.ApproveTodo, models.PermUpdate))
-	http.HandleFunc("POST /workflow/todos/{id}/reject/{rejected_by}", withAuthAndPermission(todoWorkflowHandler.RejectTodo, models.PermUpdate))
+	create.handle(http.MethodPost, "/workflow/todos", todoWorkflowHandler.CreateTodoTask)
+	view.handle(http.MethodGet, "/workflow/todos/user", todoWorkflowHandler.GetTodosByUser)
+	view.handle(http.MethodGet, "/workflow/todos/status", todoWorkflowHandler.GetTodosByStatus)
+	update.handle(http.MethodPost, "/workflow/todos/{id}/submit/{submitted_by}", todoWorkflowHandler.SubmitForReview)
+	update.handle(http.MethodPost, "/workflow/todos/{id}/approve/{approved_by}", todoWorkflowHandler.ApproveTodo)
+	update.handle(http.MethodPost, "/workflow/todos/{id}/reject/{rejected_by}", todoWorkflowHandler.RejectTodo)
 
 	// Dynamic Workflow Admin routes (for creating workflows, steps, transitions)
-	http.HandleFunc("OPTIONS /api/workflows", middleware.CORS(func(w http.ResponseWriter, r *http.Request) {}))
-	http.HandleFunc("POST /api/workflows", withAuthAndPermission(workflowAdminHandler.CreateWorkflow, models.PermCreate))
-	http.HandleFunc("GET /api/workflows", withAuthAndPermission(workflowAdminHandler.GetAllWorkflows, models.PermView))
-	http.HandleFunc("OPTIONS /api/workflows/{id}", middleware.CORS(func(w http.ResponseWriter, r *http.Request) {}))
-	http.HandleFunc("GET /api/workflows/{id}", withAuthAndPermission(workflowAdminHandler.GetWorkflow, models.PermView))
-	http.HandleFunc("OPTIONS /api/workflows/{workflow_id}/steps", middleware.CORS(func(w http.ResponseWriter, r *http.Request) {}))
-	http.HandleFunc("POST /api/workflows/{workflow_id}/steps", withAuthAndPermission(workflowAdminHandler.CreateStep, models.PermCreate))
-	http.HandleFunc("GET /api/workflows/{workflow_id}/steps", withAuthAndPermission(workflowAdminHandler.GetWorkflowSteps, models.PermView))
-	http.HandleFunc("OPTIONS /api/workflows/{workflow_id}/transitions", middleware.CORS(func(w http.ResponseWriter, r *http.Request) {}))
-	http.HandleFunc("POST /api/workflows/{workflow_id}/transitions", withAuthAndPermission(workflowAdminHandler.CreateTransition, models.PermCreate))
-	http.HandleFunc("GET /api/workflows/{workflow_id}/transitions", withAuthAndPermission(workflowAdminHandler.GetWorkflowTransitions, models.PermView))
+	create.handle(http.MethodPost, "/api/workflows", workflowAdminHandler.CreateWorkflow)
+	view.handle(http.MethodGet, "/api/workflows", workflowAdminHandler.GetAllWorkflows)
+	view.handle(http.MethodGet, "/api/workflows/{id}", workflowAdminHandler.GetWorkflow)
+	create.handle(http.MethodPost, "/api/workflows/{id}/simulate", workflowAdminHandler.SimulateWorkflow)
+	view.handle(http.MethodGet, "/api/workflows/{id}/metrics", workflowAdminHandler.GetWorkflowMetrics)
+	create.handle(http.MethodPost, "/api/workflows/{workflow_id}/steps", workflowAdminHandler.CreateStep)
+	view.handle(http.MethodGet, "/api/workflows/{workflow_id}/steps", workflowAdminHandler.GetWorkflowSteps)
+	create.handle(http.MethodPost, "/api/workflows/{workflow_id}/transitions", workflowAdminHandler.CreateTransition)
+	view.handle(http.MethodGet, "/api/workflows/{workflow_id}/transitions", workflowAdminHandler.GetWorkflowTransitions)
 	// Dynamic Workflow Instance routes
-	http.HandleFunc("OPTIONS /api/tasks", middleware.CORS(func(w http.ResponseWriter, r *http.Request) {}))
-	http.HandleFunc("POST /api/tasks", withAuthAndPermission(workflowInstanceHandler.StartTask, models.PermCreate))
-	http.HandleFunc("OPTIONS /api/tasks/{instance_id}", middleware.CORS(func(w http.ResponseWriter, r *http.Request) {}))
-	http.HandleFunc("GET /api/tasks/{instance_id}", withAuthAndPermission(workflowInstanceHandler.GetTask, models.PermView))
-	http.HandleFunc("OPTIONS /api/tasks/{instance_id}/execute", middleware.CORS(func(w http.ResponseWriter, r *http.Request) {}))
-	http.HandleFunc("POST /api/tasks/{instance_id}/execute", withAuthAndPermission(workflowInstanceHandler.ExecuteAction, models.PermUpdate))
-	http.HandleFunc("OPTIONS /api/tasks/{instance_id}/actions", middleware.CORS(func(w http.ResponseWriter, r *http.Request) {}))
-	http.HandleFunc("GET /api/tasks/{instance_id}/actions", withAuthAndPermission(workflowInstanceHandler.GetAvailableActions, models.PermView))
-	http.HandleFunc("OPTIONS /api/tasks/{instance_id}/history", middleware.CORS(func(w http.ResponseWriter, r *http.Request) {}))
-	http.HandleFunc("OPTIONS /api/tasks/user", middleware.CORS(func(w http.ResponseWriter, r *http.Request) {}))
-	http.HandleFunc("GET /api/tasks/user", withAuthAndPermission(workflowInstanceHandler.GetTasksByUser, models.PermView))
-	http.HandleFunc("OPTIONS /api/workflows/{workflow_id}/tasks", middleware.CORS(func(w http.ResponseWriter, r *http.Request) {}))
-	http.HandleFunc("GET /api/workflows/{workflow_id}/tasks", withAuthAndPermission(workflowInstanceHandler.GetTasksByWorkflow, models.PermView))
+	create.handle(http.MethodPost, "/api/tasks", workflowInstanceHandler.StartTask)
+	update.handle(http.MethodPost, "/api/tasks/bulk-execute", workflowInstanceHandler.BulkExecuteAction)
+	view.handle(http.MethodGet, "/api/tasks/{instance_id}", workflowInstanceHandler.GetTask)
+	update.handle(http.MethodPost, "/api/tasks/{instance_id}/execute", workflowInstanceHandler.ExecuteAction)
+	view.handle(http.MethodGet, "/api/tasks/{instance_id}/actions", workflowInstanceHandler.GetAvailableActions)
+	view.handle(http.MethodGet, "/api/tasks/{instance_id}/history", workflowInstanceHandler.GetTaskHistory)
+	update.handle(http.MethodPost, "/api/tasks/{instance_id}/reassign", workflowInstanceHandler.ReassignTask)
+	update.handle(http.MethodPost, "/api/tasks/{instance_id}/claim", workflowInstanceHandler.ClaimTask)
+	update.handle(http.MethodPost, "/api/tasks/{instance_id}/release", workflowInstanceHandler.ReleaseTask)
+	del.handle(http.MethodPost, "/api/tasks/{instance_id}/suspend", workflowAdminHandler.SuspendTask)
+	del.handle(http.MethodPost, "/api/tasks/{instance_id}/resume", workflowAdminHandler.ResumeTask)
+	del.handle(http.MethodPost, "/api/tasks/{instance_id}/cancel", workflowAdminHandler.CancelTask)
+	view.handle(http.MethodGet, "/api/tasks/{instance_id}/votes", workflowInstanceHandler.GetTaskVotes)
+	update.handle(http.MethodGet, "/api/tasks/overdue", workflowInstanceHandler.GetOverdueTasks)
+	view.handle(http.MethodGet, "/api/tasks/queue", workflowInstanceHandler.GetQueue)
+	view.handle(http.MethodGet, "/api/tasks/user", workflowInstanceHandler.GetTasksByUser)
+	update.handle(http.MethodPost, "/api/delegations", workflowInstanceHandler.CreateDelegation)
+	view.handle(http.MethodGet, "/api/delegations", workflowInstanceHandler.GetDelegations)
+	update.handle(http.MethodDelete, "/api/delegations/{id}", workflowInstanceHandler.DeleteDelegation)
+	view.handle(http.MethodGet, "/api/workflows/{workflow_id}/tasks", workflowInstanceHandler.GetTasksByWorkflow)
 }
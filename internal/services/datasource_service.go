@@ -1,18 +1,21 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"todo-api/pkg/apperrors"
 
+	"todo-api/internal/interfaces"
 	"todo-api/internal/models"
-	"todo-api/internal/repository"
 )
 
 type DataSourceService struct {
-	repo *repository.DataSourceRepository
+	repo    interfaces.DataSourceRepositoryInterface
+	metrics *WorkflowMetricsService
 }
 
-func NewDataSourceService(repo *repository.DataSourceRepository) *DataSourceService {
-	return &DataSourceService{repo: repo}
+func NewDataSourceService(repo interfaces.DataSourceRepositoryInterface, metrics *WorkflowMetricsService) *DataSourceService {
+	return &DataSourceService{repo: repo, metrics: metrics}
 }
 
 // GetAllDataSources returns metadata for all available data sources
@@ -32,16 +35,17 @@ func (s *DataSourceService) GetDataSourceByID(id string) *models.DataSourceMetad
 }
 
 // GetDataSourceData fetches data for a specific data source based on widget type
-func (s *DataSourceService) GetDataSourceData(dataSourceID string, widgetType string) (interface{}, error) {
+func (s *DataSourceService) GetDataSourceData(ctx context.Context, dataSourceID string, widgetType string, query models.DataSourceQuery) (interface{}, error) {
 	// Validate widget type
-	if widgetType != "pie_chart" && widgetType != "table" {
-		return nil, fmt.Errorf("invalid widget_type: must be 'pie_chart' or 'table'")
+	if widgetType != "pie_chart" && widgetType != "table" && widgetType != "bar_chart" {
+		return nil, apperrors.Validation("invalid widget_type: must be 'pie_chart', 'bar_chart' or 'table'",
+			apperrors.FieldError{Field: "widget_type", Message: "must be 'pie_chart', 'bar_chart' or 'table'"})
 	}
 
 	// Check if data source exists
 	ds := s.GetDataSourceByID(dataSourceID)
 	if ds == nil {
-		return nil, fmt.Errorf("data source not found: %s", dataSourceID)
+		return nil, apperrors.NotFound("data source not found: %s", dataSourceID)
 	}
 
 	// Check if widget type is compatible
@@ -53,34 +57,47 @@ func (s *DataSourceService) GetDataSourceData(dataSourceID string, widgetType st
 		}
 	}
 	if !isCompatible {
-		return nil, fmt.Errorf("widget type '%s' is not compatible with data source '%s'", widgetType, dataSourceID)
+		return nil, apperrors.Validation(fmt.Sprintf("widget type '%s' is not compatible with data source '%s'", widgetType, dataSourceID),
+			apperrors.FieldError{Field: "widget_type", Message: "is not compatible with the data source"})
+	}
+
+	if ds.RequiresEntity && query.EntityID == "" {
+		return nil, apperrors.Validation(fmt.Sprintf("data source '%s' requires entity_id", dataSourceID),
+			apperrors.FieldError{Field: "entity_id", Message: "is required"})
 	}
 
 	// Fetch data based on data source ID and widget type
 	switch dataSourceID {
 	case "todos_by_priority":
 		if widgetType == "pie_chart" {
-			return s.repo.GetTodosByPriority()
+			return s.repo.GetTodosByPriority(ctx)
 		}
-		return s.repo.GetTodosByPriorityTable()
+		return s.repo.GetTodosByPriorityTable(ctx)
 
 	case "todos_by_status":
 		if widgetType == "pie_chart" {
-			return s.repo.GetTodosByStatus()
+			return s.repo.GetTodosByStatus(ctx)
 		}
-		return s.repo.GetTodosByStatusTable()
+		return s.repo.GetTodosByStatusTable(ctx)
 
 	case "todos_list":
-		return s.repo.GetTodosList()
+		return s.repo.GetTodosList(ctx)
 
 	case "users_by_role":
 		if widgetType == "pie_chart" {
-			return s.repo.GetUsersByRole()
+			return s.repo.GetUsersByRole(ctx)
 		}
-		return s.repo.GetUsersByRoleTable()
+		return s.repo.GetUsersByRoleTable(ctx)
 
 	case "user_activity":
-		return s.repo.GetUserActivity()
+		return s.repo.GetUserActivity(ctx)
+
+	case DataSourceWorkflowCycleTime, DataSourceWorkflowStepDwell, DataSourceWorkflowRework, DataSourceWorkflowThroughput:
+		metrics, err := s.metrics.GetWorkflowMetrics(ctx, query.EntityID, query.From, query.To)
+		if err != nil {
+			return nil, err
+		}
+		return metricsWidget(dataSourceID, widgetType, metrics)
 
 	default:
 		return nil, fmt.Errorf("data source not implemented: %s", dataSourceID)
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+// BackgroundWorker is a worker whose state is reported by the health probes
+type BackgroundWorker interface {
+	Status() models.WorkerStatus
+}
+
+// HealthService checks the database and background workers for the liveness and readiness probes
+type HealthService struct {
+	db       *sql.DB
+	inMemory bool
+	timeout  time.Duration
+	workers  []BackgroundWorker
+}
+
+// NewHealthService creates a health service that pings db with the given deadline
+func NewHealthService(db *sql.DB, timeout time.Duration, workers ...BackgroundWorker) *HealthService {
+	return &HealthService{db: db, timeout: timeout, workers: workers}
+}
+
+// NewMemoryHealthService creates a health service for demo mode, where the data lives
+// in memory and is always reachable
+func NewMemoryHealthService(workers ...BackgroundWorker) *HealthService {
+	return &HealthService{inMemory: true, workers: workers}
+}
+
+// Live reports whether the process is working. Only a stopped background worker makes
+// it unavailable, since restarting the process cannot fix an unreachable database.
+func (s *HealthService) Live(ctx context.Context) *models.HealthResponse {
+	health := s.check(ctx)
+	if stopped := stoppedWorker(health.Workers); stopped != "" {
+		return unavailable(health, stopped+" is not running")
+	}
+	health.Status = models.HealthOK
+	health.Message = "API is alive"
+	return health
+}
+
+// Ready reports whether the API can serve requests: the database answers within the
+// deadline, is migrated and clean, and every background worker is running
+func (s *HealthService) Ready(ctx context.Context) *models.HealthResponse {
+	health := s.check(ctx)
+	switch {
+	case health.Database.Status != models.HealthOK:
+		return unavailable(health, "database is unavailable")
+	case health.Database.InMemory:
+	case health.Database.MigrationVersion == 0:
+		return unavailable(health, "database has no migrations applied")
+	case health.Database.MigrationDirty:
+		return unavailable(health, "database migration is dirty")
+	}
+	if stopped := stoppedWorker(health.Workers); stopped != "" {
+		return unavailable(health, stopped+" is not running")
+	}
+	health.Status = models.HealthOK
+	health.Message = "API is ready"
+	return health
+}
+
+// check pings the database, reads its migration version and collects the worker states
+func (s *HealthService) check(ctx context.Context) *models.HealthResponse {
+	health := &models.HealthResponse{Workers: []models.WorkerStatus{}}
+	for _, worker := range s.workers {
+		health.Workers = append(health.Workers, worker.Status())
+	}
+
+	if s.inMemory {
+		health.Database = models.DatabaseHealth{Status: models.HealthOK, InMemory: true}
+		return health
+	}
+	if s.db == nil {
+		health.Database = models.DatabaseHealth{Status: models.HealthUnavailable, Error: "database is not connected"}
+		return health
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := s.db.PingContext(ctx)
+	health.Database.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		health.Database.Status = models.HealthUnavailable
+		health.Database.Error = err.Error()
+		return health
+	}
+
+	version, dirty, err := database.MigrationVersion(ctx, s.db)
+	if err != nil {
+		health.Database.Status = models.HealthUnavailable
+		health.Database.Error = err.Error()
+		return health
+	}
+	health.Database.Status = models.HealthOK
+	health.Database.MigrationVersion = version
+	health.Database.MigrationDirty = dirty
+	return health
+}
+
+// stoppedWorker returns the name of the first worker that is not running, or ""
+func stoppedWorker(workers []models.WorkerStatus) string {
+	for _, worker := range workers {
+		if !worker.Running {
+			return worker.Name
+		}
+	}
+	return ""
+}
+
+func unavailable(health *models.HealthResponse, message string) *models.HealthResponse {
+	health.Status = models.HealthUnavailable
+	health.Message = message
+	return health
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"todo-api/internal/models"
+	"todo-api/internal/repository"
+)
+
+// fakeWorker reports a fixed worker status
+type fakeWorker struct {
+	status models.WorkerStatus
+}
+
+func (w fakeWorker) Status() models.WorkerStatus {
+	return w.status
+}
+
+// unreachableDB returns a connection pool whose pings are refused
+func unreachableDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 user=nobody dbname=nothing sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestHealthService_DatabaseDown(t *testing.T) {
+	// Arrange
+	worker := fakeWorker{models.WorkerStatus{Name: "sla_scheduler", Running: true}}
+	service := NewHealthService(unreachableDB(t), time.Second, worker)
+
+	// Act
+	live := service.Live(context.Background())
+	ready := service.Ready(context.Background())
+
+	// Assert - the process is alive but cannot serve requests
+	if live.Status != models.HealthOK {
+		t.Errorf("Expected the API to be alive, got %s: %s", live.Status, live.Message)
+	}
+	if ready.Status != models.HealthUnavailable || ready.Database.Error == "" {
+		t.Errorf("Expected the API not to be ready with a database error, got %+v", ready)
+	}
+	if len(ready.Workers) != 1 || !ready.Workers[0].Running {
+		t.Errorf("Expected the worker status to be reported, got %+v", ready.Workers)
+	}
+}
+
+func TestHealthService_WorkerStopped(t *testing.T) {
+	// Arrange
+	worker := fakeWorker{models.WorkerStatus{Name: "sla_scheduler", Running: false}}
+	service := NewHealthService(nil, time.Second, worker)
+
+	// Act
+	live := service.Live(context.Background())
+
+	// Assert
+	if live.Status != models.HealthUnavailable || live.Message != "sla_scheduler is not running" {
+		t.Errorf("Expected the stopped worker to fail the liveness probe, got %s: %s", live.Status, live.Message)
+	}
+}
+
+func TestSLAScheduler_Status(t *testing.T) {
+	// Arrange
+	scheduler := NewSLAScheduler(NewWorkflowEngine(repository.NewMemoryRepositories()), time.Hour)
+
+	// Act
+	scheduler.Start()
+	running := scheduler.Status()
+	scheduler.Stop()
+	stopped := scheduler.Status()
+
+	// Assert
+	if !running.Running || running.Name != "sla_scheduler" {
+		t.Errorf("Expected a running sla_scheduler, got %+v", running)
+	}
+	if stopped.Running {
+		t.Errorf("Expected the scheduler to report it stopped, got %+v", stopped)
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 
 	"todo-api/internal/interfaces"
@@ -18,54 +19,54 @@ func NewRoleService(repo interfaces.RoleRepositoryInterface) *RoleService {
 }
 
 // CreateRole creates a new role in the database
-func (s *RoleService) CreateRole(role *models.Role) error {
-	return s.repo.CreateRole(role)
+func (s *RoleService) CreateRole(ctx context.Context, role *models.Role) error {
+	return s.repo.CreateRole(ctx, role)
 }
 
 // GetRoleByID retrieves a role by its ID
-func (s *RoleService) GetRoleByID(roleID uuid.UUID) (*models.Role, error) {
-	return s.repo.GetRoleByID(roleID)
+func (s *RoleService) GetRoleByID(ctx context.Context, roleID uuid.UUID) (*models.Role, error) {
+	return s.repo.GetRoleByID(ctx, roleID)
 }
 
 // GetRoleByName retrieves a role by its name
-func (s *RoleService) GetRoleByName(name string) (*models.Role, error) {
-	return s.repo.GetRoleByName(name)
+func (s *RoleService) GetRoleByName(ctx context.Context, name string) (*models.Role, error) {
+	return s.repo.GetRoleByName(ctx, name)
 }
 
 // GetAllRoles retrieves all roles from the database
-func (s *RoleService) GetAllRoles() ([]models.Role, error) {
-	return s.repo.GetAllRoles()
+func (s *RoleService) GetAllRoles(ctx context.Context) ([]models.Role, error) {
+	return s.repo.GetAllRoles(ctx)
 }
 
 // UpdateRole updates an existing role
-func (s *RoleService) UpdateRole(role *models.Role) error {
-	return s.repo.UpdateRole(role)
+func (s *RoleService) UpdateRole(ctx context.Context, role *models.Role) error {
+	return s.repo.UpdateRole(ctx, role)
 }
 
 // DeleteRole deletes a role by its ID
-func (s *RoleService) DeleteRole(roleID uuid.UUID) error {
-	return s.repo.DeleteRole(roleID)
+func (s *RoleService) DeleteRole(ctx context.Context, roleID uuid.UUID) error {
+	return s.repo.DeleteRole(ctx, roleID)
 }
 
 // AssignRoleToUser assigns a role to a user
-func (s *RoleService) AssignRoleToUser(userID, roleID uuid.UUID) error {
-	return s.repo.AssignRoleToUser(userID, roleID)
+func (s *RoleService) AssignRoleToUser(ctx context.Context, userID, roleID uuid.UUID) error {
+	return s.repo.AssignRoleToUser(ctx, userID, roleID)
 }
 
 // InitializePredefinedRoles creates the predefined roles if they don't exist
-func (s *RoleService) InitializePredefinedRoles() error {
+func (s *RoleService) InitializePredefinedRoles(ctx context.Context) error {
 	predefinedRoles := models.GetPredefinedRoles()
 
 	for _, role := range predefinedRoles {
 		// Check if role already exists
-		existingRole, err := s.GetRoleByName(role.Name)
+		existingRole, err := s.GetRoleByName(ctx, role.Name)
 		if err == nil && existingRole != nil {
 			// Role exists, skip
 			continue
 		}
 
 		// Create the role
-		err = s.CreateRole(&role)
+		err = s.CreateRole(ctx, &role)
 		if err != nil {
 			return fmt.Errorf("failed to initialize role %s: %w", role.Name, err)
 		}
@@ -75,8 +76,8 @@ func (s *RoleService) InitializePredefinedRoles() error {
 }
 
 // CheckPermission checks if a user has a specific permission
-func (s *RoleService) CheckPermission(userID uuid.UUID, permission string) (bool, error) {
-	permissions, err := s.repo.GetUserPermissions(userID)
+func (s *RoleService) CheckPermission(ctx context.Context, userID uuid.UUID, permission string) (bool, error) {
+	permissions, err := s.repo.GetUserPermissions(ctx, userID)
 	if err != nil {
 		return false, err
 	}
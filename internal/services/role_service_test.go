@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -21,56 +22,56 @@ type MockRoleRepository struct {
 	GetUserPermissionsFunc func(userID uuid.UUID) (*models.Permissions, error)
 }
 
-func (m *MockRoleRepository) CreateRole(role *models.Role) error {
+func (m *MockRoleRepository) CreateRole(ctx context.Context, role *models.Role) error {
 	if m.CreateRoleFunc != nil {
 		return m.CreateRoleFunc(role)
 	}
 	return nil
 }
 
-func (m *MockRoleRepository) GetRoleByID(roleID uuid.UUID) (*models.Role, error) {
+func (m *MockRoleRepository) GetRoleByID(ctx context.Context, roleID uuid.UUID) (*models.Role, error) {
 	if m.GetRoleByIDFunc != nil {
 		return m.GetRoleByIDFunc(roleID)
 	}
 	return nil, nil
 }
 
-func (m *MockRoleRepository) GetRoleByName(name string) (*models.Role, error) {
+func (m *MockRoleRepository) GetRoleByName(ctx context.Context, name string) (*models.Role, error) {
 	if m.GetRoleByNameFunc != nil {
 		return m.GetRoleByNameFunc(name)
 	}
 	return nil, nil
 }
 
-func (m *MockRoleRepository) GetAllRoles() ([]models.Role, error) {
+func (m *MockRoleRepository) GetAllRoles(ctx context.Context) ([]models.Role, error) {
 	if m.GetAllRolesFunc != nil {
 		return m.GetAllRolesFunc()
 	}
 	return []models.Role{}, nil
 }
 
-func (m *MockRoleRepository) UpdateRole(role *models.Role) error {
+func (m *MockRoleRepository) UpdateRole(ctx context.Context, role *models.Role) error {
 	if m.UpdateRoleFunc != nil {
 		return m.UpdateRoleFunc(role)
 	}
 	return nil
 }
 
-func (m *MockRoleRepository) DeleteRole(roleID uuid.UUID) error {
+func (m *MockRoleRepository) DeleteRole(ctx context.Context, roleID uuid.UUID) error {
 	if m.DeleteRoleFunc != nil {
 		return m.DeleteRoleFunc(roleID)
 	}
 	return nil
 }
 
-func (m *MockRoleRepository) AssignRoleToUser(userID, roleID uuid.UUID) error {
+func (m *MockRoleRepository) AssignRoleToUser(ctx context.Context, userID, roleID uuid.UUID) error {
 	if m.AssignRoleToUserFunc != nil {
 		return m.AssignRoleToUserFunc(userID, roleID)
 	}
 	return nil
 }
 
-func (m *MockRoleRepository) GetUserPermissions(userID uuid.UUID) (*models.Permissions, error) {
+func (m *MockRoleRepository) GetUserPermissions(ctx context.Context, userID uuid.UUID) (*models.Permissions, error) {
 	if m.GetUserPermissionsFunc != nil {
 		return m.GetUserPermissionsFunc(userID)
 	}
@@ -100,7 +101,7 @@ func TestRoleService_CheckPermission(t *testing.T) {
 	service := NewRoleService(mockRepo)
 
 	// Act - Test permission user has (view)
-	hasPermission, err := service.CheckPermission(userID, "view")
+	hasPermission, err := service.CheckPermission(context.Background(), userID, "view")
 
 	// Assert
 	if err != nil {
@@ -111,7 +112,7 @@ func TestRoleService_CheckPermission(t *testing.T) {
 	}
 
 	// Test permission user has (create)
-	hasPermission, err = service.CheckPermission(userID, "create")
+	hasPermission, err = service.CheckPermission(context.Background(), userID, "create")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -120,7 +121,7 @@ func TestRoleService_CheckPermission(t *testing.T) {
 	}
 
 	// Test permission user doesn't have (delete)
-	hasPermission, err = service.CheckPermission(userID, "delete")
+	hasPermission, err = service.CheckPermission(context.Background(), userID, "delete")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -145,7 +146,7 @@ func TestRoleService_InitializePredefinedRoles(t *testing.T) {
 	service := NewRoleService(mockRepo)
 
 	// Act
-	err := service.InitializePredefinedRoles()
+	err := service.InitializePredefinedRoles(context.Background())
 
 	// Assert
 	if err != nil {
@@ -199,7 +200,7 @@ func TestRoleService_CreateRole(t *testing.T) {
 	}
 
 	// Act
-	err := service.CreateRole(testRole)
+	err := service.CreateRole(context.Background(), testRole)
 
 	// Assert
 	if err != nil {
@@ -253,7 +254,7 @@ func TestRoleService_GetRoleByID(t *testing.T) {
 	service := NewRoleService(mockRepo)
 
 	// Act
-	role, err := service.GetRoleByID(roleID)
+	role, err := service.GetRoleByID(context.Background(), roleID)
 
 	// Assert
 	if err != nil {
@@ -307,7 +308,7 @@ func TestRoleService_UpdateRole(t *testing.T) {
 	}
 
 	// Act
-	err := service.UpdateRole(testRole)
+	err := service.UpdateRole(context.Background(), testRole)
 
 	// Assert
 	if err != nil {
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+	"todo-api/internal/models"
+	"todo-api/pkg/logging"
+)
+
+// SLAScheduler periodically escalates workflow instances that overstay their step SLA
+type SLAScheduler struct {
+	engine   *WorkflowEngine
+	interval time.Duration
+
+	started bool
+	ctx     context.Context // Cancelled by Stop, which also aborts the queries of a running check
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu      sync.Mutex // Guards the fields below, which are read by the health probes
+	running bool
+	lastRun time.Time
+	lastErr error
+}
+
+// NewSLAScheduler creates a scheduler that checks for overdue instances every interval
+func NewSLAScheduler(engine *WorkflowEngine, interval time.Duration) *SLAScheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SLAScheduler{
+		engine:   engine,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs the scheduler in the background until Stop is called
+func (s *SLAScheduler) Start() {
+	s.started = true
+	s.setRunning(true)
+	go func() {
+		defer close(s.done)
+		defer s.setRunning(false)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.RunOnce(s.ctx)
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop signals the scheduler to exit, cancelling the current run, and waits for it to finish
+func (s *SLAScheduler) Stop() {
+	s.cancel()
+	if s.started {
+		<-s.done
+	}
+}
+
+// RunOnce escalates all currently overdue instances
+func (s *SLAScheduler) RunOnce(ctx context.Context) {
+	escalated, err := s.engine.EscalateOverdue(ctx)
+
+	s.mu.Lock()
+	s.lastRun = time.Now()
+	s.lastErr = err
+	s.mu.Unlock()
+
+	if err != nil {
+		logging.FromContext(ctx).Warn("SLA check failed", "error", err)
+		return
+	}
+	if escalated > 0 {
+		logging.FromContext(ctx).Info("SLA check escalated instances", "escalated", escalated)
+	}
+}
+
+// Status reports whether the scheduler is running and how its last check went
+func (s *SLAScheduler) Status() models.WorkerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := models.WorkerStatus{Name: "sla_scheduler", Running: s.running}
+	if !s.lastRun.IsZero() {
+		lastRun := s.lastRun
+		status.LastRunAt = &lastRun
+	}
+	if s.lastErr != nil {
+		status.LastError = s.lastErr.Error()
+	}
+	return status
+}
+
+func (s *SLAScheduler) setRunning(running bool) {
+	s.mu.Lock()
+	s.running = running
+	s.mu.Unlock()
+}
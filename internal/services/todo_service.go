@@ -1,47 +1,75 @@
 package services
 
 import (
+	"context"
+	"todo-api/internal/interfaces"
 	"todo-api/internal/models"
 	"todo-api/internal/repository"
+	"todo-api/pkg/apperrors"
 )
 
 type TodoService struct {
-	repo *repository.TodoRepository
+	repo         interfaces.TodoRepositoryInterface
+	instanceRepo interfaces.WorkflowInstanceRepositoryInterface
 }
 
 // NewTodoService creates a new todo service with dependency injection
-func NewTodoService(repo *repository.TodoRepository) *TodoService {
+func NewTodoService(repo interfaces.TodoRepositoryInterface, instanceRepo interfaces.WorkflowInstanceRepositoryInterface) *TodoService {
 	return &TodoService{
-		repo: repo,
+		repo:         repo,
+		instanceRepo: instanceRepo,
 	}
 }
 
 // CreateTodo handles todo creation business logic
-func (s *TodoService) CreateTodo(todo *models.Todo) error {
-	return s.repo.Create(todo)
+func (s *TodoService) CreateTodo(ctx context.Context, todo *models.Todo) error {
+	return s.repo.Create(ctx, todo)
 }
 
-// GetTodoByID retrieves a todo by ID
-func (s *TodoService) GetTodoByID(id string) (*models.Todo, error) {
-	return s.repo.GetById(id)
+// GetTodoByID retrieves a todo by ID together with its workflow state
+func (s *TodoService) GetTodoByID(ctx context.Context, id string) (*models.Todo, error) {
+	todo, err := s.repo.GetById(ctx, id)
+	if err != nil || todo == nil {
+		return todo, err
+	}
+
+	todo.Workflow, err = s.instanceRepo.GetTodoWorkflowState(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return todo, nil
 }
 
 // GetTodosByUserID retrieves all todos for a specific user
-func (s *TodoService) GetTodosByUserID(userID string) ([]models.Todo, error) {
-	return s.repo.GetByUserId(userID)
+func (s *TodoService) GetTodosByUserID(ctx context.Context, userID string) ([]models.Todo, error) {
+	return s.repo.GetByUserId(ctx, userID)
 }
 
 // GetAllTodos retrieves all todos
-func (s *TodoService) GetAllTodos() ([]models.Todo, error) {
-	return s.repo.GetAll()
+func (s *TodoService) GetAllTodos(ctx context.Context) ([]models.Todo, error) {
+	return s.repo.GetAll(ctx)
 }
 
 // UpdateTodo handles todo update business logic
-func (s *TodoService) UpdateTodo(id string, todo *models.Todo) (int64, error) {
-	return s.repo.Update(id, todo)
+func (s *TodoService) UpdateTodo(ctx context.Context, id string, todo *models.Todo) (int64, error) {
+	return s.repo.Update(ctx, id, todo)
 }
 
-// DeleteTodo handles todo deletion business logic
-func (s *TodoService) DeleteTodo(id string) (int64, error) {
-	return s.repo.Delete(id)
+// DeleteTodo deletes a todo. Todos that ran through a workflow keep their instances
+// and history, so they cannot be deleted; neither can shared todos.
+func (s *TodoService) DeleteTodo(ctx context.Context, id string) (int64, error) {
+	state, err := s.instanceRepo.GetTodoWorkflowState(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if state != nil {
+		return 0, apperrors.Conflict("todo %s has run through workflow %s and cannot be deleted", id, state.WorkflowName)
+	}
+
+	deleted, err := s.repo.Delete(ctx, id)
+	if repository.IsForeignKeyViolation(err) {
+		return 0, apperrors.Conflict("todo %s is still referenced by a workflow instance or a shared task", id)
+	}
+	return deleted, err
 }
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"todo-api/internal/models"
+	"todo-api/internal/repository"
+	"todo-api/pkg/apperrors"
+
+	"github.com/google/uuid"
+)
+
+func TestDeleteTodo_WithWorkflowInstance(t *testing.T) {
+	// Arrange - one todo ran through the built-in workflow, the other did not
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	service := NewTodoService(repos.Todos, repos.Instances)
+	inWorkflow := &models.Todo{Id: uuid.NewString(), TaskName: "Report", TaskDescription: "Quarterly", UserID: "alice"}
+	plain := &models.Todo{Id: uuid.NewString(), TaskName: "Groceries", TaskDescription: "Milk", UserID: "alice"}
+	for _, todo := range []*models.Todo{inWorkflow, plain} {
+		if err := repos.Todos.Create(ctx, todo); err != nil {
+			t.Fatalf("Failed to create todo: %v", err)
+		}
+	}
+	if _, err := NewWorkflowEngine(repos).StartWorkflow(ctx, models.BuiltinApprovalWorkflowID, inWorkflow.Id, "alice", nil); err != nil {
+		t.Fatalf("Failed to start workflow: %v", err)
+	}
+
+	// Act
+	_, inWorkflowErr := service.DeleteTodo(ctx, inWorkflow.Id)
+	deleted, plainErr := service.DeleteTodo(ctx, plain.Id)
+
+	// Assert - the todo with an instance is kept with a conflict, the other one is deleted
+	if apperrors.KindOf(inWorkflowErr) != apperrors.KindConflict {
+		t.Errorf("Expected a conflict, got %v", inWorkflowErr)
+	}
+	if plainErr != nil || deleted != 1 {
+		t.Errorf("Expected the plain todo to be deleted, got %d, %v", deleted, plainErr)
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todo-api/internal/interfaces"
+	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// TodoWorkflowService keeps the legacy /workflow/todos API working on top of the
+// WorkflowEngine, using the built-in Draft/Review/Approved workflow
+type TodoWorkflowService struct {
+	engine       *WorkflowEngine
+	todoRepo     interfaces.TodoRepositoryInterface
+	instanceRepo interfaces.WorkflowInstanceRepositoryInterface
+	userRepo     interfaces.UserRepositoryInterface
+	txManager    interfaces.TxManagerInterface
+}
+
+// NewTodoWorkflowService creates a new todo workflow service with dependency injection
+func NewTodoWorkflowService(engine *WorkflowEngine, todoRepo interfaces.TodoRepositoryInterface, instanceRepo interfaces.WorkflowInstanceRepositoryInterface, userRepo interfaces.UserRepositoryInterface, txManager interfaces.TxManagerInterface) *TodoWorkflowService {
+	return &TodoWorkflowService{
+		engine:       engine,
+		todoRepo:     todoRepo,
+		instanceRepo: instanceRepo,
+		userRepo:     userRepo,
+		txManager:    txManager,
+	}
+}
+
+// CreateTodoTask creates a todo and starts it in the Draft step
+func (s *TodoWorkflowService) CreateTodoTask(ctx context.Context, task *models.TodoTask) error {
+	var fields []apperrors.FieldError
+	if utf8.RuneCountInString(task.Title) > models.MaxTodoNameLength {
+		fields = append(fields, apperrors.FieldError{Field: "title", Message: fmt.Sprintf("must be at most %d characters", models.MaxTodoNameLength)})
+	}
+	if utf8.RuneCountInString(task.Description) > models.MaxTodoDescriptionLength {
+		fields = append(fields, apperrors.FieldError{Field: "description", Message: fmt.Sprintf("must be at most %d characters", models.MaxTodoDescriptionLength)})
+	}
+	if len(fields) > 0 {
+		return apperrors.Validation("todo task is too long", fields...)
+	}
+
+	assignee, err := s.resolveUser(ctx, task.AssignedTo)
+	if err != nil {
+		return err
+	}
+
+	// Don't leave a todo behind that the legacy API cannot see
+	now := time.Now()
+	todo := &models.Todo{
+		Id:              uuid.New().String(),
+		TaskName:        task.Title,
+		TaskDescription: task.Description,
+		UserID:          assignee,
+	}
+	err = s.txManager.InTx(ctx, func(ctx context.Context) error {
+		if err := s.todoRepo.Create(ctx, todo); err != nil {
+			return fmt.Errorf("failed to create todo: %w", err)
+		}
+		_, err := s.engine.StartWorkflow(ctx, models.BuiltinApprovalWorkflowID, todo.Id, assignee, nil)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	task.ID = todo.Id
+	task.AssignedTo = assignee
+	task.Status = models.StatusDraft
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	return nil
+}
+
+// GetTodosByUser returns all todos assigned to a user
+func (s *TodoWorkflowService) GetTodosByUser(ctx context.Context, userID string) ([]models.TodoTask, error) {
+	assignee, err := s.resolveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.instanceRepo.GetTodoTasksByAssignee(ctx, models.BuiltinApprovalWorkflowID, assignee)
+}
+
+// GetTodosByStatus returns all todos with a specific status
+func (s *TodoWorkflowService) GetTodosByStatus(ctx context.Context, status models.TodoStatus) ([]models.TodoTask, error) {
+	return s.instanceRepo.GetTodoTasksByStep(ctx, models.BuiltinApprovalWorkflowID, string(status))
+}
+
+// SubmitForReview moves a draft todo to Review
+func (s *TodoWorkflowService) SubmitForReview(ctx context.Context, todoID string, submittedBy string) error {
+	return s.execute(ctx, todoID, models.ActionSubmit, submittedBy)
+}
+
+// ApproveTodo moves a todo in review to Approved
+func (s *TodoWorkflowService) ApproveTodo(ctx context.Context, todoID string, approvedBy string) error {
+	return s.execute(ctx, todoID, models.ActionApprove, approvedBy)
+}
+
+// RejectTodo sends a todo in review back to Draft
+func (s *TodoWorkflowService) RejectTodo(ctx context.Context, todoID string, rejectedBy string) error {
+	return s.execute(ctx, todoID, models.ActionReject, rejectedBy)
+}
+
+// execute runs an action on the open built-in workflow instance of a todo
+func (s *TodoWorkflowService) execute(ctx context.Context, todoID, action, userID string) error {
+	instance, err := s.instanceRepo.GetOpenInstanceByTodo(ctx, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to get todo: %w", err)
+	}
+	if instance == nil || instance.WorkflowId != models.BuiltinApprovalWorkflowID {
+		return apperrors.NotFound("todo not found or already approved")
+	}
+
+	actor, err := s.resolveUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.engine.ExecuteTransition(ctx, instance.ID, action, actor, "", nil, nil)
+	return err
+}
+
+// resolveUser accepts a user ID or, as the old API allowed free-form names, a username
+func (s *TodoWorkflowService) resolveUser(ctx context.Context, idOrUsername string) (string, error) {
+	if _, err := uuid.Parse(idOrUsername); err == nil {
+		if user, err := s.userRepo.GetUserByID(ctx, idOrUsername); err == nil && user != nil {
+			return user.UserID.String(), nil
+		}
+	}
+
+	user, err := s.userRepo.GetUserByUsername(ctx, idOrUsername)
+	if err != nil || user == nil {
+		return "", apperrors.NotFound("user %s not found", idOrUsername)
+	}
+	return user.UserID.String(), nil
+}
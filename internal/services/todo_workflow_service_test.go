@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"todo-api/internal/models"
+	"todo-api/internal/repository"
+	"todo-api/pkg/apperrors"
+
+	"github.com/google/uuid"
+)
+
+func TestTodoWorkflowService_ApprovalInMemory(t *testing.T) {
+	// Arrange - the whole stack over the in-memory repositories
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	service := NewTodoWorkflowService(NewWorkflowEngine(repos), repos.Todos, repos.Instances, repos.Users, repos.Tx)
+	user := &models.User{UserID: uuid.New(), Username: "alice", Email: "alice@example.com", IsActive: true}
+	if err := repos.Users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	task := &models.TodoTask{Title: "Write report", Description: "Quarterly numbers", AssignedTo: "alice"}
+
+	// Act
+	if err := service.CreateTodoTask(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if err := service.SubmitForReview(ctx, task.ID, "alice"); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	if err := service.ApproveTodo(ctx, task.ID, user.UserID.String()); err != nil {
+		t.Fatalf("Failed to approve: %v", err)
+	}
+
+	// Assert - the task is approved and finishing the workflow completed the todo
+	approved, err := service.GetTodosByStatus(ctx, models.StatusApproved)
+	if err != nil {
+		t.Fatalf("Failed to list approved tasks: %v", err)
+	}
+	if len(approved) != 1 || approved[0].ID != task.ID {
+		t.Errorf("Expected the task to be approved, got %+v", approved)
+	}
+	todo, err := repos.Todos.GetById(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("Failed to get todo: %v", err)
+	}
+	if !todo.Completed {
+		t.Errorf("Expected the todo to be completed")
+	}
+}
+
+func TestTodoWorkflowService_RejectInMemory(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	service := NewTodoWorkflowService(NewWorkflowEngine(repos), repos.Todos, repos.Instances, repos.Users, repos.Tx)
+	user := &models.User{UserID: uuid.New(), Username: "bob", Email: "bob@example.com", IsActive: true}
+	if err := repos.Users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	task := &models.TodoTask{Title: "Draft", Description: "Needs work", AssignedTo: "bob"}
+	if err := service.CreateTodoTask(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if err := service.SubmitForReview(ctx, task.ID, "bob"); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+
+	// Act
+	err := service.RejectTodo(ctx, task.ID, "bob")
+
+	// Assert - the task is back in Draft and can be submitted again
+	if err != nil {
+		t.Fatalf("Failed to reject: %v", err)
+	}
+	drafts, err := service.GetTodosByStatus(ctx, models.StatusDraft)
+	if err != nil {
+		t.Fatalf("Failed to list drafts: %v", err)
+	}
+	if len(drafts) != 1 || drafts[0].ID != task.ID {
+		t.Errorf("Expected the task back in Draft, got %+v", drafts)
+	}
+	if err := service.SubmitForReview(ctx, task.ID, "bob"); err != nil {
+		t.Errorf("Expected the task to be submitted again, got %v", err)
+	}
+}
+
+func TestTodoWorkflowService_CreateTooLong(t *testing.T) {
+	// Arrange - titles and descriptions longer than the todos columns
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	service := NewTodoWorkflowService(NewWorkflowEngine(repos), repos.Todos, repos.Instances, repos.Users, repos.Tx)
+	user := &models.User{UserID: uuid.New(), Username: "carol", Email: "carol@example.com", IsActive: true}
+	if err := repos.Users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		task    *models.TodoTask
+		wantErr bool
+	}{
+		{"longest allowed", &models.TodoTask{Title: strings.Repeat("t", models.MaxTodoNameLength), Description: strings.Repeat("d", models.MaxTodoDescriptionLength), AssignedTo: "carol"}, false},
+		{"title too long", &models.TodoTask{Title: strings.Repeat("t", models.MaxTodoNameLength+1), AssignedTo: "carol"}, true},
+		{"description too long", &models.TodoTask{Title: "Report", Description: strings.Repeat("d", models.MaxTodoDescriptionLength+1), AssignedTo: "carol"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := service.CreateTodoTask(ctx, tt.task)
+
+			// Assert
+			if tt.wantErr && apperrors.KindOf(err) != apperrors.KindValidation {
+				t.Errorf("Expected a validation error, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
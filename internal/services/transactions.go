@@ -0,0 +1,18 @@
+package services
+
+import (
+	"context"
+	"todo-api/internal/interfaces"
+)
+
+// inTx runs fn in a transaction of txManager and returns its result. Retries start
+// fn over, so it must load what it changes inside the transaction.
+func inTx[T any](ctx context.Context, txManager interfaces.TxManagerInterface, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := txManager.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	return result, err
+}
@@ -1,48 +1,36 @@
 package services
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"todo-api/internal/interfaces"
+	"todo-api/internal/metrics"
 	"todo-api/internal/models"
-	"todo-api/internal/repository"
+	"todo-api/pkg/apperrors"
 	"todo-api/pkg/utils"
 
 	"github.com/google/uuid"
 )
 
 type UserService struct {
-	repo     *repository.UserRepository
-	roleRepo *repository.RoleRepository
+	repo      interfaces.UserRepositoryInterface
+	roleRepo  interfaces.RoleRepositoryInterface
+	txManager interfaces.TxManagerInterface
 }
 
 // NewUserService creates a new user service with dependency injection
-func NewUserService(repo *repository.UserRepository) *UserService {
+func NewUserService(repo interfaces.UserRepositoryInterface, roleRepo interfaces.RoleRepositoryInterface, txManager interfaces.TxManagerInterface) *UserService {
 	return &UserService{
-		repo:     repo,
-		roleRepo: repository.NewRoleRepository(),
+		repo:      repo,
+		roleRepo:  roleRepo,
+		txManager: txManager,
 	}
 }
 
-// Register handles user registration business logic
-func (s *UserService) Register(user *models.User) error {
-	// Check if username already exists
-	exists, err := s.repo.UserExists(user.Username)
-	if err != nil {
-		return err
-	}
-	if exists {
-		return errors.New("username already exists")
-	}
-
-	// Check if email already exists
-	emailExists, err := s.repo.EmailExists(user.Email)
-	if err != nil {
-		return err
-	}
-	if emailExists {
-		return errors.New("email already exists")
-	}
-
+// Register handles user registration business logic. The uniqueness checks and the
+// insert run in one serializable transaction, so concurrent registrations of the
+// same username or email cannot both pass the checks.
+func (s *UserService) Register(ctx context.Context, user *models.User) error {
 	// Hash the password
 	hashedPassword, err := utils.HashPassword(user.Password)
 	if err != nil {
@@ -56,36 +44,66 @@ func (s *UserService) Register(user *models.User) error {
 	// Set user as active by default
 	user.IsActive = true
 
-	// Auto-assign default "User" role if no role is set
-	if user.RoleID == nil {
-		defaultRole, err := s.roleRepo.GetRoleByName(models.RoleUser)
-		if err == nil && defaultRole != nil {
-			user.RoleID = &defaultRole.RoleId
+	return s.txManager.InSerializableTx(ctx, func(ctx context.Context) error {
+		// Check if username already exists
+		exists, err := s.repo.UserExists(ctx, user.Username)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return apperrors.Conflict("username already exists")
+		}
+
+		// Check if email already exists
+		emailExists, err := s.repo.EmailExists(ctx, user.Email)
+		if err != nil {
+			return err
+		}
+		if emailExists {
+			return apperrors.Conflict("email already exists")
+		}
+
+		// Auto-assign default "User" role if no role is set
+		if user.RoleID == nil {
+			defaultRole, err := s.roleRepo.GetRoleByName(ctx, models.RoleUser)
+			if err == nil && defaultRole != nil {
+				user.RoleID = &defaultRole.RoleId
+			}
+			// If default role doesn't exist, user will be created without a role
+			// They can be assigned a role later by an admin
 		}
-		// If default role doesn't exist, user will be created without a role
-		// They can be assigned a role later by an admin
-	}
 
-	// Create the user
-	return s.repo.CreateUser(user)
+		// Create the user
+		return s.repo.CreateUser(ctx, user)
+	})
+}
+
+// Login handles user authentication business logic and counts the attempt
+func (s *UserService) Login(ctx context.Context, email, password string) (map[string]interface{}, error) {
+	response, err := s.login(ctx, email, password)
+	if err != nil {
+		metrics.Logins.Inc(metrics.LoginFailure)
+		return nil, err
+	}
+	metrics.Logins.Inc(metrics.LoginSuccess)
+	return response, nil
 }
 
-// Login handles user authentication business logic
-func (s *UserService) Login(email, password string) (map[string]interface{}, error) {
+func (s *UserService) login(ctx context.Context, email, password string) (map[string]interface{}, error) {
 	// Get user by email
-	user, err := s.repo.GetUserByEmail(email)
+	user, err := s.repo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, apperrors.Unauthorized("invalid credentials")
 	}
 
 	// Check if user account is active
 	if !user.IsActive {
-		return nil, errors.New("account is inactive, please contact administrator")
+		return nil, apperrors.Forbidden("account is inactive, please contact administrator")
 	}
 
 	// Verify password
 	if utils.ComparePasswords(user.Password, password) != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, apperrors.Unauthorized("invalid credentials")
 	}
 
 	// Generate JWT token
@@ -107,45 +125,44 @@ func (s *UserService) Login(email, password string) (map[string]interface{}, err
 }
 
 // GetAllUsers retrieves all users
-func (s *UserService) GetAllUsers() ([]models.User, error) {
-	return s.repo.GetAllUsers()
+func (s *UserService) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	return s.repo.GetAllUsers(ctx)
 }
 
 // GetUserByID retrieves a user by ID
-func (s *UserService) GetUserByID(id interface{}) (*models.User, error) {
-	return s.repo.GetUserByID(id)
+func (s *UserService) GetUserByID(ctx context.Context, id interface{}) (*models.User, error) {
+	return s.repo.GetUserByID(ctx, id)
 }
 
 // GetUserByRoleId retrieves a user by role ID
-func (s *UserService) GetUserByRoleId(roleId uuid.UUID) (*models.User, error) {
-	return s.repo.GetUsersByRoleId(roleId)
+func (s *UserService) GetUserByRoleId(ctx context.Context, roleId uuid.UUID) (*models.User, error) {
+	return s.repo.GetUsersByRoleId(ctx, roleId)
 }
 
 // UpdateUser handles user update business logic with validation
-func (s *UserService) UpdateUser(updates *models.User) (*models.User, error) {
+func (s *UserService) UpdateUser(ctx context.Context, updates *models.User) (*models.User, error) {
 
 	// Validate email uniqueness if being changed
 	if updates.Email != "" {
-		existingUser, err := s.repo.GetUserByEmail(updates.Email)
-		fmt.Println("existing user", existingUser)
+		existingUser, err := s.repo.GetUserByEmail(ctx, updates.Email)
 		if err == nil && existingUser.UserID != updates.UserID {
-			return nil, errors.New("email already in use by another user")
+			return nil, apperrors.Conflict("email already in use by another user")
 		}
 	}
 
 	// Validate username uniqueness if being changed
 	if updates.Username != "" {
-		existingUser, err := s.repo.GetUserByUsername(updates.Username)
+		existingUser, err := s.repo.GetUserByUsername(ctx, updates.Username)
 		if err == nil && existingUser.UserID != updates.UserID {
-			return nil, errors.New("username already in use by another user")
+			return nil, apperrors.Conflict("username already in use by another user")
 		}
 	}
 
 	// Update the user
-	return s.repo.UpdateUser(updates)
+	return s.repo.UpdateUser(ctx, updates)
 }
 
 // DeleteUser deletes a user by ID
-func (s *UserService) DeleteUser(id int) error {
-	return s.repo.DeleteUser(id)
+func (s *UserService) DeleteUser(ctx context.Context, id int) error {
+	return s.repo.DeleteUser(ctx, id)
 }
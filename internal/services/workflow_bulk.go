@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+)
+
+// MaxBulkInstances limits the number of instances a single bulk action may touch
+const MaxBulkInstances = 100
+
+// errBulkItemFailed undoes the writes of one failed instance of an all-or-nothing run
+var errBulkItemFailed = errors.New("bulk item failed")
+
+// errBulkRolledBack rolls back an all-or-nothing run in which some instances failed
+var errBulkRolledBack = errors.New("rolled back because other instances failed")
+
+// BulkExecuteTransition executes the same action on many instances. Every instance goes
+// through ExecuteTransition, so conditions, forms, votes and automatic routing apply as usual.
+// In best_effort mode each instance is applied on its own. In all_or_nothing mode the actions
+// run in one transaction that is only committed when all of them succeed; every instance is
+// still tried so the result names each failure.
+func (e *WorkflowEngine) BulkExecuteTransition(ctx context.Context, instanceIDs []string, actionName, userID, comments string, data map[string]interface{}, mode string) (*models.BulkExecuteResult, error) {
+	if mode == "" {
+		mode = models.BulkBestEffort
+	}
+	if err := validateBulkRequest(instanceIDs, mode); err != nil {
+		return nil, err
+	}
+
+	if mode == models.BulkAllOrNothing {
+		return e.bulkAllOrNothing(ctx, instanceIDs, actionName, userID, comments, data)
+	}
+
+	result := &models.BulkExecuteResult{Mode: mode, Results: []models.BulkInstanceResult{}}
+	for _, instanceID := range instanceIDs {
+		item := executeBulkItem(ctx, e, instanceID, actionName, userID, comments, data)
+		countBulkItem(result, item)
+		result.Results = append(result.Results, item)
+	}
+	return result, nil
+}
+
+// bulkAllOrNothing runs every action in one transaction. A nested transaction around each
+// instance undoes a failed action, including any database error it caused, so the remaining
+// instances are still evaluated before the whole transaction is rolled back.
+func (e *WorkflowEngine) bulkAllOrNothing(ctx context.Context, instanceIDs []string, actionName, userID, comments string, data map[string]interface{}) (*models.BulkExecuteResult, error) {
+	var result *models.BulkExecuteResult
+	err := e.txManager.InTx(ctx, func(ctx context.Context) error {
+		// Built inside the transaction so a retried run starts over
+		result = &models.BulkExecuteResult{Mode: models.BulkAllOrNothing, Results: []models.BulkInstanceResult{}}
+		for _, instanceID := range instanceIDs {
+			var item models.BulkInstanceResult
+			err := e.txManager.InTx(ctx, func(ctx context.Context) error {
+				item = executeBulkItem(ctx, e, instanceID, actionName, userID, comments, data)
+				if !item.Applied {
+					return errBulkItemFailed
+				}
+				return nil
+			})
+			if err != nil && !errors.Is(err, errBulkItemFailed) {
+				return err
+			}
+			countBulkItem(result, item)
+			result.Results = append(result.Results, item)
+		}
+		if result.Failed > 0 {
+			return errBulkRolledBack
+		}
+		return nil
+	})
+
+	if errors.Is(err, errBulkRolledBack) {
+		rollBackBulk(result, errBulkRolledBack.Error())
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("bulk action rolled back: %w", err)
+	}
+	return result, nil
+}
+
+// executeBulkItem runs the action on one instance and collects the history entries it writes
+func executeBulkItem(ctx context.Context, engine *WorkflowEngine, instanceID, actionName, userID, comments string, data map[string]interface{}) models.BulkInstanceResult {
+	itemEngine := *engine
+	history := []*models.WorkflowHistory{}
+	itemEngine.recorded = &history
+
+	item := models.BulkInstanceResult{InstanceID: instanceID}
+	result, err := itemEngine.ExecuteTransition(ctx, instanceID, actionName, userID, comments, data, nil)
+	if err != nil {
+		item.Error = err.Error()
+		item.Conflict = errors.Is(err, ErrInstanceConflict)
+		return item
+	}
+
+	item.Applied = true
+	item.Result = result
+	item.History = history
+	return item
+}
+
+// countBulkItem adds an item to the applied or failed count
+func countBulkItem(result *models.BulkExecuteResult, item models.BulkInstanceResult) {
+	if item.Applied {
+		result.Applied++
+	} else {
+		result.Failed++
+	}
+}
+
+// rollBackBulk marks the actions of a rolled back all-or-nothing run as not applied
+func rollBackBulk(result *models.BulkExecuteResult, reason string) {
+	result.RolledBack = true
+	result.Applied = 0
+	for i := range result.Results {
+		item := &result.Results[i]
+		if item.Applied {
+			item.Applied = false
+			item.Error = reason
+			item.Result = nil
+			item.History = nil
+		}
+	}
+}
+
+// validateBulkRequest checks the mode and instance list of a bulk action
+func validateBulkRequest(instanceIDs []string, mode string) error {
+	if mode != models.BulkAllOrNothing && mode != models.BulkBestEffort {
+		return bulkFieldError("mode", fmt.Sprintf("must be %q or %q", models.BulkAllOrNothing, models.BulkBestEffort))
+	}
+	if len(instanceIDs) == 0 {
+		return bulkFieldError("instance_ids", "must not be empty")
+	}
+	if len(instanceIDs) > MaxBulkInstances {
+		return bulkFieldError("instance_ids", fmt.Sprintf("must not list more than %d instances, got %d", MaxBulkInstances, len(instanceIDs)))
+	}
+
+	seen := map[string]bool{}
+	for _, id := range instanceIDs {
+		if id == "" {
+			return bulkFieldError("instance_ids", "must not contain empty IDs")
+		}
+		if seen[id] {
+			return bulkFieldError("instance_ids", fmt.Sprintf("lists instance %s more than once", id))
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// bulkFieldError reports an invalid field of a bulk request
+func bulkFieldError(field, message string) error {
+	return apperrors.Validation(field+" "+message, apperrors.FieldError{Field: field, Message: message})
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"todo-api/internal/models"
+)
+
+func TestValidateBulkRequest(t *testing.T) {
+	tooMany := make([]string, MaxBulkInstances+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("instance-%d", i)
+	}
+
+	tests := []struct {
+		name    string
+		ids     []string
+		mode    string
+		wantErr bool
+	}{
+		{"best effort", []string{"a", "b"}, models.BulkBestEffort, false},
+		{"all or nothing", []string{"a"}, models.BulkAllOrNothing, false},
+		{"unknown mode", []string{"a"}, "some", true},
+		{"empty list", nil, models.BulkBestEffort, true},
+		{"duplicate", []string{"a", "b", "a"}, models.BulkBestEffort, true},
+		{"empty id", []string{"a", ""}, models.BulkBestEffort, true},
+		{"too many", tooMany, models.BulkBestEffort, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := validateBulkRequest(tt.ids, tt.mode)
+
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error: %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRollBackBulk(t *testing.T) {
+	// Arrange - one action succeeded, one failed
+	result := &models.BulkExecuteResult{
+		Applied: 1,
+		Failed:  1,
+		Results: []models.BulkInstanceResult{
+			{InstanceID: "a", Applied: true, Result: &models.TransitionResult{}, History: []*models.WorkflowHistory{{}}},
+			{InstanceID: "b", Error: "user not authorized to perform this action"},
+		},
+	}
+
+	// Act
+	rollBackBulk(result, "rolled back")
+
+	// Assert - nothing is applied and the original failure is kept
+	if !result.RolledBack || result.Applied != 0 || result.Failed != 1 {
+		t.Errorf("Expected a rolled back result with one failure, got %+v", result)
+	}
+	if a := result.Results[0]; a.Applied || a.Result != nil || a.History != nil || a.Error != "rolled back" {
+		t.Errorf("Expected the successful action to be rolled back, got %+v", a)
+	}
+	if b := result.Results[1]; b.Error != "user not authorized to perform this action" {
+		t.Errorf("Expected the failure to be kept, got %q", b.Error)
+	}
+}
+
+func TestBulkExecuteTransition_AllOrNothing(t *testing.T) {
+	// Arrange - two open instances and one that is already approved
+	f := newApprovalFixture(t)
+	first := f.startInstance(t)
+	second := f.startInstance(t)
+	done := f.startInstance(t)
+	if _, err := f.engine.ExecuteTransition(context.Background(), done.ID, "approve", "reviewer", "", nil, nil); err != nil {
+		t.Fatalf("Failed to approve: %v", err)
+	}
+	instanceRepo := f.repos.Instances
+
+	// Act
+	result, err := f.engine.BulkExecuteTransition(context.Background(), []string{first.ID, done.ID, second.ID}, "approve", "reviewer", "", nil, models.BulkAllOrNothing)
+
+	// Assert - the completed instance fails the run and nothing is applied
+	if err != nil {
+		t.Fatalf("Expected a result, got %v", err)
+	}
+	if !result.RolledBack || result.Applied != 0 || result.Failed != 1 {
+		t.Fatalf("Expected a rolled back run with one failure, got %+v", result)
+	}
+	for _, id := range []string{first.ID, second.ID} {
+		instance, err := instanceRepo.GetInstance(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Failed to get instance: %v", err)
+		}
+		if instance.Status != models.InstanceActive || instance.CurrentStepId == f.approved.ID {
+			t.Errorf("Expected instance %s to be untouched, got %s in %s", id, instance.Status, instance.CurrentStepId)
+		}
+	}
+
+	// Act - without the completed instance every action is applied
+	result, err = f.engine.BulkExecuteTransition(context.Background(), []string{first.ID, second.ID}, "approve", "reviewer", "", nil, models.BulkAllOrNothing)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected a result, got %v", err)
+	}
+	if result.RolledBack || result.Applied != 2 {
+		t.Fatalf("Expected both actions to be applied, got %+v", result)
+	}
+	for _, item := range result.Results {
+		if len(item.History) != 1 || item.History[0].ActionTaken != "approve" {
+			t.Errorf("Expected the approve history entry for %s, got %+v", item.InstanceID, item.History)
+		}
+		instance, err := instanceRepo.GetInstance(context.Background(), item.InstanceID)
+		if err != nil {
+			t.Fatalf("Failed to get instance: %v", err)
+		}
+		if instance.Status != models.InstanceCompleted {
+			t.Errorf("Expected instance %s to be completed, got %s", item.InstanceID, instance.Status)
+		}
+	}
+}
+
+func TestBulkExecuteTransition_BestEffort(t *testing.T) {
+	// Arrange
+	f := newApprovalFixture(t)
+	open := f.startInstance(t)
+	done := f.startInstance(t)
+	if _, err := f.engine.ExecuteTransition(context.Background(), done.ID, "approve", "reviewer", "", nil, nil); err != nil {
+		t.Fatalf("Failed to approve: %v", err)
+	}
+
+	// Act
+	result, err := f.engine.BulkExecuteTransition(context.Background(), []string{open.ID, done.ID}, "approve", "reviewer", "", nil, models.BulkBestEffort)
+
+	// Assert - the open instance is approved despite the failure
+	if err != nil {
+		t.Fatalf("Expected a result, got %v", err)
+	}
+	if result.RolledBack || result.Applied != 1 || result.Failed != 1 {
+		t.Fatalf("Expected one applied and one failed action, got %+v", result)
+	}
+	if !result.Results[0].Applied || result.Results[1].Applied {
+		t.Errorf("Expected results in request order, got %+v", result.Results)
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+	"todo-api/pkg/logging"
+
+	"github.com/google/uuid"
+)
+
+// ReassignTask hands an instance over to another user.
+// Only the current assignee or one of their active delegates may reassign,
+// unless override is set (e.g. for administrators).
+func (e *WorkflowEngine) ReassignTask(ctx context.Context, instanceID, assignedTo, performedBy, comments string, override bool) (*models.AssignedTodo, error) {
+	return inTx(ctx, e.txManager, func(ctx context.Context) (*models.AssignedTodo, error) {
+		return e.reassignTask(ctx, instanceID, assignedTo, performedBy, comments, override)
+	})
+}
+
+func (e *WorkflowEngine) reassignTask(ctx context.Context, instanceID, assignedTo, performedBy, comments string, override bool) (*models.AssignedTodo, error) {
+	instance, err := e.instanceRepo.GetInstance(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("instance not found: %w", err)
+	}
+	if instance.IsClosed() {
+		return nil, apperrors.Conflict("cannot reassign a %s instance", instance.Status)
+	}
+
+	if !override && !e.actsFor(ctx, instance.AssignedTo, performedBy) {
+		return nil, apperrors.Forbidden("user not authorized to reassign this task")
+	}
+	if instance.AssignedTo == assignedTo {
+		return nil, apperrors.Conflict("task is already assigned to %s", assignedTo)
+	}
+	if _, err := e.userRepo.GetUserByID(ctx, assignedTo); err != nil {
+		return nil, apperrors.Invalid("assignee not found: %w", err)
+	}
+
+	previous := instance.AssignedTo
+	if err := e.reassign(ctx, instance, assignedTo); err != nil {
+		return nil, err
+	}
+
+	reassignComment := "reassigned from " + previous + " to " + assignedTo
+	if comments != "" {
+		reassignComment += ": " + comments
+	}
+	stepID := instance.CurrentStepId
+	if err := e.recordHistory(ctx, instance.ID, &stepID, stepID, "reassigned", performedBy, reassignComment); err != nil {
+		return nil, err
+	}
+
+	return instance, nil
+}
+
+// GetTasksForUser returns the instances assigned to a user together with the
+// instances of everyone currently delegating to them
+func (e *WorkflowEngine) GetTasksForUser(ctx context.Context, userID string) ([]*models.AssignedTodo, error) {
+	delegatorIDs, err := e.delegateRepo.GetActiveDelegatorIDs(ctx, userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delegations: %w", err)
+	}
+
+	instances, err := e.instanceRepo.GetInstancesByAssignees(ctx, append([]string{userID}, delegatorIDs...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+	return instances, nil
+}
+
+// CreateDelegation lets the delegate act on the delegator's tasks between startsAt and endsAt
+func (e *WorkflowEngine) CreateDelegation(ctx context.Context, delegatorID, delegateID string, startsAt, endsAt time.Time, reason string) (*models.WorkflowDelegation, error) {
+	if delegatorID == delegateID {
+		return nil, apperrors.Validation("cannot delegate to yourself", apperrors.FieldError{Field: "delegate_id", Message: "must not be the delegator"})
+	}
+	if !endsAt.After(startsAt) {
+		return nil, apperrors.Validation("ends_at must be after starts_at", apperrors.FieldError{Field: "ends_at", Message: "must be after starts_at"})
+	}
+	if _, err := e.userRepo.GetUserByID(ctx, delegateID); err != nil {
+		return nil, apperrors.Invalid("delegate not found: %w", err)
+	}
+
+	delegation := &models.WorkflowDelegation{
+		ID:          uuid.New().String(),
+		DelegatorID: delegatorID,
+		DelegateID:  delegateID,
+		StartsAt:    startsAt,
+		EndsAt:      endsAt,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+	}
+	if err := e.delegateRepo.CreateDelegation(ctx, delegation); err != nil {
+		return nil, fmt.Errorf("failed to create delegation: %w", err)
+	}
+	return delegation, nil
+}
+
+// GetDelegations returns the delegations a user gave or received
+func (e *WorkflowEngine) GetDelegations(ctx context.Context, userID string) ([]*models.WorkflowDelegation, error) {
+	return e.delegateRepo.GetDelegationsByUser(ctx, userID)
+}
+
+// DeleteDelegation removes a delegation; only its delegator may do so unless override is set
+func (e *WorkflowEngine) DeleteDelegation(ctx context.Context, delegationID, userID string, override bool) error {
+	delegation, err := e.delegateRepo.GetDelegation(ctx, delegationID)
+	if err != nil {
+		return err
+	}
+	if !override && delegation.DelegatorID != userID {
+		return apperrors.Forbidden("only the delegator can remove a delegation")
+	}
+	return e.delegateRepo.DeleteDelegation(ctx, delegationID)
+}
+
+// actsFor reports whether userID may act as assignee, either directly or through
+// an active delegation. Delegations are not transitive.
+func (e *WorkflowEngine) actsFor(ctx context.Context, assignee, userID string) bool {
+	if assignee == userID {
+		return true
+	}
+
+	delegated, err := e.delegateRepo.IsActiveDelegate(ctx, assignee, userID, time.Now())
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to check delegation", "assignee", assignee, "user_id", userID, "error", err)
+		return false
+	}
+	return delegated
+}
@@ -1,151 +1,525 @@
 package services
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"todo-api/internal/interfaces"
+	"todo-api/internal/metrics"
 	"todo-api/internal/models"
 	"todo-api/internal/repository"
+	"todo-api/pkg/apperrors"
+	"todo-api/pkg/logging"
 
 	"github.com/google/uuid"
 )
 
+// ErrTodoHasOpenInstance is returned when a workflow is started for a todo that is already running through one
+var ErrTodoHasOpenInstance = apperrors.Conflict("todo already has an active workflow instance")
+
+// ErrAssigneeRequired is returned when a workflow whose start step is not a work queue is started without an assignee
+var ErrAssigneeRequired = apperrors.Validation("assigned_to is required unless the start step is a work queue",
+	apperrors.FieldError{Field: "assigned_to", Message: "is required unless the start step is a work queue"})
+
+// ErrInstanceConflict is returned when an instance changed between reading and writing it,
+// or no longer matches the caller's precondition
+var ErrInstanceConflict = repository.ErrVersionConflict
+
 type WorkflowEngine struct {
-	workflowRepo *repository.WorkflowRepository
-	instanceRepo *repository.WorkflowInstanceRepository
+	workflowRepo interfaces.WorkflowRepositoryInterface
+	instanceRepo interfaces.WorkflowInstanceRepositoryInterface
+	voteRepo     interfaces.WorkflowVoteRepositoryInterface
+	delegateRepo interfaces.WorkflowDelegationRepositoryInterface
+	userRepo     interfaces.UserRepositoryInterface
+	roleRepo     interfaces.RoleRepositoryInterface
+	todoRepo     interfaces.TodoRepositoryInterface
+	txManager    interfaces.TxManagerInterface
+
+	recorded    *[]*models.WorkflowHistory // Collects the history written by one bulk item, nil otherwise
+	namedActors bool                       // Users are looked up by any ID, as simulated actors are named
 }
 
-func NewWorkflowEngine() *WorkflowEngine {
+// NewWorkflowEngine returns an engine over the given repositories, whose writes it
+// runs in transactions of repos.Tx
+func NewWorkflowEngine(repos *repository.Repositories) *WorkflowEngine {
 	return &WorkflowEngine{
-		workflowRepo: repository.NewWorkflowRepository(),
-		instanceRepo: repository.NewWorkflowInstanceRepository(),
+		workflowRepo: repos.Workflows,
+		instanceRepo: repos.Instances,
+		voteRepo:     repos.Votes,
+		delegateRepo: repos.Delegations,
+		userRepo:     repos.Users,
+		roleRepo:     repos.Roles,
+		todoRepo:     repos.Todos,
+		txManager:    repos.Tx,
+	}
+}
+
+// StartWorkflow creates a new workflow instance for a todo at the start step.
+// The payload holds the initial business data of the instance and may be nil.
+func (e *WorkflowEngine) StartWorkflow(ctx context.Context, workflowID, todoID, assignedTo string, payload map[string]interface{}) (*models.AssignedTodo, error) {
+	return inTx(ctx, e.txManager, func(ctx context.Context) (*models.AssignedTodo, error) {
+		return e.startInstance(ctx, workflowID, todoID, assignedTo, payload, nil)
+	})
+}
+
+// startInstance creates an instance, as a child of parentID when a subworkflow step starts it,
+// and enters its start step
+func (e *WorkflowEngine) startInstance(ctx context.Context, workflowID, todoID, assignedTo string, payload map[string]interface{}, parentID *string) (*models.AssignedTodo, error) {
+	instance, startStep, err := e.createInstance(ctx, workflowID, todoID, assignedTo, payload, parentID)
+	if err != nil {
+		return nil, err
 	}
+	if err := e.enterStartStep(ctx, instance, startStep); err != nil {
+		return nil, err
+	}
+	return instance, nil
 }
 
-// StartWorkflow creates a new workflow instance at the start step
-func (e *WorkflowEngine) StartWorkflow(workflowID, assignedTo string) (*models.AssignedTodo, error) {
+// enterStartStep starts the children of a subworkflow start step and follows automatic transitions
+func (e *WorkflowEngine) enterStartStep(ctx context.Context, instance *models.AssignedTodo, startStep *models.WorkflowStep) error {
+	if startStep.IsSubworkflow() {
+		if err := e.startChildren(ctx, instance, startStep); err != nil {
+			return err
+		}
+	}
+	e.routeAutomatically(ctx, instance)
+	return nil
+}
+
+// createInstance validates and stores a new instance at the start step of its workflow
+func (e *WorkflowEngine) createInstance(ctx context.Context, workflowID, todoID, assignedTo string, payload map[string]interface{}, parentID *string) (*models.AssignedTodo, *models.WorkflowStep, error) {
 	// Get workflow to ensure it exists and is active
-	workflow, err := e.workflowRepo.GetWorkflow(workflowID)
+	workflow, err := e.workflowRepo.GetWorkflow(ctx, workflowID)
 	if err != nil {
-		return nil, fmt.Errorf("workflow not found: %w", err)
+		return nil, nil, fmt.Errorf("workflow not found: %w", err)
 	}
 
 	if !workflow.IsActive {
-		return nil, fmt.Errorf("workflow is not active")
+		return nil, nil, apperrors.Conflict("workflow is not active")
+	}
+
+	// A todo runs through one workflow at a time
+	todo, err := e.todoRepo.GetById(ctx, todoID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+	if todo == nil {
+		return nil, nil, apperrors.NotFound("todo not found")
+	}
+	running, err := e.instanceRepo.GetOpenInstanceByTodo(ctx, todoID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check running instances: %w", err)
+	}
+	if running != nil {
+		return nil, nil, ErrTodoHasOpenInstance
 	}
 
 	// Get the start step
-	startStep, err := e.workflowRepo.GetStartStep(workflowID)
+	startStep, err := e.workflowRepo.GetStartStep(ctx, workflowID)
 	if err != nil {
-		return nil, fmt.Errorf("start step not found: %w", err)
+		return nil, nil, fmt.Errorf("start step not found: %w", err)
+	}
+
+	// Instances starting on a queue step wait there for a claim
+	if startStep.IsQueue() {
+		assignedTo = ""
+	} else if assignedTo == "" {
+		return nil, nil, ErrAssigneeRequired
 	}
 
 	// Create the instance
+	now := time.Now()
 	instance := &models.AssignedTodo{
 		ID:            uuid.New().String(),
 		WorkflowId:    workflowID,
 		CurrentStepId: startStep.ID,
+		TodoId:        todoID,
 		AssignedTo:    assignedTo,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		StepEnteredAt: now,
+		DueAt:         stepDueAt(startStep, now),
+		Status:        models.InstanceActive,
+		Payload:       payload,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+
+		ParentInstanceID: parentID,
 	}
 
-	err = e.instanceRepo.CreateInstance(instance)
+	err = e.instanceRepo.CreateInstance(ctx, instance)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create instance: %w", err)
+		// The partial unique index catches a concurrent start for the same todo
+		if repository.IsUniqueViolation(err) {
+			return nil, nil, ErrTodoHasOpenInstance
+		}
+		return nil, nil, fmt.Errorf("failed to create instance: %w", err)
 	}
 
-	return instance, nil
+	if startStep.IsQueue() {
+		err = e.recordHistoryWithData(ctx, instance.ID, nil, startStep.ID, "created", models.SystemActor, "Workflow instance created in the "+startStep.QueueRole+" queue", payload)
+	} else {
+		err = e.recordHistoryWithData(ctx, instance.ID, nil, startStep.ID, "created", assignedTo, "Workflow instance created", payload)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	repository.AfterCommit(ctx, func() { metrics.WorkflowInstancesStarted.Inc(workflowID) })
+
+	return instance, startStep, nil
 }
 
-// ExecuteTransition moves an instance from one step to another
-func (e *WorkflowEngine) ExecuteTransition(instanceID, actionName, userID, comments string) error {
+// ExecuteTransition moves an instance from one step to another.
+// On multi-approval steps the action is recorded as a vote instead, and the
+// transition only fires once the quorum is met or the veto action is chosen.
+// Data submitted for the transition's form is validated and merged into the instance payload.
+// When expect is not nil the instance must still match it; concurrent changes are detected
+// through the instance version and reported as ErrInstanceConflict.
+// The step change, votes, history and automatic routing are committed together.
+func (e *WorkflowEngine) ExecuteTransition(ctx context.Context, instanceID, actionName, userID, comments string, data map[string]interface{}, expect *models.InstancePrecondition) (*models.TransitionResult, error) {
+	return inTx(ctx, e.txManager, func(ctx context.Context) (*models.TransitionResult, error) {
+		return e.executeTransition(ctx, instanceID, actionName, userID, comments, data, expect)
+	})
+}
+
+func (e *WorkflowEngine) executeTransition(ctx context.Context, instanceID, actionName, userID, comments string, data map[string]interface{}, expect *models.InstancePrecondition) (*models.TransitionResult, error) {
 	// Get the instance
-	instance, err := e.instanceRepo.GetInstance(instanceID)
+	instance, err := e.instanceRepo.GetInstance(ctx, instanceID)
 	if err != nil {
-		return fmt.Errorf("instance not found: %w", err)
+		return nil, fmt.Errorf("instance not found: %w", err)
+	}
+	if err := checkPrecondition(instance, expect); err != nil {
+		return nil, err
+	}
+	if !instance.IsActive() {
+		return nil, apperrors.Conflict("cannot execute actions on a %s instance", instance.Status)
 	}
 
 	// Find the transition
-	transition, err := e.workflowRepo.FindTransition(instance.WorkflowId, instance.CurrentStepId, actionName)
+	transition, err := e.workflowRepo.FindTransition(ctx, instance.WorkflowId, instance.CurrentStepId, actionName)
+	if err != nil {
+		return nil, apperrors.Invalid("invalid action for current step: %w", err)
+	}
+	if transition.Automatic {
+		return nil, apperrors.Invalid("action %s is automatic and cannot be triggered manually", actionName)
+	}
+
+	currentStep, err := e.workflowRepo.GetStep(ctx, instance.CurrentStepId)
 	if err != nil {
-		return fmt.Errorf("invalid action for current step: %w", err)
+		return nil, fmt.Errorf("failed to get current step: %w", err)
+	}
+	if currentStep.IsQueue() && instance.IsUnclaimed() {
+		return nil, apperrors.Conflict("task is waiting in the %s queue and must be claimed first", currentStep.QueueRole)
 	}
 
 	// Validate the transition
-	canTransition, err := e.ValidateTransition(instance, transition, userID)
+	canTransition, check, err := e.checkTransition(ctx, instance, transition, userID)
 	if err != nil {
-		return err
+		return nil, &rejection{check: check, err: err}
 	}
 	if !canTransition {
-		return fmt.Errorf("user not authorized to perform this action")
+		return nil, &rejection{check: check, err: apperrors.Forbidden("user not authorized to perform this action")}
+	}
+	if transition.ConditionType == "assigned_user_only" && userID != instance.AssignedTo {
+		comments = strings.TrimSpace(comments + " (on behalf of " + instance.AssignedTo + ")")
+	}
+
+	if err := validateFormData(transition, data); err != nil {
+		return nil, err
+	}
+
+	if currentStep.IsSubworkflow() {
+		waiting, err := e.awaitChildren(ctx, instance, currentStep)
+		if err != nil {
+			return nil, err
+		}
+		if waiting {
+			return nil, apperrors.Conflict("step %s is waiting for its subworkflow instances to finish", currentStep.StepName)
+		}
+	}
+
+	if currentStep.RequiresVotes() {
+		return e.castVote(ctx, instance, currentStep, transition, userID, comments, data)
+	}
+
+	if err := e.mergePayload(ctx, instance, data); err != nil {
+		return nil, err
 	}
 
 	// Execute the transition
-	err = e.instanceRepo.UpdateInstanceStep(instanceID, transition.ToStepID)
+	err = e.applyTransition(ctx, instance, transition, userID, comments, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TransitionResult{
+		InstanceID:    instance.ID,
+		ActionName:    transition.ActionName,
+		FromStepID:    transition.FromStepID,
+		ToStepID:      transition.ToStepID,
+		CurrentStepID: instance.CurrentStepId,
+		Version:       instance.Version,
+		Transitioned:  true,
+	}, nil
+}
+
+// checkPrecondition compares an instance with the state the caller expects it in
+func checkPrecondition(instance *models.AssignedTodo, expect *models.InstancePrecondition) error {
+	if expect == nil {
+		return nil
+	}
+	if expect.Version != 0 && expect.Version != instance.Version {
+		return fmt.Errorf("%w: expected version %d, instance is at version %d", ErrInstanceConflict, expect.Version, instance.Version)
+	}
+	if expect.StepID != "" && expect.StepID != instance.CurrentStepId {
+		return fmt.Errorf("%w: expected step %s, instance is in step %s", ErrInstanceConflict, expect.StepID, instance.CurrentStepId)
+	}
+	return nil
+}
+
+// applyTransition moves an instance along an already validated transition
+// and then follows any automatic transitions of the step it lands in
+func (e *WorkflowEngine) applyTransition(ctx context.Context, instance *models.AssignedTodo, transition *models.WorkflowTransition, performedBy, comments string, data map[string]interface{}) error {
+	if err := e.moveInstance(ctx, instance, transition, performedBy, comments, data); err != nil {
+		return err
+	}
+	e.routeAutomatically(ctx, instance)
+	return nil
+}
+
+// moveInstance performs a single hop along a transition and keeps the in-memory instance in sync.
+// When it fails the instance may be ahead of the rolled back rows, so it must not be used further.
+func (e *WorkflowEngine) moveInstance(ctx context.Context, instance *models.AssignedTodo, transition *models.WorkflowTransition, performedBy, comments string, data map[string]interface{}) error {
+	toStep, err := e.workflowRepo.GetStep(ctx, transition.ToStepID)
+	if err != nil {
+		return fmt.Errorf("failed to get target step: %w", err)
+	}
+
+	// Entering a step restarts the SLA clock, entering a queue step puts the instance back in the pool
+	now := time.Now()
+	assignedTo := instance.AssignedTo
+	if toStep.IsQueue() {
+		assignedTo = ""
+	}
+	err = e.instanceRepo.UpdateInstanceStep(ctx, instance.ID, instance.Version, toStep.ID, assignedTo, now, stepDueAt(toStep, now))
 	if err != nil {
 		return fmt.Errorf("failed to update instance step: %w", err)
 	}
+	instance.Version++
 
-	// Check if we reached an end step
-	toStep, err := e.workflowRepo.GetStep(transition.ToStepID)
-	if err == nil && toStep.Final {
-		// Could trigger completion hooks here
-		// e.g., send notifications, update external systems, etc.
+	fromStepID := instance.CurrentStepId
+	if err := e.recordHistoryWithData(ctx, instance.ID, &fromStepID, toStep.ID, transition.ActionName, performedBy, comments, data); err != nil {
+		return err
+	}
+	repository.AfterCommit(ctx, func() { metrics.WorkflowTransitions.Inc(transition.ActionName) })
+
+	instance.CurrentStepId = toStep.ID
+	instance.AssignedTo = assignedTo
+	instance.StepEnteredAt = now
+	instance.DueAt = stepDueAt(toStep, now)
+	instance.EscalatedAt = nil
+	instance.UpdatedAt = now
+
+	// Reaching an end step completes the instance
+	if toStep.Final {
+		instance.Status = models.InstanceCompleted
+		instance.DueAt = nil
+		if err := e.instanceRepo.UpdateInstanceStatus(ctx, instance); err != nil {
+			return fmt.Errorf("failed to complete instance: %w", err)
+		}
+		instance.Version++
+		workflowID := instance.WorkflowId
+		repository.AfterCommit(ctx, func() { metrics.WorkflowInstancesCompleted.Inc(workflowID) })
+		e.completeTodo(ctx, instance)
+		e.notifyParent(ctx, instance)
+		return nil
 	}
 
+	if toStep.IsSubworkflow() {
+		return e.startChildren(ctx, instance, toStep)
+	}
 	return nil
 }
 
-// ValidateTransition checks if a user can perform a transition
-func (e *WorkflowEngine) ValidateTransition(instance *models.AssignedTodo, transition *models.WorkflowTransition, userID string) (bool, error) {
-	// Check condition type
-	switch transition.ConditionType {
-	case "assigned_user_only":
-		// Only the assigned user can perform this action
-		return instance.AssignedTo == userID, nil
+// validateFormData checks the data submitted with an action against the transition's form.
+// Actions without a form take no data.
+func validateFormData(transition *models.WorkflowTransition, data map[string]interface{}) error {
+	if transition.FormSchema == nil {
+		if len(data) > 0 {
+			return apperrors.Validation(fmt.Sprintf("action %s does not accept data", transition.ActionName),
+				apperrors.FieldError{Field: "data", Message: "must be empty"})
+		}
+		return nil
+	}
+	return transition.FormSchema.Validate(data)
+}
 
-	case "not_assigned_user":
-		// Anyone except the assigned user (e.g., for approval by someone else)
-		return instance.AssignedTo != userID, nil
+// mergePayload stores submitted form data in the instance payload, overwriting existing keys
+func (e *WorkflowEngine) mergePayload(ctx context.Context, instance *models.AssignedTodo, data map[string]interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
 
-	case "any_user":
-		// Any authenticated user can perform this action
-		return true, nil
+	if instance.Payload == nil {
+		instance.Payload = map[string]interface{}{}
+	}
+	for key, value := range data {
+		instance.Payload[key] = value
+	}
 
-	case "":
-		// No condition specified, allow by default
-		return true, nil
+	instance.UpdatedAt = time.Now()
+	if err := e.instanceRepo.UpdateInstancePayload(ctx, instance.ID, instance.Version, instance.Payload, instance.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to update instance payload: %w", err)
+	}
+	instance.Version++
+	return nil
+}
 
-	default:
-		// Unknown condition type
-		return false, fmt.Errorf("unknown condition type: %s", transition.ConditionType)
+// completeTodo marks the todo of a finished instance completed when its workflow asks for it.
+// This is best effort, the instance itself is already completed.
+func (e *WorkflowEngine) completeTodo(ctx context.Context, instance *models.AssignedTodo) {
+	workflow, err := e.workflowRepo.GetWorkflow(ctx, instance.WorkflowId)
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to get workflow of instance", "instance_id", instance.ID, "error", err)
+		return
+	}
+	if !workflow.CompleteTodoOnFinish || instance.TodoId == "" {
+		return
+	}
+
+	// Under a savepoint, so a failure does not abort the transaction of the action
+	err = e.txManager.InTx(ctx, func(ctx context.Context) error {
+		_, err := e.todoRepo.SetCompleted(ctx, instance.TodoId, true)
+		return err
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to complete todo of instance", "todo_id", instance.TodoId, "instance_id", instance.ID, "error", err)
+	}
+}
+
+// recordHistory appends an entry to the audit trail of an instance.
+// It runs in the transaction of the action, so an action is never saved without its history.
+func (e *WorkflowEngine) recordHistory(ctx context.Context, instanceID string, fromStepID *string, toStepID, action, performedBy, comments string) error {
+	return e.recordHistoryWithData(ctx, instanceID, fromStepID, toStepID, action, performedBy, comments, nil)
+}
+
+// recordHistoryWithData appends a history entry together with the form data submitted with the action
+func (e *WorkflowEngine) recordHistoryWithData(ctx context.Context, instanceID string, fromStepID *string, toStepID, action, performedBy, comments string, data map[string]interface{}) error {
+	entry := &models.WorkflowHistory{
+		ID:          uuid.New().String(),
+		InstanceID:  instanceID,
+		FromStepID:  fromStepID,
+		ToStepID:    toStepID,
+		ActionTaken: action,
+		PerformedBy: performedBy,
+		Comments:    comments,
+		Timestamp:   time.Now(),
+		Data:        data,
+	}
+
+	if err := e.instanceRepo.AddHistory(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record history: %w", err)
+	}
+	if e.recorded != nil {
+		*e.recorded = append(*e.recorded, entry)
+	}
+	return nil
+}
+
+// GetInstanceHistory returns the audit trail of an instance, newest first
+func (e *WorkflowEngine) GetInstanceHistory(ctx context.Context, instanceID string) ([]*models.WorkflowHistory, error) {
+	if _, err := e.instanceRepo.GetInstance(ctx, instanceID); err != nil {
+		return nil, fmt.Errorf("instance not found: %w", err)
+	}
+	return e.instanceRepo.GetHistory(ctx, instanceID)
+}
+
+// stepDueAt returns when an instance entering the step at the given time becomes overdue
+func stepDueAt(step *models.WorkflowStep, enteredAt time.Time) *time.Time {
+	if step.SLAMinutes <= 0 {
+		return nil
 	}
+	dueAt := enteredAt.Add(time.Duration(step.SLAMinutes) * time.Minute)
+	return &dueAt
+}
+
+// ValidateTransition checks if a user can perform a transition
+func (e *WorkflowEngine) ValidateTransition(ctx context.Context, instance *models.AssignedTodo, transition *models.WorkflowTransition, userID string) (bool, error) {
+	allowed, _, err := e.checkTransition(ctx, instance, transition, userID)
+	return allowed, err
+}
+
+// checkTransition is ValidateTransition that also names the check that refused the user
+func (e *WorkflowEngine) checkTransition(ctx context.Context, instance *models.AssignedTodo, transition *models.WorkflowTransition, userID string) (bool, string, error) {
+	return checkCondition(ctx, transition, instance.AssignedTo, userID, e.actsFor, func() map[string]interface{} {
+		return e.guardVars(ctx, instance, userID)
+	})
 }
 
 // GetAvailableActions returns the actions a user can take on an instance
-func (e *WorkflowEngine) GetAvailableActions(instanceID, userID string) ([]models.AvailableAction, error) {
+func (e *WorkflowEngine) GetAvailableActions(ctx context.Context, instanceID, userID string) ([]models.AvailableAction, error) {
 	// Get the instance
-	instance, err := e.instanceRepo.GetInstance(instanceID)
+	instance, err := e.instanceRepo.GetInstance(ctx, instanceID)
 	if err != nil {
 		return nil, fmt.Errorf("instance not found: %w", err)
 	}
+	if !instance.IsActive() {
+		return []models.AvailableAction{}, nil
+	}
 
 	// Get available transitions from current step
-	transitions, err := e.workflowRepo.GetAvailableTransitions(instance.WorkflowId, instance.CurrentStepId)
+	transitions, err := e.workflowRepo.GetAvailableTransitions(ctx, instance.WorkflowId, instance.CurrentStepId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transitions: %w", err)
 	}
 
+	// On multi-approval steps only reviewers who have not voted yet can act
+	currentStep, err := e.workflowRepo.GetStep(ctx, instance.CurrentStepId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current step: %w", err)
+	}
+	// Unclaimed queue tasks must be claimed before anyone can act on them
+	if currentStep.IsQueue() && instance.IsUnclaimed() {
+		return []models.AvailableAction{}, nil
+	}
+	if currentStep.IsSubworkflow() {
+		children, err := e.instanceRepo.GetChildInstances(ctx, instance.ID, instance.StepEnteredAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get child instances: %w", err)
+		}
+		if countOpen(children) > 0 {
+			return []models.AvailableAction{}, nil
+		}
+	}
+	if currentStep.RequiresVotes() {
+		if _, eligible := e.reviewerRole(ctx, currentStep, userID); !eligible {
+			return []models.AvailableAction{}, nil
+		}
+		votes, err := e.voteRepo.GetVotes(ctx, instance.ID, currentStep.ID, instance.StepEnteredAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get votes: %w", err)
+		}
+		if hasVoted(votes, userID) {
+			return []models.AvailableAction{}, nil
+		}
+	}
+
 	var actions []models.AvailableAction
 	for _, transition := range transitions {
+		// Automatic transitions are fired by the engine, never offered to users
+		if transition.Automatic {
+			continue
+		}
+
 		// Check if user can perform this transition
-		canPerform, err := e.ValidateTransition(instance, transition, userID)
+		canPerform, err := e.ValidateTransition(ctx, instance, transition, userID)
 		if err != nil || !canPerform {
 			continue
 		}
 
 		// Get the target step name
-		toStep, err := e.workflowRepo.GetStep(transition.ToStepID)
+		toStep, err := e.workflowRepo.GetStep(ctx, transition.ToStepID)
 		if err != nil {
 			continue
 		}
@@ -154,6 +528,7 @@ func (e *WorkflowEngine) GetAvailableActions(instanceID, userID string) ([]model
 			ActionName:   transition.ActionName,
 			ToStepName:   toStep.StepName,
 			TransitionID: transition.ID,
+			FormSchema:   transition.FormSchema,
 		})
 	}
 
@@ -161,35 +536,221 @@ func (e *WorkflowEngine) GetAvailableActions(instanceID, userID string) ([]model
 }
 
 // GetInstanceWithDetails returns an instance with current step and available actions
-func (e *WorkflowEngine) GetInstanceWithDetails(instanceID, userID string) (*models.WorkflowInstanceWithDetails, error) {
+func (e *WorkflowEngine) GetInstanceWithDetails(ctx context.Context, instanceID, userID string) (*models.WorkflowInstanceWithDetails, error) {
 	// Get the instance
-	instance, err := e.instanceRepo.GetInstance(instanceID)
+	instance, err := e.instanceRepo.GetInstance(ctx, instanceID)
 	if err != nil {
 		return nil, fmt.Errorf("instance not found: %w", err)
 	}
 
 	// Get current step
-	currentStep, err := e.workflowRepo.GetStep(instance.CurrentStepId)
+	currentStep, err := e.workflowRepo.GetStep(ctx, instance.CurrentStepId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current step: %w", err)
 	}
 
 	// Get workflow
-	workflow, err := e.workflowRepo.GetWorkflow(instance.WorkflowId)
+	workflow, err := e.workflowRepo.GetWorkflow(ctx, instance.WorkflowId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get workflow: %w", err)
 	}
 
 	// Get available actions
-	actions, err := e.GetAvailableActions(instanceID, userID)
+	actions, err := e.GetAvailableActions(ctx, instanceID, userID)
 	if err != nil {
 		actions = []models.AvailableAction{} // Empty if error
 	}
 
+	// Get the instances started by subworkflow steps
+	children, err := e.instanceRepo.GetChildInstances(ctx, instance.ID, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get child instances: %w", err)
+	}
+
 	return &models.WorkflowInstanceWithDetails{
 		AssignedTodo:     *instance,
 		CurrentStepName:  currentStep.StepName,
 		WorkflowName:     workflow.Name,
 		AvailableActions: actions,
+		Children:         children,
 	}, nil
 }
+
+// GetOverdueTasks returns all instances that have passed the SLA of their current step
+func (e *WorkflowEngine) GetOverdueTasks(ctx context.Context) ([]*models.OverdueTask, error) {
+	tasks, err := e.instanceRepo.GetOverdueInstances(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overdue tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// EscalateOverdue fires the configured escalation for every overdue instance
+// that has not been escalated yet and returns how many were escalated.
+// Each instance is escalated in its own transaction. Instances that were moved,
+// closed or escalated by another request since the overdue list was read are skipped.
+func (e *WorkflowEngine) EscalateOverdue(ctx context.Context) (int, error) {
+	tasks, err := e.GetOverdueTasks(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	escalated := 0
+	for _, task := range tasks {
+		if task.EscalatedAt != nil {
+			continue
+		}
+
+		var fired bool
+		err := e.txManager.InTx(ctx, func(ctx context.Context) error {
+			// Reloaded inside the transaction, which may be retried
+			instance, err := e.instanceRepo.GetInstance(ctx, task.ID)
+			if err != nil {
+				return fmt.Errorf("instance not found: %w", err)
+			}
+			fired = isOverdue(instance, time.Now())
+			if !fired {
+				return nil
+			}
+			return e.escalate(ctx, instance)
+		})
+		if errors.Is(err, ErrInstanceConflict) {
+			logging.FromContext(ctx).Debug("instance changed while escalating", "instance_id", task.ID)
+			continue
+		}
+		if err != nil {
+			logging.FromContext(ctx).Warn("failed to escalate instance", "instance_id", task.ID, "error", err)
+			continue
+		}
+		if fired {
+			escalated++
+		}
+	}
+
+	return escalated, nil
+}
+
+// ValidateEscalation checks that the escalation of a step names something that exists:
+// a user for reassign_user, a role for reassign_role and, once the step has outgoing
+// transitions, one of their actions for auto_transition.
+func (e *WorkflowEngine) ValidateEscalation(ctx context.Context, step *models.WorkflowStep) error {
+	switch step.EscalationAction {
+	case models.EscalationReassignUser:
+		if user, err := e.userRepo.GetUserByID(ctx, step.EscalationValue); err != nil || user == nil {
+			return apperrors.Invalid("escalation_value: user not found: %s", step.EscalationValue)
+		}
+
+	case models.EscalationReassignRole:
+		if role, err := e.roleRepo.GetRoleByName(ctx, step.EscalationValue); err != nil || role == nil {
+			return apperrors.Invalid("escalation_value: role not found: %s", step.EscalationValue)
+		}
+
+	case models.EscalationAutoTransition:
+		return e.validateStepAction(ctx, step, "escalation_value", step.EscalationValue)
+	}
+	return nil
+}
+
+// validateStepAction checks that a step has an outgoing transition with the given action.
+// Steps are created before their transitions, so a step without any passes.
+func (e *WorkflowEngine) validateStepAction(ctx context.Context, step *models.WorkflowStep, field, action string) error {
+	transitions, err := e.workflowRepo.GetTransitions(ctx, step.WorkflowID)
+	if err != nil {
+		return fmt.Errorf("failed to get transitions: %w", err)
+	}
+	var actions []string
+	for _, transition := range transitions {
+		if transition.FromStepID != step.ID {
+			continue
+		}
+		if transition.ActionName == action {
+			return nil
+		}
+		actions = append(actions, transition.ActionName)
+	}
+	if len(actions) > 0 {
+		return apperrors.Invalid("%s: step %s has no action %q, it has %s", field, step.StepName, action, strings.Join(actions, ", "))
+	}
+	return nil
+}
+
+// isOverdue reports whether an instance is still active, past the SLA of its current step and not escalated yet
+func isOverdue(instance *models.AssignedTodo, now time.Time) bool {
+	return instance.IsActive() && instance.EscalatedAt == nil && instance.DueAt != nil && instance.DueAt.Before(now)
+}
+
+// escalate applies the escalation action of the instance's current step
+func (e *WorkflowEngine) escalate(ctx context.Context, instance *models.AssignedTodo) error {
+	step, err := e.workflowRepo.GetStep(ctx, instance.CurrentStepId)
+	if err != nil {
+		return fmt.Errorf("failed to get current step: %w", err)
+	}
+
+	comments := fmt.Sprintf("SLA of %d minutes exceeded in step %s", step.SLAMinutes, step.StepName)
+
+	switch step.EscalationAction {
+	case models.EscalationAutoTransition:
+		// Moving on resets due_at and escalated_at for the new step
+		transition, err := e.workflowRepo.FindTransition(ctx, instance.WorkflowId, instance.CurrentStepId, step.EscalationValue)
+		if err != nil {
+			return fmt.Errorf("escalation action %q not found: %w", step.EscalationValue, err)
+		}
+		return e.applyTransition(ctx, instance, transition, models.SystemActor, comments, nil)
+
+	case models.EscalationReassignUser:
+		if err := e.reassign(ctx, instance, step.EscalationValue); err != nil {
+			return err
+		}
+		comments += ", reassigned to " + step.EscalationValue
+
+	case models.EscalationReassignRole:
+		role, err := e.roleRepo.GetRoleByName(ctx, step.EscalationValue)
+		if err != nil || role == nil {
+			return fmt.Errorf("escalation role %q not found", step.EscalationValue)
+		}
+		// Escalations go to the active member with the lowest ID, the same one every time
+		user, err := e.userRepo.GetUsersByRoleId(ctx, role.RoleId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("escalation role %q has no active members", step.EscalationValue)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get a member of role %q: %w", step.EscalationValue, err)
+		}
+		if err := e.reassign(ctx, instance, user.UserID.String()); err != nil {
+			return err
+		}
+		comments += ", reassigned to " + user.Username + " (" + role.Name + ")"
+
+	case models.EscalationNotify, "":
+		// Nothing to change on the instance, the history entry is the escalation event
+
+	default:
+		return fmt.Errorf("unknown escalation action: %s", step.EscalationAction)
+	}
+
+	now := time.Now()
+	if err := e.instanceRepo.MarkEscalated(ctx, instance.ID, instance.Version, now); err != nil {
+		return fmt.Errorf("failed to mark instance escalated: %w", err)
+	}
+	instance.Version++
+	instance.EscalatedAt = &now
+
+	stepID := instance.CurrentStepId
+	if err := e.recordHistory(ctx, instance.ID, &stepID, stepID, "escalated", models.SystemActor, comments); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("escalated instance", "instance_id", instance.ID, "comments", comments)
+
+	return nil
+}
+
+// reassign hands an instance over to another user
+func (e *WorkflowEngine) reassign(ctx context.Context, instance *models.AssignedTodo, assignedTo string) error {
+	instance.AssignedTo = assignedTo
+	instance.UpdatedAt = time.Now()
+	if err := e.instanceRepo.UpdateInstance(ctx, instance); err != nil {
+		return fmt.Errorf("failed to reassign instance: %w", err)
+	}
+	instance.Version++
+	return nil
+}
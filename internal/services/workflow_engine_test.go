@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"todo-api/internal/models"
+	"todo-api/internal/repository"
+	"todo-api/pkg/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// newEscalationWorkflow creates a workflow whose Review step escalates with the given action
+// and value once its one-minute SLA is exceeded
+func newEscalationWorkflow(t *testing.T, repos *repository.Repositories, action, value string) (*models.WorkflowStep, *models.WorkflowStep) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now()
+	workflow := &models.Workflow{ID: uuid.NewString(), Name: "Escalation", IsActive: true, CreatedAt: now, UpdatedAt: now}
+	review := &models.WorkflowStep{ID: uuid.NewString(), WorkflowID: workflow.ID, StepName: "Review", StepOrder: 1, Initial: true,
+		SLAMinutes: 1, EscalationAction: action, EscalationValue: value}
+	done := &models.WorkflowStep{ID: uuid.NewString(), WorkflowID: workflow.ID, StepName: "Done", StepOrder: 2, Final: true}
+	if err := repos.Workflows.CreateWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("Failed to create workflow: %v", err)
+	}
+	for _, step := range []*models.WorkflowStep{review, done} {
+		if err := repos.Workflows.CreateStep(ctx, step); err != nil {
+			t.Fatalf("Failed to create step: %v", err)
+		}
+	}
+	approve := &models.WorkflowTransition{ID: uuid.NewString(), WorkflowID: workflow.ID, FromStepID: review.ID, ToStepID: done.ID,
+		ActionName: "approve", ConditionType: "any_user"}
+	if err := repos.Workflows.CreateTransition(ctx, approve); err != nil {
+		t.Fatalf("Failed to create transition: %v", err)
+	}
+	return review, done
+}
+
+func TestEscalateOverdue_EscalatesOnce(t *testing.T) {
+	// Arrange - an instance whose Review SLA ran out a minute ago
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	engine := NewWorkflowEngine(repos)
+	review, _ := newEscalationWorkflow(t, repos, models.EscalationNotify, "")
+	todo := &models.Todo{Id: uuid.NewString(), TaskName: "Contract", TaskDescription: "Sign off", UserID: "alice"}
+	if err := repos.Todos.Create(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	instance, err := engine.StartWorkflow(ctx, review.WorkflowID, todo.Id, "alice", nil)
+	if err != nil {
+		t.Fatalf("Failed to start workflow: %v", err)
+	}
+	overdue := time.Now().Add(-time.Minute)
+	instance.DueAt = &overdue
+	if err := repos.Instances.UpdateInstanceStatus(ctx, instance); err != nil {
+		t.Fatalf("Failed to backdate the SLA: %v", err)
+	}
+
+	// Act - the scheduler runs twice
+	first, firstErr := engine.EscalateOverdue(ctx)
+	second, secondErr := engine.EscalateOverdue(ctx)
+
+	// Assert - only the first run escalates, and marking it bumped the version
+	if firstErr != nil || secondErr != nil {
+		t.Fatalf("Expected no errors, got %v and %v", firstErr, secondErr)
+	}
+	if first != 1 || second != 0 {
+		t.Errorf("Expected 1 then 0 escalations, got %d then %d", first, second)
+	}
+	stored, err := repos.Instances.GetInstance(ctx, instance.ID)
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err)
+	}
+	if stored.EscalatedAt == nil || stored.Version != instance.Version+2 {
+		t.Errorf("Expected an escalated instance at version %d, got %+v", instance.Version+2, stored)
+	}
+}
+
+func TestValidateEscalation(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	engine := NewWorkflowEngine(repos)
+	review, done := newEscalationWorkflow(t, repos, models.EscalationNotify, "")
+	user := &models.User{UserID: uuid.New(), Username: "alice", Email: "alice@example.com", IsActive: true}
+	if err := repos.Users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		step    *models.WorkflowStep
+		action  string
+		value   string
+		wantErr bool
+	}{
+		{"existing user", review, models.EscalationReassignUser, user.UserID.String(), false},
+		{"unknown user", review, models.EscalationReassignUser, uuid.NewString(), true},
+		{"unknown role", review, models.EscalationReassignRole, "Nobody", true},
+		{"action of the step", review, models.EscalationAutoTransition, "approve", false},
+		{"unknown action", review, models.EscalationAutoTransition, "reject", true},
+		{"step without transitions yet", done, models.EscalationAutoTransition, "reopen", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := *tt.step
+			step.EscalationAction = tt.action
+			step.EscalationValue = tt.value
+
+			// Act
+			err := engine.ValidateEscalation(ctx, &step)
+
+			// Assert
+			if tt.wantErr && apperrors.KindOf(err) != apperrors.KindValidation {
+				t.Errorf("Expected a validation error, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEscalate_ReassignsToActiveRoleMember(t *testing.T) {
+	first := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	second := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	third := uuid.MustParse("00000000-0000-0000-0000-000000000003")
+
+	tests := []struct {
+		name     string
+		active   map[uuid.UUID]bool // Members of the role and whether they are active
+		wantUser uuid.UUID
+		wantErr  string
+	}{
+		{"lowest active member", map[uuid.UUID]bool{third: true, first: false, second: true}, second, ""},
+		{"no active members", map[uuid.UUID]bool{first: false}, uuid.Nil, `escalation role "Supervisors" has no active members`},
+		{"no members", map[uuid.UUID]bool{}, uuid.Nil, `escalation role "Supervisors" has no active members`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			repos := repository.NewMemoryRepositories()
+			engine := NewWorkflowEngine(repos)
+			role := &models.Role{Name: "Supervisors"}
+			if err := repos.Roles.CreateRole(ctx, role); err != nil {
+				t.Fatalf("Failed to create role: %v", err)
+			}
+			for id, active := range tt.active {
+				user := &models.User{UserID: id, Username: id.String(), Email: id.String() + "@example.com", RoleID: &role.RoleId, IsActive: active}
+				if err := repos.Users.CreateUser(ctx, user); err != nil {
+					t.Fatalf("Failed to create user: %v", err)
+				}
+			}
+			review, _ := newEscalationWorkflow(t, repos, models.EscalationReassignRole, role.Name)
+			todo := &models.Todo{Id: uuid.NewString(), TaskName: "Contract", TaskDescription: "Sign off", UserID: "alice"}
+			if err := repos.Todos.Create(ctx, todo); err != nil {
+				t.Fatalf("Failed to create todo: %v", err)
+			}
+			instance, err := engine.StartWorkflow(ctx, review.WorkflowID, todo.Id, "alice", nil)
+			if err != nil {
+				t.Fatalf("Failed to start workflow: %v", err)
+			}
+
+			// Act
+			err = engine.escalate(ctx, instance)
+
+			// Assert
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if instance.AssignedTo != tt.wantUser.String() {
+				t.Errorf("Expected the task to be reassigned to %s, got %s", tt.wantUser, instance.AssignedTo)
+			}
+		})
+	}
+}
+
+func TestValidateApproval_VetoAction(t *testing.T) {
+	// Arrange - Review already has its approve transition, Done has none yet
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	engine := NewWorkflowEngine(repos)
+	review, done := newEscalationWorkflow(t, repos, models.EscalationNotify, "")
+
+	tests := []struct {
+		name    string
+		step    *models.WorkflowStep
+		veto    string
+		wantErr bool
+	}{
+		{"action of the step", review, "approve", false},
+		{"unknown action", review, "reject", true},
+		{"step without transitions yet", done, "reject", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := *tt.step
+			step.ApprovalMode = models.ApprovalAllRoles
+			step.VetoAction = tt.veto
+
+			// Act
+			err := engine.ValidateApproval(ctx, &step)
+
+			// Assert
+			if tt.wantErr && apperrors.KindOf(err) != apperrors.KindValidation {
+				t.Errorf("Expected a validation error, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
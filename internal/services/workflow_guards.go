@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"todo-api/internal/expression"
+	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// ConditionExpression is the condition type whose condition value is a guard expression
+const ConditionExpression = "expression"
+
+// guardEnv declares the variables available to transition guard expressions.
+// The payload is open because its shape is defined by each workflow's forms.
+var guardEnv = expression.Env{
+	"payload": expression.Object(nil),
+	"actor": expression.Object(map[string]*expression.Type{
+		"id":       expression.String,
+		"username": expression.String,
+		"email":    expression.String,
+		"role":     expression.String,
+	}),
+	"instance": expression.Object(map[string]*expression.Type{
+		"id":              expression.String,
+		"workflow_id":     expression.String,
+		"current_step_id": expression.String,
+		"todo_id":         expression.String,
+		"assigned_to":     expression.String,
+		"status":          expression.String,
+	}),
+	"todo": expression.Object(map[string]*expression.Type{
+		"id":               expression.String,
+		"task_name":        expression.String,
+		"task_description": expression.String,
+		"completed":        expression.Bool,
+		"user_id":          expression.String,
+	}),
+}
+
+// ValidateGuardExpression parses and type-checks a guard expression so bad
+// conditions are rejected when the transition is created
+func ValidateGuardExpression(src string) error {
+	if _, err := expression.Compile(src, guardEnv); err != nil {
+		return apperrors.Invalid("invalid condition expression: %w", err)
+	}
+	return nil
+}
+
+// evaluateGuard evaluates a transition's guard expression against the guard variables
+func evaluateGuard(transition *models.WorkflowTransition, vars map[string]interface{}) (bool, error) {
+	program, err := expression.Compile(transition.ConditionValue, guardEnv)
+	if err != nil {
+		return false, apperrors.Invalid("invalid condition expression on action %s: %w", transition.ActionName, err)
+	}
+
+	allowed, err := program.Eval(vars)
+	if err != nil {
+		return false, apperrors.Invalid("failed to evaluate condition of action %s: %w", transition.ActionName, err)
+	}
+	return allowed, nil
+}
+
+// rejection is an action refused by one of the engine's checks. It names the check
+// for the simulator and otherwise behaves like the error it wraps.
+type rejection struct {
+	check string
+	err   error
+}
+
+func (r *rejection) Error() string { return r.err.Error() }
+
+func (r *rejection) Unwrap() error { return r.err }
+
+// checkCondition decides whether userID may fire a transition. actsFor tells
+// whether the user acts for the assignee and vars supplies the guard variables;
+// each is only called when the condition type needs it. When the transition is
+// not allowed, the returned reason names the check that rejected it.
+func checkCondition(
+	ctx context.Context,
+	transition *models.WorkflowTransition,
+	assignee, userID string,
+	actsFor func(ctx context.Context, assignee, userID string) bool,
+	vars func() map[string]interface{},
+) (bool, string, error) {
+	switch transition.ConditionType {
+	case "assigned_user_only":
+		// Only the assigned user, or someone they currently delegate to, can perform this action
+		if !actsFor(ctx, assignee, userID) {
+			return false, fmt.Sprintf("assigned_user_only: only %s or their delegates may perform %s", assignee, transition.ActionName), nil
+		}
+		return true, "", nil
+
+	case "not_assigned_user":
+		// Anyone except the assigned user and their delegates (e.g., for approval by someone else)
+		if actsFor(ctx, assignee, userID) {
+			return false, fmt.Sprintf("not_assigned_user: %s and their delegates may not perform %s", assignee, transition.ActionName), nil
+		}
+		return true, "", nil
+
+	case "any_user", "":
+		// Any authenticated user can perform this action
+		return true, "", nil
+
+	case ConditionExpression:
+		// The guard expression decides, based on the instance data, the actor and the todo
+		allowed, err := evaluateGuard(transition, vars())
+		if err != nil {
+			return false, "expression: " + err.Error(), err
+		}
+		if !allowed {
+			return false, fmt.Sprintf("expression: %s is false", transition.ConditionValue), nil
+		}
+		return true, "", nil
+
+	default:
+		// Unknown condition type
+		err := apperrors.Invalid("unknown condition type: %s", transition.ConditionType)
+		return false, err.Error(), err
+	}
+}
+
+// guardVars builds the values of the guard environment. Unknown users and
+// missing todos leave their fields empty rather than failing the evaluation.
+func (e *WorkflowEngine) guardVars(ctx context.Context, instance *models.AssignedTodo, userID string) map[string]interface{} {
+	actor := map[string]interface{}{"id": userID, "username": "", "email": "", "role": ""}
+	if user := e.lookupUser(ctx, userID); user != nil {
+		actor["username"] = user.Username
+		actor["email"] = user.Email
+		if user.Role != nil {
+			actor["role"] = user.Role.Name
+		}
+	}
+
+	todo := &models.Todo{Id: instance.TodoId}
+	if t, err := e.todoRepo.GetById(ctx, instance.TodoId); err == nil && t != nil {
+		todo = t
+	}
+
+	return buildGuardVars(instance, actor, todo)
+}
+
+// lookupUser returns the user with the given ID, or nil when there is none. IDs that
+// are not UUIDs, like models.SystemActor, name no user and are not queried: on
+// PostgreSQL the failing query would abort the transaction the caller runs in.
+func (e *WorkflowEngine) lookupUser(ctx context.Context, userID string) *models.User {
+	if userID == models.SystemActor {
+		return nil
+	}
+	if _, err := uuid.Parse(userID); err != nil && !e.namedActors {
+		return nil
+	}
+	user, err := e.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+// buildGuardVars assembles the guard variables from an instance, the actor and the instance's todo
+func buildGuardVars(instance *models.AssignedTodo, actor map[string]interface{}, todo *models.Todo) map[string]interface{} {
+	payload := instance.Payload
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+
+	return map[string]interface{}{
+		"payload": payload,
+		"actor":   actor,
+		"instance": map[string]interface{}{
+			"id":              instance.ID,
+			"workflow_id":     instance.WorkflowId,
+			"current_step_id": instance.CurrentStepId,
+			"todo_id":         instance.TodoId,
+			"assigned_to":     instance.AssignedTo,
+			"status":          instance.Status,
+		},
+		"todo": map[string]interface{}{
+			"id":               todo.Id,
+			"task_name":        todo.TaskName,
+			"task_description": todo.TaskDescription,
+			"completed":        todo.Completed,
+			"user_id":          todo.UserID,
+		},
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+)
+
+// SuspendInstance pauses an active instance. No actions can be executed and
+// the step SLA stops counting until the instance is resumed.
+func (e *WorkflowEngine) SuspendInstance(ctx context.Context, instanceID, performedBy, reason string) (*models.AssignedTodo, error) {
+	return inTx(ctx, e.txManager, func(ctx context.Context) (*models.AssignedTodo, error) {
+		return e.suspendInstance(ctx, instanceID, performedBy, reason)
+	})
+}
+
+func (e *WorkflowEngine) suspendInstance(ctx context.Context, instanceID, performedBy, reason string) (*models.AssignedTodo, error) {
+	instance, err := e.instanceRepo.GetInstance(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("instance not found: %w", err)
+	}
+	if instance.Status != models.InstanceActive {
+		return nil, apperrors.Conflict("only active instances can be suspended, instance is %s", instance.Status)
+	}
+
+	now := time.Now()
+	instance.SuspendedAt = &now
+	return e.changeStatus(ctx, instance, models.InstanceSuspended, "suspended", performedBy, reason)
+}
+
+// ResumeInstance reactivates a suspended instance, extending its step due date
+// by the time it spent suspended
+func (e *WorkflowEngine) ResumeInstance(ctx context.Context, instanceID, performedBy, reason string) (*models.AssignedTodo, error) {
+	return inTx(ctx, e.txManager, func(ctx context.Context) (*models.AssignedTodo, error) {
+		return e.resumeInstance(ctx, instanceID, performedBy, reason)
+	})
+}
+
+func (e *WorkflowEngine) resumeInstance(ctx context.Context, instanceID, performedBy, reason string) (*models.AssignedTodo, error) {
+	instance, err := e.instanceRepo.GetInstance(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("instance not found: %w", err)
+	}
+	if instance.Status != models.InstanceSuspended {
+		return nil, apperrors.Conflict("only suspended instances can be resumed, instance is %s", instance.Status)
+	}
+
+	if instance.DueAt != nil && instance.SuspendedAt != nil {
+		dueAt := instance.DueAt.Add(time.Since(*instance.SuspendedAt))
+		instance.DueAt = &dueAt
+	}
+	instance.SuspendedAt = nil
+	instance, err = e.changeStatus(ctx, instance, models.InstanceActive, "resumed", performedBy, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	// Children may have finished while the instance was suspended
+	e.routeAutomatically(ctx, instance)
+	return instance, nil
+}
+
+// CancelInstance aborts an active or suspended instance for good
+// Open children are cancelled in the same transaction.
+func (e *WorkflowEngine) CancelInstance(ctx context.Context, instanceID, performedBy, reason string) (*models.AssignedTodo, error) {
+	return inTx(ctx, e.txManager, func(ctx context.Context) (*models.AssignedTodo, error) {
+		return e.cancelInstance(ctx, instanceID, performedBy, reason)
+	})
+}
+
+func (e *WorkflowEngine) cancelInstance(ctx context.Context, instanceID, performedBy, reason string) (*models.AssignedTodo, error) {
+	instance, err := e.instanceRepo.GetInstance(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("instance not found: %w", err)
+	}
+	if instance.IsClosed() {
+		return nil, apperrors.Conflict("instance is already %s", instance.Status)
+	}
+
+	instance.SuspendedAt = nil
+	instance.DueAt = nil
+	instance, err = e.changeStatus(ctx, instance, models.InstanceCancelled, "cancelled", performedBy, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	e.cancelChildren(ctx, instance)
+	e.notifyParent(ctx, instance)
+	return instance, nil
+}
+
+// changeStatus saves a new lifecycle state and records it in the instance history
+func (e *WorkflowEngine) changeStatus(ctx context.Context, instance *models.AssignedTodo, status, action, performedBy, reason string) (*models.AssignedTodo, error) {
+	instance.Status = status
+	instance.UpdatedAt = time.Now()
+	if err := e.instanceRepo.UpdateInstanceStatus(ctx, instance); err != nil {
+		return nil, fmt.Errorf("failed to update instance status: %w", err)
+	}
+	instance.Version++
+
+	stepID := instance.CurrentStepId
+	if err := e.recordHistory(ctx, instance.ID, &stepID, stepID, action, performedBy, reason); err != nil {
+		return nil, err
+	}
+
+	return instance, nil
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todo-api/internal/interfaces"
+	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+)
+
+// DefaultMetricsWindow is the date range used when a metrics request gives no start date
+const DefaultMetricsWindow = 30 * 24 * time.Hour
+
+// WorkflowMetricsService computes process metrics of workflows from their history
+type WorkflowMetricsService struct {
+	metricsRepo interfaces.WorkflowMetricsRepositoryInterface
+}
+
+func NewWorkflowMetricsService(metricsRepo interfaces.WorkflowMetricsRepositoryInterface) *WorkflowMetricsService {
+	return &WorkflowMetricsService{metricsRepo: metricsRepo}
+}
+
+// GetWorkflowMetrics returns cycle time, step dwell time, rework and user throughput of a workflow within [from, to)
+func (s *WorkflowMetricsService) GetWorkflowMetrics(ctx context.Context, workflowID string, from, to time.Time) (*models.WorkflowMetrics, error) {
+	cycleTime, err := s.metricsRepo.GetCycleTime(ctx, workflowID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := s.metricsRepo.GetStepMetrics(ctx, workflowID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.metricsRepo.GetUserThroughput(ctx, workflowID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &models.WorkflowMetrics{
+		WorkflowID: workflowID,
+		From:       from,
+		To:         to,
+		CycleTime:  *cycleTime,
+		Steps:      steps,
+		Users:      users,
+		Bottleneck: findBottleneck(steps),
+	}
+	for _, step := range steps {
+		metrics.Rework.Reentries += step.Reentries
+		metrics.Rework.SentBack += step.SentBack
+	}
+
+	return metrics, nil
+}
+
+// findBottleneck returns the non-final step with the longest average dwell time.
+// Ties go to the step with more instances waiting on it; nil when no step has been left yet.
+func findBottleneck(steps []models.StepMetrics) *models.StepMetrics {
+	var bottleneck *models.StepMetrics
+	for i := range steps {
+		step := &steps[i]
+		if step.Final || step.AvgDwellHours <= 0 {
+			continue
+		}
+		if bottleneck == nil || step.AvgDwellHours > bottleneck.AvgDwellHours ||
+			(step.AvgDwellHours == bottleneck.AvgDwellHours && step.Waiting > bottleneck.Waiting) {
+			bottleneck = step
+		}
+	}
+	return bottleneck
+}
+
+// ParseMetricsRange reads the from and to query parameters of a metrics request.
+// Both accept RFC 3339 timestamps or dates; a date as "to" includes that whole day.
+// to defaults to now and from to DefaultMetricsWindow before to.
+func ParseMetricsRange(fromParam, toParam string, now time.Time) (time.Time, time.Time, error) {
+	to := now
+	if toParam != "" {
+		parsed, dateOnly, err := parseMetricsTime(toParam)
+		if err != nil {
+			return time.Time{}, time.Time{}, apperrors.Validation("invalid to: "+err.Error(), apperrors.FieldError{Field: "to", Message: err.Error()})
+		}
+		to = parsed
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+	}
+
+	from := to.Add(-DefaultMetricsWindow)
+	if fromParam != "" {
+		parsed, _, err := parseMetricsTime(fromParam)
+		if err != nil {
+			return time.Time{}, time.Time{}, apperrors.Validation("invalid from: "+err.Error(), apperrors.FieldError{Field: "from", Message: err.Error()})
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, apperrors.Validation("from must be before to", apperrors.FieldError{Field: "from", Message: "must be before to"})
+	}
+	return from, to, nil
+}
+
+// parseMetricsTime parses an RFC 3339 timestamp or a YYYY-MM-DD date in local time
+func parseMetricsTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected YYYY-MM-DD or RFC 3339, got %q", value)
+	}
+	return t, true, nil
+}
+
+// Workflow metrics data sources. They require the workflow ID as entity.
+const (
+	DataSourceWorkflowCycleTime  = "workflow_cycle_time"
+	DataSourceWorkflowStepDwell  = "workflow_step_dwell"
+	DataSourceWorkflowRework     = "workflow_rework"
+	DataSourceWorkflowThroughput = "workflow_user_throughput"
+)
+
+// metricsWidget renders workflow metrics for a data source widget
+func metricsWidget(dataSourceID, widgetType string, metrics *models.WorkflowMetrics) (interface{}, error) {
+	switch dataSourceID {
+	case DataSourceWorkflowCycleTime:
+		return &models.TableData{
+			Columns: []models.TableColumn{
+				{Key: "completed", Header: "Completed", Width: "25%"},
+				{Key: "median_hours", Header: "Median (h)", Width: "25%"},
+				{Key: "p90_hours", Header: "P90 (h)", Width: "25%"},
+				{Key: "average_hours", Header: "Average (h)", Width: "25%"},
+			},
+			Rows: []map[string]interface{}{{
+				"completed":     metrics.CycleTime.Completed,
+				"median_hours":  roundHours(metrics.CycleTime.MedianHours),
+				"p90_hours":     roundHours(metrics.CycleTime.P90Hours),
+				"average_hours": roundHours(metrics.CycleTime.AverageHours),
+			}},
+		}, nil
+
+	case DataSourceWorkflowStepDwell:
+		if widgetType == "bar_chart" {
+			bars := []models.BarChartBar{}
+			for _, step := range metrics.Steps {
+				if !step.Final {
+					bars = append(bars, models.BarChartBar{Label: step.StepName, Value: roundHours(step.AvgDwellHours), Color: barColor(step, metrics.Bottleneck)})
+				}
+			}
+			return bars, nil
+		}
+		table := &models.TableData{
+			Columns: []models.TableColumn{
+				{Key: "step", Header: "Step", Width: "30%"},
+				{Key: "visits", Header: "Visits", Width: "15%"},
+				{Key: "avg_dwell_hours", Header: "Avg Dwell (h)", Width: "20%"},
+				{Key: "max_dwell_hours", Header: "Max Dwell (h)", Width: "20%"},
+				{Key: "waiting", Header: "Waiting", Width: "15%"},
+			},
+			Rows: []map[string]interface{}{},
+		}
+		for _, step := range metrics.Steps {
+			table.Rows = append(table.Rows, map[string]interface{}{
+				"step":            step.StepName,
+				"visits":          step.Visits,
+				"avg_dwell_hours": roundHours(step.AvgDwellHours),
+				"max_dwell_hours": roundHours(step.MaxDwellHours),
+				"waiting":         step.Waiting,
+			})
+		}
+		return table, nil
+
+	case DataSourceWorkflowRework:
+		if widgetType == "bar_chart" {
+			bars := []models.BarChartBar{}
+			for _, step := range metrics.Steps {
+				bars = append(bars, models.BarChartBar{Label: step.StepName, Value: float64(step.Reentries), Color: "#f59e0b"})
+			}
+			return bars, nil
+		}
+		table := &models.TableData{
+			Columns: []models.TableColumn{
+				{Key: "step", Header: "Step", Width: "40%"},
+				{Key: "reentries", Header: "Re-entries", Width: "30%"},
+				{Key: "sent_back", Header: "Sent Back", Width: "30%"},
+			},
+			Rows: []map[string]interface{}{},
+		}
+		for _, step := range metrics.Steps {
+			table.Rows = append(table.Rows, map[string]interface{}{
+				"step":      step.StepName,
+				"reentries": step.Reentries,
+				"sent_back": step.SentBack,
+			})
+		}
+		return table, nil
+
+	case DataSourceWorkflowThroughput:
+		if widgetType == "bar_chart" {
+			bars := []models.BarChartBar{}
+			for _, user := range metrics.Users {
+				bars = append(bars, models.BarChartBar{Label: userLabel(user), Value: float64(user.Actions), Color: "#3b82f6"})
+			}
+			return bars, nil
+		}
+		table := &models.TableData{
+			Columns: []models.TableColumn{
+				{Key: "user", Header: "User", Width: "40%"},
+				{Key: "actions", Header: "Actions", Width: "20%"},
+				{Key: "instances", Header: "Tasks", Width: "20%"},
+				{Key: "response_hours", Header: "Avg Response (h)", Width: "20%"},
+			},
+			Rows: []map[string]interface{}{},
+		}
+		for _, user := range metrics.Users {
+			table.Rows = append(table.Rows, map[string]interface{}{
+				"user":           userLabel(user),
+				"actions":        user.Actions,
+				"instances":      user.Instances,
+				"response_hours": roundHours(user.ResponseHours),
+			})
+		}
+		return table, nil
+	}
+
+	return nil, fmt.Errorf("data source not implemented: %s", dataSourceID)
+}
+
+// barColor highlights the bottleneck step
+func barColor(step models.StepMetrics, bottleneck *models.StepMetrics) string {
+	if bottleneck != nil && bottleneck.StepID == step.StepID {
+		return "#ef4444"
+	}
+	return "#3b82f6"
+}
+
+// userLabel prefers the username and falls back to the recorded user ID
+func userLabel(user models.UserThroughput) string {
+	if user.Username != "" {
+		return user.Username
+	}
+	return user.UserID
+}
+
+// roundHours rounds a duration in hours to two decimals for display
+func roundHours(hours float64) float64 {
+	return float64(int64(hours*100+0.5)) / 100
+}
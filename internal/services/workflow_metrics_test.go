@@ -0,0 +1,95 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"todo-api/internal/models"
+)
+
+func TestFindBottleneck(t *testing.T) {
+	// Arrange - review stalls longest; the final step never has dwell time
+	steps := []models.StepMetrics{
+		{StepID: "draft", AvgDwellHours: 2},
+		{StepID: "review", AvgDwellHours: 30, Waiting: 1},
+		{StepID: "legal", AvgDwellHours: 30, Waiting: 4},
+		{StepID: "done", Final: true},
+	}
+
+	// Act
+	bottleneck := findBottleneck(steps)
+
+	// Assert - the tie goes to the step with more waiting instances
+	if bottleneck == nil || bottleneck.StepID != "legal" {
+		t.Fatalf("Expected legal to be the bottleneck, got %+v", bottleneck)
+	}
+
+	// No step left yet means no bottleneck
+	if bottleneck := findBottleneck([]models.StepMetrics{{StepID: "draft", Visits: 3, Waiting: 3}}); bottleneck != nil {
+		t.Errorf("Expected no bottleneck without dwell times, got %+v", bottleneck)
+	}
+}
+
+func TestParseMetricsRange(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 12, 20, 15, 0, 0, 0, time.UTC)
+
+	// Act & Assert - defaults to the window before now
+	from, to, err := ParseMetricsRange("", "", now)
+	if err != nil {
+		t.Fatalf("Expected the default range to parse, got %v", err)
+	}
+	if !to.Equal(now) || !from.Equal(now.Add(-DefaultMetricsWindow)) {
+		t.Errorf("Expected the default window before now, got %v - %v", from, to)
+	}
+
+	// A date as "to" includes the whole day
+	from, to, err = ParseMetricsRange("2024-12-01", "2024-12-01", now)
+	if err != nil {
+		t.Fatalf("Expected dates to parse, got %v", err)
+	}
+	if to.Sub(from) != 24*time.Hour {
+		t.Errorf("Expected a single day, got %v - %v", from, to)
+	}
+
+	// RFC 3339 timestamps are used as given
+	from, _, err = ParseMetricsRange("2024-12-01T08:30:00Z", "", now)
+	if err != nil || !from.Equal(time.Date(2024, 12, 1, 8, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected an RFC 3339 from, got %v (%v)", from, err)
+	}
+
+	// Invalid and inverted ranges are rejected
+	for _, r := range [][2]string{{"yesterday", ""}, {"", "12/01/2024"}, {"2024-12-10", "2024-12-01"}} {
+		if _, _, err := ParseMetricsRange(r[0], r[1], now); err == nil {
+			t.Errorf("Expected from=%q to=%q to be rejected", r[0], r[1])
+		}
+	}
+}
+
+func TestMetricsWidget_StepDwellBars(t *testing.T) {
+	// Arrange
+	steps := []models.StepMetrics{
+		{StepID: "draft", StepName: "Draft", AvgDwellHours: 1.234},
+		{StepID: "review", StepName: "Review", AvgDwellHours: 12},
+		{StepID: "done", StepName: "Done", Final: true},
+	}
+	metrics := &models.WorkflowMetrics{Steps: steps, Bottleneck: findBottleneck(steps)}
+
+	// Act
+	data, err := metricsWidget(DataSourceWorkflowStepDwell, "bar_chart", metrics)
+
+	// Assert - final steps are left out and the bottleneck is highlighted
+	if err != nil {
+		t.Fatalf("Expected bars, got %v", err)
+	}
+	bars := data.([]models.BarChartBar)
+	if len(bars) != 2 {
+		t.Fatalf("Expected 2 bars, got %d", len(bars))
+	}
+	if bars[0].Value != 1.23 {
+		t.Errorf("Expected dwell rounded to 1.23, got %v", bars[0].Value)
+	}
+	if bars[1].Color == bars[0].Color {
+		t.Error("Expected the bottleneck bar to be highlighted")
+	}
+}
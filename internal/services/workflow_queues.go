@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+)
+
+// ErrTaskNotClaimable is returned when a task is no longer waiting in its queue,
+// typically because another user claimed it first
+var ErrTaskNotClaimable = apperrors.Conflict("task is not waiting in a work queue")
+
+// GetQueue returns the unclaimed tasks a user may claim, i.e. those waiting on queue steps of the user's role
+func (e *WorkflowEngine) GetQueue(ctx context.Context, userID string) ([]*models.AssignedTodo, error) {
+	roleName := e.roleName(ctx, userID)
+	if roleName == "" {
+		return []*models.AssignedTodo{}, nil
+	}
+
+	instances, err := e.instanceRepo.GetQueuedInstances(ctx, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue: %w", err)
+	}
+	return instances, nil
+}
+
+// ClaimTask assigns an unclaimed task on a queue step to a member of the queue role.
+// Concurrent claims are decided by the database; the loser gets ErrTaskNotClaimable.
+func (e *WorkflowEngine) ClaimTask(ctx context.Context, instanceID, userID, comments string) (*models.AssignedTodo, error) {
+	return inTx(ctx, e.txManager, func(ctx context.Context) (*models.AssignedTodo, error) {
+		return e.claimTask(ctx, instanceID, userID, comments)
+	})
+}
+
+func (e *WorkflowEngine) claimTask(ctx context.Context, instanceID, userID, comments string) (*models.AssignedTodo, error) {
+	instance, step, err := e.queuedInstance(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if !instance.IsUnclaimed() {
+		return nil, fmt.Errorf("%w: already claimed by %s", ErrTaskNotClaimable, instance.AssignedTo)
+	}
+	if e.roleName(ctx, userID) != step.QueueRole {
+		err := apperrors.Forbidden("user is not a member of the %s queue", step.QueueRole)
+		return nil, &rejection{check: "queue: " + err.Error(), err: err}
+	}
+
+	claimed, err := e.instanceRepo.ClaimInstance(ctx, instance.ID, step.ID, userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim task: %w", err)
+	}
+	if !claimed {
+		return nil, fmt.Errorf("%w: it was claimed or moved by another request", ErrTaskNotClaimable)
+	}
+
+	if err := e.recordHistory(ctx, instance.ID, &step.ID, step.ID, "claimed", userID, queueComment("claimed from the "+step.QueueRole+" queue", comments)); err != nil {
+		return nil, err
+	}
+	return e.instanceRepo.GetInstance(ctx, instance.ID)
+}
+
+// ReleaseTask puts a claimed task back into its queue. Only the current assignee
+// may release it unless override is set.
+func (e *WorkflowEngine) ReleaseTask(ctx context.Context, instanceID, userID, comments string, override bool) (*models.AssignedTodo, error) {
+	return inTx(ctx, e.txManager, func(ctx context.Context) (*models.AssignedTodo, error) {
+		return e.releaseTask(ctx, instanceID, userID, comments, override)
+	})
+}
+
+func (e *WorkflowEngine) releaseTask(ctx context.Context, instanceID, userID, comments string, override bool) (*models.AssignedTodo, error) {
+	instance, step, err := e.queuedInstance(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if instance.IsUnclaimed() {
+		return nil, apperrors.Conflict("task has not been claimed")
+	}
+	if !override && instance.AssignedTo != userID {
+		return nil, apperrors.Forbidden("only %s can release this task", instance.AssignedTo)
+	}
+
+	released, err := e.instanceRepo.ReleaseInstance(ctx, instance.ID, step.ID, instance.AssignedTo, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to release task: %w", err)
+	}
+	if !released {
+		return nil, fmt.Errorf("%w: task was changed by another request", ErrInstanceConflict)
+	}
+
+	if err := e.recordHistory(ctx, instance.ID, &step.ID, step.ID, "released", userID, queueComment("released "+instance.AssignedTo+" back to the "+step.QueueRole+" queue", comments)); err != nil {
+		return nil, err
+	}
+	return e.instanceRepo.GetInstance(ctx, instance.ID)
+}
+
+// queuedInstance loads an active instance together with its current step, which must be a queue step
+func (e *WorkflowEngine) queuedInstance(ctx context.Context, instanceID string) (*models.AssignedTodo, *models.WorkflowStep, error) {
+	instance, err := e.instanceRepo.GetInstance(ctx, instanceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("instance not found: %w", err)
+	}
+	if !instance.IsActive() {
+		return nil, nil, apperrors.Conflict("cannot claim or release a %s instance", instance.Status)
+	}
+
+	step, err := e.workflowRepo.GetStep(ctx, instance.CurrentStepId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get current step: %w", err)
+	}
+	if !step.IsQueue() {
+		return nil, nil, fmt.Errorf("%w: step %s has no queue", ErrTaskNotClaimable, step.StepName)
+	}
+	return instance, step, nil
+}
+
+// roleName returns the name of a user's role, or "" when the user has none
+func (e *WorkflowEngine) roleName(ctx context.Context, userID string) string {
+	user := e.lookupUser(ctx, userID)
+	if user == nil || user.Role == nil {
+		return ""
+	}
+	return user.Role.Name
+}
+
+// queueComment appends the caller's comments to a claim or release comment
+func queueComment(comment, comments string) string {
+	if comments != "" {
+		return comment + ": " + comments
+	}
+	return comment
+}
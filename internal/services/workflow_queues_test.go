@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestSimulate_ClaimsQueueSteps(t *testing.T) {
+	// Arrange - submitted drafts wait in the Moderator queue, only the claimer may approve
+	workflow := &models.Workflow{ID: "wf", Name: "Queued review", IsActive: true}
+	steps := []*models.WorkflowStep{
+		{ID: "draft", StepName: "Draft", StepOrder: 1, Initial: true},
+		{ID: "review", StepName: "Review", StepOrder: 2, QueueRole: models.RoleModerator},
+		{ID: "done", StepName: "Done", StepOrder: 3, Final: true},
+	}
+	transitions := []*models.WorkflowTransition{
+		{ID: "t1", FromStepID: "draft", ToStepID: "review", ActionName: "submit", ConditionType: "assigned_user_only"},
+		{ID: "t2", FromStepID: "review", ToStepID: "done", ActionName: "approve", ConditionType: "assigned_user_only"},
+	}
+	input := &models.SimulationInput{
+		AssignedTo: "alice",
+		Roles:      map[string]string{"bob": models.RoleModerator, "carol": models.RoleModerator},
+		Steps: []models.SimulationAction{
+			{Actor: "alice", Action: "submit"},
+			{Actor: "alice", Action: "approve"},
+			{Actor: "bob", Action: "approve"},
+		},
+	}
+
+	// Act
+	result := simulateInMemory(t, workflow, steps, transitions, input)
+
+	// Assert - alice is not in the queue, bob claims and approves
+	if result.Status != models.InstanceCompleted {
+		t.Fatalf("Expected the run to complete, got %s in %s", result.Status, result.CurrentStepName)
+	}
+	if got := result.Steps[1].RejectedBy; !strings.HasPrefix(got, "queue") {
+		t.Errorf("Expected alice to be rejected by the queue, got %q", got)
+	}
+	if !result.Steps[2].Claimed || !result.Steps[2].Transitioned {
+		t.Errorf("Expected bob to claim and approve, got %+v", result.Steps[2])
+	}
+}
+
+func TestClaimTask_ConcurrentClaims(t *testing.T) {
+	// Arrange - the review step becomes a Moderator queue and five moderators race for the task
+	requireDatabase(t)
+	f := newQueuedApprovalFixture(t, models.RoleModerator)
+	if err := NewRoleService(f.repos.Roles).InitializePredefinedRoles(context.Background()); err != nil {
+		t.Fatalf("Failed to initialize roles: %v", err)
+	}
+	moderator, err := f.repos.Roles.GetRoleByName(context.Background(), models.RoleModerator)
+	if err != nil {
+		t.Fatalf("Failed to get moderator role: %v", err)
+	}
+
+	var moderators []string
+	for i := 0; i < 5; i++ {
+		user := &models.User{UserID: uuid.New(), Username: "moderator-" + uuid.NewString()[:8], IsActive: true, RoleID: &moderator.RoleId}
+		user.Email = user.Username + "@example.com"
+		user.Password = "not-used"
+		if err := f.repos.Users.CreateUser(context.Background(), user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if database.DB != nil {
+			t.Cleanup(func() { deleteRows(t, `DELETE FROM users WHERE id = $1`, user.UserID) })
+		}
+		moderators = append(moderators, user.UserID.String())
+	}
+
+	todo := &models.Todo{Id: uuid.NewString(), TaskName: "fixture", TaskDescription: "fixture", UserID: f.user.UserID.String()}
+	if err := f.repos.Todos.Create(context.Background(), todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	instance, err := f.engine.StartWorkflow(context.Background(), f.workflow.ID, todo.Id, "", nil)
+	if err != nil {
+		t.Fatalf("Failed to start workflow: %v", err)
+	}
+	if !instance.IsUnclaimed() {
+		t.Fatalf("Expected the task to start in the queue, got assignee %q", instance.AssignedTo)
+	}
+
+	// Act
+	start := make(chan struct{})
+	errs := make([]error, len(moderators))
+	var wg sync.WaitGroup
+	for i, userID := range moderators {
+		wg.Add(1)
+		go func(i int, userID string) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = f.engine.ClaimTask(context.Background(), instance.ID, userID, "")
+		}(i, userID)
+	}
+	close(start)
+	wg.Wait()
+
+	// Assert - exactly one claim wins and only it is recorded
+	winner := ""
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			if winner != "" {
+				t.Fatalf("Expected one claim to succeed, %s and %s both did", winner, moderators[i])
+			}
+			winner = moderators[i]
+		case !errors.Is(err, ErrTaskNotClaimable):
+			t.Errorf("Expected ErrTaskNotClaimable for the losing claims, got %v", err)
+		}
+	}
+	if winner == "" {
+		t.Fatalf("Expected one claim to succeed, got %v", errs)
+	}
+	if claims := countHistory(t, f.engine, instance.ID, "claimed"); claims != 1 {
+		t.Errorf("Expected one claimed history entry, got %d", claims)
+	}
+
+	// Act - the winner puts the task back
+	released, err := f.engine.ReleaseTask(context.Background(), instance.ID, winner, "out of office", false)
+
+	// Assert - the task is waiting in the queue again
+	if err != nil {
+		t.Fatalf("Expected the release to succeed, got %v", err)
+	}
+	if !released.IsUnclaimed() {
+		t.Errorf("Expected the task to be unassigned, got %q", released.AssignedTo)
+	}
+	queue, err := f.engine.GetQueue(context.Background(), moderators[0])
+	if err != nil {
+		t.Fatalf("Failed to get queue: %v", err)
+	}
+	found := false
+	for _, queued := range queue {
+		found = found || queued.ID == instance.ID
+	}
+	if !found {
+		t.Errorf("Expected the released task in the Moderator queue")
+	}
+}
+
+// countHistory counts the history entries of an instance with the given action
+func countHistory(t *testing.T, engine *WorkflowEngine, instanceID, action string) int {
+	t.Helper()
+	history, err := engine.GetInstanceHistory(context.Background(), instanceID)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	count := 0
+	for _, entry := range history {
+		if entry.ActionTaken == action {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+	"todo-api/pkg/logging"
+)
+
+// maxAutomaticHops bounds the automatic transitions followed after a single
+// user action, so a cycle of always-true guards cannot spin forever
+const maxAutomaticHops = 10
+
+// ValidateAutomaticTransition checks that a transition can be fired by the engine.
+// Conditions about the acting user make no sense without one, and there is nobody to fill in a form.
+func ValidateAutomaticTransition(transition *models.WorkflowTransition) error {
+	if !transition.Automatic {
+		return nil
+	}
+	switch transition.ConditionType {
+	case "", "any_user", ConditionExpression:
+	default:
+		return apperrors.Invalid("automatic transitions support condition types \"\", \"any_user\" and %q, got %q",
+			ConditionExpression, transition.ConditionType)
+	}
+	if transition.FormSchema != nil {
+		return apperrors.Invalid("automatic transitions cannot have a form_schema")
+	}
+	return nil
+}
+
+// routeAutomatically follows the automatic transitions of the instance's current step
+// until none applies, the instance completes, or the hop limit is reached.
+// It is best effort: the action that got the instance here already succeeded. Each hop
+// runs under its own savepoint, so a failing hop is undone without aborting the action.
+func (e *WorkflowEngine) routeAutomatically(ctx context.Context, instance *models.AssignedTodo) {
+	logger := logging.FromContext(ctx)
+	for hops := 0; instance.IsActive(); hops++ {
+		// A subworkflow step holds the instance until all of its children are closed
+		step, err := e.workflowRepo.GetStep(ctx, instance.CurrentStepId)
+		if err != nil {
+			logger.Warn("failed to get current step of instance", "instance_id", instance.ID, "error", err)
+			return
+		}
+		if step.IsSubworkflow() {
+			waiting := false
+			err := e.txManager.InTx(ctx, func(ctx context.Context) (err error) {
+				waiting, err = e.awaitChildren(ctx, instance, step)
+				return err
+			})
+			if err != nil {
+				logger.Warn("failed to check child instances", "instance_id", instance.ID, "error", err)
+				return
+			}
+			if waiting {
+				return
+			}
+		}
+
+		transitions, err := e.workflowRepo.GetAvailableTransitions(ctx, instance.WorkflowId, instance.CurrentStepId)
+		if err != nil {
+			logger.Warn("failed to get automatic transitions of instance", "instance_id", instance.ID, "error", err)
+			return
+		}
+
+		transition := firstSatisfied(logger, transitions, func(t *models.WorkflowTransition) (bool, error) {
+			return e.automaticGuardHolds(ctx, instance, t)
+		})
+		if transition == nil {
+			return
+		}
+
+		if hops == maxAutomaticHops {
+			logger.Warn("instance stopped after too many automatic transitions, the workflow may contain a loop", "instance_id", instance.ID, "hops", maxAutomaticHops)
+			stepID := instance.CurrentStepId
+			err := e.txManager.InTx(ctx, func(ctx context.Context) error {
+				return e.recordHistory(ctx, instance.ID, &stepID, stepID, "automatic_routing_halted", models.SystemActor,
+					fmt.Sprintf("Stopped after %d automatic transitions, the workflow may contain a loop", maxAutomaticHops))
+			})
+			if err != nil {
+				logger.Warn("failed to record halted routing of instance", "instance_id", instance.ID, "error", err)
+			}
+			return
+		}
+
+		// The hop moves a copy, so a hop that is rolled back leaves the instance as stored
+		moved := *instance
+		err = e.txManager.InTx(ctx, func(ctx context.Context) error {
+			return e.moveInstance(ctx, &moved, transition, models.SystemActor, "Automatic transition", nil)
+		})
+		if err != nil {
+			logger.Warn("failed to apply automatic transition", "action", transition.ActionName, "instance_id", instance.ID, "error", err)
+			return
+		}
+		*instance = moved
+	}
+}
+
+// automaticGuardHolds evaluates the condition of an automatic transition with the system as actor
+func (e *WorkflowEngine) automaticGuardHolds(ctx context.Context, instance *models.AssignedTodo, transition *models.WorkflowTransition) (bool, error) {
+	return automaticConditionHolds(transition, func() map[string]interface{} {
+		return e.guardVars(ctx, instance, models.SystemActor)
+	})
+}
+
+// automaticConditionHolds reports whether an automatic transition may fire
+func automaticConditionHolds(transition *models.WorkflowTransition, vars func() map[string]interface{}) (bool, error) {
+	if !transition.Automatic {
+		return false, nil
+	}
+	switch transition.ConditionType {
+	case "", "any_user":
+		return true, nil
+	case ConditionExpression:
+		return evaluateGuard(transition, vars())
+	}
+	return false, fmt.Errorf("unsupported condition type %q on automatic transition", transition.ConditionType)
+}
+
+// firstSatisfied returns the first transition, in order, whose guard holds.
+// A guard that fails to evaluate is logged to logger and treated as not satisfied.
+func firstSatisfied(logger *slog.Logger, transitions []*models.WorkflowTransition, holds func(*models.WorkflowTransition) (bool, error)) *models.WorkflowTransition {
+	for _, transition := range transitions {
+		ok, err := holds(transition)
+		if err != nil {
+			logger.Warn("skipping automatic transition", "action", transition.ActionName, "error", err)
+			continue
+		}
+		if ok {
+			return transition
+		}
+	}
+	return nil
+}
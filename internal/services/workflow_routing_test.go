@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"todo-api/internal/expression"
+	"todo-api/internal/models"
+	"todo-api/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func TestFirstSatisfied_RoutesByPriority(t *testing.T) {
+	// Arrange - high priority todos go to senior review, everything else to standard review
+	transitions := []*models.WorkflowTransition{
+		{ActionName: "route_senior", Automatic: true, ConditionType: ConditionExpression, ConditionValue: `payload.priority == "high"`},
+		{ActionName: "route_standard", Automatic: true},
+	}
+	holdsFor := func(payload map[string]interface{}) func(*models.WorkflowTransition) (bool, error) {
+		return func(transition *models.WorkflowTransition) (bool, error) {
+			if transition.ConditionType != ConditionExpression {
+				return true, nil
+			}
+			program, err := expression.Compile(transition.ConditionValue, guardEnv)
+			if err != nil {
+				return false, err
+			}
+			return program.Eval(map[string]interface{}{"payload": payload})
+		}
+	}
+
+	// Act
+	high := firstSatisfied(slog.Default(), transitions, holdsFor(map[string]interface{}{"priority": "high"}))
+	low := firstSatisfied(slog.Default(), transitions, holdsFor(map[string]interface{}{"priority": "low"}))
+
+	// Assert
+	if high == nil || high.ActionName != "route_senior" {
+		t.Errorf("Expected high priority to route to senior review, got %v", high)
+	}
+	if low == nil || low.ActionName != "route_standard" {
+		t.Errorf("Expected low priority to route to standard review, got %v", low)
+	}
+}
+
+func TestFirstSatisfied_SkipsFailingGuards(t *testing.T) {
+	// Arrange
+	transitions := []*models.WorkflowTransition{
+		{ActionName: "broken"},
+		{ActionName: "fallback"},
+	}
+
+	// Act
+	got := firstSatisfied(slog.Default(), transitions, func(transition *models.WorkflowTransition) (bool, error) {
+		if transition.ActionName == "broken" {
+			return false, errors.New("division by zero")
+		}
+		return true, nil
+	})
+
+	// Assert
+	if got == nil || got.ActionName != "fallback" {
+		t.Errorf("Expected the fallback transition, got %v", got)
+	}
+}
+
+func TestValidateAutomaticTransition(t *testing.T) {
+	tests := []struct {
+		name       string
+		transition *models.WorkflowTransition
+		wantErr    bool
+	}{
+		{"manual transition", &models.WorkflowTransition{ConditionType: "assigned_user_only"}, false},
+		{"unconditional", &models.WorkflowTransition{Automatic: true}, false},
+		{"expression", &models.WorkflowTransition{Automatic: true, ConditionType: ConditionExpression}, false},
+		{"user condition", &models.WorkflowTransition{Automatic: true, ConditionType: "not_assigned_user"}, true},
+		{"with form", &models.WorkflowTransition{Automatic: true, FormSchema: &models.FormSchema{}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := ValidateAutomaticTransition(tt.transition)
+
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error: %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestStartWorkflow_RoutesThroughExpressionGuard(t *testing.T) {
+	// Arrange - small amounts are approved without review. On PostgreSQL a failed
+	// query for the system actor would abort the transaction and lose the start.
+	requireDatabase(t)
+	ctx := context.Background()
+	f := newApprovalFixture(t)
+	autoApprove := &models.WorkflowTransition{ID: uuid.NewString(), WorkflowID: f.workflow.ID, FromStepID: f.review.ID, ToStepID: f.approved.ID,
+		ActionName: "auto_approve", Automatic: true, ConditionType: ConditionExpression, ConditionValue: "payload.amount < 100", CreatedAt: time.Now()}
+	if err := f.repos.Workflows.CreateTransition(ctx, autoApprove); err != nil {
+		t.Fatalf("Failed to create transition: %v", err)
+	}
+
+	for _, tt := range []struct {
+		amount   float64
+		wantStep string
+	}{{50, f.approved.ID}, {500, f.review.ID}} {
+		todo := &models.Todo{Id: uuid.NewString(), TaskName: "fixture", TaskDescription: "fixture", UserID: f.user.UserID.String()}
+		if err := f.repos.Todos.Create(ctx, todo); err != nil {
+			t.Fatalf("Failed to create todo: %v", err)
+		}
+
+		// Act
+		instance, err := f.engine.StartWorkflow(ctx, f.workflow.ID, todo.Id, f.user.UserID.String(), map[string]interface{}{"amount": tt.amount})
+
+		// Assert - the routing was committed, and the returned instance matches it
+		if err != nil {
+			t.Fatalf("Expected amount %v to start, got %v", tt.amount, err)
+		}
+		stored, err := f.repos.Instances.GetInstance(ctx, instance.ID)
+		if err != nil {
+			t.Fatalf("Failed to get instance: %v", err)
+		}
+		if stored.CurrentStepId != tt.wantStep || stored.Version != instance.Version || stored.CurrentStepId != instance.CurrentStepId {
+			t.Errorf("Expected amount %v to end in step %s, got %+v (returned %+v)", tt.amount, tt.wantStep, stored, instance)
+		}
+	}
+}
+
+func TestStartWorkflow_FailedHopKeepsInstanceInSync(t *testing.T) {
+	// Arrange - the automatic transition leads to a subworkflow step whose subworkflow is gone
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	engine := NewWorkflowEngine(repos)
+	now := time.Now()
+	workflow := &models.Workflow{ID: uuid.NewString(), Name: "Routing", IsActive: true, CreatedAt: now, UpdatedAt: now}
+	start := &models.WorkflowStep{ID: uuid.NewString(), WorkflowID: workflow.ID, StepName: "Start", StepOrder: 1, Initial: true}
+	split := &models.WorkflowStep{ID: uuid.NewString(), WorkflowID: workflow.ID, StepName: "Split", StepOrder: 2, SubworkflowID: uuid.NewString()}
+	if err := repos.Workflows.CreateWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("Failed to create workflow: %v", err)
+	}
+	for _, step := range []*models.WorkflowStep{start, split} {
+		if err := repos.Workflows.CreateStep(ctx, step); err != nil {
+			t.Fatalf("Failed to create step: %v", err)
+		}
+	}
+	route := &models.WorkflowTransition{ID: uuid.NewString(), WorkflowID: workflow.ID, FromStepID: start.ID, ToStepID: split.ID,
+		ActionName: "route", Automatic: true}
+	if err := repos.Workflows.CreateTransition(ctx, route); err != nil {
+		t.Fatalf("Failed to create transition: %v", err)
+	}
+	todo := &models.Todo{Id: uuid.NewString(), TaskName: "Order", TaskDescription: "Ship it", UserID: "alice"}
+	if err := repos.Todos.Create(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+
+	// Act
+	instance, err := engine.StartWorkflow(ctx, workflow.ID, todo.Id, "alice", nil)
+
+	// Assert - the failed hop was rolled back, and the returned instance was not moved either
+	if err != nil {
+		t.Fatalf("Expected the start to succeed, got %v", err)
+	}
+	stored, err := repos.Instances.GetInstance(ctx, instance.ID)
+	if err != nil {
+		t.Fatalf("Failed to get instance: %v", err)
+	}
+	if stored.CurrentStepId != start.ID || instance.CurrentStepId != start.ID || instance.Version != stored.Version {
+		t.Errorf("Expected the instance to stay in Start at version %d, got %+v (returned %+v)", stored.Version, stored, instance)
+	}
+}
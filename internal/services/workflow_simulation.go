@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"todo-api/internal/interfaces"
+	"todo-api/internal/models"
+	"todo-api/internal/repository"
+	"todo-api/pkg/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// errSimulationDone rolls back the scratch transaction a simulation runs in
+var errSimulationDone = errors.New("simulation done")
+
+// SimulateWorkflow runs a scripted sequence of actions through a workflow without
+// writing anything. The workflow definition, and those of the subworkflows it starts,
+// are read from the database and copied into an in-memory store, where the engine
+// executes the script as it would for a real task. Inactive workflows can be simulated,
+// so workflows can be tested before they are activated.
+func (e *WorkflowEngine) SimulateWorkflow(ctx context.Context, workflowID string, input *models.SimulationInput) (*models.SimulationResult, error) {
+	if _, err := e.workflowRepo.GetWorkflow(ctx, workflowID); err != nil {
+		return nil, err
+	}
+
+	scratch := repository.NewMemoryRepositories()
+	if err := copyWorkflow(ctx, e.workflowRepo, scratch.Workflows, workflowID); err != nil {
+		return nil, err
+	}
+	return simulate(ctx, scratch, workflowID, input)
+}
+
+// copyWorkflow copies a workflow with its steps and transitions, and the workflows its
+// subworkflow steps start, into another repository. Copies are active so tasks can start.
+func copyWorkflow(ctx context.Context, from, to interfaces.WorkflowRepositoryInterface, workflowID string) error {
+	if _, err := to.GetWorkflow(ctx, workflowID); err == nil {
+		return nil
+	}
+
+	workflow, err := from.GetWorkflow(ctx, workflowID)
+	if err != nil {
+		return fmt.Errorf("failed to get workflow %s: %w", workflowID, err)
+	}
+	steps, err := from.GetWorkflowSteps(ctx, workflowID)
+	if err != nil {
+		return fmt.Errorf("failed to get steps: %w", err)
+	}
+	transitions, err := from.GetTransitions(ctx, workflowID)
+	if err != nil {
+		return fmt.Errorf("failed to get transitions: %w", err)
+	}
+
+	workflow.IsActive = true
+	if err := to.CreateWorkflow(ctx, workflow); err != nil {
+		return err
+	}
+	for _, step := range steps {
+		step.WorkflowID = workflowID
+		if err := to.CreateStep(ctx, step); err != nil {
+			return err
+		}
+	}
+	for _, transition := range transitions {
+		transition.WorkflowID = workflowID
+		if err := to.CreateTransition(ctx, transition); err != nil {
+			return err
+		}
+	}
+
+	for _, step := range steps {
+		if step.IsSubworkflow() {
+			if err := copyWorkflow(ctx, from, to, step.SubworkflowID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// simulationUsers stands in for the users of a simulation. Actors are looked up by the
+// ID the script uses and hold the role the script gives them; delegations are not simulated.
+type simulationUsers struct {
+	interfaces.UserRepositoryInterface
+	roles map[string]string
+}
+
+func (u *simulationUsers) GetUserByID(ctx context.Context, id interface{}) (*models.User, error) {
+	actor := fmt.Sprint(id)
+	user := &models.User{Username: actor, IsActive: true}
+	if role := u.roles[actor]; role != "" {
+		user.Role = &models.Role{Name: role}
+	}
+	return user, nil
+}
+
+// simulation holds the state of a dry run
+type simulation struct {
+	engine      *WorkflowEngine
+	steps       map[string]*models.WorkflowStep
+	transitions []*models.WorkflowTransition
+	history     []*models.WorkflowHistory // Written by the engine, including entries of failed actions
+	seen        int                       // Entries of history already turned into hops
+	instanceID  string
+	result      *models.SimulationResult
+}
+
+// simulate runs the scripted actions through the engine over the scratch repositories,
+// which must hold the workflow. Everything runs in one transaction that is rolled back,
+// so nothing meant for a committed action, like metrics, happens.
+func simulate(ctx context.Context, scratch *repository.Repositories, workflowID string, input *models.SimulationInput) (*models.SimulationResult, error) {
+	steps, err := scratch.Workflows.GetWorkflowSteps(ctx, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get steps: %w", err)
+	}
+	transitions, err := scratch.Workflows.GetTransitions(ctx, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transitions: %w", err)
+	}
+
+	scratch.Users = &simulationUsers{UserRepositoryInterface: scratch.Users, roles: input.Roles}
+	s := &simulation{
+		engine:      NewWorkflowEngine(scratch),
+		steps:       map[string]*models.WorkflowStep{},
+		transitions: transitions,
+		result: &models.SimulationResult{
+			WorkflowID: workflowID,
+			Path:       []models.SimulationHop{},
+			Steps:      []models.SimulationStepResult{},
+		},
+	}
+	s.engine.recorded = &s.history
+	s.engine.namedActors = true
+
+	hasStart := false
+	for _, step := range steps {
+		s.steps[step.ID] = step
+		hasStart = hasStart || step.Initial
+	}
+	s.result.Problems = lintWorkflow(s.steps, transitions)
+	if !hasStart {
+		s.result.HaltedReason = "workflow has no start step"
+		return s.result, nil
+	}
+
+	err = scratch.Tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.run(ctx, scratch, workflowID, input); err != nil {
+			return err
+		}
+		return errSimulationDone
+	})
+	if !errors.Is(err, errSimulationDone) {
+		return nil, err
+	}
+	return s.result, nil
+}
+
+// run starts the simulated task, executes the script and reports where the task ended up
+func (s *simulation) run(ctx context.Context, scratch *repository.Repositories, workflowID string, input *models.SimulationInput) error {
+	todo := &models.Todo{Id: uuid.New().String(), UserID: input.AssignedTo}
+	if err := scratch.Todos.Create(ctx, todo); err != nil {
+		return err
+	}
+	payload := map[string]interface{}{}
+	for key, value := range input.Payload {
+		payload[key] = value
+	}
+	instance, err := s.engine.StartWorkflow(ctx, workflowID, todo.Id, input.AssignedTo, payload)
+	if err != nil {
+		s.result.HaltedReason = err.Error()
+		return nil
+	}
+	s.instanceID = instance.ID
+	if err := s.follow(ctx); err != nil {
+		return err
+	}
+
+	for _, action := range input.Steps {
+		out, err := s.execute(ctx, action)
+		if err != nil {
+			return err
+		}
+		s.result.Steps = append(s.result.Steps, out)
+	}
+
+	instance, err = s.engine.instanceRepo.GetInstance(ctx, s.instanceID)
+	if err != nil {
+		return err
+	}
+	s.result.Status = instance.Status
+	s.result.CurrentStepID = instance.CurrentStepId
+	s.result.CurrentStepName = s.steps[instance.CurrentStepId].StepName
+	s.result.Payload = instance.Payload
+	if s.result.Payload == nil {
+		s.result.Payload = map[string]interface{}{}
+	}
+	return nil
+}
+
+// execute attempts one scripted action the way the task API would
+func (s *simulation) execute(ctx context.Context, action models.SimulationAction) (models.SimulationStepResult, error) {
+	instance, err := s.engine.instanceRepo.GetInstance(ctx, s.instanceID)
+	if err != nil {
+		return models.SimulationStepResult{}, err
+	}
+	out := models.SimulationStepResult{
+		Actor:            action.Actor,
+		Action:           action.Action,
+		StepName:         s.steps[instance.CurrentStepId].StepName,
+		AvailableActions: []string{},
+	}
+	if s.result.HaltedReason != "" {
+		out.Error = "simulation halted: " + s.result.HaltedReason
+		return out, nil
+	}
+
+	// Scripts claim queue tasks implicitly, as a queue member would before acting
+	if step := s.steps[instance.CurrentStepId]; instance.IsActive() && step.IsQueue() && instance.IsUnclaimed() {
+		err := s.attempt(func() error {
+			_, err := s.engine.ClaimTask(ctx, s.instanceID, action.Actor, "")
+			return err
+		})
+		if err != nil {
+			s.reject(&out, err)
+			return out, s.follow(ctx)
+		}
+		out.Claimed = true
+	}
+
+	available, err := s.engine.GetAvailableActions(ctx, s.instanceID, action.Actor)
+	if err != nil {
+		return out, err
+	}
+	for _, a := range available {
+		out.AvailableActions = append(out.AvailableActions, a.ActionName)
+	}
+
+	var result *models.TransitionResult
+	err = s.attempt(func() (err error) {
+		result, err = s.engine.ExecuteTransition(ctx, s.instanceID, action.Action, action.Actor, "", action.Data, nil)
+		return err
+	})
+	if err != nil {
+		s.reject(&out, err)
+		return out, s.follow(ctx)
+	}
+	out.Executed = true
+	out.Transitioned = result.Transitioned
+	out.Tally = result.Tally
+	return out, s.follow(ctx)
+}
+
+// reject reports a refused action, naming the check that refused it when there is one
+func (s *simulation) reject(out *models.SimulationStepResult, err error) {
+	var refused *rejection
+	if !errors.As(err, &refused) {
+		out.Error = err.Error()
+		return
+	}
+	out.RejectedBy = refused.check
+	if apperrors.KindOf(refused.err) != apperrors.KindForbidden {
+		out.Error = refused.err.Error()
+	}
+}
+
+// follow turns the history the engine wrote since the last call into hops and stops
+// the run where the engine stopped routing or a subworkflow took over
+func (s *simulation) follow(ctx context.Context) error {
+	for _, entry := range s.history[s.seen:] {
+		if entry.InstanceID != s.instanceID {
+			continue
+		}
+		if entry.ActionTaken == "automatic_routing_halted" {
+			s.result.HaltedReason = entry.Comments
+			continue
+		}
+		hop := models.SimulationHop{StepID: entry.ToStepID, StepName: s.steps[entry.ToStepID].StepName}
+		if entry.FromStepID != nil {
+			transition := s.findTransition(*entry.FromStepID, entry.ActionTaken)
+			if transition == nil || transition.ToStepID != entry.ToStepID {
+				continue // Votes, claims and other entries that do not move the task
+			}
+			hop.Action = transition.ActionName
+			hop.Actor = entry.PerformedBy
+			hop.Automatic = transition.Automatic
+		}
+		s.result.Path = append(s.result.Path, hop)
+	}
+	s.seen = len(s.history)
+
+	instance, err := s.engine.instanceRepo.GetInstance(ctx, s.instanceID)
+	if err != nil {
+		return err
+	}
+	if step := s.steps[instance.CurrentStepId]; instance.IsActive() && step.IsSubworkflow() && s.result.HaltedReason == "" {
+		s.result.HaltedReason = fmt.Sprintf("step %s starts subworkflow %s, which is not simulated", step.StepName, step.SubworkflowID)
+	}
+	return nil
+}
+
+// attempt runs one engine call of an action. The history of a call that failed was
+// rolled back with it, so it is dropped.
+func (s *simulation) attempt(call func() error) error {
+	written := len(s.history)
+	err := call()
+	if err != nil {
+		s.history = s.history[:written]
+	}
+	return err
+}
+
+func (s *simulation) findTransition(fromStepID, actionName string) *models.WorkflowTransition {
+	for _, transition := range s.transitions {
+		if transition.FromStepID == fromStepID && transition.ActionName == actionName {
+			return transition
+		}
+	}
+	return nil
+}
+
+// lintWorkflow reports definition problems that would only surface when a task hits them
+func lintWorkflow(steps map[string]*models.WorkflowStep, transitions []*models.WorkflowTransition) []string {
+	problems := []string{}
+	starts := 0
+	for _, step := range steps {
+		if step.Initial {
+			starts++
+		}
+	}
+	if starts > 1 {
+		problems = append(problems, fmt.Sprintf("workflow has %d start steps", starts))
+	}
+
+	for _, transition := range transitions {
+		prefix := "transition " + transition.ActionName + ": "
+		if _, ok := steps[transition.FromStepID]; !ok {
+			problems = append(problems, prefix+"from_step_id is not a step of this workflow")
+		}
+		if _, ok := steps[transition.ToStepID]; !ok {
+			problems = append(problems, prefix+"to_step_id is not a step of this workflow")
+		}
+		switch transition.ConditionType {
+		case "", "any_user", "assigned_user_only", "not_assigned_user":
+		case ConditionExpression:
+			if err := ValidateGuardExpression(transition.ConditionValue); err != nil {
+				problems = append(problems, prefix+err.Error())
+			}
+		default:
+			problems = append(problems, prefix+"unknown condition type: "+transition.ConditionType)
+		}
+		if err := ValidateAutomaticTransition(transition); err != nil {
+			problems = append(problems, prefix+err.Error())
+		}
+	}
+
+	// Steps are created before their transitions, so the actions they name are checked here
+	actions := map[string]bool{}
+	for _, transition := range transitions {
+		actions[transition.FromStepID+" "+transition.ActionName] = true
+	}
+	var stepProblems []string
+	for _, step := range steps {
+		prefix := "step " + step.StepName + ": "
+		if step.VetoAction != "" && !actions[step.ID+" "+step.VetoAction] {
+			stepProblems = append(stepProblems, prefix+"veto_action "+step.VetoAction+" is not an action of the step")
+		}
+		if step.EscalationAction == models.EscalationAutoTransition && !actions[step.ID+" "+step.EscalationValue] {
+			stepProblems = append(stepProblems, prefix+"escalation_value "+step.EscalationValue+" is not an action of the step")
+		}
+	}
+	slices.Sort(stepProblems)
+	return append(problems, stepProblems...)
+}
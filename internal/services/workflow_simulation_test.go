@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-api/internal/models"
+	"todo-api/internal/repository"
+)
+
+// simulateInMemory stores a workflow in memory and simulates the script through the engine
+func simulateInMemory(t *testing.T, workflow *models.Workflow, steps []*models.WorkflowStep, transitions []*models.WorkflowTransition, input *models.SimulationInput) *models.SimulationResult {
+	t.Helper()
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	if err := repos.Workflows.CreateWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("Failed to create workflow: %v", err)
+	}
+	for _, step := range steps {
+		step.WorkflowID = workflow.ID
+		if err := repos.Workflows.CreateStep(ctx, step); err != nil {
+			t.Fatalf("Failed to create step: %v", err)
+		}
+	}
+	for _, transition := range transitions {
+		transition.WorkflowID = workflow.ID
+		if err := repos.Workflows.CreateTransition(ctx, transition); err != nil {
+			t.Fatalf("Failed to create transition: %v", err)
+		}
+	}
+
+	result, err := NewWorkflowEngine(repos).SimulateWorkflow(ctx, workflow.ID, input)
+	if err != nil {
+		t.Fatalf("Failed to simulate: %v", err)
+	}
+	return result
+}
+
+// simulationWorkflow routes submitted todos through triage to a review step
+func simulationWorkflow() (*models.Workflow, []*models.WorkflowStep, []*models.WorkflowTransition) {
+	workflow := &models.Workflow{ID: "wf"}
+	steps := []*models.WorkflowStep{
+		{ID: "draft", StepName: "Draft", Initial: true},
+		{ID: "triage", StepName: "Triage"},
+		{ID: "senior", StepName: "Senior Review"},
+		{ID: "standard", StepName: "Standard Review"},
+		{ID: "done", StepName: "Done", Final: true},
+	}
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	transitions := []*models.WorkflowTransition{
+		{ID: "t1", FromStepID: "draft", ToStepID: "triage", ActionName: "submit", ConditionType: "assigned_user_only", CreatedAt: created},
+		{ID: "t2", FromStepID: "triage", ToStepID: "senior", ActionName: "route_senior", Automatic: true,
+			ConditionType: ConditionExpression, ConditionValue: `payload.priority == "high"`, CreatedAt: created.Add(time.Minute)},
+		{ID: "t3", FromStepID: "triage", ToStepID: "standard", ActionName: "route_standard", Automatic: true, CreatedAt: created.Add(2 * time.Minute)},
+		{ID: "t4", FromStepID: "standard", ToStepID: "done", ActionName: "approve",
+			ConditionType: ConditionExpression, ConditionValue: `actor.role == "Moderator"`, CreatedAt: created},
+		{ID: "t5", FromStepID: "senior", ToStepID: "done", ActionName: "approve", ConditionType: "user_role", CreatedAt: created},
+	}
+	return workflow, steps, transitions
+}
+
+func TestSimulate_FollowsGuardsAndAutomaticRouting(t *testing.T) {
+	// Arrange
+	workflow, steps, transitions := simulationWorkflow()
+	input := &models.SimulationInput{
+		AssignedTo: "alice",
+		Payload:    map[string]interface{}{"priority": "low"},
+		Roles:      map[string]string{"bob": "Moderator"},
+		Steps: []models.SimulationAction{
+			{Actor: "bob", Action: "submit"},
+			{Actor: "alice", Action: "submit"},
+			{Actor: "alice", Action: "approve"},
+			{Actor: "bob", Action: "approve"},
+		},
+	}
+
+	// Act
+	result := simulateInMemory(t, workflow, steps, transitions, input)
+
+	// Assert
+	if result.Status != models.InstanceCompleted || result.CurrentStepName != "Done" {
+		t.Fatalf("Expected the run to complete in Done, got %s in %s", result.Status, result.CurrentStepName)
+	}
+
+	var path []string
+	for _, hop := range result.Path {
+		path = append(path, hop.StepName)
+	}
+	if got := strings.Join(path, " > "); got != "Draft > Triage > Standard Review > Done" {
+		t.Errorf("Unexpected path %s", got)
+	}
+	if hop := result.Path[2]; !hop.Automatic || hop.Actor != models.SystemActor {
+		t.Errorf("Expected routing to standard review to be automatic by the system, got %+v", hop)
+	}
+
+	if !strings.HasPrefix(result.Steps[0].RejectedBy, "assigned_user_only") {
+		t.Errorf("Expected bob's submit to be rejected by assigned_user_only, got %q", result.Steps[0].RejectedBy)
+	}
+	if !strings.HasPrefix(result.Steps[2].RejectedBy, "expression") {
+		t.Errorf("Expected alice's approval to be rejected by the expression, got %q", result.Steps[2].RejectedBy)
+	}
+	if got := result.Steps[3].AvailableActions; len(got) != 1 || got[0] != "approve" {
+		t.Errorf("Expected bob to be offered approve, got %v", got)
+	}
+	if !result.Steps[3].Transitioned {
+		t.Error("Expected bob's approval to complete the run")
+	}
+}
+
+func TestSimulate_ReportsMisconfiguredConditionTypes(t *testing.T) {
+	// Arrange - high priority todos land in senior review, whose approve uses an unknown condition type
+	workflow, steps, transitions := simulationWorkflow()
+	input := &models.SimulationInput{
+		AssignedTo: "alice",
+		Payload:    map[string]interface{}{"priority": "high"},
+		Steps: []models.SimulationAction{
+			{Actor: "alice", Action: "submit"},
+			{Actor: "bob", Action: "approve"},
+		},
+	}
+
+	// Act
+	result := simulateInMemory(t, workflow, steps, transitions, input)
+
+	// Assert
+	if result.CurrentStepName != "Senior Review" {
+		t.Fatalf("Expected the run to stop in Senior Review, got %s", result.CurrentStepName)
+	}
+	if len(result.Problems) != 1 || !strings.Contains(result.Problems[0], "unknown condition type: user_role") {
+		t.Errorf("Expected the unknown condition type to be reported, got %v", result.Problems)
+	}
+	if got := result.Steps[1].RejectedBy; got != "unknown condition type: user_role" {
+		t.Errorf("Expected approve to be rejected by the unknown condition type, got %q", got)
+	}
+}
+
+func TestLintWorkflow_ReportsUnknownStepActions(t *testing.T) {
+	// Arrange - Review vetoes with an action it does not have and escalates with one it has
+	review := &models.WorkflowStep{ID: "review", StepName: "Review", ApprovalMode: models.ApprovalAllRoles, VetoAction: "decline",
+		EscalationAction: models.EscalationAutoTransition, EscalationValue: "approve"}
+	done := &models.WorkflowStep{ID: "done", StepName: "Done", Final: true}
+	transitions := []*models.WorkflowTransition{{FromStepID: "review", ToStepID: "done", ActionName: "approve"}}
+
+	// Act
+	problems := lintWorkflow(map[string]*models.WorkflowStep{"review": review, "done": done}, transitions)
+
+	// Assert
+	if len(problems) != 1 || problems[0] != "step Review: veto_action decline is not an action of the step" {
+		t.Errorf("Expected the unknown veto action to be reported, got %v", problems)
+	}
+}
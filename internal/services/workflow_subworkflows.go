@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todo-api/internal/models"
+	"todo-api/pkg/apperrors"
+	"todo-api/pkg/logging"
+
+	"github.com/google/uuid"
+)
+
+// maxSubworkflowDepth bounds how deeply subworkflows may nest, so a workflow
+// that (indirectly) starts itself cannot create instances forever
+const maxSubworkflowDepth = 5
+
+// MaxSubworkflowChildren limits the number of child instances one subworkflow step may start,
+// since they are all created in the transaction of the action that enters the step
+const MaxSubworkflowChildren = 50
+
+// ChildrenPayloadKey is the payload field of a parent instance that receives
+// the outcome of its children once they are all closed
+const ChildrenPayloadKey = "children"
+
+// startChildren starts the child instances of a subworkflow step. Every child
+// gets its own todo, derived from the parent's, because a todo runs through
+// one workflow at a time. All children are created before any of them moves,
+// so a child finishing right away cannot release the parent early.
+func (e *WorkflowEngine) startChildren(ctx context.Context, parent *models.AssignedTodo, step *models.WorkflowStep) error {
+	depth, err := e.nestingDepth(ctx, parent)
+	if err != nil {
+		return err
+	}
+	if depth >= maxSubworkflowDepth {
+		return apperrors.Invalid("subworkflows cannot be nested more than %d levels deep", maxSubworkflowDepth)
+	}
+
+	payloads, err := childPayloads(step, parent.Payload)
+	if err != nil {
+		return err
+	}
+
+	todo, err := e.todoRepo.GetById(ctx, parent.TodoId)
+	if err != nil || todo == nil {
+		return fmt.Errorf("failed to get todo of instance %s: %v", parent.ID, err)
+	}
+
+	type pendingChild struct {
+		instance  *models.AssignedTodo
+		startStep *models.WorkflowStep
+	}
+	var children []pendingChild
+	for i, payload := range payloads {
+		childTodo := &models.Todo{
+			Id:              uuid.New().String(),
+			TaskName:        childTodoName(todo.TaskName, step.StepName, i+1, len(payloads)),
+			TaskDescription: todo.TaskDescription,
+			UserID:          todo.UserID,
+		}
+		if err := e.todoRepo.Create(ctx, childTodo); err != nil {
+			return fmt.Errorf("failed to create todo for child instance: %w", err)
+		}
+
+		child, startStep, err := e.createInstance(ctx, step.SubworkflowID, childTodo.Id, childAssignee(parent.AssignedTo, payload), payload, &parent.ID)
+		if err != nil {
+			return fmt.Errorf("failed to start subworkflow: %w", err)
+		}
+		children = append(children, pendingChild{instance: child, startStep: startStep})
+	}
+
+	stepID := step.ID
+	err = e.recordHistory(ctx, parent.ID, &stepID, stepID, "subworkflows_started", models.SystemActor,
+		fmt.Sprintf("Started %d instance(s) of workflow %s", len(children), step.SubworkflowID))
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		if err := e.enterStartStep(ctx, child.instance, child.startStep); err != nil {
+			return fmt.Errorf("failed to start subworkflow: %w", err)
+		}
+	}
+
+	// Children that finished right away may already have moved the parent on
+	fresh, err := e.instanceRepo.GetInstance(ctx, parent.ID)
+	if err != nil {
+		return fmt.Errorf("failed to reload instance: %w", err)
+	}
+	*parent = *fresh
+	return nil
+}
+
+// awaitChildren reports whether a subworkflow step still waits for open children.
+// Once all are closed their outcome is merged into the parent payload, where
+// guard expressions of the following transitions can read it.
+func (e *WorkflowEngine) awaitChildren(ctx context.Context, parent *models.AssignedTodo, step *models.WorkflowStep) (bool, error) {
+	children, err := e.instanceRepo.GetChildInstances(ctx, parent.ID, parent.StepEnteredAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to get child instances: %w", err)
+	}
+	if countOpen(children) > 0 {
+		return true, nil
+	}
+
+	stepNames := map[string]string{}
+	for _, child := range children {
+		if _, ok := stepNames[child.CurrentStepId]; ok {
+			continue
+		}
+		if childStep, err := e.workflowRepo.GetStep(ctx, child.CurrentStepId); err == nil {
+			stepNames[child.CurrentStepId] = childStep.StepName
+		}
+	}
+
+	outcome := map[string]interface{}{ChildrenPayloadKey: childrenOutcome(step, children, stepNames)}
+	if err := e.mergePayload(ctx, parent, outcome); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// notifyParent tells the parent of a closed child instance, so the parent
+// can move on once its last child is done
+func (e *WorkflowEngine) notifyParent(ctx context.Context, child *models.AssignedTodo) {
+	if child.ParentInstanceID == nil {
+		return
+	}
+
+	parent, err := e.instanceRepo.GetInstance(ctx, *child.ParentInstanceID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to get parent of instance", "instance_id", child.ID, "error", err)
+		return
+	}
+	if parent.IsClosed() {
+		return
+	}
+
+	// Under a savepoint, so a failure does not abort the transaction of the child's action
+	stepID := parent.CurrentStepId
+	err = e.txManager.InTx(ctx, func(ctx context.Context) error {
+		return e.recordHistory(ctx, parent.ID, &stepID, stepID, "child_"+child.Status, models.SystemActor,
+			fmt.Sprintf("Child instance %s is %s", child.ID, child.Status))
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to notify parent of instance", "instance_id", child.ID, "error", err)
+	}
+
+	// A suspended parent picks up its children when it is resumed
+	if parent.IsActive() {
+		e.routeAutomatically(ctx, parent)
+	}
+}
+
+// cancelChildren cancels the open children of a cancelled instance
+func (e *WorkflowEngine) cancelChildren(ctx context.Context, parent *models.AssignedTodo) {
+	children, err := e.instanceRepo.GetChildInstances(ctx, parent.ID, time.Time{})
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to get child instances", "instance_id", parent.ID, "error", err)
+		return
+	}
+	for _, child := range children {
+		if child.IsClosed() {
+			continue
+		}
+		if _, err := e.CancelInstance(ctx, child.ID, models.SystemActor, "Parent instance cancelled"); err != nil {
+			logging.FromContext(ctx).Warn("failed to cancel child instance", "instance_id", child.ID, "error", err)
+		}
+	}
+}
+
+// nestingDepth counts the ancestors of an instance
+func (e *WorkflowEngine) nestingDepth(ctx context.Context, instance *models.AssignedTodo) (int, error) {
+	depth := 0
+	for current := instance; current.ParentInstanceID != nil && depth <= maxSubworkflowDepth; depth++ {
+		parent, err := e.instanceRepo.GetInstance(ctx, *current.ParentInstanceID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get parent instance: %w", err)
+		}
+		current = parent
+	}
+	return depth, nil
+}
+
+// childPayloads returns the initial payload of every child a subworkflow step starts.
+// Children inherit the parent payload; with SubworkflowItems set one child is
+// started per item of that payload list, receiving it as "item" and "item_index".
+func childPayloads(step *models.WorkflowStep, parentPayload map[string]interface{}) ([]map[string]interface{}, error) {
+	inherit := func() map[string]interface{} {
+		payload := map[string]interface{}{}
+		for key, value := range parentPayload {
+			if key != step.SubworkflowItems && key != ChildrenPayloadKey {
+				payload[key] = value
+			}
+		}
+		return payload
+	}
+
+	if step.SubworkflowItems == "" {
+		return []map[string]interface{}{inherit()}, nil
+	}
+
+	items, ok := parentPayload[step.SubworkflowItems].([]interface{})
+	if !ok {
+		return nil, apperrors.Invalid("payload field %s of step %s must be a list", step.SubworkflowItems, step.StepName)
+	}
+
+	if len(items) > MaxSubworkflowChildren {
+		return nil, apperrors.Invalid("payload field %s of step %s must not list more than %d items, got %d",
+			step.SubworkflowItems, step.StepName, MaxSubworkflowChildren, len(items))
+	}
+
+	payloads := make([]map[string]interface{}, 0, len(items))
+	for i, item := range items {
+		payload := inherit()
+		payload["item"] = item
+		payload["item_index"] = i
+		payloads = append(payloads, payload)
+	}
+	return payloads, nil
+}
+
+// childTodoName names the todo of the n-th of count children after the parent's todo and the step,
+// shortening the parent's name so the result fits todos.task_name
+func childTodoName(parentName, stepName string, n, count int) string {
+	suffix := []rune(fmt.Sprintf(" (%s %d/%d)", stepName, n, count))
+	name := []rune(parentName)
+	if room := models.MaxTodoNameLength - len(suffix); len(name) > room {
+		name = name[:max(room, 0)]
+	}
+	full := append(name, suffix...)
+	if len(full) > models.MaxTodoNameLength {
+		full = full[:models.MaxTodoNameLength]
+	}
+	return string(full)
+}
+
+// childAssignee assigns a child to the item's "assigned_to" field when present,
+// and to the parent's assignee otherwise
+func childAssignee(parentAssignee string, payload map[string]interface{}) string {
+	if item, ok := payload["item"].(map[string]interface{}); ok {
+		if assignee, ok := item["assigned_to"].(string); ok && assignee != "" {
+			return assignee
+		}
+	}
+	return parentAssignee
+}
+
+// countOpen counts the children that are neither completed nor cancelled
+func countOpen(children []*models.AssignedTodo) int {
+	open := 0
+	for _, child := range children {
+		if !child.IsClosed() {
+			open++
+		}
+	}
+	return open
+}
+
+// childrenOutcome summarises closed children for the parent payload
+func childrenOutcome(step *models.WorkflowStep, children []*models.AssignedTodo, stepNames map[string]string) map[string]interface{} {
+	completed, cancelled := 0, 0
+	results := make([]interface{}, 0, len(children))
+	for _, child := range children {
+		switch child.Status {
+		case models.InstanceCompleted:
+			completed++
+		case models.InstanceCancelled:
+			cancelled++
+		}
+		results = append(results, map[string]interface{}{
+			"instance_id": child.ID,
+			"todo_id":     child.TodoId,
+			"assigned_to": child.AssignedTo,
+			"status":      child.Status,
+			"step_id":     child.CurrentStepId,
+			"step_name":   stepNames[child.CurrentStepId],
+			"payload":     child.Payload,
+		})
+	}
+
+	return map[string]interface{}{
+		"step":        step.StepName,
+		"workflow_id": step.SubworkflowID,
+		"total":       len(children),
+		"completed":   completed,
+		"cancelled":   cancelled,
+		"results":     results,
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"todo-api/internal/models"
+)
+
+func TestChildPayloads_OnePerItem(t *testing.T) {
+	// Arrange - one review per purchase order line, each with its own reviewer
+	step := &models.WorkflowStep{StepName: "review lines", SubworkflowID: "line-review", SubworkflowItems: "lines"}
+	parentPayload := map[string]interface{}{
+		"cost_center": "R&D",
+		"lines": []interface{}{
+			map[string]interface{}{"sku": "A-1", "assigned_to": "alice"},
+			map[string]interface{}{"sku": "B-2"},
+		},
+	}
+
+	// Act
+	payloads, err := childPayloads(step, parentPayload)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(payloads) != 2 {
+		t.Fatalf("Expected 2 child payloads, got %d", len(payloads))
+	}
+	for i, payload := range payloads {
+		if payload["cost_center"] != "R&D" {
+			t.Errorf("Expected child %d to inherit cost_center, got %v", i, payload["cost_center"])
+		}
+		if payload["item_index"] != i {
+			t.Errorf("Expected child %d to have item_index %d, got %v", i, i, payload["item_index"])
+		}
+		if _, ok := payload["lines"]; ok {
+			t.Errorf("Expected child %d not to inherit the item list", i)
+		}
+	}
+	if got := childAssignee("bob", payloads[0]); got != "alice" {
+		t.Errorf("Expected the first child to be assigned to alice, got %s", got)
+	}
+	if got := childAssignee("bob", payloads[1]); got != "bob" {
+		t.Errorf("Expected the second child to fall back to the parent assignee, got %s", got)
+	}
+}
+
+func TestChildPayloads_SingleChildAndInvalidItems(t *testing.T) {
+	// Arrange
+	single := &models.WorkflowStep{SubworkflowID: "legal-review"}
+	perItem := &models.WorkflowStep{SubworkflowID: "legal-review", SubworkflowItems: "contracts"}
+	payload := map[string]interface{}{"contracts": "not a list"}
+
+	// Act
+	singlePayloads, singleErr := childPayloads(single, payload)
+	_, perItemErr := childPayloads(perItem, payload)
+
+	// Assert
+	if singleErr != nil || len(singlePayloads) != 1 {
+		t.Errorf("Expected exactly one child without subworkflow_items, got %d (%v)", len(singlePayloads), singleErr)
+	}
+	if perItemErr == nil {
+		t.Error("Expected an error when the item field is not a list")
+	}
+}
+
+func TestChildPayloads_TooManyItems(t *testing.T) {
+	// Arrange
+	step := &models.WorkflowStep{StepName: "review lines", SubworkflowID: "line-review", SubworkflowItems: "lines"}
+	payload := map[string]interface{}{"lines": make([]interface{}, MaxSubworkflowChildren+1)}
+
+	// Act
+	_, err := childPayloads(step, payload)
+
+	// Assert
+	if err == nil {
+		t.Errorf("Expected an error for more than %d items", MaxSubworkflowChildren)
+	}
+}
+
+func TestChildTodoName(t *testing.T) {
+	tests := []struct {
+		name       string
+		parentName string
+		stepName   string
+		want       string
+	}{
+		{"fits", "Order 42", "Review", "Order 42 (Review 1/3)"},
+		{"long parent name", strings.Repeat("ä", 200), "Review", strings.Repeat("ä", 187) + " (Review 1/3)"},
+		{"long step name", "Order 42", strings.Repeat("s", 220), " (" + strings.Repeat("s", 198)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := childTodoName(tt.parentName, tt.stepName, 1, 3)
+
+			// Assert
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+			if n := utf8.RuneCountInString(got); n > models.MaxTodoNameLength {
+				t.Errorf("Expected at most %d characters, got %d", models.MaxTodoNameLength, n)
+			}
+		})
+	}
+}
+
+func TestChildrenOutcome(t *testing.T) {
+	// Arrange
+	step := &models.WorkflowStep{StepName: "review lines", SubworkflowID: "line-review"}
+	children := []*models.AssignedTodo{
+		{ID: "c1", Status: models.InstanceCompleted, CurrentStepId: "approved"},
+		{ID: "c2", Status: models.InstanceCompleted, CurrentStepId: "rejected"},
+		{ID: "c3", Status: models.InstanceCancelled, CurrentStepId: "review"},
+	}
+
+	// Act
+	outcome := childrenOutcome(step, children, map[string]string{"approved": "Approved", "rejected": "Rejected"})
+
+	// Assert
+	if outcome["total"] != 3 || outcome["completed"] != 2 || outcome["cancelled"] != 1 {
+		t.Errorf("Expected 3 children, 2 completed and 1 cancelled, got %v", outcome)
+	}
+	results := outcome["results"].([]interface{})
+	if first := results[0].(map[string]interface{}); first["step_name"] != "Approved" {
+		t.Errorf("Expected the first child to end in Approved, got %v", first["step_name"])
+	}
+	if countOpen(children) != 0 {
+		t.Error("Expected no open children")
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todo-api/internal/models"
+	"todo-api/internal/repository"
+	"todo-api/pkg/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// ValidateApproval checks the multi-approval settings of a step against what exists:
+// every reviewer role, a quorum the listed reviewer users can reach when no roles add
+// reviewers, and, once the step has outgoing transitions, the veto action among them.
+func (e *WorkflowEngine) ValidateApproval(ctx context.Context, step *models.WorkflowStep) error {
+	for _, name := range step.ReviewerRoles {
+		if role, err := e.roleRepo.GetRoleByName(ctx, name); err != nil || role == nil {
+			return apperrors.Invalid("reviewer_roles: role not found: %s", name)
+		}
+	}
+
+	if step.ApprovalMode == models.ApprovalQuorum && len(step.ReviewerUsers) > 0 && len(step.ReviewerRoles) == 0 {
+		reviewers := map[string]bool{}
+		for _, userID := range step.ReviewerUsers {
+			reviewers[userID] = true
+		}
+		if step.Quorum > len(reviewers) {
+			return apperrors.Invalid("quorum: %d exceeds the %d reviewer_users", step.Quorum, len(reviewers))
+		}
+	}
+
+	if step.VetoAction != "" {
+		return e.validateStepAction(ctx, step, "veto_action", step.VetoAction)
+	}
+	return nil
+}
+
+// castVote records a reviewer's vote on a multi-approval step and fires the
+// transition once the vote decides the step.
+// Every vote increments the instance version before the votes are counted, so of two
+// votes cast at the same time one waits for the other and then fails with
+// ErrInstanceConflict; retried, it counts the first vote and can reach the quorum.
+func (e *WorkflowEngine) castVote(ctx context.Context, instance *models.AssignedTodo, step *models.WorkflowStep, transition *models.WorkflowTransition, userID, comments string, data map[string]interface{}) (*models.TransitionResult, error) {
+	voterRole, eligible := e.reviewerRole(ctx, step, userID)
+	if !eligible {
+		return nil, &rejection{check: "reviewers: user is not a reviewer for this step", err: apperrors.Forbidden("user is not a reviewer for this step")}
+	}
+
+	now := time.Now()
+	if err := e.instanceRepo.TouchInstance(ctx, instance.ID, instance.Version, now); err != nil {
+		return nil, fmt.Errorf("failed to lock instance: %w", err)
+	}
+	instance.Version++
+	instance.UpdatedAt = now
+
+	votes, err := e.voteRepo.GetVotes(ctx, instance.ID, step.ID, instance.StepEnteredAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get votes: %w", err)
+	}
+	if hasVoted(votes, userID) {
+		return nil, apperrors.Conflict("user has already voted on this step")
+	}
+
+	vote := models.WorkflowVote{
+		ID:         uuid.New().String(),
+		InstanceID: instance.ID,
+		StepID:     step.ID,
+		VoterID:    userID,
+		VoterRole:  voterRole,
+		ActionName: transition.ActionName,
+		Comments:   comments,
+		Round:      instance.StepEnteredAt,
+		CreatedAt:  now,
+	}
+	if err := e.voteRepo.CreateVote(ctx, &vote); err != nil {
+		if repository.IsUniqueViolation(err) {
+			return nil, apperrors.Conflict("user has already voted on this step")
+		}
+		return nil, fmt.Errorf("failed to record vote: %w", err)
+	}
+	votes = append(votes, vote)
+
+	if err := e.mergePayload(ctx, instance, data); err != nil {
+		return nil, err
+	}
+
+	stepID := step.ID
+	voteComment := "voted " + transition.ActionName
+	if comments != "" {
+		voteComment += ": " + comments
+	}
+	if err := e.recordHistoryWithData(ctx, instance.ID, &stepID, stepID, "vote", userID, voteComment, data); err != nil {
+		return nil, err
+	}
+
+	result := &models.TransitionResult{
+		InstanceID:    instance.ID,
+		ActionName:    transition.ActionName,
+		FromStepID:    step.ID,
+		ToStepID:      step.ID,
+		CurrentStepID: step.ID,
+		Tally:         buildTally(step, votes),
+	}
+
+	decided, reason := voteDecides(step, transition.ActionName, votes)
+	if !decided {
+		result.Version = instance.Version
+		return result, nil
+	}
+
+	if err := e.applyTransition(ctx, instance, transition, userID, reason, nil); err != nil {
+		return nil, err
+	}
+	result.ToStepID = transition.ToStepID
+	result.CurrentStepID = instance.CurrentStepId
+	result.Version = instance.Version
+	result.Transitioned = true
+
+	return result, nil
+}
+
+// GetVoteTally returns the votes cast on the current step of an instance
+func (e *WorkflowEngine) GetVoteTally(ctx context.Context, instanceID string) (*models.VoteTally, error) {
+	instance, err := e.instanceRepo.GetInstance(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("instance not found: %w", err)
+	}
+
+	step, err := e.workflowRepo.GetStep(ctx, instance.CurrentStepId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current step: %w", err)
+	}
+
+	votes, err := e.voteRepo.GetVotes(ctx, instance.ID, step.ID, instance.StepEnteredAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get votes: %w", err)
+	}
+
+	return buildTally(step, votes), nil
+}
+
+// reviewerRole reports whether a user may vote on a step, along with the
+// role name the vote is counted under
+func (e *WorkflowEngine) reviewerRole(ctx context.Context, step *models.WorkflowStep, userID string) (string, bool) {
+	roleName := e.roleName(ctx, userID)
+	if !isReviewer(step, userID, roleName) {
+		return "", false
+	}
+	return roleName, true
+}
+
+// isReviewer reports whether a user holding roleName may vote on a step.
+// Steps without reviewer lists accept anyone the transition condition allows.
+func isReviewer(step *models.WorkflowStep, userID, roleName string) bool {
+	if len(step.ReviewerUsers) == 0 && len(step.ReviewerRoles) == 0 {
+		return true
+	}
+	if containsString(step.ReviewerUsers, userID) {
+		return true
+	}
+	return roleName != "" && containsString(step.ReviewerRoles, roleName)
+}
+
+// voteDecides reports whether the votes cast so far fire the given action
+func voteDecides(step *models.WorkflowStep, actionName string, votes []models.WorkflowVote) (bool, string) {
+	if step.VetoAction != "" && actionName == step.VetoAction {
+		return true, "veto: " + actionName
+	}
+
+	switch step.ApprovalMode {
+	case models.ApprovalQuorum:
+		required := step.Quorum
+		if required < 1 {
+			required = 1
+		}
+		count := 0
+		for _, vote := range votes {
+			if vote.ActionName == actionName {
+				count++
+			}
+		}
+		if count >= required {
+			return true, fmt.Sprintf("quorum reached: %d of %d votes for %s", count, required, actionName)
+		}
+
+	case models.ApprovalAllRoles:
+		for _, role := range step.ReviewerRoles {
+			found := false
+			for _, vote := range votes {
+				if vote.ActionName == actionName && vote.VoterRole == role {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false, ""
+			}
+		}
+		return true, "all reviewer roles voted for " + actionName
+	}
+
+	return false, ""
+}
+
+// buildTally summarises the votes on a step
+func buildTally(step *models.WorkflowStep, votes []models.WorkflowVote) *models.VoteTally {
+	counts := map[string]int{}
+	for _, vote := range votes {
+		counts[vote.ActionName]++
+	}
+
+	return &models.VoteTally{
+		StepID:       step.ID,
+		ApprovalMode: step.ApprovalMode,
+		Quorum:       step.Quorum,
+		VetoAction:   step.VetoAction,
+		Counts:       counts,
+		Votes:        votes,
+	}
+}
+
+// hasVoted reports whether a user already voted
+func hasVoted(votes []models.WorkflowVote, userID string) bool {
+	for _, vote := range votes {
+		if vote.VoterID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString reports whether a slice contains a value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"todo-api/internal/models"
+	"todo-api/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func TestVoteDecides_Quorum(t *testing.T) {
+	// Arrange - two of three reviewers must approve, any reject vetoes
+	step := &models.WorkflowStep{
+		ApprovalMode:  models.ApprovalQuorum,
+		Quorum:        2,
+		ReviewerUsers: []string{"alice", "bob", "carol"},
+		VetoAction:    "reject",
+	}
+	votes := []models.WorkflowVote{
+		{VoterID: "alice", ActionName: "approve"},
+	}
+
+	// Act & Assert - one approval is not enough
+	if decided, _ := voteDecides(step, "approve", votes); decided {
+		t.Error("Expected a single approval not to meet the quorum")
+	}
+
+	// A second approval meets the quorum
+	votes = append(votes, models.WorkflowVote{VoterID: "bob", ActionName: "approve"})
+	if decided, _ := voteDecides(step, "approve", votes); !decided {
+		t.Error("Expected two approvals to meet the quorum")
+	}
+
+	// The veto action fires on the first vote
+	if decided, _ := voteDecides(step, "reject", nil); !decided {
+		t.Error("Expected the veto action to fire immediately")
+	}
+}
+
+func TestVoteDecides_AllRoles(t *testing.T) {
+	// Arrange - legal AND finance must both sign off
+	step := &models.WorkflowStep{
+		ApprovalMode:  models.ApprovalAllRoles,
+		ReviewerRoles: []string{"Legal", "Finance"},
+	}
+	votes := []models.WorkflowVote{
+		{VoterID: "u1", VoterRole: "Legal", ActionName: "approve"},
+		{VoterID: "u2", VoterRole: "Legal", ActionName: "approve"},
+	}
+
+	// Act & Assert - two legal approvals are not enough
+	if decided, _ := voteDecides(step, "approve", votes); decided {
+		t.Error("Expected approval to wait for the Finance role")
+	}
+
+	votes = append(votes, models.WorkflowVote{VoterID: "u3", VoterRole: "Finance", ActionName: "approve"})
+	if decided, _ := voteDecides(step, "approve", votes); !decided {
+		t.Error("Expected approval once every reviewer role has voted")
+	}
+}
+
+func TestCastVote_ConcurrentVotesConflict(t *testing.T) {
+	// Arrange - a review step that needs two approvals
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	engine := NewWorkflowEngine(repos)
+	now := time.Now()
+	workflow := &models.Workflow{ID: uuid.NewString(), Name: "Quorum", IsActive: true, CreatedAt: now, UpdatedAt: now}
+	review := &models.WorkflowStep{ID: uuid.NewString(), WorkflowID: workflow.ID, StepName: "Review", StepOrder: 1, Initial: true,
+		ApprovalMode: models.ApprovalQuorum, Quorum: 2}
+	done := &models.WorkflowStep{ID: uuid.NewString(), WorkflowID: workflow.ID, StepName: "Done", StepOrder: 2, Final: true}
+	approve := &models.WorkflowTransition{ID: uuid.NewString(), WorkflowID: workflow.ID, FromStepID: review.ID, ToStepID: done.ID,
+		ActionName: "approve", ConditionType: "any_user"}
+	if err := repos.Workflows.CreateWorkflow(ctx, workflow); err != nil {
+		t.Fatalf("Failed to create workflow: %v", err)
+	}
+	for _, step := range []*models.WorkflowStep{review, done} {
+		if err := repos.Workflows.CreateStep(ctx, step); err != nil {
+			t.Fatalf("Failed to create step: %v", err)
+		}
+	}
+	if err := repos.Workflows.CreateTransition(ctx, approve); err != nil {
+		t.Fatalf("Failed to create transition: %v", err)
+	}
+	todo := &models.Todo{Id: uuid.NewString(), TaskName: "Contract", TaskDescription: "Sign off", UserID: "alice"}
+	if err := repos.Todos.Create(ctx, todo); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	instance, err := engine.StartWorkflow(ctx, workflow.ID, todo.Id, "alice", nil)
+	if err != nil {
+		t.Fatalf("Failed to start workflow: %v", err)
+	}
+	stale := *instance
+
+	// Act - alice votes, bob votes on the instance as it was before alice's vote
+	first, err := engine.ExecuteTransition(ctx, instance.ID, "approve", "alice", "", nil, nil)
+	if err != nil {
+		t.Fatalf("Expected alice's vote to count, got %v", err)
+	}
+	_, staleErr := engine.castVote(ctx, &stale, review, approve, "bob", "", nil)
+	_, twiceErr := engine.ExecuteTransition(ctx, instance.ID, "approve", "alice", "", nil, nil)
+	retried, retryErr := engine.ExecuteTransition(ctx, instance.ID, "approve", "bob", "", nil, nil)
+
+	// Assert - bob's stale vote conflicts, alice cannot vote twice, and bob's retry reaches the quorum
+	if first.Transitioned {
+		t.Errorf("Expected one vote not to meet the quorum")
+	}
+	if !errors.Is(staleErr, ErrInstanceConflict) {
+		t.Errorf("Expected ErrInstanceConflict for the stale vote, got %v", staleErr)
+	}
+	if twiceErr == nil {
+		t.Errorf("Expected a second vote of alice to fail")
+	}
+	if retryErr != nil || !retried.Transitioned {
+		t.Fatalf("Expected bob's retried vote to reach the quorum, got %+v, %v", retried, retryErr)
+	}
+}
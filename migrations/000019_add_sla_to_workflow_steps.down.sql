@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_assigned_todos_due_at;
+
+ALTER TABLE assigned_todos DROP COLUMN IF EXISTS escalated_at;
+ALTER TABLE assigned_todos DROP COLUMN IF EXISTS due_at;
+ALTER TABLE assigned_todos DROP COLUMN IF EXISTS step_entered_at;
+
+ALTER TABLE workflow_steps DROP COLUMN IF EXISTS escalation_value;
+ALTER TABLE workflow_steps DROP COLUMN IF EXISTS escalation_action;
+ALTER TABLE workflow_steps DROP COLUMN IF EXISTS sla_minutes;
@@ -0,0 +1,12 @@
+-- Add SLA settings to workflow steps
+ALTER TABLE workflow_steps ADD sla_minutes INT NOT NULL DEFAULT 0;
+ALTER TABLE workflow_steps ADD escalation_action VARCHAR(50); -- e.g., 'auto_transition', 'reassign_user', 'reassign_role', 'notify'
+ALTER TABLE workflow_steps ADD escalation_value VARCHAR(255); -- action name, user ID or role name
+
+-- Track when an instance entered its current step and when it becomes overdue
+ALTER TABLE assigned_todos ADD step_entered_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE assigned_todos ADD due_at TIMESTAMP NULL;
+ALTER TABLE assigned_todos ADD escalated_at TIMESTAMP NULL;
+
+-- The SLA scheduler scans for instances past their due date
+CREATE INDEX idx_assigned_todos_due_at ON assigned_todos(due_at);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_workflow_votes_voter_id;
+DROP INDEX IF EXISTS idx_workflow_votes_instance_step;
+DROP TABLE IF EXISTS workflow_votes;
+
+ALTER TABLE workflow_steps DROP CONSTRAINT IF EXISTS chk_workflow_steps_approval_mode;
+ALTER TABLE workflow_steps DROP COLUMN IF EXISTS veto_action;
+ALTER TABLE workflow_steps DROP COLUMN IF EXISTS reviewer_roles;
+ALTER TABLE workflow_steps DROP COLUMN IF EXISTS reviewer_users;
+ALTER TABLE workflow_steps DROP COLUMN IF EXISTS quorum;
+ALTER TABLE workflow_steps DROP COLUMN IF EXISTS approval_mode;
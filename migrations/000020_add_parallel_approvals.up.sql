@@ -0,0 +1,26 @@
+-- Add multi-approval settings to workflow steps
+ALTER TABLE workflow_steps ADD approval_mode VARCHAR(20) NOT NULL DEFAULT 'single'; -- 'single', 'quorum', 'all_roles'
+ALTER TABLE workflow_steps ADD quorum INT NOT NULL DEFAULT 0;
+ALTER TABLE workflow_steps ADD reviewer_users TEXT; -- JSON array of user IDs
+ALTER TABLE workflow_steps ADD reviewer_roles TEXT; -- JSON array of role names
+ALTER TABLE workflow_steps ADD veto_action VARCHAR(100);
+
+ALTER TABLE workflow_steps ADD CONSTRAINT chk_workflow_steps_approval_mode
+    CHECK (approval_mode IN ('single', 'quorum', 'all_roles'));
+
+-- Individual reviewer votes per instance and step
+CREATE TABLE workflow_votes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    instance_id UUID NOT NULL,
+    step_id UUID NOT NULL,
+    voter_id VARCHAR(100) NOT NULL,
+    voter_role VARCHAR(100),
+    action_name VARCHAR(100) NOT NULL,
+    comments VARCHAR(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_workflow_votes_instance_id FOREIGN KEY (instance_id) REFERENCES assigned_todos(id) ON DELETE CASCADE,
+    CONSTRAINT fk_workflow_votes_step_id FOREIGN KEY (step_id) REFERENCES workflow_steps(id)
+);
+
+CREATE INDEX idx_workflow_votes_instance_step ON workflow_votes(instance_id, step_id);
+CREATE INDEX idx_workflow_votes_voter_id ON workflow_votes(voter_id);
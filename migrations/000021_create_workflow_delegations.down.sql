@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_workflow_delegations_delegate;
+DROP INDEX IF EXISTS idx_workflow_delegations_delegator;
+DROP TABLE IF EXISTS workflow_delegations;
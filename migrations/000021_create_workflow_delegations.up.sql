@@ -0,0 +1,15 @@
+-- Out-of-office delegation: while active, the delegate may act on the delegator's tasks
+CREATE TABLE workflow_delegations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delegator_id VARCHAR(100) NOT NULL,
+    delegate_id VARCHAR(100) NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    reason VARCHAR(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_workflow_delegations_period CHECK (ends_at > starts_at),
+    CONSTRAINT chk_workflow_delegations_self CHECK (delegator_id <> delegate_id)
+);
+
+CREATE INDEX idx_workflow_delegations_delegator ON workflow_delegations(delegator_id, starts_at, ends_at);
+CREATE INDEX idx_workflow_delegations_delegate ON workflow_delegations(delegate_id, starts_at, ends_at);
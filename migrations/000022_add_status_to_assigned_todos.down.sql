@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_assigned_todos_status;
+
+ALTER TABLE assigned_todos DROP CONSTRAINT IF EXISTS chk_assigned_todos_status;
+ALTER TABLE assigned_todos DROP COLUMN IF EXISTS suspended_at;
+ALTER TABLE assigned_todos DROP COLUMN IF EXISTS status;
@@ -0,0 +1,13 @@
+-- Lifecycle state of a workflow instance, independent of its current step
+ALTER TABLE assigned_todos ADD status VARCHAR(20) NOT NULL DEFAULT 'active'; -- 'active', 'suspended', 'cancelled', 'completed'
+ALTER TABLE assigned_todos ADD suspended_at TIMESTAMP; -- Set while suspended, used to pause the step SLA
+
+ALTER TABLE assigned_todos ADD CONSTRAINT chk_assigned_todos_status
+    CHECK (status IN ('active', 'suspended', 'cancelled', 'completed'));
+
+-- Instances already sitting on a final step are done
+UPDATE assigned_todos a SET status = 'completed'
+FROM workflow_steps s
+WHERE a.current_step_id = s.id AND s.final = TRUE;
+
+CREATE INDEX idx_assigned_todos_status ON assigned_todos(status);
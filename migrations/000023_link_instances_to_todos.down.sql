@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_assigned_todos_open_todo;
+
+ALTER TABLE workflows DROP COLUMN IF EXISTS complete_todo_on_finish;
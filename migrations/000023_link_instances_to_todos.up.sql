@@ -0,0 +1,17 @@
+-- Optionally mark the linked todo completed when an instance reaches a final step
+ALTER TABLE workflows ADD complete_todo_on_finish BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Keep only the newest open instance per todo before enforcing uniqueness
+UPDATE assigned_todos SET status = 'cancelled'
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY todo_id ORDER BY created_at DESC) AS rn
+        FROM assigned_todos
+        WHERE status IN ('active', 'suspended')
+    ) ranked
+    WHERE ranked.rn > 1
+);
+
+-- A todo can only run through one workflow at a time
+CREATE UNIQUE INDEX idx_assigned_todos_open_todo ON assigned_todos(todo_id)
+    WHERE status IN ('active', 'suspended');
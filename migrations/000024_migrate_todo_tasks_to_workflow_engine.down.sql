@@ -0,0 +1,38 @@
+-- Move instances of the built-in workflow back into todo_tasks
+INSERT INTO todo_tasks (id, title, description, assigned_to, status, reviewed_by, approved_by, created_at, updated_at)
+SELECT t.id, t.task_name, t.task_description, a.assigned_to, s.step_name,
+       CASE WHEN s.step_name <> 'Draft' THEN (
+           SELECT h.performed_by FROM workflow_history h
+           WHERE h.instance_id = a.id AND h.action_taken = 'submit'
+           ORDER BY h.timestamp DESC LIMIT 1) END,
+       CASE WHEN s.step_name = 'Approved' THEN (
+           SELECT h.performed_by FROM workflow_history h
+           WHERE h.instance_id = a.id AND h.action_taken = 'approve'
+           ORDER BY h.timestamp DESC LIMIT 1) END,
+       t.created_at, a.updated_at
+FROM assigned_todos a
+JOIN todos t ON a.todo_id = t.id
+JOIN workflow_steps s ON a.current_step_id = s.id
+WHERE a.workflow_id = 'a0000000-0000-4000-8000-000000000001'
+  AND a.status <> 'cancelled'
+ON CONFLICT (id) DO NOTHING;
+
+CREATE TEMPORARY TABLE builtin_todo_ids AS
+SELECT todo_id FROM assigned_todos WHERE workflow_id = 'a0000000-0000-4000-8000-000000000001';
+
+-- History and votes cascade with their instances
+DELETE FROM assigned_todos WHERE workflow_id = 'a0000000-0000-4000-8000-000000000001';
+
+-- Todos that also ran through another workflow stay
+DELETE FROM builtin_todo_ids b WHERE EXISTS (SELECT 1 FROM assigned_todos a WHERE a.todo_id = b.todo_id);
+
+-- todo_tasks cannot be shared, so shares of these todos go with them
+DELETE FROM shared_tasks WHERE todo_id IN (SELECT todo_id FROM builtin_todo_ids);
+DELETE FROM todos WHERE id IN (SELECT todo_id FROM builtin_todo_ids);
+DROP TABLE builtin_todo_ids;
+
+-- todos keeps the wider task_name and task_description: other todos may already use them
+
+DELETE FROM workflow_transitions WHERE workflow_id = 'a0000000-0000-4000-8000-000000000001';
+DELETE FROM workflow_steps WHERE workflow_id = 'a0000000-0000-4000-8000-000000000001';
+DELETE FROM workflows WHERE id = 'a0000000-0000-4000-8000-000000000001';
@@ -0,0 +1,71 @@
+-- Built-in Draft -> Review -> Approved workflow replacing the hardcoded todo_tasks state machine.
+-- The IDs are fixed so the /workflow/todos compatibility routes can find it (see models.BuiltinApprovalWorkflowID).
+INSERT INTO workflows (id, name, description, is_active, complete_todo_on_finish, created_by)
+VALUES ('a0000000-0000-4000-8000-000000000001', 'Draft/Review/Approved',
+        'Built-in approval flow used by the /workflow/todos routes', TRUE, TRUE, 'system');
+
+INSERT INTO workflow_steps (id, workflow_id, step_name, step_order, initial, final) VALUES
+    ('a0000000-0000-4000-8000-000000000002', 'a0000000-0000-4000-8000-000000000001', 'Draft', 1, TRUE, FALSE),
+    ('a0000000-0000-4000-8000-000000000003', 'a0000000-0000-4000-8000-000000000001', 'Review', 2, FALSE, FALSE),
+    ('a0000000-0000-4000-8000-000000000004', 'a0000000-0000-4000-8000-000000000001', 'Approved', 3, FALSE, TRUE);
+
+-- Same rules as the old state machine: the assignee submits and, as the reviewer, approves; anyone may reject
+INSERT INTO workflow_transitions (id, workflow_id, from_step_id, to_step_id, action_name, condition_type) VALUES
+    ('a0000000-0000-4000-8000-000000000005', 'a0000000-0000-4000-8000-000000000001',
+     'a0000000-0000-4000-8000-000000000002', 'a0000000-0000-4000-8000-000000000003', 'submit', 'assigned_user_only'),
+    ('a0000000-0000-4000-8000-000000000006', 'a0000000-0000-4000-8000-000000000001',
+     'a0000000-0000-4000-8000-000000000003', 'a0000000-0000-4000-8000-000000000004', 'approve', 'assigned_user_only'),
+    ('a0000000-0000-4000-8000-000000000007', 'a0000000-0000-4000-8000-000000000001',
+     'a0000000-0000-4000-8000-000000000003', 'a0000000-0000-4000-8000-000000000002', 'reject', 'any_user');
+
+-- Todos take over todo_tasks titles and descriptions, so they get the same sizes
+ALTER TABLE todos
+    ALTER COLUMN task_name TYPE VARCHAR(200),
+    ALTER COLUMN task_description TYPE VARCHAR(500);
+
+-- Convert todo_tasks into todos plus instances of the built-in workflow.
+-- Rows whose assignee matches no user (by ID or username) cannot own a todo and stay in todo_tasks.
+CREATE TEMPORARY TABLE migrated_todo_tasks AS
+SELECT t.id, t.title, COALESCE(t.description, '') AS description,
+       u.id AS user_id, t.status, t.reviewed_by, t.approved_by, t.created_at, t.updated_at,
+       CASE t.status
+           WHEN 'Draft' THEN 'a0000000-0000-4000-8000-000000000002'::UUID
+           WHEN 'Review' THEN 'a0000000-0000-4000-8000-000000000003'::UUID
+           ELSE 'a0000000-0000-4000-8000-000000000004'::UUID
+       END AS step_id
+FROM todo_tasks t
+JOIN users u ON u.id::TEXT = t.assigned_to OR u.username = t.assigned_to;
+
+INSERT INTO todos (id, task_name, task_description, completed, user_id, created_at, updated_at)
+SELECT id, title, description, status = 'Approved', user_id, created_at, updated_at
+FROM migrated_todo_tasks;
+
+-- The instance reuses the todo_tasks ID so old links keep resolving
+INSERT INTO assigned_todos (id, workflow_id, current_step_id, todo_id, assigned_to, step_entered_at, status, created_at, updated_at)
+SELECT id, 'a0000000-0000-4000-8000-000000000001', step_id, id, user_id::TEXT, updated_at,
+       CASE WHEN status = 'Approved' THEN 'completed' ELSE 'active' END, created_at, updated_at
+FROM migrated_todo_tasks;
+
+-- Rebuild the history the old table implies
+INSERT INTO workflow_history (instance_id, from_step_id, to_step_id, action_taken, performed_by, comments, timestamp)
+SELECT id, NULL, 'a0000000-0000-4000-8000-000000000002', 'created', user_id::TEXT,
+       'Migrated from todo_tasks', created_at
+FROM migrated_todo_tasks;
+
+INSERT INTO workflow_history (instance_id, from_step_id, to_step_id, action_taken, performed_by, comments, timestamp)
+SELECT id, 'a0000000-0000-4000-8000-000000000002', 'a0000000-0000-4000-8000-000000000003', 'submit',
+       COALESCE(reviewed_by, user_id::TEXT), 'Migrated from todo_tasks',
+       CASE WHEN status = 'Review' THEN updated_at ELSE created_at END
+FROM migrated_todo_tasks
+WHERE status IN ('Review', 'Approved');
+
+INSERT INTO workflow_history (instance_id, from_step_id, to_step_id, action_taken, performed_by, comments, timestamp)
+SELECT id, 'a0000000-0000-4000-8000-000000000003', 'a0000000-0000-4000-8000-000000000004', 'approve',
+       COALESCE(approved_by, user_id::TEXT), 'Migrated from todo_tasks', updated_at
+FROM migrated_todo_tasks
+WHERE status = 'Approved';
+
+-- Converted rows are no longer read; unmatched rows are kept for manual follow-up
+DELETE FROM todo_tasks WHERE id IN (SELECT id FROM migrated_todo_tasks);
+
+DROP TABLE migrated_todo_tasks;
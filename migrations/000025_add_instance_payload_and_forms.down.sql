@@ -0,0 +1,3 @@
+ALTER TABLE workflow_history DROP COLUMN IF EXISTS data;
+ALTER TABLE workflow_transitions DROP COLUMN IF EXISTS form_schema;
+ALTER TABLE assigned_todos DROP COLUMN IF EXISTS payload;
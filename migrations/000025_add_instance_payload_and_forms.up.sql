@@ -0,0 +1,8 @@
+-- Business data carried by each workflow instance
+ALTER TABLE assigned_todos ADD payload JSONB NOT NULL DEFAULT '{}';
+
+-- Fields a caller must submit with a transition (JSON-Schema-like object)
+ALTER TABLE workflow_transitions ADD form_schema JSONB;
+
+-- Data submitted with the action that produced a history entry
+ALTER TABLE workflow_history ADD data JSONB;
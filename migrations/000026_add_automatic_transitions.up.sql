@@ -0,0 +1,3 @@
+-- Automatic transitions fire without a user action as soon as an instance
+-- enters their from step and their condition holds
+ALTER TABLE workflow_transitions ADD automatic BOOLEAN NOT NULL DEFAULT FALSE;
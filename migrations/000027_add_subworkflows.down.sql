@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_assigned_todos_parent_instance_id;
+ALTER TABLE assigned_todos DROP COLUMN IF EXISTS parent_instance_id;
+ALTER TABLE workflow_steps DROP COLUMN IF EXISTS subworkflow_items;
+ALTER TABLE workflow_steps DROP COLUMN IF EXISTS subworkflow_id;
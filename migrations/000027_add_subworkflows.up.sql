@@ -0,0 +1,8 @@
+-- A step with a subworkflow starts child instances of that workflow when it is
+-- entered, optionally one per item of a payload list, and waits for all of them
+ALTER TABLE workflow_steps ADD subworkflow_id UUID REFERENCES workflows(id);
+ALTER TABLE workflow_steps ADD subworkflow_items VARCHAR(100);
+
+-- Child instances point at the instance whose step started them
+ALTER TABLE assigned_todos ADD parent_instance_id UUID REFERENCES assigned_todos(id) ON DELETE CASCADE;
+CREATE INDEX idx_assigned_todos_parent_instance_id ON assigned_todos(parent_instance_id);
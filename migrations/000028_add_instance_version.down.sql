@@ -0,0 +1 @@
+ALTER TABLE assigned_todos DROP COLUMN IF EXISTS version;
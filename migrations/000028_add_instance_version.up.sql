@@ -0,0 +1,3 @@
+-- Optimistic concurrency: every write to an instance increments its version and
+-- step and status changes only apply when the version is still the one read
+ALTER TABLE assigned_todos ADD version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_assigned_todos_unclaimed;
+ALTER TABLE workflow_steps DROP COLUMN IF EXISTS queue_role;
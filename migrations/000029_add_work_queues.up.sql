@@ -0,0 +1,6 @@
+-- A step with a queue role puts instances that enter it into a pool instead of
+-- keeping their assignee; members of the role claim them from there
+ALTER TABLE workflow_steps ADD queue_role VARCHAR(100);
+
+-- Unclaimed instances have an empty assigned_to
+CREATE INDEX idx_assigned_todos_unclaimed ON assigned_todos(current_step_id) WHERE assigned_to = '' AND status = 'active';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_workflow_votes_voter_round;
+ALTER TABLE workflow_votes DROP COLUMN IF EXISTS round_started_at;
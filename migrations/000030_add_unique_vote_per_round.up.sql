@@ -0,0 +1,17 @@
+-- A voting round is one visit of a step, identified by the instance's step_entered_at;
+-- each reviewer votes at most once per round
+ALTER TABLE workflow_votes ADD round_started_at TIMESTAMP;
+
+-- Votes of the current visit belong to it, votes of earlier visits each count as their own round
+UPDATE workflow_votes v SET round_started_at = a.step_entered_at
+FROM assigned_todos a
+WHERE a.id = v.instance_id AND a.current_step_id = v.step_id AND v.created_at >= a.step_entered_at;
+UPDATE workflow_votes SET round_started_at = created_at WHERE round_started_at IS NULL;
+
+-- Keep the first vote of reviewers who voted twice in the same round
+DELETE FROM workflow_votes v USING workflow_votes w
+WHERE v.instance_id = w.instance_id AND v.step_id = w.step_id AND v.voter_id = w.voter_id
+    AND v.round_started_at = w.round_started_at AND (v.created_at, v.id) > (w.created_at, w.id);
+
+ALTER TABLE workflow_votes ALTER COLUMN round_started_at SET NOT NULL;
+CREATE UNIQUE INDEX uq_workflow_votes_voter_round ON workflow_votes(instance_id, step_id, voter_id, round_started_at);
@@ -0,0 +1,101 @@
+// Package apperrors defines the typed errors services return and the RFC 7807
+// problem details documents the API reports them with.
+package apperrors
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Kind classifies an error by what the client can do about it
+type Kind string
+
+const (
+	KindInternal     Kind = "internal"
+	KindValidation   Kind = "validation"
+	KindNotFound     Kind = "not-found"
+	KindConflict     Kind = "conflict"
+	KindForbidden    Kind = "forbidden"
+	KindUnauthorized Kind = "unauthorized"
+)
+
+// FieldError describes why one field of a request is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an error of a known kind. Wrapping it with fmt.Errorf and %w keeps the kind.
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  []FieldError // Only set on validation errors
+	err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// newError formats the message like fmt.Errorf, keeping an error wrapped with %w
+func newError(kind Kind, format string, args []interface{}) *Error {
+	err := fmt.Errorf(format, args...)
+	return &Error{Kind: kind, Message: err.Error(), err: errors.Unwrap(err)}
+}
+
+// NotFound reports a resource that does not exist
+func NotFound(format string, args ...interface{}) *Error {
+	return newError(KindNotFound, format, args)
+}
+
+// Conflict reports a request that clashes with the current state of a resource
+func Conflict(format string, args ...interface{}) *Error {
+	return newError(KindConflict, format, args)
+}
+
+// Forbidden reports an authenticated user who may not perform the request
+func Forbidden(format string, args ...interface{}) *Error {
+	return newError(KindForbidden, format, args)
+}
+
+// Unauthorized reports a request without valid credentials
+func Unauthorized(format string, args ...interface{}) *Error {
+	return newError(KindUnauthorized, format, args)
+}
+
+// Invalid reports a request that is malformed or not allowed as a whole
+func Invalid(format string, args ...interface{}) *Error {
+	return newError(KindValidation, format, args)
+}
+
+// Validation reports the request fields that are invalid
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+// KindOf returns the kind of the first typed error in err's chain. Missing rows
+// are not found; anything else unknown is an internal error.
+func KindOf(err error) Kind {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return KindNotFound
+	}
+	return KindInternal
+}
+
+// FieldsOf returns the invalid fields reported anywhere in err's chain
+func FieldsOf(err error) []FieldError {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Fields
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package apperrors
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not found", NotFound("todo %s not found", "todo-1"), http.StatusNotFound},
+		{"wrapped conflict", fmt.Errorf("error saving instance: %w", Conflict("instance was modified")), http.StatusConflict},
+		{"forbidden", Forbidden("not a queue member"), http.StatusForbidden},
+		{"unauthorized", Unauthorized("invalid credentials"), http.StatusUnauthorized},
+		{"invalid", Invalid("invalid action: %w", errors.New("no such transition")), http.StatusBadRequest},
+		{"missing row", fmt.Errorf("error getting role: %w", sql.ErrNoRows), http.StatusNotFound},
+		{"untyped", errors.New("pq: connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := StatusOf(tt.err)
+
+			// Assert
+			if got != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestInvalid_KeepsWrappedError(t *testing.T) {
+	// Arrange
+	cause := errors.New("no such transition")
+
+	// Act
+	err := Invalid("invalid action for current step: %w", cause)
+
+	// Assert
+	if !errors.Is(err, cause) {
+		t.Errorf("Expected the wrapped error to be kept")
+	}
+	if err.Error() != "invalid action for current step: no such transition" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+}
+
+func TestFromError_Validation(t *testing.T) {
+	// Arrange
+	err := fmt.Errorf("error starting workflow: %w",
+		Validation("assigned_to is required", FieldError{Field: "assigned_to", Message: "is required"}))
+
+	// Act
+	problem := FromError(err)
+
+	// Assert
+	if problem.Status != http.StatusBadRequest || problem.Type != "/problems/validation" {
+		t.Errorf("Expected a validation problem, got %d %s", problem.Status, problem.Type)
+	}
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "assigned_to" {
+		t.Errorf("Expected the assigned_to field error, got %+v", problem.Errors)
+	}
+}
+
+func TestFromError_HidesInternalDetail(t *testing.T) {
+	// Act
+	problem := FromError(errors.New(`pq: relation "todos" does not exist`))
+
+	// Assert
+	if problem.Status != http.StatusInternalServerError || problem.Detail != "The server failed to process the request" {
+		t.Errorf("Expected a generic internal problem, got %d: %s", problem.Status, problem.Detail)
+	}
+}
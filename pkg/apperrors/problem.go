@@ -0,0 +1,73 @@
+package apperrors
+
+import (
+	"encoding/json"
+	"net/http"
+	"todo-api/pkg/logging"
+)
+
+// ContentType is the media type of problem details documents
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code,omitempty"`   // Machine readable reason, where an endpoint defines them
+	Errors   []FieldError `json:"errors,omitempty"` // The invalid fields of a validation problem
+}
+
+// statuses maps each kind to the HTTP status it is reported with
+var statuses = map[Kind]int{
+	KindInternal:     http.StatusInternalServerError,
+	KindValidation:   http.StatusBadRequest,
+	KindNotFound:     http.StatusNotFound,
+	KindConflict:     http.StatusConflict,
+	KindForbidden:    http.StatusForbidden,
+	KindUnauthorized: http.StatusUnauthorized,
+}
+
+// StatusOf returns the HTTP status err is reported with
+func StatusOf(err error) int {
+	return statuses[KindOf(err)]
+}
+
+// NewProblem describes a failure that has no error kind, typically a malformed
+// request rejected by a handler, by its status alone
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail}
+}
+
+// FromError describes err by its kind. Internal errors get a generic detail, so
+// database and other internal messages are not shown to clients.
+func FromError(err error) *Problem {
+	kind := KindOf(err)
+	status := statuses[kind]
+	problem := &Problem{Type: "/problems/" + string(kind), Title: http.StatusText(status), Status: status, Detail: err.Error()}
+	if kind == KindInternal {
+		problem.Detail = "The server failed to process the request"
+	}
+	problem.Errors = FieldsOf(err)
+	return problem
+}
+
+// Respond writes err as a problem for the request r; internal errors are logged
+// with the request's logger
+func Respond(w http.ResponseWriter, r *http.Request, err error) {
+	problem := FromError(err)
+	if problem.Status == http.StatusInternalServerError {
+		logging.FromContext(r.Context()).Error("request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+	problem.Instance = r.URL.Path
+	Write(w, problem)
+}
+
+// Write sends a problem with its status
+func Write(w http.ResponseWriter, problem *Problem) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
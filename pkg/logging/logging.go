@@ -0,0 +1,43 @@
+// Package logging builds the structured logger and carries a request-scoped one in contexts
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+type contextKey struct{}
+
+// New creates a logger writing to w at level ("debug", "info", "warn" or "error")
+// in format ("json" or "text")
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var minLevel slog.Level
+	if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: must be debug, info, warn or error", level)
+	}
+	options := &slog.HandlerOptions{Level: minLevel}
+
+	switch strings.ToLower(format) {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	}
+	return nil, fmt.Errorf("invalid log format %q: must be json or text", format)
+}
+
+// WithLogger returns a context carrying logger, typically one annotated with the request ID
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger outside of a request
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
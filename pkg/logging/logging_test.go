@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	logger, err := New(&out, "warn", "json")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	// Act
+	logger.Info("dropped")
+	logger.Warn("kept", "instance_id", "instance-1")
+
+	// Assert - only the warning is written, as one JSON object
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a single JSON line, got %q", out.String())
+	}
+	if entry["msg"] != "kept" || entry["instance_id"] != "instance-1" {
+		t.Errorf("Unexpected entry %v", entry)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "verbose", "json"); err == nil {
+		t.Errorf("Expected an unknown level to be rejected")
+	}
+	if _, err := New(&bytes.Buffer{}, "info", "xml"); err == nil {
+		t.Errorf("Expected an unknown format to be rejected")
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	logger, _ := New(&out, "info", "text")
+	ctx := WithLogger(context.Background(), logger.With("request_id", "req-1"))
+
+	// Act
+	FromContext(ctx).Info("handled")
+
+	// Assert
+	if !bytes.Contains(out.Bytes(), []byte("request_id=req-1")) {
+		t.Errorf("Expected the request-scoped logger, got %q", out.String())
+	}
+	if FromContext(context.Background()) == nil {
+		t.Errorf("Expected the default logger outside of a request")
+	}
+}
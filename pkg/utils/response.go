@@ -3,6 +3,7 @@ package utils
 import (
 	"encoding/json"
 	"net/http"
+	"todo-api/pkg/apperrors"
 )
 
 func RespondJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -11,8 +12,9 @@ func RespondJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// RespondError reports a request the handler rejected itself as a problem with the given status
 func RespondError(w http.ResponseWriter, status int, message string) {
-	RespondJSON(w, status, map[string]string{"error": message})
+	apperrors.Write(w, apperrors.NewProblem(status, message))
 }
 
 // DecodeJson decodes JSON from request body into the provided interface